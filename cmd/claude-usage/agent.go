@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"claude-usage/internal/agent"
+	"claude-usage/internal/config"
+)
+
+// runAgent fetches usage headless and serves it to remote viewers until
+// interrupted.
+func runAgent(*options) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return agent.Run(ctx, cfg)
+}
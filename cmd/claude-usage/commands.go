@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/doctor"
+	"claude-usage/internal/service"
+	"claude-usage/internal/update"
+)
+
+// command is a one-shot subcommand that runs instead of the tray app.
+type command struct {
+	summary string
+	run     func(opts *options) error
+}
+
+// commands maps subcommand names to their implementations.
+var commands = map[string]command{
+	"agent": {
+		summary: "fetch usage without a tray and serve it to remote viewers (usage_provider \"remote\")",
+		run:     runAgent,
+	},
+	"doctor": {
+		summary: "check credentials, API access and tray support",
+		run:     runDoctor,
+	},
+	"export": {
+		summary: "print logged usage as CSV or JSON, e.g. export --format=csv --period=30d",
+		run:     exportData,
+	},
+	"import": {
+		summary: "fill the usage history from --from=ccusage.json (ccusage daily --json) or transcripts",
+		run:     importHistory,
+	},
+	"install-service": {
+		summary: "run at login as a supervised user service",
+		run:     installService,
+	},
+	"prompt": {
+		summary: "print a short usage segment for shell prompts",
+		run:     printPrompt,
+	},
+	"status": {
+		summary: "print current usage and its age; --cached never waits for a refresh",
+		run:     printStatus,
+	},
+	"stats": {
+		summary: "print local usage for --period=week, month or all",
+		run:     printStats,
+	},
+	"rollback": {
+		summary: "restore the version replaced by the last update",
+		run:     rollback,
+	},
+	"tmux": {
+		summary: "print a usage segment for the tmux status line, e.g. #(claude-usage tmux)",
+		run:     printTmux,
+	},
+	"tui": {
+		summary: "full-screen live view for terminals and SSH sessions without a tray",
+		run:     runTUI,
+	},
+	"uninstall-service": {
+		summary: "remove the user service",
+		run:     uninstallService,
+	},
+}
+
+// commandNames returns the subcommand names in sorted order.
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runCommand runs the named subcommand and exits non-zero on failure.
+func runCommand(name string, opts *options) {
+	if err := commands[name].run(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "claude-usage %s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+func installService(*options) error {
+	installed, err := service.Install(config.ActiveProfile())
+	if err != nil {
+		return err
+	}
+	fmt.Println("Installed", installed)
+	return nil
+}
+
+func uninstallService(*options) error {
+	if err := service.Uninstall(config.ActiveProfile()); err != nil {
+		return err
+	}
+	fmt.Println("Service removed")
+	return nil
+}
+
+func rollback(*options) error {
+	result, err := update.Rollback()
+	if err != nil {
+		return err
+	}
+	fmt.Println(result.Message)
+	return nil
+}
+
+func runDoctor(*options) error {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("[FAIL] Config: %v\n       -> Fix or remove %s\n", err, config.GetConfigPath())
+		cfg = config.Default()
+	}
+
+	failures := doctor.Print(os.Stdout, doctor.Run(context.Background(), cfg))
+	if failures > 0 {
+		return fmt.Errorf("%d check(s) failed", failures)
+	}
+	return nil
+}
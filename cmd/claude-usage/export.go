@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/history"
+	"claude-usage/internal/planlimits"
+	"claude-usage/internal/stats"
+)
+
+// defaultExportPeriod is the export command's --period when none is given.
+const defaultExportPeriod = "30d"
+
+// exportData writes the usage samples the tray app logged on each refresh
+// to stdout as CSV or JSON, one row per refresh or, with --daily, per day.
+func exportData(opts *options) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	stats.SetWeekStart(cfg.WeekStart())
+	planlimits.Apply(cfg)
+
+	since, err := exportSince(opts.period, time.Now())
+	if err != nil {
+		return err
+	}
+	path := config.GetSamplesPath(config.ActiveProfile())
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return errors.New("no usage recorded yet; start the tray app first")
+	}
+	samples, err := history.LoadSamples(path, since)
+	if err != nil {
+		return err
+	}
+
+	if opts.daily {
+		return history.WriteDays(os.Stdout, opts.format, history.Daily(samples, stats.Location()))
+	}
+	return history.WriteSamples(os.Stdout, opts.format, samples)
+}
+
+// exportSince returns the start of an export --period: a number of days
+// such as 30d, week or month for the current calendar week or month, or
+// all. An empty period is defaultExportPeriod.
+func exportSince(period string, now time.Time) (time.Time, error) {
+	switch period {
+	case "":
+		return exportSince(defaultExportPeriod, now)
+	case periodWeek:
+		start, _ := stats.GetWeekBounds()
+		return start, nil
+	case periodMonth:
+		start, _ := stats.GetMonthBounds()
+		return start, nil
+	case periodAll:
+		return time.Time{}, nil
+	}
+	if days, err := strconv.Atoi(strings.TrimSuffix(period, "d")); err == nil && days > 0 && strings.HasSuffix(period, "d") {
+		return now.AddDate(0, 0, -days), nil
+	}
+	return time.Time{}, fmt.Errorf("-period: must be a number of days such as %s, or %s, %s or %s, got %q",
+		defaultExportPeriod, periodWeek, periodMonth, periodAll, period)
+}
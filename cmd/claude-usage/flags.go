@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/history"
+)
+
+// options holds the parsed command-line flags.
+type options struct {
+	command     string
+	showVersion bool
+
+	// period is the time range for the stats command (week, month or all)
+	// and the export command, which also takes a number of days such as 30d
+	period string
+
+	// format and daily choose the export command's output: CSV or JSON,
+	// one row per refresh or per day
+	format string
+	daily  bool
+
+	// from is the ccusage JSON file for the import command, or - for stdin
+	from string
+
+	// shell is the shell whose prompt the prompt command prints for
+	shell string
+
+	// cached makes the status command print the last known status at
+	// once, refreshing in the background
+	cached bool
+
+	// apiBase replaces the production API's scheme and host, for a mock
+	// server
+	apiBase string
+
+	overrides config.Overrides
+}
+
+// parseFlags parses an optional command followed by command-line flags.
+// Config flags override config.json and CLAUDE_USAGE_* environment variables
+// for the current run only.
+func parseFlags(args []string) (*options, error) {
+	opts := &options{}
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		opts.command = args[0]
+		args = args[1:]
+		if _, ok := commands[opts.command]; !ok {
+			return nil, fmt.Errorf("unknown command: %s", opts.command)
+		}
+	}
+
+	fs := flag.NewFlagSet("claude-usage", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: claude-usage [command] [flags]\n\nCommands:\n")
+		for _, name := range commandNames() {
+			fmt.Fprintf(fs.Output(), "  %-20s %s\n", name, commands[name].summary)
+		}
+		fmt.Fprintf(fs.Output(), "\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	var refresh string
+	fs.BoolVar(&opts.showVersion, "version", false, "print version and exit")
+	fs.StringVar(&opts.overrides.Profile, "profile", "", "named profile from config.json to use")
+	fs.StringVar(&refresh, "refresh", "", "refresh interval, e.g. 2m or 120 (seconds)")
+	fs.Int64Var(&opts.overrides.WeeklyBudgetTokens, "budget", 0, "weekly token budget")
+	fs.StringVar(&opts.overrides.StatsPath, "stats", "", "path to Claude's stats-cache.json")
+	fs.StringVar(&opts.overrides.CredentialsPath, "credentials", "", "path to the credentials file")
+	fs.StringVar(&opts.overrides.Source, "source", "", "credential source: claude or opencode")
+	fs.BoolVar(&opts.overrides.Debug, "debug", false, "log HTTP exchanges and retry decisions")
+	fs.StringVar(&opts.period, "period", "", "period for the stats command (week, month or all, default week) or the export command (also e.g. 7d, default "+defaultExportPeriod+")")
+	fs.StringVar(&opts.format, "format", history.FormatCSV, "export command output: csv or json")
+	fs.StringVar(&opts.from, "from", "", "import command: ccusage JSON file to read, or - for stdin (default: session transcripts)")
+	fs.BoolVar(&opts.daily, "daily", false, "export command: one row per day instead of per refresh")
+	fs.StringVar(&opts.shell, "shell", "", "escape colors for this shell's prompt (prompt command): bash or zsh")
+	fs.BoolVar(&opts.cached, "cached", false, "status command: print the last known usage without waiting for a refresh")
+	fs.StringVar(&opts.apiBase, "api-base", "", "send API requests to this URL instead of the production API, e.g. a mock server")
+	fs.BoolVar(&opts.overrides.Demo, "demo", false, "show generated demo data instead of real usage (no credentials needed)")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+
+	if refresh != "" {
+		d, err := config.ParseInterval(refresh)
+		if err != nil {
+			return nil, fmt.Errorf("-refresh: %w", err)
+		}
+		opts.overrides.RefreshInterval = d
+	}
+	opts.overrides.Source = strings.ToLower(opts.overrides.Source)
+	if opts.command == "export" {
+		if _, err := exportSince(opts.period, time.Now()); err != nil {
+			return nil, err
+		}
+	} else {
+		switch opts.period {
+		case "":
+			opts.period = periodWeek
+		case periodWeek, periodMonth, periodAll:
+		default:
+			return nil, fmt.Errorf("-period: must be %s, %s or %s, got %q", periodWeek, periodMonth, periodAll, opts.period)
+		}
+	}
+	switch opts.format {
+	case history.FormatCSV, history.FormatJSON:
+	default:
+		return nil, fmt.Errorf("-format: must be %s or %s, got %q", history.FormatCSV, history.FormatJSON, opts.format)
+	}
+	switch opts.shell {
+	case "", shellBash, shellZsh:
+	default:
+		return nil, fmt.Errorf("-shell: must be %s or %s, got %q", shellBash, shellZsh, opts.shell)
+	}
+
+	return opts, nil
+}
+
+// mustParseFlags parses flags, exiting with a usage error on failure.
+func mustParseFlags() *options {
+	opts, err := parseFlags(os.Args[1:])
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "claude-usage: %v\n", err)
+		os.Exit(2)
+	}
+	return opts
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/cost"
+	"claude-usage/internal/history"
+	"claude-usage/internal/planlimits"
+	"claude-usage/internal/stats"
+	"claude-usage/internal/transcripts"
+	"claude-usage/pkg/format"
+)
+
+// importHistory fills the usage history with the weeks before the tray app
+// started recording, from ccusage JSON (--from=FILE, or - for stdin) or,
+// without --from, from the session transcripts and stats cache.
+func importHistory(opts *options) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	stats.SetWeekStart(cfg.WeekStart())
+	planlimits.Apply(cfg)
+	format.SetLocale(cfg.Locale)
+
+	var days []history.DayTotal
+	if opts.from != "" {
+		days, err = readCCUsage(opts.from)
+	} else {
+		days, err = transcriptDays(cfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	weeks := history.WeeksFromDays(days, stats.WeekBoundsAt)
+	n, err := history.ImportWeeks(config.GetHistoryPath(config.ActiveProfile()), weeks, time.Now())
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		fmt.Println("No finished weeks before the recorded history to import")
+		return nil
+	}
+
+	var tokens int64
+	for _, w := range weeks[:n] {
+		tokens += w.Tokens
+	}
+	fmt.Printf("Imported %d weeks (%s tokens) ending %s – %s\n", n, format.FormatTokens(tokens),
+		weeks[0].End.Format("Jan 2, 2006"), weeks[n-1].End.Format("Jan 2, 2006"))
+	return nil
+}
+
+// readCCUsage reads ccusage JSON from path, or stdin for -.
+func readCCUsage(path string) ([]history.DayTotal, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	days, err := history.ParseCCUsage(r, stats.Location())
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return days, nil
+}
+
+// transcriptDays re-derives daily totals from the session transcripts and
+// the stats cache, priced like the stats command.
+func transcriptDays(cfg *config.Config) ([]history.DayTotal, error) {
+	cache, cacheErr := stats.ParseStatsCache(cfg.GetStatsPath())
+	entries, _ := transcripts.NewScanner(cfg.GetProjectsPath()).Scan()
+	if cacheErr != nil && len(entries) == 0 {
+		return nil, fmt.Errorf("could not read stats cache: %w", cacheErr)
+	}
+	cache, used := transcripts.Merge(cache, entries)
+	if len(cache.DailyModelTokens) == 0 {
+		return nil, errors.New("no local usage found")
+	}
+
+	pricing := cost.Pricing(cfg.Pricing)
+	days := make([]history.DayTotal, 0, len(cache.DailyModelTokens))
+	for _, daily := range cache.DailyModelTokens {
+		date, err := stats.ParseDate(daily.Date)
+		if err != nil {
+			continue
+		}
+		d := history.DayTotal{Date: date}
+		for _, tokens := range daily.TokensByModel {
+			d.Tokens += tokens
+		}
+		d.CostUSD = cost.EstimateEntries(cache, used, pricing, date, date.AddDate(0, 0, 1).Add(-time.Nanosecond))
+		days = append(days, d)
+	}
+	return days, nil
+}
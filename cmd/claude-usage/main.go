@@ -8,11 +8,19 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"claude-usage/internal/api"
 	"claude-usage/internal/app"
 	"claude-usage/internal/config"
+	"claude-usage/internal/instance"
+	"claude-usage/internal/logging"
+	"claude-usage/internal/redact"
 	"claude-usage/internal/update"
 )
 
@@ -20,13 +28,46 @@ import (
 var Version = "dev"
 
 func main() {
-	// Setup logging
+	// Tokens must never reach the console or the log file, even in a crash
+	// report
+	log.SetOutput(redact.NewWriter(os.Stderr))
+	defer logging.LogPanic()
+
+	// Parse command-line flags
+	opts := mustParseFlags()
+	if opts.showVersion {
+		fmt.Println("claude-usage", Version)
+		return
+	}
+	config.SetOverrides(opts.overrides)
+	if opts.apiBase != "" {
+		if err := api.SetBaseURL(opts.apiBase); err != nil {
+			fmt.Fprintf(os.Stderr, "claude-usage: -api-base: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	if opts.command != "" {
+		runCommand(opts.command, opts)
+		return
+	}
+
+	// Setup logging. Desktop sessions discard stderr, so logs go to a
+	// rotating file, and also to the console when started from a terminal.
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	logPath := config.GetLogPath(config.ActiveProfile())
+	if logFile, err := logging.SetupFile(logPath, logging.IsConsole()); err != nil {
+		log.Printf("Warning: could not open log file %s: %v", logPath, err)
+	} else {
+		defer logFile.Close()
+	}
 	log.Printf("Claude Usage %s starting on %s", Version, config.GetOS())
 	log.Printf("Claude data path: %s", config.GetClaudeDir())
+	if opts.apiBase != "" {
+		log.Printf("Note: sending API requests to %s instead of the production API", opts.apiBase)
+	}
 
 	// Clean up any leftover .old file from Windows update
-	update.CleanupOldBinary()
+	go update.CleanupOldBinary()
 
 	// Check if Claude directory exists
 	claudeDir := config.GetClaudeDir()
@@ -35,14 +76,76 @@ func main() {
 		log.Println("Make sure Claude Code is installed and has been used at least once.")
 	}
 
+	// Only one instance per profile: a second launch asks the first to refresh
+	lock, err := acquireInstanceLock()
+	if errors.Is(err, instance.ErrAlreadyRunning) {
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: single-instance check unavailable: %v", err)
+	}
+
 	// Create and run the app
 	application, err := app.New(Version)
 	if err != nil {
 		log.Fatalf("Failed to create application: %v", err)
 	}
 
+	if lock != nil {
+		defer lock.Close()
+		lock.Serve(application.HandleCommand)
+	}
+
+	// Quit cleanly on Ctrl+C, service stop and logout instead of being
+	// killed mid-write; a second signal exits immediately
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		sig := <-signals
+		log.Printf("Received %v, shutting down", sig)
+		go application.Shutdown()
+		sig = <-signals
+		log.Printf("Received %v again, exiting immediately", sig)
+		os.Exit(1)
+	}()
+
 	// Run blocks until quit
 	application.Run()
 
+	// After a self-update, hand over to the new binary. The instance lock
+	// must be released first or the new process would exit immediately.
+	if application.RestartRequested() {
+		if lock != nil {
+			lock.Close()
+		}
+		if update.Supervised() {
+			log.Println("Exiting so the service manager restarts the updated version")
+			os.Exit(update.RestartExitCode)
+		}
+		log.Println("Restarting into the updated version")
+		if err := update.Restart(); err != nil {
+			log.Printf("Restart failed: %v", err)
+		}
+	}
+
 	log.Println("Claude Usage exiting")
 }
+
+// acquireInstanceLock takes the single-instance lock for the active profile.
+// If another instance already holds it, that instance is asked to refresh
+// and instance.ErrAlreadyRunning is returned.
+func acquireInstanceLock() (*instance.Lock, error) {
+	if err := config.EnsureConfigDir(); err != nil {
+		return nil, err
+	}
+
+	socketPath := config.GetInstanceSocketPath(config.ActiveProfile())
+	lock, err := instance.Acquire(socketPath)
+	if errors.Is(err, instance.ErrAlreadyRunning) {
+		log.Println("Claude Usage is already running, asking it to refresh")
+		if sendErr := instance.Send(socketPath, instance.CommandRefresh); sendErr != nil {
+			log.Printf("Warning: %v", sendErr)
+		}
+	}
+	return lock, err
+}
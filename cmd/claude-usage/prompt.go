@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/status"
+)
+
+// Shells accepted by --shell, whose prompts need color codes escaped.
+const (
+	shellBash = "bash"
+	shellZsh  = "zsh"
+)
+
+// maxStatusAge is how old the status file may be before the prompt
+// segment is hidden. The tray app rewrites it on every refresh, and the
+// longest refresh interval is a day.
+const maxStatusAge = 24 * time.Hour
+
+// ANSI colors for the prompt segment.
+const (
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiRed    = "\033[31m"
+	ansiReset  = "\033[0m"
+)
+
+// printPrompt prints a short usage segment such as "⚡42%" for shell
+// prompts, from the status file the tray app keeps. It never touches the
+// network and prints nothing when no recent status is available.
+func printPrompt(opts *options) error {
+	s, ok := recentStatus()
+	if !ok {
+		return nil
+	}
+	fmt.Print(formatPrompt(s, os.Getenv("NO_COLOR") == "", opts.shell))
+	return nil
+}
+
+// printTmux prints the usage segment with tmux style codes, for
+// status-left or status-right (e.g. "#(claude-usage tmux)").
+func printTmux(*options) error {
+	s, ok := recentStatus()
+	if !ok {
+		return nil
+	}
+	fmt.Print(formatTmux(s, os.Getenv("NO_COLOR") == ""))
+	return nil
+}
+
+// recentStatus loads the status file kept by the tray app, reporting false
+// if it is missing or older than maxStatusAge.
+func recentStatus() (status.Status, bool) {
+	s, err := status.Load(config.GetStatusPath(config.ActiveProfile()))
+	if err != nil || time.Since(s.UpdatedAt) > maxStatusAge {
+		return s, false
+	}
+	return s, true
+}
+
+// segmentText returns the uncolored segment, e.g. "⚡42%". Estimated usage
+// is marked with "~".
+func segmentText(s status.Status) string {
+	text := "⚡"
+	if s.Estimated {
+		text += "~"
+	}
+	return text + strconv.Itoa(s.WeeklyPercent) + "%"
+}
+
+// Segment colors by usage level.
+const (
+	levelLow  = iota // below 50%
+	levelMid         // below 80%
+	levelHigh        // 80% or more, or throttled
+)
+
+// segmentLevel returns how close s is to the limit.
+func segmentLevel(s status.Status) int {
+	switch {
+	case s.Throttled || s.WeeklyPercent >= 80:
+		return levelHigh
+	case s.WeeklyPercent >= 50:
+		return levelMid
+	default:
+		return levelLow
+	}
+}
+
+// formatPrompt formats s as a prompt segment, green below 50%, yellow
+// below 80% and red above that or while throttled. The color codes are
+// wrapped in the shell's non-printing markers so line editing is not
+// confused.
+func formatPrompt(s status.Status, color bool, shell string) string {
+	text := segmentText(s)
+	if !color {
+		return text
+	}
+	code := [...]string{ansiGreen, ansiYellow, ansiRed}[segmentLevel(s)]
+	return escapeForShell(code, shell) + text + escapeForShell(ansiReset, shell)
+}
+
+// formatTmux formats s with tmux style codes, colored like formatPrompt.
+func formatTmux(s status.Status, color bool) string {
+	text := segmentText(s)
+	if !color {
+		return text
+	}
+	fg := [...]string{"green", "yellow", "red"}[segmentLevel(s)]
+	return "#[fg=" + fg + "]" + text + "#[default]"
+}
+
+// escapeForShell marks code as non-printing for shell's prompt.
+func escapeForShell(code, shell string) string {
+	switch shell {
+	case shellBash:
+		// Readline's raw markers; \[ and \] are not expanded in the
+		// output of command substitutions
+		return "\001" + code + "\002"
+	case shellZsh:
+		return "%{" + code + "%}"
+	default:
+		return code
+	}
+}
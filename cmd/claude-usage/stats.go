@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/cost"
+	"claude-usage/internal/history"
+	"claude-usage/internal/planlimits"
+	"claude-usage/internal/stats"
+	"claude-usage/internal/transcripts"
+	"claude-usage/pkg/format"
+)
+
+// Periods accepted by --period.
+const (
+	periodWeek  = "week"
+	periodMonth = "month"
+	periodAll   = "all"
+)
+
+// printStats prints local usage from the stats cache and session
+// transcripts for the chosen period.
+func printStats(opts *options) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	stats.SetWeekStart(cfg.WeekStart())
+	planlimits.Apply(cfg)
+	format.SetLocale(cfg.Locale)
+	stats.SetModelNames(cfg.ModelNames)
+
+	cache, cacheErr := stats.ParseStatsCache(cfg.GetStatsPath())
+	entries, _ := transcripts.NewScanner(cfg.GetProjectsPath()).Scan()
+	if cacheErr != nil && len(entries) == 0 {
+		return fmt.Errorf("could not read stats cache: %w", cacheErr)
+	}
+	cache, used := transcripts.Merge(cache, entries)
+
+	var p *stats.PeriodStats
+	var title string
+	switch opts.period {
+	case periodMonth:
+		p = stats.CalculateMonthlyStats(cache)
+		title = "This month (" + p.Start.Format("Jan 2006") + ")"
+	case periodAll:
+		l := stats.CalculateLifetimeStats(cache)
+		p = &l.PeriodStats
+		title = "All time"
+		if !l.FirstSessionDate.IsZero() {
+			title += " (since " + l.FirstSessionDate.Format("Jan 2, 2006") + ")"
+		}
+	default:
+		start, end := stats.GetWeekBounds()
+		p = stats.CalculatePeriodStats(cache, start, end)
+		title = "This week (" + start.Format("Jan 2") + " – " + end.Format("Jan 2") + ")"
+	}
+
+	fmt.Println(title)
+	fmt.Printf("Tokens:   %s\n", format.FormatTokens(p.TotalTokens))
+
+	models := make([]string, 0, len(p.TokensByModel))
+	for model := range p.TokensByModel {
+		models = append(models, model)
+	}
+	sort.Slice(models, func(i, j int) bool { return p.TokensByModel[models[i]] > p.TokensByModel[models[j]] })
+	for _, model := range models {
+		fmt.Printf("  %-20s %s\n", stats.ModelDisplayName(model), format.FormatTokens(p.TokensByModel[model]))
+	}
+
+	if opts.period == periodWeek {
+		if change, ok := stats.CompareWeeks(cache, time.Now()).Change(); ok {
+			fmt.Printf("vs last week at this time: %s\n", format.FormatSignedPercent(int(math.Round(change))))
+		}
+	}
+
+	fmt.Printf("Messages: %d\n", p.Messages)
+	fmt.Printf("Sessions: %d\n", p.Sessions)
+	if usd := cost.EstimateEntries(cache, used, cost.Pricing(cfg.Pricing), p.Start, p.End); usd > 0 {
+		fmt.Printf("Cost:     ≈$%.2f at API prices\n", usd)
+	}
+
+	if projects := transcripts.ByProject(entries, p.Start, p.End); len(projects) > 0 {
+		fmt.Println("Top projects:")
+		if len(projects) > topProjects {
+			projects = projects[:topProjects]
+		}
+		for _, proj := range projects {
+			fmt.Printf("  %-40s %s\n", shortenPath(proj.Project), format.FormatTokens(proj.Tokens))
+		}
+	}
+
+	printRecentWeeks()
+	return nil
+}
+
+// topProjects is how many projects the stats command lists.
+const topProjects = 5
+
+// recentWeeks is how many finished weeks from the usage history the stats
+// command lists.
+const recentWeeks = 4
+
+// printRecentWeeks lists the last finished weeks of the usage history,
+// with the API's weekly utilization where the app recorded it.
+func printRecentWeeks() {
+	h, err := history.Load(config.GetHistoryPath(config.ActiveProfile()))
+	if err != nil || len(h.Weeks) == 0 {
+		return
+	}
+	weeks := h.Weeks[max(0, len(h.Weeks)-recentWeeks):]
+	fmt.Println("Recent weeks:")
+	for i := len(weeks) - 1; i >= 0; i-- {
+		w := weeks[i]
+		line := fmt.Sprintf("  ended %-8s %8s tokens", w.End.Format("Jan 2"), format.FormatTokens(w.Tokens))
+		if !w.Imported {
+			line += fmt.Sprintf("  %4s of weekly limit", format.FormatPercent(w.Percentage()))
+		}
+		fmt.Println(line)
+	}
+}
+
+// shortenPath abbreviates the home directory to ~.
+func shortenPath(path string) string {
+	home := config.GetHomeDir()
+	if home != "" && (path == home || strings.HasPrefix(path, home+string(filepath.Separator))) {
+		return "~" + path[len(home):]
+	}
+	return path
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/instance"
+	"claude-usage/internal/status"
+	"claude-usage/pkg/format"
+)
+
+// statusWaitTimeout is how long the status command waits for the tray app
+// to refresh, which includes API retries on a flaky connection.
+const statusWaitTimeout = 30 * time.Second
+
+// printStatus prints the usage the tray app last saw and how old it is.
+// When the status is older than the refresh interval, the running app is
+// asked to refresh and the command waits for the new status, up to
+// statusWaitTimeout. With --cached it prints the last status at once and
+// the refresh happens in the background.
+func printStatus(opts *options) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	format.SetLocale(cfg.Locale)
+
+	profile := config.ActiveProfile()
+	path := config.GetStatusPath(profile)
+	s, err := status.Load(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err != nil || time.Since(s.UpdatedAt) > cfg.RefreshInterval {
+		sendErr := instance.Send(config.GetInstanceSocketPath(profile), instance.CommandRefresh)
+		if sendErr == nil && !opts.cached {
+			if fresh, ok := waitForStatus(path, s.UpdatedAt); ok {
+				s, err = fresh, nil
+			}
+		}
+	}
+	if err != nil {
+		return errors.New("no usage recorded yet; start the tray app first")
+	}
+
+	fmt.Print(formatStatus(s, time.Now()))
+	return nil
+}
+
+// waitForStatus polls the status file at path until it is newer than
+// since, reporting false if that takes longer than statusWaitTimeout.
+func waitForStatus(path string, since time.Time) (status.Status, bool) {
+	deadline := time.Now().Add(statusWaitTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(250 * time.Millisecond)
+		if s, err := status.Load(path); err == nil && s.UpdatedAt.After(since) {
+			return s, true
+		}
+	}
+	return status.Status{}, false
+}
+
+// formatStatus formats s for the status command, ending with the age of
+// its usage data.
+func formatStatus(s status.Status, now time.Time) string {
+	weekly := "Weekly:  " + format.FormatPercent(s.WeeklyPercent)
+	if s.Estimated {
+		weekly = "Weekly:  ~" + format.FormatPercent(s.WeeklyPercent) + " (estimated)"
+	}
+	out := weekly + resetSuffix(s.WeeklyReset, now) + "\n"
+	if !s.Estimated {
+		out += "5-hour:  " + format.FormatPercent(s.FiveHourPercent) + resetSuffix(s.FiveHourReset, now) + "\n"
+	}
+	if s.Throttled {
+		out += "Status:  throttled\n"
+	}
+
+	age := "Data:    " + format.FormatDuration(int64(s.Age(now).Seconds())) + " old"
+	if s.Stale {
+		age += " (API unreachable, showing cached data)"
+	}
+	if s.Paused {
+		age += ", automatic refreshes paused"
+	}
+	return out + age + "\n"
+}
+
+// resetSuffix returns ", resets in 2h 10m" for an RFC 3339 reset time,
+// or "" when it is unknown.
+func resetSuffix(reset string, now time.Time) string {
+	t, err := time.Parse(time.RFC3339, reset)
+	if err != nil {
+		return ""
+	}
+	return ", resets in " + format.FormatDuration(int64(t.Sub(now).Seconds()))
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/tui"
+)
+
+// runTUI shows the full-screen live view until it is quit.
+func runTUI(*options) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return tui.Run(ctx, cfg)
+}
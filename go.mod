@@ -4,9 +4,9 @@ go 1.24.0
 
 toolchain go1.24.12
 
-require fyne.io/systray v1.12.0
-
 require (
-	github.com/godbus/dbus/v5 v5.1.0 // indirect
-	golang.org/x/sys v0.15.0 // indirect
+	fyne.io/systray v1.12.0
+	github.com/fsnotify/fsnotify v1.7.0
+	github.com/godbus/dbus/v5 v5.1.0
+	golang.org/x/sys v0.15.0
 )
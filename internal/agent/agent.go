@@ -0,0 +1,168 @@
+// Package agent runs the usage fetcher without a tray icon, serving the
+// control API so tray apps on other machines can read the rate limits with
+// the "remote" usage provider. Only the agent's machine needs the OAuth
+// credentials.
+package agent
+
+import (
+	"context"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"claude-usage/internal/api"
+	"claude-usage/internal/config"
+	"claude-usage/internal/control"
+	"claude-usage/internal/history"
+	"claude-usage/internal/perms"
+	"claude-usage/internal/planlimits"
+	"claude-usage/internal/stats"
+	"claude-usage/internal/status"
+	"claude-usage/internal/usage"
+	"claude-usage/pkg/format"
+)
+
+// Run fetches usage every refresh interval and serves it on the control
+// API until ctx is done.
+func Run(ctx context.Context, cfg *config.Config) error {
+	perms.Tighten(perms.AppFiles(cfg.Profile))
+	if warning := perms.CheckCredentials(cfg.GetCredentialsPath()); warning != "" && !config.UsesEnvCredentials() {
+		log.Printf("Warning: %s; run 'chmod 600 %s'", warning, cfg.GetCredentialsPath())
+	}
+
+	token, err := control.LoadOrCreateToken(config.GetControlTokenPath(cfg.Profile))
+	if err != nil {
+		return err
+	}
+	port := cfg.ControlPort
+	if port == 0 {
+		port = control.DefaultPort
+	}
+
+	stats.SetWeekStart(cfg.WeekStart())
+	planlimits.Apply(cfg)
+	format.SetLocale(cfg.Locale)
+	a := &agent{refreshCh: make(chan struct{}, 1), samplesPath: config.GetSamplesPath(cfg.Profile)}
+	server, err := control.Listen(cfg.ControlHost(), port, token, a)
+	if err != nil {
+		return err
+	}
+	defer server.Close()
+
+	addr := net.JoinHostPort(cfg.ControlHost(), strconv.Itoa(port))
+	log.Printf("Agent serving usage on %s with the token from %s", addr, config.GetControlTokenPath(cfg.Profile))
+	if ip := net.ParseIP(cfg.ControlHost()); ip != nil && ip.IsLoopback() {
+		log.Println("Note: the agent only listens on the loopback interface; set control_address to reach it from other machines")
+	}
+
+	l := usage.NewLoader(cfg)
+	ticker := time.NewTicker(cfg.RefreshInterval)
+	defer ticker.Stop()
+	a.load(ctx, l)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-a.refreshCh:
+			ticker.Reset(cfg.RefreshInterval)
+		case <-ticker.C:
+			if a.paused.Load() {
+				continue
+			}
+		}
+		a.load(ctx, l)
+	}
+}
+
+// agent is the control API's view of the fetch loop.
+type agent struct {
+	paused atomic.Bool
+
+	// refreshCh requests an immediate load; it holds at most one request
+	refreshCh chan struct{}
+
+	// samplesPath is the profile's sample log, written by the tray app
+	samplesPath string
+
+	// mu guards the fields below
+	mu      sync.Mutex
+	latest  usage.Usage
+	fetches []history.Fetch
+}
+
+// load loads usage with l and publishes it.
+func (a *agent) load(ctx context.Context, l *usage.Loader) {
+	u := l.Load(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+	switch {
+	case u.Err != nil:
+		log.Printf("Warning: could not fetch rate limits: %v", u.Err)
+	case u.Stats != nil && u.Stats.HasAPIData:
+		log.Printf("API rate limits: 5h=%.1f%%, weekly=%.1f%%",
+			u.Stats.FiveHourUtilization*100, u.Stats.WeeklyUtilization*100)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.latest = u
+	if u.Fetch != nil {
+		cutoff := u.Fetch.At.Add(-history.FetchRetention)
+		for len(a.fetches) > 0 && a.fetches[0].At.Before(cutoff) {
+			a.fetches = a.fetches[1:]
+		}
+		a.fetches = append(a.fetches, *u.Fetch)
+	}
+}
+
+// Status returns the usage of the last load.
+func (a *agent) Status() status.Status {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return status.New(a.latest.Stats, a.paused.Load(), a.latest.At)
+}
+
+// Fetches sums up the recent API fetches.
+func (a *agent) Fetches() history.FetchSummary {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return history.Summarize(a.fetches, time.Now())
+}
+
+// RateLimits returns the last successful API response.
+func (a *agent) RateLimits() *api.RateLimitData {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.latest.RateLimits
+}
+
+// Samples reads the samples the tray app recorded for the profile since
+// since; the agent records none itself.
+func (a *agent) Samples(since time.Time) ([]history.Sample, error) {
+	return history.LoadSamples(a.samplesPath, since)
+}
+
+// Refresh requests an immediate load.
+func (a *agent) Refresh() {
+	log.Println("Refresh requested by control API")
+	select {
+	case a.refreshCh <- struct{}{}:
+	default:
+	}
+}
+
+// SetPaused pauses or resumes automatic loads.
+func (a *agent) SetPaused(paused bool) {
+	if a.paused.Swap(paused) == paused {
+		return
+	}
+	if paused {
+		log.Println("Automatic refreshes paused by control API")
+	} else {
+		log.Println("Automatic refreshes resumed by control API")
+	}
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// SaveCache writes data to path so it can be shown while the API is
+// unreachable. The file is replaced atomically.
+func SaveCache(path string, data *RateLimitData) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadCache reads rate limit data previously written by SaveCache.
+func LoadCache(path string) (*RateLimitData, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var data RateLimitData
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCacheRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "rate-limits.json")
+	want := &RateLimitData{
+		FiveHourUtilization: 0.42,
+		WeeklyUtilization:   0.17,
+		FiveHourReset:       time.Date(2026, 1, 1, 15, 0, 0, 0, time.UTC),
+		WeeklyReset:         time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+		RepresentativeClaim: "five_hour",
+		Status:              "allowed",
+		FetchedAt:           time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	if err := SaveCache(path, want); err != nil {
+		t.Fatalf("SaveCache: %v", err)
+	}
+	got, err := LoadCache(path)
+	if err != nil {
+		t.Fatalf("LoadCache: %v", err)
+	}
+	if got.FiveHourUtilization != want.FiveHourUtilization ||
+		got.WeeklyUtilization != want.WeeklyUtilization ||
+		!got.FiveHourReset.Equal(want.FiveHourReset) ||
+		!got.WeeklyReset.Equal(want.WeeklyReset) ||
+		got.RepresentativeClaim != want.RepresentativeClaim ||
+		got.Status != want.Status ||
+		!got.FetchedAt.Equal(want.FetchedAt) {
+		t.Errorf("LoadCache() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadCacheMissing(t *testing.T) {
+	if _, err := LoadCache(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadCache() on a missing file returned no error")
+	}
+}
@@ -2,16 +2,23 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"claude-usage/internal/config"
+	"claude-usage/internal/httpclient"
+	"claude-usage/internal/redact"
 )
 
 // Configuration values are loaded from the embedded claude-usage.env file.
@@ -38,47 +45,122 @@ var (
 	oauthScopes = config.GetClaudeOAuthScopes()
 )
 
+// SetBaseURL sends usage, token refresh and Messages API requests to base,
+// such as a mock server, instead of the production API, at the same paths. base is
+// an http:// or https:// URL; a path in it is prepended to the endpoints'.
+func SetBaseURL(base string) error {
+	u, err := url.Parse(strings.TrimSuffix(base, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an http:// or https:// URL, got %q", base)
+	}
+	usage, err := rebase(u, config.GetClaudeUsageEndpoint())
+	if err != nil {
+		return err
+	}
+	token, err := rebase(u, config.GetClaudeTokenEndpoint())
+	if err != nil {
+		return err
+	}
+	messages, err := rebase(u, config.GetClaudeMessagesEndpoint())
+	if err != nil {
+		return err
+	}
+	usageEndpoint, tokenEndpoint, messagesEndpoint = usage, token, messages
+	return nil
+}
+
+// rebase returns endpoint moved to base, keeping its path.
+func rebase(base *url.URL, endpoint string) (string, error) {
+	e, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+	return base.JoinPath(e.Path).String(), nil
+}
+
 const (
 	// maxRetries is the maximum number of retry attempts for token refresh
 	maxRetries = 5
 )
 
 // RefreshTokenCallback is called when a new refresh token is received from the server.
-// The callback receives the new refresh token and should persist it.
-type RefreshTokenCallback func(newRefreshToken string)
+// The callback receives the new access and refresh tokens and should persist them.
+type RefreshTokenCallback func(accessToken, refreshToken string)
 
 // Client is a client for fetching rate limit information from the Anthropic API.
+// It is safe for concurrent use.
 type Client struct {
-	httpClient           *http.Client
+	httpClient *http.Client
+
+	// mu guards the fields below
+	mu                   sync.Mutex
 	token                string
 	refreshToken         string
 	onRefreshTokenUpdate RefreshTokenCallback
+
+	// Validators and data from the last 200 response, used to make
+	// conditional requests. The server answers 304 when nothing changed.
+	etag         string
+	lastModified string
+	lastData     *RateLimitData
+
+	// fetch is the FetchRateLimits call in flight, shared by overlapping callers
+	fetch   *fetchCall
+	fetchMu sync.Mutex
+}
+
+// fetchCall is a FetchRateLimits request shared by every caller that
+// arrives while it is in flight.
+type fetchCall struct {
+	done chan struct{}
+	data *RateLimitData
+	err  error
 }
 
 // NewClient creates a new API client with the given OAuth token.
 func NewClient(token string) *Client {
+	redact.Secret(token)
 	return &Client{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		token: token,
+		httpClient: httpclient.New(30 * time.Second),
+		token:      token,
 	}
 }
 
 // SetToken updates the OAuth token.
+// A different token may belong to a different account, so the cached
+// response is dropped.
 func (c *Client) SetToken(token string) {
+	redact.Secret(token)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if token != c.token {
+		c.clearCachedResponse()
+	}
 	c.token = token
 }
 
+// clearCachedResponse forgets the last response so the next request is unconditional.
+// The caller must hold c.mu.
+func (c *Client) clearCachedResponse() {
+	c.etag = ""
+	c.lastModified = ""
+	c.lastData = nil
+}
+
 // SetRefreshToken updates the OAuth refresh token.
 func (c *Client) SetRefreshToken(refreshToken string) {
+	redact.Secret(refreshToken)
+	c.mu.Lock()
 	c.refreshToken = refreshToken
+	c.mu.Unlock()
 }
 
 // SetRefreshTokenCallback sets a callback to be called when the refresh token is rotated.
 // This allows the app to persist the new refresh token to the credentials file.
 func (c *Client) SetRefreshTokenCallback(cb RefreshTokenCallback) {
+	c.mu.Lock()
 	c.onRefreshTokenUpdate = cb
+	c.mu.Unlock()
 }
 
 // usageResponse represents the response from /api/oauth/usage
@@ -127,66 +209,128 @@ type tokenRefreshResponse struct {
 
 // FetchRateLimits fetches usage data from the OAuth usage endpoint.
 // This is a free endpoint that doesn't consume any tokens.
-func (c *Client) FetchRateLimits() (*RateLimitData, error) {
-	return c.fetchRateLimitsWithRetry(0)
+// Cancelling ctx aborts the request, including any token refresh.
+// Rate limiting, server errors and network failures are retried with
+// backoff (see retryPolicy).
+// Overlapping calls share a single request.
+func (c *Client) FetchRateLimits(ctx context.Context) (*RateLimitData, error) {
+	for {
+		c.fetchMu.Lock()
+		call := c.fetch
+		leader := call == nil
+		if leader {
+			call = &fetchCall{done: make(chan struct{})}
+			c.fetch = call
+		}
+		c.fetchMu.Unlock()
+
+		if leader {
+			call.data, call.err = withRetry(ctx, defaultRetryPolicy, func() (*RateLimitData, error) {
+				return c.fetchRateLimitsWithRetry(ctx, 0)
+			})
+			c.fetchMu.Lock()
+			c.fetch = nil
+			c.fetchMu.Unlock()
+			close(call.done)
+			return call.data, call.err
+		}
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		// The shared request was cancelled by the caller that started it,
+		// not by us: start a new one
+		if errors.Is(call.err, context.Canceled) && ctx.Err() == nil {
+			continue
+		}
+		if call.err != nil {
+			return nil, call.err
+		}
+		data := *call.data
+		return &data, nil
+	}
 }
 
 // fetchRateLimitsWithRetry implements retry logic with automatic token refresh on 401
-func (c *Client) fetchRateLimitsWithRetry(attempt int) (*RateLimitData, error) {
-	if c.token == "" {
+func (c *Client) fetchRateLimitsWithRetry(ctx context.Context, attempt int) (*RateLimitData, error) {
+	c.mu.Lock()
+	token, hasRefreshToken := c.token, c.refreshToken != ""
+	etag, lastModified, lastData := c.etag, c.lastModified, c.lastData
+	c.mu.Unlock()
+
+	if token == "" {
 		return nil, fmt.Errorf("no OAuth token configured")
 	}
 
-	req, err := http.NewRequest("GET", usageEndpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", usageEndpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers to match Claude CLI
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("anthropic-beta", anthropicBeta)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", userAgent)
 
+	// Ask the server to skip the body if nothing changed since the last response
+	if lastData != nil {
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
 	// Make request
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &networkError{err}
 	}
 	defer resp.Body.Close()
 
 	// Handle 401 Unauthorized with token refresh
 	if resp.StatusCode == http.StatusUnauthorized {
 		if attempt >= maxRetries {
-			return nil, fmt.Errorf("max retries (%d) exceeded after token refresh attempts", maxRetries)
+			return nil, fmt.Errorf("%w: max retries (%d) exceeded after token refresh attempts", ErrTokenExpired, maxRetries)
 		}
 
-		if c.refreshToken == "" {
+		if !hasRefreshToken {
 			body, _ := io.ReadAll(resp.Body)
-			return nil, fmt.Errorf("token expired and no refresh token available. Status %d: %s", resp.StatusCode, string(body))
+			return nil, fmt.Errorf("%w and no refresh token available. Status %d: %s", ErrTokenExpired, resp.StatusCode, string(body))
 		}
 
 		log.Printf("Token expired (attempt %d/%d), refreshing...", attempt+1, maxRetries)
 
-		// Attempt to refresh the token
-		newToken, err := c.RefreshAccessToken()
-		if err != nil {
-			return nil, fmt.Errorf("failed to refresh token: %w", err)
+		// Attempt to refresh the token; RefreshAccessToken stores the new one
+		if _, err := c.RefreshAccessToken(ctx); err != nil {
+			return nil, fmt.Errorf("%w: failed to refresh token: %w", ErrTokenExpired, err)
 		}
-
-		// Token already updated in RefreshAccessToken, but being explicit
-		c.token = newToken
 		log.Printf("Token refreshed successfully, retrying request")
 
 		// Retry the request with the new token
-		return c.fetchRateLimitsWithRetry(attempt + 1)
+		return c.fetchRateLimitsWithRetry(ctx, attempt+1)
+	}
+
+	// Unchanged since the last response: reuse it without parsing
+	if resp.StatusCode == http.StatusNotModified && lastData != nil {
+		data := *lastData
+		data.FetchedAt = time.Now()
+		return &data, nil
 	}
 
 	// Check for other errors
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, asScopeError(newStatusError(resp, body))
 	}
 
 	// Parse response
@@ -195,21 +339,35 @@ func (c *Client) fetchRateLimitsWithRetry(attempt int) (*RateLimitData, error) {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	// Convert to RateLimitData
-	return parseUsageResponse(&usage), nil
+	// Convert to RateLimitData, remembering it for conditional requests
+	data := parseUsageResponse(&usage)
+	c.mu.Lock()
+	if c.token == token {
+		c.etag = resp.Header.Get("ETag")
+		c.lastModified = resp.Header.Get("Last-Modified")
+		c.lastData = data
+	}
+	c.mu.Unlock()
+
+	result := *data
+	return &result, nil
 }
 
 // RefreshAccessToken uses the refresh token to obtain a new access token.
 // Returns the new access token on success.
-func (c *Client) RefreshAccessToken() (string, error) {
-	if c.refreshToken == "" {
+func (c *Client) RefreshAccessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	refreshToken := c.refreshToken
+	c.mu.Unlock()
+
+	if refreshToken == "" {
 		return "", fmt.Errorf("no refresh token available")
 	}
 
 	// Prepare the refresh request
 	reqBody := tokenRefreshRequest{
 		GrantType:    "refresh_token",
-		RefreshToken: c.refreshToken,
+		RefreshToken: refreshToken,
 		ClientID:     clientID,
 		Scope:        oauthScopes,
 	}
@@ -219,7 +377,7 @@ func (c *Client) RefreshAccessToken() (string, error) {
 		return "", fmt.Errorf("failed to marshal refresh request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", tokenEndpoint, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenEndpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return "", fmt.Errorf("failed to create refresh request: %w", err)
 	}
@@ -248,18 +406,29 @@ func (c *Client) RefreshAccessToken() (string, error) {
 		return "", fmt.Errorf("failed to parse refresh response: %w", err)
 	}
 
-	// Update the access token
+	redact.Secret(refreshResp.AccessToken)
+	redact.Secret(refreshResp.RefreshToken)
+
+	// Update the access token. It belongs to the same account, so the
+	// cached response stays valid.
+	c.mu.Lock()
 	c.token = refreshResp.AccessToken
 
 	// Check if we got a new refresh token (some OAuth servers rotate them)
-	if refreshResp.RefreshToken != "" && refreshResp.RefreshToken != c.refreshToken {
-		oldRefreshToken := c.refreshToken
+	rotated := refreshResp.RefreshToken != "" && refreshResp.RefreshToken != c.refreshToken
+	if rotated {
 		c.refreshToken = refreshResp.RefreshToken
+	}
+	onUpdate := c.onRefreshTokenUpdate
+	c.mu.Unlock()
+
+	if rotated {
+		oldRefreshToken := refreshToken
 		log.Printf("Received a new refresh token from the server (token rotated)")
 
 		// Call the callback to persist the new refresh token
-		if c.onRefreshTokenUpdate != nil {
-			c.onRefreshTokenUpdate(refreshResp.RefreshToken)
+		if onUpdate != nil {
+			onUpdate(refreshResp.AccessToken, refreshResp.RefreshToken)
 		}
 
 		// Also write a debug warning file next to the binary (for troubleshooting)
@@ -272,7 +441,8 @@ func (c *Client) RefreshAccessToken() (string, error) {
 }
 
 // writeRefreshTokenWarning creates a debug file next to the binary when a new refresh token is received.
-// This is kept for debugging purposes even though we now persist tokens to credentials file.
+// It identifies the tokens by fingerprint only; the new token itself is kept by the rotation callback,
+// in the credentials file or the encrypted token store.
 func (c *Client) writeRefreshTokenWarning(newRefreshToken, oldRefreshToken string) error {
 	exe, err := os.Executable()
 	if err != nil {
@@ -286,20 +456,22 @@ func (c *Client) writeRefreshTokenWarning(newRefreshToken, oldRefreshToken strin
 
 A new refresh token was received at: %s
 
-The credentials file should have been updated automatically.
-This file is kept for debugging purposes.
+The new token was saved to the credentials file, or to the encrypted token
+store with read_only_credentials. Tokens are never written to this file;
+the fingerprints below only tell them apart.
 
 Old refresh token: %s
 New refresh token: %s
 
-If you see authentication errors after restart, check the credentials file.
+If you see authentication errors after restart, check the log and run
+claude-usage doctor.
 `,
 		time.Now().Format(time.RFC3339),
-		oldRefreshToken,
-		newRefreshToken,
+		redact.Fingerprint(oldRefreshToken),
+		redact.Fingerprint(newRefreshToken),
 	)
 
-	if err := os.WriteFile(warningPath, []byte(content), 0644); err != nil {
+	if err := os.WriteFile(warningPath, []byte(content), 0600); err != nil {
 		return fmt.Errorf("failed to write warning file: %w", err)
 	}
 
@@ -347,6 +519,31 @@ func parseUsageResponse(usage *usageResponse) *RateLimitData {
 		data.RepresentativeClaim = "seven_day"
 	}
 
+	// Parse OAuth apps and Cowork buckets
+	if usage.SevenDayOauthApps != nil {
+		data.OAuthAppsUtilization = usage.SevenDayOauthApps.Utilization / 100.0
+		if t, err := time.Parse(time.RFC3339, usage.SevenDayOauthApps.ResetsAt); err == nil {
+			data.OAuthAppsReset = t
+		}
+	}
+	if usage.SevenDayCowork != nil {
+		data.CoworkUtilization = usage.SevenDayCowork.Utilization / 100.0
+		if t, err := time.Parse(time.RFC3339, usage.SevenDayCowork.ResetsAt); err == nil {
+			data.CoworkReset = t
+		}
+	}
+
+	// Parse extra usage (API reports credits in cents)
+	if usage.ExtraUsage.IsEnabled {
+		data.ExtraUsageEnabled = true
+		if usage.ExtraUsage.UsedCredits != nil {
+			data.ExtraUsageUsed = *usage.ExtraUsage.UsedCredits / 100.0
+		}
+		if usage.ExtraUsage.MonthlyLimit != nil {
+			data.ExtraUsageLimit = *usage.ExtraUsage.MonthlyLimit / 100.0
+		}
+	}
+
 	// Check if throttled (utilization >= 100%)
 	if data.FiveHourUtilization >= 1.0 || data.WeeklyUtilization >= 1.0 {
 		data.Status = "throttled"
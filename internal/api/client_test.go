@@ -0,0 +1,269 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"claude-usage/internal/testserver"
+)
+
+func TestFetchRateLimitsConditionalRequest(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls > 1 {
+			if got := r.Header.Get("If-None-Match"); got != `"v1"` {
+				t.Errorf("If-None-Match = %q, want %q", got, `"v1"`)
+			}
+			if got := r.Header.Get("If-Modified-Since"); got != "Thu, 01 Jan 2026 12:00:00 GMT" {
+				t.Errorf("If-Modified-Since = %q", got)
+			}
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if r.Header.Get("If-None-Match") != "" {
+			t.Error("first request should be unconditional")
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Thu, 01 Jan 2026 12:00:00 GMT")
+		w.Write([]byte(`{"five_hour":{"utilization":42},"seven_day":{"utilization":10}}`))
+	}))
+	defer srv.Close()
+
+	oldEndpoint := usageEndpoint
+	usageEndpoint = srv.URL
+	defer func() { usageEndpoint = oldEndpoint }()
+
+	client := NewClient("token")
+	first, err := client.FetchRateLimits(context.Background())
+	if err != nil {
+		t.Fatalf("first FetchRateLimits: %v", err)
+	}
+	second, err := client.FetchRateLimits(context.Background())
+	if err != nil {
+		t.Fatalf("second FetchRateLimits: %v", err)
+	}
+	if second.FiveHourUtilization != 0.42 {
+		t.Errorf("FiveHourUtilization after 304 = %v, want 0.42", second.FiveHourUtilization)
+	}
+	if second.FetchedAt.Before(first.FetchedAt) {
+		t.Error("FetchedAt was not updated on 304")
+	}
+
+	// A new token drops the cached response
+	client.SetToken("other")
+	if client.lastData != nil || client.etag != "" {
+		t.Error("SetToken with a new token kept the cached response")
+	}
+}
+
+func TestFetchRateLimitsDeduplicatesConcurrentCalls(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		w.Write([]byte(`{"five_hour":{"utilization":42},"seven_day":{"utilization":10}}`))
+	}))
+	defer srv.Close()
+
+	oldEndpoint := usageEndpoint
+	usageEndpoint = srv.URL
+	defer func() { usageEndpoint = oldEndpoint }()
+
+	client := NewClient("token")
+	var wg sync.WaitGroup
+	results := make([]*RateLimitData, 3)
+	fetch := func(i int) {
+		defer wg.Done()
+		data, err := client.FetchRateLimits(context.Background())
+		if err != nil {
+			t.Errorf("FetchRateLimits: %v", err)
+			return
+		}
+		results[i] = data
+	}
+
+	// Start one request, then pile more on while it is in flight
+	wg.Add(len(results))
+	go fetch(0)
+	<-started
+	for i := 1; i < len(results); i++ {
+		go fetch(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+	for i, data := range results {
+		if data == nil || data.FiveHourUtilization != 0.42 {
+			t.Errorf("result %d = %+v", i, data)
+		}
+	}
+}
+
+func TestParseUsageResponseBuckets(t *testing.T) {
+	var usage usageResponse
+	body := `{"five_hour":{"utilization":10},"seven_day":{"utilization":20},
+		"seven_day_oauth_apps":{"utilization":35,"resets_at":"2026-01-05T00:00:00Z"},
+		"seven_day_cowork":{"utilization":60,"resets_at":"2026-01-06T00:00:00Z"}}`
+	if err := json.Unmarshal([]byte(body), &usage); err != nil {
+		t.Fatal(err)
+	}
+
+	data := parseUsageResponse(&usage)
+	if data.OAuthAppsUtilization != 0.35 || data.CoworkUtilization != 0.60 {
+		t.Errorf("OAuth apps = %v, Cowork = %v, want 0.35, 0.6", data.OAuthAppsUtilization, data.CoworkUtilization)
+	}
+	if want := time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC); !data.CoworkReset.Equal(want) {
+		t.Errorf("CoworkReset = %v, want %v", data.CoworkReset, want)
+	}
+}
+
+func TestParseUsageResponseExtraUsage(t *testing.T) {
+	var usage usageResponse
+	body := `{"five_hour":{"utilization":10},"seven_day":{"utilization":20},
+		"extra_usage":{"is_enabled":true,"monthly_limit":5000,"used_credits":1240,"utilization":24.8}}`
+	if err := json.Unmarshal([]byte(body), &usage); err != nil {
+		t.Fatal(err)
+	}
+
+	data := parseUsageResponse(&usage)
+	if !data.ExtraUsageEnabled || data.ExtraUsageUsed != 12.40 || data.ExtraUsageLimit != 50 {
+		t.Errorf("extra usage = %v $%v / $%v, want true $12.4 / $50",
+			data.ExtraUsageEnabled, data.ExtraUsageUsed, data.ExtraUsageLimit)
+	}
+}
+
+// useTestServer points the client at a fake API server until the test ends.
+func useTestServer(t *testing.T) *testserver.Server {
+	srv := testserver.New()
+	t.Cleanup(srv.Close)
+	oldUsage, oldToken, oldMessages := usageEndpoint, tokenEndpoint, messagesEndpoint
+	t.Cleanup(func() { usageEndpoint, tokenEndpoint, messagesEndpoint = oldUsage, oldToken, oldMessages })
+	if err := SetBaseURL(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
+
+func TestSetBaseURL(t *testing.T) {
+	oldUsage, oldToken, oldMessages := usageEndpoint, tokenEndpoint, messagesEndpoint
+	defer func() { usageEndpoint, tokenEndpoint, messagesEndpoint = oldUsage, oldToken, oldMessages }()
+
+	if err := SetBaseURL("http://127.0.0.1:8080/mock/"); err != nil {
+		t.Fatal(err)
+	}
+	if usageEndpoint != "http://127.0.0.1:8080/mock"+testserver.UsagePath {
+		t.Errorf("usage endpoint = %q", usageEndpoint)
+	}
+	if tokenEndpoint != "http://127.0.0.1:8080/mock"+testserver.TokenPath {
+		t.Errorf("token endpoint = %q", tokenEndpoint)
+	}
+	if messagesEndpoint != "http://127.0.0.1:8080/mock"+testserver.MessagesPath {
+		t.Errorf("messages endpoint = %q", messagesEndpoint)
+	}
+
+	for _, base := range []string{"", "localhost:8080", "ftp://example.com"} {
+		if err := SetBaseURL(base); err == nil {
+			t.Errorf("SetBaseURL(%q) succeeded", base)
+		}
+	}
+}
+
+func TestFetchRateLimits_TestServer(t *testing.T) {
+	srv := useTestServer(t)
+	reset := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	srv.SetUsage(testserver.Usage{FiveHour: 100, FiveHourReset: reset, Weekly: 55})
+
+	data, err := NewClient(testserver.AccessToken).FetchRateLimits(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.FiveHourUtilization != 1 || data.WeeklyUtilization != 0.55 || !data.FiveHourReset.Equal(reset) {
+		t.Errorf("got %+v", data)
+	}
+	if data.Status != "throttled" || data.RepresentativeClaim != "five_hour" {
+		t.Errorf("status = %q, claim = %q, want throttled, five_hour", data.Status, data.RepresentativeClaim)
+	}
+}
+
+func TestFetchRateLimits_TokenRotation(t *testing.T) {
+	srv := useTestServer(t)
+	srv.ExpireToken()
+
+	client := NewClient(testserver.AccessToken)
+	client.SetRefreshToken(testserver.RefreshToken)
+	var rotated [2]string
+	client.SetRefreshTokenCallback(func(accessToken, refreshToken string) {
+		rotated = [2]string{accessToken, refreshToken}
+	})
+
+	if _, err := client.FetchRateLimits(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	access, refresh := srv.Tokens()
+	if rotated != [2]string{access, refresh} {
+		t.Errorf("callback got %v, want the server's new tokens %q, %q", rotated, access, refresh)
+	}
+	if srv.TokenRequests() != 1 || srv.UsageRequests() != 2 {
+		t.Errorf("got %d token and %d usage requests, want 1 and 2", srv.TokenRequests(), srv.UsageRequests())
+	}
+
+	// The new token is used from now on
+	if _, err := client.FetchRateLimits(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if srv.TokenRequests() != 1 {
+		t.Errorf("refreshed the token again")
+	}
+}
+
+func TestFetchRateLimits_TokenExpiredWithoutRefreshToken(t *testing.T) {
+	srv := useTestServer(t)
+	srv.ExpireToken()
+
+	_, err := NewClient(testserver.AccessToken).FetchRateLimits(context.Background())
+	if !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("got %v, want ErrTokenExpired", err)
+	}
+}
+
+func TestFetchRateLimits_Throttled(t *testing.T) {
+	srv := useTestServer(t)
+	client := NewClient(testserver.AccessToken)
+
+	// Retry-After is honoured...
+	srv.Throttle(1, time.Second)
+	start := time.Now()
+	if _, err := client.FetchRateLimits(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, before Retry-After", elapsed)
+	}
+
+	// ...unless it is beyond the retry budget
+	srv.Throttle(1, 2*defaultRetryPolicy.MaxElapsed)
+	_, err := client.FetchRateLimits(context.Background())
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("got %v, want a 429 StatusError", err)
+	}
+	if srv.UsageRequests() != 3 {
+		t.Errorf("got %d usage requests, want 3", srv.UsageRequests())
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Demo cycle lengths. The 5-hour window sweeps from empty to throttled every
+// demoFiveHourCycle so every colour and notification threshold is crossed
+// within a few minutes.
+const (
+	demoFiveHourCycle = 10 * time.Minute
+	demoWeeklyCycle   = time.Hour
+)
+
+// DemoProvider generates realistic, changing rate limits without credentials
+// or network access. It is used by --demo for screenshots, UI work and
+// testing notification thresholds.
+type DemoProvider struct {
+	start time.Time
+}
+
+// NewDemoProvider creates a demo provider whose cycles start now.
+func NewDemoProvider() *DemoProvider {
+	return &DemoProvider{start: time.Now()}
+}
+
+// FetchRateLimits returns generated rate limits for the current time.
+func (p *DemoProvider) FetchRateLimits(ctx context.Context) (*RateLimitData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return p.dataAt(time.Now()), nil
+}
+
+// dataAt generates rate limits for the given time.
+func (p *DemoProvider) dataAt(now time.Time) *RateLimitData {
+	elapsed := now.Sub(p.start)
+
+	// 5-hour window: a sawtooth from 0% to 105%, with its reset countdown
+	// scaled so the window "resets" when the sawtooth drops
+	phase := float64(elapsed%demoFiveHourCycle) / float64(demoFiveHourCycle)
+	fiveHour := phase * 1.05
+	fiveHourReset := now.Add(time.Duration((1 - phase) * float64(5*time.Hour)))
+
+	// Weekly window: drifts slowly between 20% and 80%
+	weeklyPhase := float64(elapsed%demoWeeklyCycle) / float64(demoWeeklyCycle)
+	weekly := 0.5 - 0.3*math.Cos(2*math.Pi*weeklyPhase)
+	weeklyReset := p.start.Add(3*24*time.Hour + 7*time.Hour)
+
+	data := &RateLimitData{
+		FiveHourUtilization: fiveHour,
+		WeeklyUtilization:   weekly,
+		FiveHourReset:       fiveHourReset,
+		WeeklyReset:         weeklyReset,
+		SonnetUtilization:   weekly * 0.8,
+		SonnetReset:         weeklyReset,
+		ExtraUsageEnabled:   true,
+		ExtraUsageUsed:      math.Round(weekly*2000) / 100,
+		ExtraUsageLimit:     50,
+		Status:              "allowed",
+		FetchedAt:           now,
+	}
+
+	if data.FiveHourUtilization > data.WeeklyUtilization {
+		data.RepresentativeClaim = "five_hour"
+	} else {
+		data.RepresentativeClaim = "seven_day"
+	}
+	if data.FiveHourUtilization >= 1.0 || data.WeeklyUtilization >= 1.0 {
+		data.Status = "throttled"
+	}
+
+	return data
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strconv"
+	"time"
+
+	"claude-usage/internal/config"
+)
+
+// Regular API responses to OAuth requests carry the same rate limits as
+// the usage endpoint in these headers, as fractions, with resets in Unix
+// seconds.
+const (
+	headerPrefix         = "Anthropic-Ratelimit-Unified-"
+	headerStatus         = headerPrefix + "Status"
+	headerFiveHour       = headerPrefix + "5h-Utilization"
+	headerFiveHourReset  = headerPrefix + "5h-Reset"
+	headerWeekly         = headerPrefix + "7d-Utilization"
+	headerWeeklyReset    = headerPrefix + "7d-Reset"
+	headerRepresentative = headerPrefix + "Representative-Claim"
+	headerOverageStatus  = headerPrefix + "Overage-Status"
+
+	// headerStatusRejected is the status of a throttled account
+	headerStatusRejected = "rejected"
+)
+
+// errNoRateLimitHeaders is returned when a response has no rate limit
+// headers.
+var errNoRateLimitHeaders = errors.New("no anthropic-ratelimit-unified headers")
+
+// The probe request: the smallest Messages API request there is.
+const (
+	anthropicVersion = "2023-06-01"
+	probeMaxTokens   = 1
+	probePrompt      = "quota"
+)
+
+// HeaderTolerance is how far apart, as a fraction, the usage endpoint and
+// the rate limit headers may be before they are said to disagree. Usage
+// moves on between the two requests, and the headers are rounded.
+const HeaderTolerance = 0.05
+
+// messagesEndpoint is the Messages API endpoint probed for rate limit
+// headers, and probeModel the model asked.
+var (
+	messagesEndpoint = config.GetClaudeMessagesEndpoint()
+	probeModel       = config.GetClaudeProbeModel()
+)
+
+// HeaderProber is implemented by providers that can read the rate limit
+// headers of a regular API request, such as *Client.
+type HeaderProber interface {
+	ProbeRateLimitHeaders(ctx context.Context) (*RateLimitData, error)
+}
+
+var _ HeaderProber = (*Client)(nil)
+
+// ParseRateLimitHeaders returns the rate limits in the
+// anthropic-ratelimit-unified-* headers of h, and false if there are none.
+func ParseRateLimitHeaders(h http.Header) (*RateLimitData, bool) {
+	fiveHour, okFiveHour := parseFraction(h.Get(headerFiveHour))
+	weekly, okWeekly := parseFraction(h.Get(headerWeekly))
+	if !okFiveHour && !okWeekly {
+		return nil, false
+	}
+
+	data := &RateLimitData{
+		FiveHourUtilization: fiveHour,
+		WeeklyUtilization:   weekly,
+		FiveHourReset:       parseUnix(h.Get(headerFiveHourReset)),
+		WeeklyReset:         parseUnix(h.Get(headerWeeklyReset)),
+		RepresentativeClaim: h.Get(headerRepresentative),
+		OverageStatus:       h.Get(headerOverageStatus),
+		Status:              "allowed",
+		FetchedAt:           time.Now(),
+	}
+	if h.Get(headerStatus) == headerStatusRejected || fiveHour >= 1.0 || weekly >= 1.0 {
+		data.Status = "throttled"
+	}
+	return data, true
+}
+
+// ReadHeaderFile reads rate limits from a file of response headers saved
+// by a local proxy: "Name: value" lines, optionally after a status line,
+// as curl -D writes them. FetchedAt is the file's modification time.
+func ReadHeaderFile(path string) (*RateLimitData, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(f)
+	if status, _ := r.Peek(5); string(status) == "HTTP/" {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	h, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	data, ok := ParseRateLimitHeaders(http.Header(h))
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", path, errNoRateLimitHeaders)
+	}
+	data.FetchedAt = info.ModTime()
+	return data, nil
+}
+
+// HeadersDisagree reports whether the rate limits from the headers differ
+// from those of the usage endpoint by more than HeaderTolerance in either
+// window.
+func HeadersDisagree(usage, headers *RateLimitData) bool {
+	return math.Abs(usage.FiveHourUtilization-headers.FiveHourUtilization) > HeaderTolerance ||
+		math.Abs(usage.WeeklyUtilization-headers.WeeklyUtilization) > HeaderTolerance
+}
+
+// ProbeRateLimitHeaders sends a one-token Messages API request with the
+// current token and returns the rate limits in its response headers. It
+// uses a little of the quota it measures, so call it sparingly. The token
+// is not refreshed; the headers are read whatever the status, as
+// throttled responses carry them too.
+func (c *Client) ProbeRateLimitHeaders(ctx context.Context) (*RateLimitData, error) {
+	c.mu.Lock()
+	token := c.token
+	c.mu.Unlock()
+
+	if token == "" {
+		return nil, fmt.Errorf("no OAuth token configured")
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"model":      probeModel,
+		"max_tokens": probeMaxTokens,
+		"messages":   []map[string]string{{"role": "user", "content": probePrompt}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal probe request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", messagesEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("anthropic-beta", anthropicBeta)
+	req.Header.Set("anthropic-version", anthropicVersion)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &networkError{err}
+	}
+	defer resp.Body.Close()
+
+	if data, ok := ParseRateLimitHeaders(resp.Header); ok {
+		io.Copy(io.Discard, resp.Body)
+		return data, nil
+	}
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp, respBody)
+	}
+	return nil, fmt.Errorf("%w in the response", errNoRateLimitHeaders)
+}
+
+// parseFraction parses a utilization header.
+func parseFraction(s string) (float64, bool) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || f < 0 {
+		return 0, false
+	}
+	return f, true
+}
+
+// parseUnix parses a reset header, returning the zero time if invalid.
+func parseUnix(s string) time.Time {
+	secs, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || secs <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0)
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"claude-usage/internal/testserver"
+)
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	h := http.Header{}
+	if _, ok := ParseRateLimitHeaders(h); ok {
+		t.Error("parsed rate limits from no headers")
+	}
+
+	h.Set("anthropic-ratelimit-unified-status", "rejected")
+	h.Set("anthropic-ratelimit-unified-5h-utilization", "0.48")
+	h.Set("anthropic-ratelimit-unified-5h-reset", "1767607200")
+	h.Set("anthropic-ratelimit-unified-7d-utilization", "0.12")
+	h.Set("anthropic-ratelimit-unified-representative-claim", "five_hour")
+	data, ok := ParseRateLimitHeaders(h)
+	if !ok {
+		t.Fatal("no rate limits parsed")
+	}
+	if data.FiveHourUtilization != 0.48 || data.WeeklyUtilization != 0.12 {
+		t.Errorf("got 5h %v, weekly %v, want 0.48, 0.12", data.FiveHourUtilization, data.WeeklyUtilization)
+	}
+	if !data.FiveHourReset.Equal(time.Unix(1767607200, 0)) || !data.WeeklyReset.IsZero() {
+		t.Errorf("got resets %v, %v", data.FiveHourReset, data.WeeklyReset)
+	}
+	if data.Status != "throttled" || data.RepresentativeClaim != "five_hour" {
+		t.Errorf("got status %q, claim %q, want throttled, five_hour", data.Status, data.RepresentativeClaim)
+	}
+}
+
+func TestReadHeaderFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "headers.txt")
+	dump := "HTTP/2 200 \r\n" +
+		"content-type: application/json\r\n" +
+		"anthropic-ratelimit-unified-5h-utilization: 0.3\r\n" +
+		"anthropic-ratelimit-unified-7d-utilization: 0.61\r\n" +
+		"\r\n"
+	if err := os.WriteFile(path, []byte(dump), 0600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+	os.Chtimes(path, modTime, modTime)
+
+	data, err := ReadHeaderFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.FiveHourUtilization != 0.3 || data.WeeklyUtilization != 0.61 || !data.FetchedAt.Equal(modTime) {
+		t.Errorf("got %+v", data)
+	}
+
+	// Without a status line or a final blank line, as hand-written files go
+	os.WriteFile(path, []byte("anthropic-ratelimit-unified-7d-utilization: 0.5"), 0600)
+	if data, err := ReadHeaderFile(path); err != nil || data.WeeklyUtilization != 0.5 {
+		t.Errorf("got %+v, %v", data, err)
+	}
+
+	os.WriteFile(path, []byte("content-type: text/plain\n"), 0600)
+	if _, err := ReadHeaderFile(path); !errors.Is(err, errNoRateLimitHeaders) {
+		t.Errorf("got %v, want errNoRateLimitHeaders", err)
+	}
+}
+
+func TestHeadersDisagree(t *testing.T) {
+	usage := &RateLimitData{FiveHourUtilization: 0.42, WeeklyUtilization: 0.10}
+	if HeadersDisagree(usage, &RateLimitData{FiveHourUtilization: 0.44, WeeklyUtilization: 0.10}) {
+		t.Error("2 points apart should agree")
+	}
+	if !HeadersDisagree(usage, &RateLimitData{FiveHourUtilization: 0.42, WeeklyUtilization: 0.20}) {
+		t.Error("10 points apart in the week should disagree")
+	}
+}
+
+func TestProbeRateLimitHeaders(t *testing.T) {
+	srv := useTestServer(t)
+	srv.SetHeaderUsage(testserver.Usage{FiveHour: 55, Weekly: 12})
+
+	data, err := NewClient(testserver.AccessToken).ProbeRateLimitHeaders(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.FiveHourUtilization != 0.55 || data.WeeklyUtilization != 0.12 {
+		t.Errorf("got 5h %v, weekly %v, want 0.55, 0.12", data.FiveHourUtilization, data.WeeklyUtilization)
+	}
+
+	// Throttled responses carry the headers too
+	srv.SetHeaderUsage(testserver.Usage{FiveHour: 100, Weekly: 40})
+	data, err = NewClient(testserver.AccessToken).ProbeRateLimitHeaders(context.Background())
+	if err != nil || data.Status != "throttled" {
+		t.Errorf("got %+v, %v, want throttled rate limits", data, err)
+	}
+
+	srv.ExpireToken()
+	_, err = NewClient(testserver.AccessToken).ProbeRateLimitHeaders(context.Background())
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("got %v, want a 401 StatusError", err)
+	}
+}
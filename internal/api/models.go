@@ -1,7 +1,11 @@
 // Package api provides a client for fetching Claude API rate limit information.
 package api
 
-import "time"
+import (
+	"time"
+
+	"claude-usage/internal/stats"
+)
 
 // RateLimitData represents rate limit information from Anthropic API.
 type RateLimitData struct {
@@ -32,10 +36,45 @@ type RateLimitData struct {
 	OpusReset         time.Time
 	SonnetReset       time.Time
 
+	// Weekly utilization of the OAuth apps and Cowork buckets
+	OAuthAppsUtilization float64
+	CoworkUtilization    float64
+	OAuthAppsReset       time.Time
+	CoworkReset          time.Time
+
+	// Extra usage (pay-as-you-go credits), in dollars.
+	// ExtraUsageLimit is zero when no monthly limit is set.
+	ExtraUsageEnabled bool
+	ExtraUsageUsed    float64
+	ExtraUsageLimit   float64
+
 	// FetchedAt is when this data was fetched
 	FetchedAt time.Time
 }
 
+// Apply copies the rate limits into weeklyStats and marks it as holding
+// API data.
+func (r *RateLimitData) Apply(weeklyStats *stats.WeeklyStats) {
+	weeklyStats.HasAPIData = true
+	weeklyStats.FiveHourUtilization = r.FiveHourUtilization
+	weeklyStats.WeeklyUtilization = r.WeeklyUtilization
+	weeklyStats.FiveHourReset = r.FiveHourReset
+	weeklyStats.WeeklyReset = r.WeeklyReset
+	weeklyStats.RateLimitStatus = r.Status
+	weeklyStats.RepresentativeClaim = r.RepresentativeClaim
+	weeklyStats.OpusUtilization = r.OpusUtilization
+	weeklyStats.SonnetUtilization = r.SonnetUtilization
+	weeklyStats.OpusReset = r.OpusReset
+	weeklyStats.SonnetReset = r.SonnetReset
+	weeklyStats.OAuthAppsUtilization = r.OAuthAppsUtilization
+	weeklyStats.CoworkUtilization = r.CoworkUtilization
+	weeklyStats.OAuthAppsReset = r.OAuthAppsReset
+	weeklyStats.CoworkReset = r.CoworkReset
+	weeklyStats.ExtraUsageEnabled = r.ExtraUsageEnabled
+	weeklyStats.ExtraUsageUsed = r.ExtraUsageUsed
+	weeklyStats.ExtraUsageLimit = r.ExtraUsageLimit
+}
+
 // GetWeeklyPercentage returns the weekly utilization as a percentage (0-100).
 func (r *RateLimitData) GetWeeklyPercentage() int {
 	if r == nil {
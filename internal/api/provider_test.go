@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+	if err := os.WriteFile(path, []byte(`{"FiveHourUtilization":0.5,"WeeklyUtilization":0.25}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := NewFileProvider(path).FetchRateLimits(context.Background())
+	if err != nil {
+		t.Fatalf("FetchRateLimits: %v", err)
+	}
+	if data.FiveHourUtilization != 0.5 || data.WeeklyUtilization != 0.25 {
+		t.Errorf("utilization = %v / %v, want 0.5 / 0.25", data.FiveHourUtilization, data.WeeklyUtilization)
+	}
+	if data.FetchedAt.IsZero() {
+		t.Error("FetchedAt not set from the file's modification time")
+	}
+	if data.Status != "allowed" {
+		t.Errorf("Status = %q, want %q", data.Status, "allowed")
+	}
+
+	if _, err := NewFileProvider(filepath.Join(t.TempDir(), "missing.json")).FetchRateLimits(context.Background()); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestDemoProviderCycles(t *testing.T) {
+	p := NewDemoProvider()
+
+	start := p.dataAt(p.start)
+	if start.FiveHourUtilization != 0 || start.IsThrottled() {
+		t.Errorf("at start: 5h = %v, status = %q; want 0, allowed", start.FiveHourUtilization, start.Status)
+	}
+
+	peak := p.dataAt(p.start.Add(demoFiveHourCycle - time.Second))
+	if !peak.IsThrottled() || !peak.IsLimitedByFiveHour() {
+		t.Errorf("at peak: status = %q, claim = %q; want throttled by five_hour", peak.Status, peak.RepresentativeClaim)
+	}
+
+	for d := time.Duration(0); d < demoWeeklyCycle; d += time.Minute {
+		data := p.dataAt(p.start.Add(d))
+		if data.WeeklyUtilization < 0.2 || data.WeeklyUtilization > 0.8 {
+			t.Fatalf("at %s: weekly = %v, want 0.2-0.8", d, data.WeeklyUtilization)
+		}
+		if !data.FiveHourReset.After(p.start.Add(d)) {
+			t.Fatalf("at %s: 5h reset %v is not in the future", d, data.FiveHourReset)
+		}
+	}
+}
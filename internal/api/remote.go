@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"claude-usage/internal/httpclient"
+)
+
+var _ UsageProvider = (*RemoteProvider)(nil)
+
+// RemoteProvider reads rate limit data from the control API of an agent
+// (see "claude-usage agent") on another machine, so only the agent needs
+// the OAuth credentials. The agent answers GET /rate-limits in the format
+// of the offline cache (see SaveCache).
+type RemoteProvider struct {
+	URL   string
+	Token string
+
+	httpClient *http.Client
+}
+
+// NewRemoteProvider creates a provider that reads rate limits from the
+// agent at url, authenticating with token.
+func NewRemoteProvider(url, token string) *RemoteProvider {
+	return &RemoteProvider{
+		URL:        strings.TrimSuffix(url, "/"),
+		Token:      token,
+		httpClient: httpclient.New(30 * time.Second),
+	}
+}
+
+// FetchRateLimits asks the agent for its last rate limits. FetchedAt is
+// when the agent fetched them, so data the agent could not refresh shows
+// its age. A 503 StatusError means the agent has not fetched any yet.
+func (p *RemoteProvider) FetchRateLimits(ctx context.Context) (*RateLimitData, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.URL+"/rate-limits", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &networkError{err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newStatusError(resp, body)
+	}
+
+	var data RateLimitData
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if data.Status == "" {
+		data.Status = "allowed"
+	}
+	return &data, nil
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRemoteProvider(t *testing.T) {
+	ready := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rate-limits" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if !ready {
+			http.Error(w, "no rate limits yet", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"FiveHourUtilization":0.3,"WeeklyUtilization":0.55,"FetchedAt":"2026-01-14T12:00:00Z"}`))
+	}))
+	defer srv.Close()
+
+	p := NewRemoteProvider(srv.URL+"/", "secret")
+	_, err := p.FetchRateLimits(context.Background())
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got %v, want a 503 StatusError", err)
+	}
+
+	ready = true
+	data, err := p.FetchRateLimits(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.FiveHourUtilization != 0.3 || data.WeeklyUtilization != 0.55 || data.Status != "allowed" {
+		t.Errorf("got 5h %v, weekly %v, status %q", data.FiveHourUtilization, data.WeeklyUtilization, data.Status)
+	}
+	if data.FetchedAt.IsZero() {
+		t.Error("FetchedAt not read from the agent")
+	}
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"claude-usage/internal/logging"
+)
+
+// retryPolicy controls retries of transient failures (429, 5xx and network
+// errors) with exponential backoff and full jitter.
+type retryPolicy struct {
+	// InitialDelay is the backoff ceiling for the first retry; it doubles
+	// after every attempt up to MaxDelay.
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+
+	// MaxElapsed bounds the total time spent retrying, so a long outage
+	// fails the refresh instead of stalling it.
+	MaxElapsed time.Duration
+}
+
+// defaultRetryPolicy keeps total retry time well under the shortest
+// refresh interval.
+var defaultRetryPolicy = retryPolicy{
+	InitialDelay: 2 * time.Second,
+	MaxDelay:     30 * time.Second,
+	MaxElapsed:   90 * time.Second,
+}
+
+// ErrTokenExpired is returned, wrapped, when the API rejects the token and
+// it cannot be refreshed. Logging in again fixes it.
+var ErrTokenExpired = errors.New("token expired")
+
+// StatusError is returned when the API responds with an unexpected status.
+type StatusError struct {
+	StatusCode int
+	Body       string
+
+	// RetryAfter is the server-requested delay from a Retry-After header,
+	// or zero if none was sent.
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// RequiredScopes are the OAuth scopes the usage endpoint needs.
+var RequiredScopes = []string{"user:profile"}
+
+// ScopeError is returned when the API rejects the token because it lacks
+// an OAuth scope. Logging in again grants it.
+type ScopeError struct {
+	// Scope is the missing scope: the one named in the response, or
+	// the first of RequiredScopes
+	Scope string
+
+	Err *StatusError
+}
+
+// Error implements the error interface.
+func (e *ScopeError) Error() string {
+	return "re-login needed: missing " + e.Scope + " scope"
+}
+
+// Unwrap returns the underlying StatusError.
+func (e *ScopeError) Unwrap() error {
+	return e.Err
+}
+
+// scopeName matches OAuth scope names such as "user:profile".
+var scopeName = regexp.MustCompile(`user:[a-z_:]+`)
+
+// asScopeError returns a ScopeError for a 403 that mentions scopes, or
+// err unchanged.
+func asScopeError(err *StatusError) error {
+	if err.StatusCode != http.StatusForbidden || !strings.Contains(strings.ToLower(err.Body), "scope") {
+		return err
+	}
+	scope := scopeName.FindString(err.Body)
+	if scope == "" {
+		scope = RequiredScopes[0]
+	}
+	return &ScopeError{Scope: scope, Err: err}
+}
+
+// newStatusError builds a StatusError from a response and its body.
+func newStatusError(resp *http.Response, body []byte) *StatusError {
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// isRetryable reports whether err is worth retrying: rate limiting, server
+// errors and network failures. Cancellation is never retried.
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+
+	var netErr *networkError
+	return errors.As(err, &netErr)
+}
+
+// networkError marks a failure to reach the server at all.
+type networkError struct {
+	err error
+}
+
+// Error implements the error interface.
+func (e *networkError) Error() string {
+	return "failed to make request: " + e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *networkError) Unwrap() error {
+	return e.err
+}
+
+// parseRetryAfter parses a Retry-After header value, either delay seconds
+// or an HTTP date. Returns zero if the header is empty or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// withRetry calls fn until it succeeds, fails permanently, or the policy's
+// time budget runs out. The server's Retry-After takes precedence over the
+// computed backoff.
+func withRetry[T any](ctx context.Context, policy retryPolicy, fn func() (T, error)) (T, error) {
+	start := time.Now()
+	ceiling := policy.InitialDelay
+
+	for attempt := 1; ; attempt++ {
+		result, err := fn()
+		if err == nil {
+			return result, nil
+		}
+		if !isRetryable(err) {
+			logging.Debugf("Attempt %d failed, not retrying: %v", attempt, err)
+			return result, err
+		}
+
+		// Full jitter: a random delay up to the current ceiling
+		delay := time.Duration(rand.Int63n(int64(ceiling) + 1))
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+			delay = statusErr.RetryAfter
+		}
+
+		if time.Since(start)+delay > policy.MaxElapsed {
+			logging.Debugf("Attempt %d failed, retry budget of %s spent: %v", attempt, policy.MaxElapsed, err)
+			return result, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+		logging.Debugf("Attempt %d failed, retrying in %s: %v", attempt, delay.Round(time.Millisecond), err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, ctx.Err()
+		case <-timer.C:
+		}
+
+		ceiling *= 2
+		if ceiling > policy.MaxDelay {
+			ceiling = policy.MaxDelay
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"-5", 0},
+		{"soon", 0},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFetchRateLimitsRetriesTransientErrors(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Write([]byte(`{"five_hour":{"utilization":42},"seven_day":{"utilization":10}}`))
+		}
+	}))
+	defer srv.Close()
+
+	oldEndpoint, oldPolicy := usageEndpoint, defaultRetryPolicy
+	usageEndpoint = srv.URL
+	defaultRetryPolicy = retryPolicy{InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, MaxElapsed: time.Second}
+	defer func() { usageEndpoint, defaultRetryPolicy = oldEndpoint, oldPolicy }()
+
+	data, err := NewClient("token").FetchRateLimits(context.Background())
+	if err != nil {
+		t.Fatalf("FetchRateLimits: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("got %d calls, want 3", calls)
+	}
+	if data.FiveHourUtilization != 0.42 {
+		t.Errorf("FiveHourUtilization = %v, want 0.42", data.FiveHourUtilization)
+	}
+}
+
+func TestFetchRateLimitsDoesNotRetryClientErrors(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	oldEndpoint := usageEndpoint
+	usageEndpoint = srv.URL
+	defer func() { usageEndpoint = oldEndpoint }()
+
+	if _, err := NewClient("token").FetchRateLimits(context.Background()); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 1 {
+		t.Errorf("got %d calls, want 1", calls)
+	}
+}
+
+func TestFetchRateLimitsReportsMissingScope(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error":{"type":"permission_error","message":"OAuth token does not meet scope requirement user:profile"}}`))
+	}))
+	defer srv.Close()
+
+	oldEndpoint := usageEndpoint
+	usageEndpoint = srv.URL
+	defer func() { usageEndpoint = oldEndpoint }()
+
+	_, err := NewClient("token").FetchRateLimits(context.Background())
+	var scopeErr *ScopeError
+	if !errors.As(err, &scopeErr) || scopeErr.Scope != "user:profile" {
+		t.Fatalf("got %v, want a ScopeError for user:profile", err)
+	}
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+		t.Errorf("ScopeError does not unwrap to the 403: %v", err)
+	}
+
+	// Other 403s stay plain status errors
+	if err := asScopeError(&StatusError{StatusCode: http.StatusForbidden, Body: "account disabled"}); errors.As(err, &scopeErr) {
+		t.Errorf("got a ScopeError for %v", err)
+	}
+}
+
+func TestFetchRateLimitsReportsExpiredToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	oldEndpoint := usageEndpoint
+	usageEndpoint = srv.URL
+	defer func() { usageEndpoint = oldEndpoint }()
+
+	if _, err := NewClient("token").FetchRateLimits(context.Background()); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("got %v, want ErrTokenExpired", err)
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// TeamUsageProvider is implemented by providers that can also fetch
+// organization-wide usage, such as *Client.
+type TeamUsageProvider interface {
+	FetchTeamRateLimits(ctx context.Context, url string) (*RateLimitData, error)
+}
+
+var _ TeamUsageProvider = (*Client)(nil)
+
+// FetchTeamRateLimits fetches team-wide usage from url, an organization
+// usage endpoint answering in the format of the personal usage endpoint.
+// The request is made with the current token, which is not refreshed; a
+// 403 StatusError means the token may not read organization usage.
+func (c *Client) FetchTeamRateLimits(ctx context.Context, url string) (*RateLimitData, error) {
+	c.mu.Lock()
+	token := c.token
+	c.mu.Unlock()
+
+	if token == "" {
+		return nil, fmt.Errorf("no OAuth token configured")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("anthropic-beta", anthropicBeta)
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &networkError{err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newStatusError(resp, body)
+	}
+
+	var usage usageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&usage); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return parseUsageResponse(&usage), nil
+}
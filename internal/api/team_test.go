@@ -0,0 +1,38 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchTeamRateLimits(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if r.URL.Path == "/forbidden" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"five_hour":{"utilization":30},"seven_day":{"utilization":55}}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient("token")
+	data, err := client.FetchTeamRateLimits(context.Background(), srv.URL+"/team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.FiveHourUtilization != 0.3 || data.WeeklyUtilization != 0.55 {
+		t.Errorf("got 5h %v, weekly %v", data.FiveHourUtilization, data.WeeklyUtilization)
+	}
+
+	_, err = client.FetchTeamRateLimits(context.Background(), srv.URL+"/forbidden")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+		t.Errorf("got %v, want a 403 StatusError", err)
+	}
+}
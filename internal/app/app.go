@@ -2,50 +2,303 @@
 package app
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"maps"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"claude-usage/internal/api"
+	"claude-usage/internal/autostart"
+	"claude-usage/internal/chart"
+	"claude-usage/internal/clipboard"
 	"claude-usage/internal/config"
+	"claude-usage/internal/control"
+	"claude-usage/internal/events"
+	"claude-usage/internal/history"
+	"claude-usage/internal/hotkey"
+	"claude-usage/internal/httpclient"
 	"claude-usage/internal/icon"
+	"claude-usage/internal/instance"
+	"claude-usage/internal/integrations"
+	"claude-usage/internal/launch"
+	"claude-usage/internal/logging"
+	"claude-usage/internal/mqtt"
+	"claude-usage/internal/netwatch"
+	"claude-usage/internal/notify"
+	"claude-usage/internal/perms"
+	"claude-usage/internal/planlimits"
 	"claude-usage/internal/stats"
+	"claude-usage/internal/status"
+	"claude-usage/internal/taskbar"
+	"claude-usage/internal/tokenstore"
+	"claude-usage/internal/transcripts"
 	"claude-usage/internal/tray"
 	"claude-usage/internal/update"
+	"claude-usage/internal/usage"
+	"claude-usage/pkg/format"
 )
 
+// usagePageURL is the claude.ai page showing plan usage limits.
+const usagePageURL = "https://claude.ai/settings/usage"
+
+// shutdownTimeout is how long quitting waits for a running refresh to
+// finish, so token rotations, history and status files are written
+// rather than cut off.
+const shutdownTimeout = 5 * time.Second
+
+// maxSummaryLen is how much of a release's notes is shown before updating.
+const maxSummaryLen = 200
+
+// staleCheckInterval is how often the tray checks whether the data it shows
+// has become stale (see config.StaleAfter).
+const staleCheckInterval = time.Minute
+
+// maxStaleAge is how old cached rate limits may be and still be shown while
+// the API is unreachable. Older data spans a full weekly window and says
+// nothing useful.
+const maxStaleAge = 7 * 24 * time.Hour
+
+// flashInterval is how long each frame shows while the icon flashes.
+const flashInterval = 700 * time.Millisecond
+
+// sparklineSpan is how much usage the icon sparkline shows.
+const sparklineSpan = 24 * time.Hour
+
 // App is the main application struct that coordinates all components.
 type App struct {
-	config    *config.Config
-	version   string
-	tray      *tray.Tray
-	iconGen   *icon.Generator
-	apiClient *api.Client
-	stats     *stats.WeeklyStats
-	statsMu   sync.RWMutex
-	stopCh    chan struct{}
+	config   *config.Config
+	version  string
+	tray     *tray.Tray
+	iconGen  *icon.Generator
+	stopCh   chan struct{}
+	stopOnce sync.Once
+
+	// latest is the snapshot of the last refresh, nil before the first
+	latest atomic.Pointer[snapshot]
+
+	// bus carries events between the refresh loop and the tray, history,
+	// alerts and integrations (see presentLoop, observeLoop, notifyLoop)
+	bus events.Bus
+
+	// loops tracks the refresh loop and the snapshot consumers, which
+	// quitting waits for
+	loops sync.WaitGroup
+
+	// fetches are the recent API fetches from the history; guarded by
+	// fetchesMu
+	fetches   []history.Fetch
+	fetchesMu sync.Mutex
+
+	// provider is created lazily and dropped when the credential source or
+	// usage provider changes; providerMu guards the field, providers
+	// themselves are safe for concurrent use
+	provider   api.UsageProvider
+	providerMu sync.Mutex
+
+	// ctx is cancelled on quit, aborting in-flight API requests
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// cancelFetch cancels the in-flight API fetch, if any, so a newer
+	// refresh request can supersede it
+	cancelFetch   context.CancelFunc
+	cancelFetchMu sync.Mutex
+
 	refreshCh chan struct{}
+
+	// loopMu is held while the refresh loop works. heartbeat is when it
+	// last did (Unix nanoseconds) and loopGen counts the loops started by
+	// the watchdog; see watchdogLoop.
+	loopMu    sync.Mutex
+	heartbeat atomic.Int64
+	loopGen   atomic.Int64
+
+	// configCh delivers a reloaded or changed config to the running
+	// refresh loop; changeMu serializes changeConfig
+	configCh chan *config.Config
+	changeMu sync.Mutex
+
+	// lastRateLimits is the last successful API response, shown as stale
+	// data while the API is unreachable. Only used by the refresh loop.
+	lastRateLimits *api.RateLimitData
+
+	// transcripts incrementally scans Claude Code session transcripts.
+	// Recreated when the projects folder changes; only used by the
+	// refresh loop.
+	transcripts *transcripts.Scanner
+	projectsDir string
+
+	// sparkline holds the samples of the last sparklineSpan behind the
+	// icon sparkline, nil while it is off. Only used by the refresh loop.
+	sparkline []history.Sample
+
+	// paceAlerted is the weekly reset of the window last warned about
+	// being ahead of pace. Only used by observeLoop.
+	paceAlerted time.Time
+
+	// control serves the local control API; nil when disabled.
+	// controlMu guards it against quit racing a config reload.
+	control   *control.Server
+	controlMu sync.Mutex
+
+	// dbus exports usage on the Linux session bus; nil elsewhere or when
+	// the bus is unavailable. Set once in onReady.
+	dbus *control.DBusService
+
+	// badge shows usage on a Windows taskbar button; nil when disabled.
+	// Only used by the refresh loop.
+	badge *taskbar.Badge
+
+	// hotkey shows a usage summary when its shortcut is pressed; nil when
+	// disabled. Only used by the refresh loop.
+	hotkey *hotkey.Listener
+
+	// netWatch refreshes when connectivity returns; nil where it is not
+	// available. Set once in onReady.
+	netWatch *netwatch.Watcher
+
+	// flash alternates the tray icon while usage is nearly exhausted
+	flash flasher
+
+	// noTrayHost is set when the desktop can't show the tray icon; the
+	// control API then serves a dashboard even without control_port.
+	// Set once by watchTrayHost.
+	noTrayHost atomic.Bool
+
+	// paused stops automatic refreshes; manual ones still run
+	paused atomic.Bool
+
+	// away stops automatic refreshes while the screen is locked or the
+	// user idle; see presenceLoop
+	away atomic.Bool
+
+	// powerSaving is set while battery saver is on; see checkPower.
+	// powerUnknown is set once the power source could not be read, so
+	// that is logged once; used by checkPower only.
+	powerSaving  atomic.Bool
+	powerUnknown bool
+
+	// configProblems describes invalid config values, shown in the tooltip
+	configProblems []config.FieldError
+	configMu       sync.RWMutex
+
+	// failing is set while refreshes fail, offering Retry Now; used by
+	// refresh only
+	failing atomic.Bool
+
+	// teamForbidden is set once the team usage endpoint rejected the
+	// token, so the note is logged only once; used by refresh only
+	teamForbidden bool
+
+	// headers are the last rate limits read from response headers, and
+	// headerMismatch whether they disagreed with the usage endpoint;
+	// headerProbedAt is when the last probe was sent and headerErr the
+	// last error reading them, logged once. Used by refresh only.
+	headers        *api.RateLimitData
+	headerMismatch bool
+	headerProbedAt time.Time
+	headerErr      string
+
+	// authProblem explains why the API rejects the token, such as a
+	// missing scope, for the tooltip; guarded by configMu
+	authProblem string
+
+	// credentialsExposed warns that other users can read the credentials
+	// file, for the tooltip; guarded by configMu
+	credentialsExposed string
+
+	// updateMu serializes update checks and installs from the menu and the
+	// background checker
+	updateMu        sync.Mutex
+	latestVersion   string
+	latestSummary   string
+	notifiedVersion string
+	updateInstalled bool
+
+	// restartRequested asks main to start the updated binary after Run returns
+	restartRequested atomic.Bool
 }
 
 // New creates a new App instance with the given version string.
 func New(version string) (*App, error) {
 	cfg, err := config.Load()
+	problems := configProblems(cfg, err)
 	if err != nil {
 		log.Printf("Warning: could not load config, using defaults: %v", err)
 		cfg = config.Default()
 	}
 
+	configureHTTP(cfg)
+	logging.SetDebug(cfg.Debug)
+	stats.SetWeekStart(cfg.WeekStart())
+	planlimits.Apply(cfg)
+	format.SetLocale(cfg.Locale)
+	stats.SetModelNames(cfg.ModelNames)
+	tray.SetAbsoluteResetTimes(cfg.ResetTimeFormat == config.ResetAbsolute)
+	setTooltipTemplate(cfg.TooltipTemplate)
+	tray.SetASCIIBars(cfg.BarStyle == config.BarASCII || (cfg.BarStyle != config.BarUnicode && !tray.UnicodeBarsSupported()))
+	tray.SetAccessible(cfg.AccessibilityMode)
+	tray.SetTooltipLayout(tooltipLayout(cfg))
+
 	log.Printf("Using credential source: %s", cfg.GetSourceDisplayName())
+	if config.UsesEnvCredentials() {
+		log.Printf("Using OAuth tokens from %s: rotated tokens are kept in %s", config.EnvAccessToken, tokenstore.Location(cfg))
+	} else if cfg.ReadOnlyCredentials {
+		log.Printf("Read-only credentials: rotated tokens are kept in %s", tokenstore.Location(cfg))
+	}
+	if cfg.Profile != "" {
+		log.Printf("Using config profile: %s", cfg.Profile)
+	}
 
-	return &App{
+	t := tray.New(version, cfg.GetSourceDisplayName())
+	t.SetRefreshInterval(cfg.RefreshInterval)
+	t.SetIconMetric(cfg.IconMetric)
+	t.SetAlertThresholds(cfg.GetAlertThresholds())
+	t.SetNotifications(cfg.NotifyRefreshErrors, cfg.NotifyWeekStart)
+	t.SetProfile(cfg.Profile)
+	t.SetAutostart(autostart.IsEnabled(cfg.Profile))
+	t.SetRollbackAvailable(update.HasPrevious())
+	t.SetTeamUsage(cfg.TeamUsageURL != "", cfg.ShowTeamUsage)
+	t.SetTelemetry(cfg.TelemetryEnabled())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &App{
+		ctx:       ctx,
+		cancel:    cancel,
 		config:    cfg,
 		version:   version,
-		tray:      tray.New(version, cfg.GetSourceDisplayName()),
+		tray:      t,
 		iconGen:   icon.DefaultGenerator(),
-		apiClient: nil, // Will be initialized when we have a token
 		stopCh:    make(chan struct{}),
 		refreshCh: make(chan struct{}, 1),
-	}, nil
+		configCh:  make(chan *config.Config, 1),
+	}
+	a.setConfigProblems(problems)
+
+	perms.Tighten(perms.AppFiles(cfg.Profile))
+	a.checkCredentialsPerms(cfg)
+
+	if cached, err := api.LoadCache(config.GetRateLimitCachePath(cfg.Profile)); err == nil {
+		a.lastRateLimits = cached
+	} else if !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Warning: could not load cached rate limits: %v", err)
+	}
+
+	return a, nil
 }
 
 // Run starts the application. This blocks until the app is quit.
@@ -56,9 +309,102 @@ func (a *App) Run() {
 		a.triggerRefresh()
 	})
 
+	a.tray.SetOnRetry(func() {
+		log.Println("Retry triggered")
+		a.triggerRefresh()
+	})
+
+	a.tray.SetOnCopyUsage(func() {
+		log.Println("Copy usage triggered")
+		a.copyUsage()
+	})
+
+	a.tray.SetOnExportData(func() {
+		log.Println("Export data triggered")
+		a.exportData()
+	})
+	a.tray.SetOnExportChart(func() {
+		log.Println("Export chart triggered")
+		a.exportChart()
+	})
+
+	a.tray.SetOnOpenUsage(func() {
+		log.Println("Open usage page triggered")
+		if err := launch.Open(usagePageURL); err != nil {
+			log.Printf("Could not open usage page: %v", err)
+		}
+	})
+
+	a.tray.SetOnOpenConfig(func() {
+		log.Println("Open config folder triggered")
+		a.openConfigDir()
+	})
+
+	a.tray.SetOnOpenLog(func() {
+		log.Println("Open log file triggered")
+		if err := launch.Open(config.GetLogPath(a.config.Profile)); err != nil {
+			log.Printf("Could not open log file: %v", err)
+		}
+	})
+
 	a.tray.SetOnUpdate(func() {
 		log.Println("Update triggered")
-		a.performUpdate()
+		a.updateMu.Lock()
+		defer a.updateMu.Unlock()
+		if a.updateInstalled {
+			a.restart()
+			return
+		}
+		if a.checkForUpdate() {
+			a.announceInstall()
+			a.performUpdate()
+		}
+	})
+
+	a.tray.SetOnRollback(func() {
+		log.Println("Rollback triggered")
+		a.updateMu.Lock()
+		defer a.updateMu.Unlock()
+		a.performRollback()
+	})
+
+	a.tray.SetOnEditConfig(func() {
+		log.Println("Edit config triggered")
+		a.editConfig()
+	})
+
+	a.tray.SetOnAutostart(func() {
+		log.Println("Autostart toggle triggered")
+		a.toggleAutostart()
+	})
+
+	a.tray.SetOnRefreshInterval(func(d time.Duration) {
+		log.Printf("Refresh interval changed to %s", d)
+		a.setRefreshInterval(d)
+	})
+
+	a.tray.SetOnIconMetric(func(metric string) {
+		log.Printf("Icon metric changed to %s", metric)
+		a.setIconMetric(metric)
+	})
+
+	a.tray.SetOnAlertThresholds(func(thresholds []int) {
+		log.Printf("Alert thresholds changed to %v", thresholds)
+		a.setAlertThresholds(thresholds)
+	})
+
+	a.tray.SetOnNotifyErrors(func() {
+		a.changeConfig(func(cfg *config.Config) {
+			cfg.NotifyRefreshErrors = !cfg.NotifyRefreshErrors
+			log.Printf("Refresh error notifications: %v", cfg.NotifyRefreshErrors)
+		})
+	})
+
+	a.tray.SetOnNotifyWeek(func() {
+		a.changeConfig(func(cfg *config.Config) {
+			cfg.NotifyWeekStart = !cfg.NotifyWeekStart
+			log.Printf("New usage week notifications: %v", cfg.NotifyWeekStart)
+		})
 	})
 
 	a.tray.SetOnSourceToggle(func() {
@@ -66,11 +412,24 @@ func (a *App) Run() {
 		a.toggleSource()
 	})
 
+	a.tray.SetOnTeamUsage(func() {
+		log.Println("Team usage toggle triggered")
+		a.toggleTeamUsage()
+	})
+
+	a.tray.SetOnTelemetry(func() {
+		log.Println("Anonymous statistics toggle triggered")
+		a.toggleTelemetry()
+	})
+
 	a.tray.SetOnQuit(func() {
 		log.Println("Quit triggered")
-		close(a.stopCh)
+		a.stop()
 	})
 
+	// Windows ends the tray at logout or shutdown without a Quit click
+	a.tray.SetOnExit(a.stop)
+
 	// Run the tray (this will call onReady when initialized)
 	a.tray.Run(a.onReady)
 }
@@ -79,258 +438,1816 @@ func (a *App) Run() {
 func (a *App) onReady() {
 	log.Println("System tray ready")
 
-	// Initial refresh
+	// Export usage on D-Bus (Linux only) before the first refresh, so it
+	// is announced
+	dbusService, err := control.ExportDBus(control.DBusName(a.config.Profile), appController{a})
+	if err != nil {
+		log.Printf("Note: D-Bus service not available: %v", err)
+	}
+	a.dbus = dbusService
+
+	a.setTaskbarBadge(a.config.TaskbarBadge)
+	a.setHotkey(a.config.Hotkey)
+
+	netWatch, err := netwatch.Watch(a.networkBack)
+	if err != nil {
+		log.Printf("Note: network changes not detected: %v", err)
+	}
+	a.netWatch = netWatch
+
+	// Subscribe to the event bus before the first refresh publishes
+	a.loops.Add(3)
+	go a.presentLoop(a.bus.Subscribe(events.StatsUpdated, events.Redraw, events.UpdateAvailable))
+	go a.observeLoop(a.bus.Subscribe(events.StatsUpdated, events.ConfigChanged))
+	go a.notifyLoop(a.bus.Subscribe(events.Throttled, events.ThresholdCrossed, events.WindowReset, events.ConfigChanged))
+
+	// Battery saver applies from the first refresh
+	a.checkPower()
+
+	// Initial refresh, retried while it fails during the startup grace
+	// period, when the network may still be coming up
+	graceEnd := time.Now().Add(a.config.StartupGrace())
 	a.refresh()
 
-	// Start the refresh loop
-	go a.refreshLoop()
-}
+	// Serve the local control API, if enabled
+	a.startControl(a.controlPort(a.config))
 
-// refreshLoop periodically refreshes the stats.
-func (a *App) refreshLoop() {
-	ticker := time.NewTicker(a.config.RefreshInterval)
-	defer ticker.Stop()
+	// Start the refresh loop, watched over by the watchdog
+	a.beat()
+	a.loops.Add(6)
+	go a.refreshLoop(a.loopGen.Load())
+	go a.watchdogLoop()
+	go a.presenceLoop()
+	go a.powerLoop()
+	go a.retryFirstRefresh(graceEnd)
+	go a.watchTrayHost(graceEnd)
+
+	// Reload config.json when it changes on disk
+	go config.WatchFile(config.GetConfigPath(), config.DefaultWatchInterval, a.stopCh, a.reloadConfig)
+
+	// Check for new releases in the background
+	go a.updateCheckLoop()
 
+	// Keep the estimated plan limits up to date
+	go a.planLimitsLoop()
+
+	// Ask once whether anonymous statistics may be sent, then send them
+	// daily if so
+	if a.currentConfig().Telemetry == "" && !config.DoNotTrack() {
+		go a.askTelemetryConsent()
+	}
+	go a.telemetryLoop()
+}
+
+// planLimitsLoop fetches the plan limits table once a day, or hourly
+// after a failure.
+func (a *App) planLimitsLoop() {
 	for {
+		a.configMu.RLock()
+		cfg := a.config
+		a.configMu.RUnlock()
+
+		// Re-read the setting every hour while fetching is disabled so
+		// enabling it in config.json takes effect without a restart
+		wait := time.Hour
+		if cfg.FetchPlanLimits {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			next, err := planlimits.Update(ctx, cfg)
+			cancel()
+			if err != nil {
+				log.Printf("Warning: could not update plan limits: %v", err)
+			}
+			wait = next
+		}
+
 		select {
 		case <-a.stopCh:
-			log.Println("Refresh loop stopped")
 			return
-		case <-ticker.C:
-			log.Println("Auto refresh triggered")
-			a.refresh()
-		case <-a.refreshCh:
-			a.refresh()
+		case <-time.After(wait):
 		}
 	}
 }
 
-// triggerRefresh requests an immediate refresh.
-func (a *App) triggerRefresh() {
-	select {
-	case a.refreshCh <- struct{}{}:
-	default:
-		// Channel is full, refresh already pending
-	}
-}
+// updateCheckLoop periodically checks for a new release. A newer release is
+// announced with a notification, or installed directly if auto_update is set.
+func (a *App) updateCheckLoop() {
+	for {
+		a.configMu.RLock()
+		hours := a.config.UpdateCheckHours
+		autoUpdate := a.config.AutoUpdate
+		a.configMu.RUnlock()
 
-// refresh reloads stats and updates the tray icon and tooltip.
-func (a *App) refresh() {
-	log.Println("Refreshing stats...")
+		if hours > 0 {
+			a.backgroundUpdateCheck(autoUpdate)
+		}
 
-	// Parse credentials for plan info and OAuth token (required for API)
-	credsPath := a.config.GetCredentialsPath()
-	var creds *stats.Credentials
-	var err error
+		// Re-read the interval every hour while checks are disabled so
+		// enabling them in config.json takes effect without a restart
+		wait := time.Duration(hours) * time.Hour
+		if hours == 0 {
+			wait = time.Hour
+		}
 
-	// Use appropriate parser based on source
-	if a.config.IsOpenCode() {
-		creds, err = stats.ParseOpenCodeCredentials(credsPath)
-	} else {
-		creds, err = stats.ParseCredentials(credsPath)
+		select {
+		case <-a.stopCh:
+			return
+		case <-time.After(wait):
+		}
 	}
+}
 
-	if err != nil {
-		log.Printf("Error: could not parse credentials: %v", err)
-		log.Printf("Credentials path: %s", credsPath)
-		a.setError()
+// backgroundUpdateCheck runs one background update check, notifying about
+// (or installing) each new release once.
+func (a *App) backgroundUpdateCheck(autoUpdate bool) {
+	a.updateMu.Lock()
+	defer a.updateMu.Unlock()
+
+	if a.updateInstalled || !a.checkForUpdate() {
 		return
 	}
 
-	// Verify we have an access token
-	if creds.ClaudeAiOauth.AccessToken == "" {
-		log.Printf("Error: no access token in credentials file")
-		a.setError()
+	if autoUpdate {
+		log.Println("Installing update automatically (auto_update is enabled)")
+		a.announceInstall()
+		a.performUpdate()
 		return
 	}
 
-	// Parse stats cache (optional - only used as fallback when API unavailable)
-	statsPath := a.config.GetStatsPath()
-	cache, err := stats.ParseStatsCache(statsPath)
-	if err != nil {
-		log.Printf("Note: stats cache not available: %v", err)
-		// Continue without stats cache - will use API data
-		cache = nil
+	latest := a.latestVersion
+	if latest == a.notifiedVersion {
+		return
+	}
+	a.notifiedVersion = latest
+	body := fmt.Sprintf("Claude Usage %s is available. Use Update in the tray menu to install it.", latest)
+	if a.latestSummary != "" {
+		body = fmt.Sprintf("Claude Usage %s is available: %s\nUse Update in the tray menu to install it.", latest, a.latestSummary)
+	}
+	if err := notify.Show("Update available", body); err != nil {
+		log.Printf("Could not show notification: %v", err)
 	}
+}
 
-	// Calculate weekly stats (cache can be nil)
-	weeklyStats := stats.CalculateWeeklyStats(cache, creds)
+// announceInstall tells the user which release is about to be installed
+// and what it brings. Callers hold updateMu.
+func (a *App) announceInstall() {
+	body := "Claude Usage " + a.latestVersion
+	if a.latestSummary != "" {
+		body += ": " + a.latestSummary
+	}
+	if err := notify.Show("Installing update", body); err != nil {
+		log.Printf("Could not show notification: %v", err)
+	}
+}
 
-	// Fetch real rate limits from API
-	a.fetchAndApplyRateLimits(weeklyStats, creds.ClaudeAiOauth.AccessToken, creds.ClaudeAiOauth.RefreshToken)
+// refreshLoop periodically refreshes the stats. gen is the loop's
+// generation; it exits once the watchdog has started a newer one.
+func (a *App) refreshLoop(gen int64) {
+	defer a.loops.Done()
+	a.configMu.RLock()
+	ticker := time.NewTicker(a.config.RefreshInterval)
+	a.configMu.RUnlock()
+	defer ticker.Stop()
 
-	// Store stats
-	a.statsMu.Lock()
-	a.stats = weeklyStats
-	a.statsMu.Unlock()
+	// pending is a reloaded config not yet applied while a stuck loop
+	// still runs
+	var pending *config.Config
+	// saverTicks counts ticks while battery saver is on, which refreshes
+	// on every powerSaverFactor-th
+	saverTicks := 0
+	for {
+		var work func()
+		select {
+		case <-a.stopCh:
+			log.Println("Refresh loop stopped")
+			return
+		case <-ticker.C:
+			if a.paused.Load() {
+				logging.Debugf("Auto refresh skipped (paused)")
+				a.beat()
+				continue
+			}
+			if a.away.Load() {
+				logging.Debugf("Auto refresh skipped (away)")
+				a.beat()
+				continue
+			}
+			if a.powerSaving.Load() {
+				if saverTicks++; saverTicks%powerSaverFactor != 0 {
+					logging.Debugf("Auto refresh skipped (battery saver)")
+					a.beat()
+					continue
+				}
+			}
+			log.Println("Auto refresh triggered")
+			work = a.refresh
+		case <-a.refreshCh:
+			work = a.refresh
+		case cfg := <-a.configCh:
+			pending = cfg
+		}
 
-	// Update tray
-	a.updateTray(weeklyStats)
+		if cfg := pending; cfg != nil {
+			work = func() {
+				if a.applyConfig(cfg) {
+					ticker.Reset(a.config.RefreshInterval)
+				}
+				a.refresh()
+			}
+		}
+		if work != nil && a.exclusive(work) {
+			pending = nil
+		}
 
-	if weeklyStats.HasAPIData {
-		log.Printf("Stats refreshed: %d%% weekly usage (API), %d total tokens", weeklyStats.GetPercentage(), weeklyStats.TotalTokens)
-	} else {
-		log.Printf("Stats refreshed: %d total tokens this week (estimated)", weeklyStats.TotalTokens)
+		// Replaced by the watchdog while stuck
+		if a.loopGen.Load() != gen {
+			log.Println("Stuck refresh loop exited")
+			return
+		}
 	}
 }
 
-// fetchAndApplyRateLimits fetches rate limits from the API and applies them to weeklyStats.
-func (a *App) fetchAndApplyRateLimits(weeklyStats *stats.WeeklyStats, token string, refreshToken string) {
-	// Initialize or update API client
-	if a.apiClient == nil {
-		a.apiClient = api.NewClient(token)
+// startControl (re)starts the control API on port, or stops it when port
+// is zero.
+func (a *App) startControl(port int) {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
 
-		// Set up callback to persist new refresh tokens when the server rotates them
-		a.apiClient.SetRefreshTokenCallback(a.createRefreshTokenCallback())
-	} else {
-		a.apiClient.SetToken(token)
+	// Quitting
+	select {
+	case <-a.stopCh:
+		return
+	default:
 	}
 
-	// Always set the refresh token so the client can auto-refresh on 401
-	a.apiClient.SetRefreshToken(refreshToken)
+	a.control.Close()
+	a.control = nil
+	if port == 0 {
+		return
+	}
 
-	// Fetch rate limits
-	rateLimits, err := a.apiClient.FetchRateLimits()
+	token, err := control.LoadOrCreateToken(config.GetControlTokenPath(a.config.Profile))
 	if err != nil {
-		log.Printf("Warning: could not fetch rate limits from API: %v", err)
+		log.Printf("Warning: could not load control API token: %v", err)
 		return
 	}
-
-	// Apply to weekly stats
-	weeklyStats.HasAPIData = true
-	weeklyStats.FiveHourUtilization = rateLimits.FiveHourUtilization
-	weeklyStats.WeeklyUtilization = rateLimits.WeeklyUtilization
-	weeklyStats.FiveHourReset = rateLimits.FiveHourReset
-	weeklyStats.WeeklyReset = rateLimits.WeeklyReset
-	weeklyStats.RateLimitStatus = rateLimits.Status
-	weeklyStats.RepresentativeClaim = rateLimits.RepresentativeClaim
-	weeklyStats.OpusUtilization = rateLimits.OpusUtilization
-	weeklyStats.SonnetUtilization = rateLimits.SonnetUtilization
-	weeklyStats.OpusReset = rateLimits.OpusReset
-	weeklyStats.SonnetReset = rateLimits.SonnetReset
-
-	log.Printf("API rate limits: 5h=%.1f%%, weekly=%.1f%%, status=%s",
-		rateLimits.FiveHourUtilization*100,
-		rateLimits.WeeklyUtilization*100,
-		rateLimits.Status)
+	host := a.config.ControlHost()
+	server, err := control.Listen(host, port, token, appController{a})
+	if err != nil {
+		log.Printf("Warning: could not start control API: %v", err)
+		return
+	}
+	a.control = server
+	log.Printf("Control API listening on %s", net.JoinHostPort(host, strconv.Itoa(port)))
 }
 
-// updateTray updates the tray icon and tooltip with current stats.
-func (a *App) updateTray(weeklyStats *stats.WeeklyStats) {
-	// Get usage percentage
-	percentage := weeklyStats.GetPercentage()
+// setTooltipTemplate applies the configured tooltip layout. The config has
+// already been validated, so errors are unexpected.
+func setTooltipTemplate(text string) {
+	if err := tray.SetTooltipTemplate(text); err != nil {
+		log.Printf("Warning: invalid tooltip template: %v", err)
+	}
+}
 
-	// Generate icon with percentage text overlay
-	iconBytes, err := a.iconGen.GenerateWithPercentage(weeklyStats, percentage)
+// setTaskbarBadge creates or removes the taskbar button.
+func (a *App) setTaskbarBadge(enabled bool) {
+	if !enabled {
+		a.badge.Close()
+		a.badge = nil
+		return
+	}
+	if a.badge != nil {
+		return
+	}
+	badge, err := taskbar.New(func() {
+		log.Println("Quit triggered from the taskbar")
+		a.stop()
+		a.tray.Quit()
+	})
 	if err != nil {
-		log.Printf("Error generating icon: %v", err)
+		log.Printf("Warning: could not show the taskbar badge: %v", err)
 		return
 	}
-
-	// Update icon
-	a.tray.SetIcon(iconBytes)
-
-	// Update tooltip with platform-appropriate format (Windows gets compact version)
-	tooltip := tray.FormatTooltipForPlatform(weeklyStats)
-	a.tray.SetTooltip(tooltip)
-
-	log.Printf("Icon updated: %d%% usage", percentage)
+	a.badge = badge
 }
 
-// setError sets the tray to an error state.
-func (a *App) setError() {
-	iconBytes, err := a.iconGen.GenerateError()
+// setHotkey registers spec as the shortcut that shows usage, replacing
+// the previous one; empty removes it. The config has already been
+// validated, so parse errors are unexpected.
+func (a *App) setHotkey(spec string) {
+	a.hotkey.Close()
+	a.hotkey = nil
+	if spec == "" {
+		return
+	}
+	hk, err := hotkey.Parse(spec)
 	if err != nil {
-		log.Printf("Error generating error icon: %v", err)
+		log.Printf("Warning: invalid hotkey: %v", err)
 		return
 	}
-
-	a.tray.SetIcon(iconBytes)
-	sourceName := a.config.GetSourceDisplayName()
-	a.tray.SetTooltip("Claude Usage\n━━━━━━━━━━━━━━━━━━\nError loading credentials\nMake sure " + sourceName + " is installed\nand you are logged in")
+	listener, err := hotkey.Register(hk, a.showUsage)
+	if err != nil {
+		log.Printf("Warning: could not register the hotkey %s: %v", hk, err)
+		return
+	}
+	a.hotkey = listener
+	log.Printf("Hotkey %s shows usage", hk)
 }
 
-// toggleSource switches between Claude Code and OpenCode credential sources.
-func (a *App) toggleSource() {
-	oldSource := a.config.GetSourceDisplayName()
-	a.config.ToggleSource()
-	newSource := a.config.GetSourceDisplayName()
-
-	log.Printf("Switching credential source from %s to %s", oldSource, newSource)
+// networkBack refreshes when connectivity returns, so the icon recovers
+// from an offline error without waiting for the next tick. Refreshes
+// paused by the control API or while the user is away wait.
+func (a *App) networkBack() {
+	if a.paused.Load() || a.away.Load() {
+		return
+	}
+	log.Println("Network connection restored; refreshing")
+	a.triggerRefresh()
+}
 
-	// Save the new config
-	if err := a.config.Save(); err != nil {
-		log.Printf("Warning: could not save config: %v", err)
+// showUsage shows the usage summary as a notification.
+func (a *App) showUsage() {
+	title, body, _ := strings.Cut(tray.FormatSummary(a.GetStats()), "\n")
+	if err := notify.Show(title, body); err != nil {
+		log.Printf("Could not show notification: %v", err)
 	}
+}
 
-	// Update the menu item
-	a.tray.UpdateSourceToggle(newSource)
+// controlPort returns the port to serve the control API on for cfg: the
+// configured one, or DefaultPort for the dashboard when there is no tray
+// icon. Zero disables the API.
+func (a *App) controlPort(cfg *config.Config) int {
+	if cfg.ControlPort == 0 && a.noTrayHost.Load() {
+		return control.DefaultPort
+	}
+	return cfg.ControlPort
+}
 
-	// Reset the API client so it gets re-initialized with the new credentials
-	a.apiClient = nil
+// appController exposes the app to the control API.
+type appController struct {
+	a *App
+}
 
-	// Trigger a refresh to load the new credentials
-	a.triggerRefresh()
+// Status returns the stats shown in the tray.
+func (c appController) Status() status.Status {
+	s := c.a.latest.Load()
+	if s == nil {
+		return status.New(nil, c.a.paused.Load(), time.Time{})
+	}
+	return status.New(s.stats, c.a.paused.Load(), s.updated)
 }
 
-// createRefreshTokenCallback creates a callback function to persist new refresh tokens.
-// This uses the current config to determine the correct update function.
-func (a *App) createRefreshTokenCallback() func(string) {
-	return func(newRefreshToken string) {
-		log.Printf("Persisting new refresh token to credentials file...")
-		credsPath := a.config.GetCredentialsPath()
-
-		var err error
-		if a.config.IsOpenCode() {
-			err = stats.UpdateOpenCodeRefreshToken(credsPath, newRefreshToken)
-		} else {
-			err = stats.UpdateRefreshToken(credsPath, newRefreshToken)
-		}
+// Fetches sums up the recent API fetches.
+func (c appController) Fetches() history.FetchSummary {
+	c.a.fetchesMu.Lock()
+	defer c.a.fetchesMu.Unlock()
+	return history.Summarize(c.a.fetches, time.Now())
+}
 
-		if err != nil {
-			log.Printf("ERROR: Failed to update credentials file with new refresh token: %v", err)
-			log.Printf("The new refresh token is in memory but NOT saved. You may need to re-authenticate on restart.")
-		} else {
-			log.Printf("Successfully updated credentials file with new refresh token")
-		}
+// RateLimits returns the rate limits of the last refresh that fetched any.
+func (c appController) RateLimits() *api.RateLimitData {
+	if s := c.a.latest.Load(); s != nil {
+		return s.rateLimits
 	}
+	return nil
 }
 
-// GetStats returns the current weekly stats (thread-safe).
-func (a *App) GetStats() *stats.WeeklyStats {
-	a.statsMu.RLock()
-	defer a.statsMu.RUnlock()
-	return a.stats
+// Samples reads the usage samples recorded since since.
+func (c appController) Samples(since time.Time) ([]history.Sample, error) {
+	return history.LoadSamples(config.GetSamplesPath(c.a.currentConfig().Profile), since)
 }
 
-// performUpdate downloads and installs the latest version.
-func (a *App) performUpdate() {
-	log.Printf("Starting update from %s", update.GetDownloadURL())
-
-	// Show progress in tooltip
-	a.tray.SetTooltip("Downloading update...")
+// Refresh signals the refresh loop to refresh now.
+func (c appController) Refresh() {
+	log.Println("Refresh requested by control API")
+	c.a.triggerRefresh()
+}
 
-	// Perform the update
-	result, err := update.Update()
-	if err != nil {
-		log.Printf("Update failed: %v", err)
-		a.tray.SetTooltip("Update failed: " + err.Error())
-		// Restore normal tooltip after a delay
-		go func() {
-			time.Sleep(5 * time.Second)
-			a.statsMu.RLock()
-			stats := a.stats
-			a.statsMu.RUnlock()
-			if stats != nil {
-				a.updateTray(stats)
-			}
-		}()
+// SetPaused pauses or resumes automatic refreshes.
+func (c appController) SetPaused(paused bool) {
+	if c.a.paused.Swap(paused) == paused {
 		return
 	}
+	if paused {
+		log.Println("Automatic refreshes paused by control API")
+	} else {
+		log.Println("Automatic refreshes resumed by control API")
+	}
+}
 
-	log.Printf("Update result: %s", result.Message)
-	a.tray.SetTooltip("Update installed. Please restart the application.")
-
-	// Mark update as complete - changes menu item to "Restart Required"
-	a.tray.SetUpdateComplete()
+// HandleCommand handles a command sent by another launch of the app
+// (see package instance).
+func (a *App) HandleCommand(command string) {
+	switch command {
+	case instance.CommandRefresh:
+		log.Println("Refresh requested by another instance")
+		a.triggerRefresh()
+	default:
+		log.Printf("Ignoring unknown instance command: %q", command)
+	}
+}
+
+// triggerRefresh requests an immediate refresh, superseding any API fetch
+// still in flight.
+func (a *App) triggerRefresh() {
+	a.cancelInFlight()
+
+	select {
+	case a.refreshCh <- struct{}{}:
+	default:
+		// Channel is full, refresh already pending
+	}
+}
+
+// cancelInFlight cancels the API fetch in flight, if any.
+func (a *App) cancelInFlight() {
+	a.cancelFetchMu.Lock()
+	if a.cancelFetch != nil {
+		a.cancelFetch()
+	}
+	a.cancelFetchMu.Unlock()
+}
+
+// refresh reloads stats and updates the tray icon and tooltip.
+func (a *App) refresh() {
+	log.Println("Refreshing stats...")
+
+	// Parse credentials for plan info and OAuth token (required for API)
+	a.checkCredentialsPerms(a.config)
+	creds, err := usage.ReadCredentials(a.config)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		log.Printf("Credentials path: %s", a.config.GetCredentialsPath())
+		a.setError(&refreshError{icon.ErrorNoCredentials, err})
+		return
+	}
+
+	// Calculate weekly stats from the stats cache and the transcripts
+	// (Claude Code only), which the API data then overrides
+	weeklyStats, cache := usage.WeekStats(a.config, creds, a.scanTranscripts(), a.lastRateLimits, time.Now())
+
+	// Show the last known rate limits while the first fetch is in flight,
+	// so the tray never waits on the network
+	a.showCached(weeklyStats)
+
+	// Fetch real rate limits from the usage provider
+	err = a.fetchAndApplyRateLimits(weeklyStats, creds)
+	if errors.Is(err, context.Canceled) {
+		// Quitting, or a newer refresh is about to run
+		log.Println("Refresh cancelled")
+		return
+	}
+	a.setAuthProblem(a.authProblemFor(creds, err))
+	a.setFailing(err)
+
+	// Without live or cached rate limits, show why rather than a guess
+	if err != nil && !weeklyStats.HasAPIData {
+		a.setError(fetchError(err))
+		return
+	}
+
+	// weeklyStats is complete; from here on it is only read
+	a.publishStats(&snapshot{
+		stats:   weeklyStats,
+		updated: time.Now(),
+		statistics: tray.FormatStatisticsLines(
+			stats.CalculateMonthlyStats(cache), stats.CalculateLifetimeStats(cache), weeklyStats.MonthCostUSD),
+		sparkline:  a.updateSparkline(weeklyStats),
+		rateLimits: a.lastRateLimits,
+	})
+
+	if weeklyStats.APIDataStale {
+		log.Printf("Stats refreshed: %d%% weekly usage (cached), %d total tokens", weeklyStats.GetPercentage(), weeklyStats.TotalTokens)
+	} else if weeklyStats.HasAPIData {
+		log.Printf("Stats refreshed: %d%% weekly usage (API), %d total tokens", weeklyStats.GetPercentage(), weeklyStats.TotalTokens)
+	} else {
+		log.Printf("Stats refreshed: %d total tokens this week (estimated)", weeklyStats.TotalTokens)
+	}
+}
+
+// updateSparkline adds fresh API data in weeklyStats to the samples behind
+// the icon sparkline and returns its columns, or nil while icon_sparkline
+// is off. The samples are seeded from the sample log, so the sparkline
+// survives restarts.
+func (a *App) updateSparkline(weeklyStats *stats.WeeklyStats) []float64 {
+	if !a.config.IconSparkline {
+		a.sparkline = nil
+		return nil
+	}
+
+	now := time.Now()
+	if a.sparkline == nil {
+		samples, err := history.LoadSamples(config.GetSamplesPath(a.config.Profile), now.Add(-sparklineSpan))
+		if err != nil {
+			log.Printf("Warning: could not read usage samples: %v", err)
+		}
+		a.sparkline = append([]history.Sample{}, samples...)
+	}
+	if weeklyStats.HasAPIData && !weeklyStats.APIDataStale {
+		a.sparkline = append(a.sparkline, history.SampleFromStats(weeklyStats, now))
+	}
+
+	cutoff := now.Add(-sparklineSpan)
+	for len(a.sparkline) > 0 && a.sparkline[0].At.Before(cutoff) {
+		a.sparkline = a.sparkline[1:]
+	}
+	return history.Sparkline(a.sparkline, now, sparklineSpan, icon.IconSize)
+}
+
+// publishStats makes s the latest snapshot and announces it on the bus.
+func (a *App) publishStats(s *snapshot) {
+	a.latest.Store(s)
+	a.bus.Publish(events.Event{Kind: events.StatsUpdated, Stats: s.stats, Cached: s.cached})
+}
+
+// redraw asks presentLoop to show the latest snapshot again, after a
+// change in how it is shown. Unlike publishStats, nothing records it as
+// new usage.
+func (a *App) redraw() {
+	a.bus.Publish(events.Event{Kind: events.Redraw})
+}
+
+// presentLoop shows the latest snapshot in the tray, on D-Bus and in the
+// status files, and announces new releases in the menu, until quit.
+func (a *App) presentLoop(ch <-chan events.Event) {
+	defer a.loops.Done()
+
+	// Data turns stale between refreshes; redraw once it does. The age
+	// shown is then updated by the refreshes that keep failing.
+	ticker := time.NewTicker(staleCheckInterval)
+	defer ticker.Stop()
+	shownStale := false
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case <-ticker.C:
+			s := a.latest.Load()
+			if s != nil && !shownStale && a.iconGen.StaleAge(s.stats, time.Now()) > 0 {
+				a.updateTray(s)
+				shownStale = true
+			}
+		case e := <-ch:
+			switch e.Kind {
+			case events.StatsUpdated:
+				// Skip to the latest snapshot when several are queued
+				s := a.latest.Load()
+				a.updateTray(s)
+				shownStale = a.iconGen.StaleAge(s.stats, time.Now()) > 0
+				a.dbus.Update(s.stats)
+				a.tray.SetStatistics(s.statistics)
+				if !s.cached {
+					a.saveStatus(s.stats)
+				}
+			case events.Redraw:
+				if s := a.latest.Load(); s != nil {
+					a.updateTray(s)
+					shownStale = a.iconGen.StaleAge(s.stats, time.Now()) > 0
+				}
+			case events.UpdateAvailable:
+				a.tray.SetUpdateAvailable(e.Version, e.Summary)
+			}
+		}
+	}
+}
+
+// observeLoop acts on changes in usage between refreshes: it records the
+// history, warns about the pace and extra usage spend, publishes MQTT state
+// and turns crossed thresholds and throttling into events on the bus.
+func (a *App) observeLoop(ch <-chan events.Event) {
+	defer a.loops.Done()
+	cfg := a.currentConfig()
+	detector := integrations.DetectorFromConfig(cfg)
+	publisher := mqtt.FromConfig(cfg)
+
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case e := <-ch:
+			switch {
+			case e.Kind == events.ConfigChanged:
+				if thresholdsChanged(e.Previous, e.Config) {
+					detector = integrations.DetectorFromConfig(e.Config)
+				}
+				if mqttChanged(e.Previous, e.Config) {
+					publisher = mqtt.FromConfig(e.Config)
+				}
+			case !e.Cached:
+				a.recordHistory(e.Stats)
+				a.checkPace(e.Stats)
+				for _, usage := range detector.Observe(e.Stats, time.Now()) {
+					if usage.Kind == integrations.KindSpend {
+						notifySpend(usage)
+					}
+					a.bus.Publish(events.FromUsage(usage))
+				}
+				if publisher != nil {
+					go publishMQTT(a.ctx, publisher, e.Stats)
+				}
+			}
+		}
+	}
+}
+
+// notifySpend warns that extra usage spend crossed a spend alert, so
+// pay-as-you-go users are not surprised by the bill.
+func notifySpend(e integrations.Event) {
+	title := "Extra usage at " + tray.Percent(e.Threshold)
+	if e.Threshold >= 100 {
+		title = "Extra usage limit reached"
+	}
+	body := "Extra: " + e.Spend() + " of this month's credit limit"
+	if tray.Accessible() {
+		body = fmt.Sprintf("Extra usage %s of %s, %s of this month's credit limit",
+			format.FormatDollars(e.ExtraUsed), format.FormatDollars(e.ExtraLimit), tray.Percent(e.Percent))
+	}
+	log.Printf("Spend alert: %s", body)
+	if err := notify.Show(title, body); err != nil {
+		log.Printf("Could not show notification: %v", err)
+	}
+}
+
+// notifyLoop posts usage events to the configured integrations.
+func (a *App) notifyLoop(ch <-chan events.Event) {
+	defer a.loops.Done()
+	dispatcher := integrations.FromConfig(a.currentConfig())
+
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case e := <-ch:
+			if e.Kind == events.ConfigChanged {
+				if integrationsChanged(e.Previous, e.Config) {
+					dispatcher = integrations.FromConfig(e.Config)
+				}
+				continue
+			}
+			dispatcher.Notify(a.ctx, e.Usage)
+		}
+	}
+}
+
+// saveStatus keeps the status file used by the prompt and tmux commands
+// current, and writes status_file_path for external tools when set.
+func (a *App) saveStatus(weeklyStats *stats.WeeklyStats) {
+	cfg := a.currentConfig()
+	s := status.New(weeklyStats, a.paused.Load(), time.Now())
+	if err := status.Save(config.GetStatusPath(cfg.Profile), s); err != nil {
+		log.Printf("Warning: could not save status: %v", err)
+	}
+	if path := cfg.StatusFilePath; path != "" {
+		if err := status.Save(path, s); err != nil {
+			log.Printf("Warning: could not write status file: %v", err)
+		}
+	}
+}
+
+// scanTranscripts returns usage entries from Claude Code's session
+// transcripts, or nil for OpenCode, with battery saver on or when there
+// are none.
+func (a *App) scanTranscripts() []transcripts.Entry {
+	if a.config.IsOpenCode() || a.powerSaving.Load() {
+		return nil
+	}
+	dir := a.config.GetProjectsPath()
+	if a.transcripts == nil || a.projectsDir != dir {
+		a.transcripts = transcripts.NewScanner(dir)
+		a.projectsDir = dir
+	}
+
+	start := time.Now()
+	entries, err := a.transcripts.Scan()
+	if err != nil {
+		log.Printf("Warning: could not scan transcripts: %v", err)
+	}
+	logging.Debugf("Scanned %d transcript entries in %v", len(entries), time.Since(start).Round(time.Millisecond))
+	return entries
+}
+
+// fetchAndApplyRateLimits fetches rate limits from the usage provider and applies them to weeklyStats.
+// On failure the last known rate limits, if any, are applied and marked stale,
+// and the error is returned. creds may be nil for providers that don't need them.
+func (a *App) fetchAndApplyRateLimits(weeklyStats *stats.WeeklyStats, creds *stats.Credentials) error {
+	provider := a.getProvider(creds)
+
+	// Fetch rate limits, cancellable by quit or a newer refresh request
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.cancelFetchMu.Lock()
+	a.cancelFetch = cancel
+	a.cancelFetchMu.Unlock()
+	defer func() {
+		a.cancelFetchMu.Lock()
+		a.cancelFetch = nil
+		a.cancelFetchMu.Unlock()
+		cancel()
+	}()
+
+	start := time.Now()
+	rateLimits, err := provider.FetchRateLimits(ctx)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+		a.recordFetch(start, err)
+		log.Printf("Warning: could not fetch rate limits from API: %v", err)
+
+		// Fall back to the last known data rather than losing it all
+		if last := a.lastRateLimits; last != nil && time.Since(last.FetchedAt) < maxStaleAge {
+			last.Apply(weeklyStats)
+			weeklyStats.APIDataStale = true
+			weeklyStats.APIFetchedAt = last.FetchedAt
+			log.Printf("Showing cached rate limits from %s", last.FetchedAt.Format(time.RFC3339))
+		}
+		return err
+	}
+
+	a.recordFetch(start, nil)
+
+	rateLimits.Apply(weeklyStats)
+	weeklyStats.APIFetchedAt = rateLimits.FetchedAt
+	a.lastRateLimits = rateLimits
+
+	// Only real API data is cached; demo or file data must not stand in
+	// for it later
+	if a.config.UsesOAuth() {
+		if err := api.SaveCache(config.GetRateLimitCachePath(a.config.Profile), rateLimits); err != nil {
+			log.Printf("Warning: could not cache rate limits: %v", err)
+		}
+	}
+
+	log.Printf("API rate limits: 5h=%.1f%%, weekly=%.1f%%, status=%s",
+		rateLimits.FiveHourUtilization*100,
+		rateLimits.WeeklyUtilization*100,
+		rateLimits.Status)
+
+	if a.config.ShowTeamUsage && a.config.TeamUsageURL != "" {
+		a.applyTeamUsage(ctx, provider, weeklyStats)
+	}
+	a.checkRateLimitHeaders(ctx, provider, rateLimits, weeklyStats)
+	return nil
+}
+
+// recordFetch adds an API fetch started at start to the history and
+// updates the Diagnostics submenu. Only fetches from the real API are
+// recorded.
+func (a *App) recordFetch(start time.Time, err error) {
+	if !a.config.UsesOAuth() {
+		return
+	}
+
+	fe := history.Fetch{At: start, LatencyMS: time.Since(start).Milliseconds(), OK: err == nil}
+	var statusErr *api.StatusError
+	if errors.As(err, &statusErr) {
+		fe.StatusCode = statusErr.StatusCode
+	}
+
+	fetches, err := history.RecordFetch(config.GetHistoryPath(a.config.Profile), fe)
+	if err != nil {
+		log.Printf("Warning: could not record API fetch: %v", err)
+		return
+	}
+
+	a.fetchesMu.Lock()
+	a.fetches = fetches
+	a.fetchesMu.Unlock()
+	a.tray.SetDiagnostics(tray.FormatDiagnosticsLines(history.Summarize(fetches, time.Now())))
+}
+
+// applyTeamUsage adds organization-wide usage to weeklyStats. A failure
+// only drops the team line from the tooltip.
+func (a *App) applyTeamUsage(ctx context.Context, provider api.UsageProvider, weeklyStats *stats.WeeklyStats) {
+	team, ok := provider.(api.TeamUsageProvider)
+	if !ok {
+		return
+	}
+
+	data, err := team.FetchTeamRateLimits(ctx, a.config.TeamUsageURL)
+	if err != nil {
+		var statusErr *api.StatusError
+		if errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusForbidden || statusErr.StatusCode == http.StatusUnauthorized) {
+			if !a.teamForbidden {
+				log.Printf("Note: the token may not read team usage (HTTP %d); check team_usage_url", statusErr.StatusCode)
+			}
+			a.teamForbidden = true
+		} else if !errors.Is(err, context.Canceled) {
+			log.Printf("Warning: could not fetch team usage: %v", err)
+		}
+		return
+	}
+
+	a.teamForbidden = false
+	weeklyStats.HasTeamData = true
+	weeklyStats.TeamFiveHourUtilization = data.FiveHourUtilization
+	weeklyStats.TeamWeeklyUtilization = data.WeeklyUtilization
+	logging.Debugf("Team rate limits: 5h=%.1f%%, weekly=%.1f%%", data.FiveHourUtilization*100, data.WeeklyUtilization*100)
+}
+
+// showCached shows weeklyStats with the cached rate limits, marked stale,
+// until the first refresh completes. It does nothing once stats are shown
+// or without recent cached API data.
+func (a *App) showCached(weeklyStats *stats.WeeklyStats) {
+	last := a.lastRateLimits
+	if last == nil || time.Since(last.FetchedAt) >= maxStaleAge || !a.config.UsesOAuth() {
+		return
+	}
+
+	if a.latest.Load() != nil {
+		return
+	}
+	cached := *weeklyStats
+	last.Apply(&cached)
+	cached.APIDataStale = true
+	cached.APIFetchedAt = last.FetchedAt
+
+	logging.Debugf("Showing cached rate limits from %s until the API answers", last.FetchedAt.Format(time.RFC3339))
+	a.publishStats(&snapshot{stats: &cached, updated: time.Now(), cached: true, sparkline: a.updateSparkline(&cached)})
+}
+
+// recordHistory snapshots the weekly window into the usage history and, if
+// enabled, announces a new week. Only fresh OAuth API data is recorded.
+func (a *App) recordHistory(weeklyStats *stats.WeeklyStats) {
+	cfg := a.currentConfig()
+	if !weeklyStats.HasAPIData || weeklyStats.APIDataStale || weeklyStats.WeeklyReset.IsZero() || !cfg.UsesOAuth() {
+		return
+	}
+
+	if err := history.AppendSample(config.GetSamplesPath(cfg.Profile), history.SampleFromStats(weeklyStats, time.Now())); err != nil {
+		log.Printf("Warning: could not log usage sample: %v", err)
+	}
+
+	finished, err := history.Record(config.GetHistoryPath(cfg.Profile), history.FromStats(weeklyStats))
+	if err != nil {
+		log.Printf("Warning: could not update usage history: %v", err)
+		return
+	}
+	if finished == nil {
+		return
+	}
+
+	log.Printf("New usage week started; last week: %d%%, %d tokens", finished.Percentage(), finished.Tokens)
+	if cfg.NotifyWeekStart {
+		body := fmt.Sprintf("Last week: %s / %s tokens", format.FormatPercent(finished.Percentage()), format.FormatTokens(finished.Tokens))
+		if tray.Accessible() {
+			body = fmt.Sprintf("Last week: %s of the weekly limit, %s tokens", tray.Percent(finished.Percentage()), tray.Tokens(finished.Tokens))
+		}
+		if err := notify.Show("New usage week started", body); err != nil {
+			log.Printf("Could not show notification: %v", err)
+		}
+	}
+}
+
+// checkPace warns once per weekly window when usage gets pace_alert_margin
+// percentage points ahead of the share of the week elapsed. Only fresh API
+// data is checked.
+func (a *App) checkPace(weeklyStats *stats.WeeklyStats) {
+	margin := a.currentConfig().PaceAlertMargin
+	if margin <= 0 || !weeklyStats.HasAPIData || weeklyStats.APIDataStale {
+		return
+	}
+	// Reset times jitter by a few seconds; a later one is a new window
+	if weeklyStats.WeeklyReset.Sub(a.paceAlerted) < time.Hour {
+		return
+	}
+	delta := weeklyStats.PaceDelta()
+	if delta < float64(margin) {
+		return
+	}
+	a.paceAlerted = weeklyStats.WeeklyReset
+
+	body := fmt.Sprintf("%s of the weekly limit used with %s of the week gone",
+		tray.Percent(weeklyStats.GetPercentage()), tray.Percent(int(math.Round(weeklyStats.WeekProgress()*100))))
+	log.Printf("Ahead of usage pace by %.0f points: %s", delta, body)
+	if err := notify.Show("Ahead of usage pace", body); err != nil {
+		log.Printf("Could not show notification: %v", err)
+	}
+}
+
+// getProvider returns the usage provider, creating it on first use. Providers
+// that authenticate with OAuth get the tokens from creds.
+func (a *App) getProvider(creds *stats.Credentials) api.UsageProvider {
+	a.providerMu.Lock()
+	defer a.providerMu.Unlock()
+
+	if a.provider == nil {
+		a.provider = a.newProvider()
+	}
+
+	if auth, ok := a.provider.(api.TokenAuthenticator); ok && creds != nil {
+		auth.SetToken(creds.ClaudeAiOauth.AccessToken)
+
+		// Always set the refresh token so the client can auto-refresh on 401
+		auth.SetRefreshToken(creds.ClaudeAiOauth.RefreshToken)
+	}
+	return a.provider
+}
+
+// newProvider creates the usage provider selected in the config.
+func (a *App) newProvider() api.UsageProvider {
+	switch a.config.GetUsageProvider() {
+	case config.ProviderFile:
+		log.Printf("Reading rate limits from %s", a.config.UsageFilePath)
+		return api.NewFileProvider(a.config.UsageFilePath)
+	case config.ProviderRemote:
+		log.Printf("Reading rate limits from the agent at %s", a.config.RemoteURL)
+		return api.NewRemoteProvider(a.config.RemoteURL, a.config.RemoteToken)
+	case config.ProviderDemo:
+		log.Println("Demo mode: showing generated usage data")
+		return api.NewDemoProvider()
+	default:
+		client := api.NewClient("")
+
+		// Set up callback to persist new refresh tokens when the server rotates them
+		client.SetRefreshTokenCallback(a.createRefreshTokenCallback())
+		return client
+	}
+}
+
+// resetProvider drops the usage provider so it is re-created with new
+// credentials or settings.
+func (a *App) resetProvider() {
+	a.providerMu.Lock()
+	a.provider = nil
+	a.providerMu.Unlock()
+}
+
+// updateTray updates the tray icon and tooltip with the stats of s.
+func (a *App) updateTray(s *snapshot) {
+	weeklyStats := s.stats
+	cfg := a.currentConfig()
+
+	// Get the icon metric's value: by default the weekly usage percentage,
+	// of the personal budget in budget mode
+	budgetTokens, budgetUSD := cfg.Budget()
+	budget := budgetTokens > 0 || budgetUSD > 0
+	percentage, label := iconValue(weeklyStats, cfg.IconMetric, budgetTokens, budgetUSD)
+
+	// Generate icon with percentage text overlay, marked once the data is old
+	a.iconGen.StaleAfter = cfg.StaleAfter()
+	a.iconGen.Budget = budget && (cfg.IconMetric == "" || cfg.IconMetric == config.IconWeekly)
+	iconBytes, err := a.iconGen.GenerateWithPercentage(weeklyStats, percentage, s.sparkline)
+	if err != nil {
+		log.Printf("Error generating icon: %v", err)
+		return
+	}
+
+	// Update icon, flashing it while the limiting window is nearly exhausted
+	a.flash.stop()
+	a.tray.SetIcon(iconBytes)
+	if cfg.FlashIcon && !a.powerSaving.Load() && icon.NearlyExhausted(weeklyStats) {
+		if dimmed, err := a.iconGen.GenerateDimmed(weeklyStats, percentage, s.sparkline); err == nil {
+			a.flash.start(a.tray, iconBytes, dimmed)
+		}
+	}
+	a.badge.Set(iconBytes, "Claude Usage "+label)
+
+	// Update tooltip with platform-appropriate format (Windows gets compact version)
+	tooltip := tray.FormatTooltipForPlatform(weeklyStats)
+	if budget {
+		tooltip += "\n" + tray.FormatBudgetLine(weeklyStats, budgetTokens, budgetUSD)
+	}
+	if cfg.ShowDailyUsage && tooltipLayout(cfg) != tray.LayoutCompact {
+		tooltip += "\n" + tray.FormatDailyLine(weeklyStats)
+	}
+	if a.powerSaving.Load() {
+		tooltip += "\nBattery saver on"
+	}
+	tooltip = a.decorateTooltip(tooltip)
+	if age := a.iconGen.StaleAge(weeklyStats, time.Now()); age > 0 {
+		tooltip = tray.FormatStaleLine(age) + "\n" + tooltip
+	}
+	a.tray.SetTooltip(tooltip)
+
+	// Update reset countdown menu items (hidden without API data, except
+	// for the session window estimated from transcripts)
+	if weeklyStats.HasAPIData {
+		a.tray.SetResetTimes(weeklyStats.FiveHourReset, weeklyStats.WeeklyReset)
+	} else {
+		a.tray.SetResetTimes(weeklyStats.SessionReset, time.Time{})
+	}
+
+	log.Printf("Icon updated: %s", label)
+}
+
+// iconValue returns the number shown on the icon for metric and its label
+// (e.g. "42%" or "$12"). The weekly metric shows the percentage of the
+// budget when budgetTokens or budgetUSD is set.
+func iconValue(weeklyStats *stats.WeeklyStats, metric string, budgetTokens int64, budgetUSD float64) (int, string) {
+	var percentage int
+	switch metric {
+	case config.IconFiveHour:
+		percentage = weeklyStats.GetFiveHourPercentage()
+	case config.IconOpus:
+		percentage = int(weeklyStats.OpusUtilization * 100)
+	case config.IconSonnet:
+		percentage = int(weeklyStats.SonnetUtilization * 100)
+	case config.IconCost:
+		return min(int(weeklyStats.WeekCostUSD), 99), format.FormatDollars(weeklyStats.WeekCostUSD)
+	default:
+		percentage = weeklyStats.GetPercentage()
+		if budgetTokens > 0 || budgetUSD > 0 {
+			percentage = min(int(weeklyStats.BudgetPercentage(budgetTokens, budgetUSD)), 99)
+		}
+	}
+	return percentage, tray.Percent(percentage)
+}
+
+// setError sets the tray to the error state of e.
+func (a *App) setError(e *refreshError) {
+	a.setFailing(e)
+
+	iconBytes, err := a.iconGen.GenerateError(e.kind)
+	if err != nil {
+		log.Printf("Error generating error icon: %v", err)
+		return
+	}
+
+	a.flash.stop()
+	a.tray.SetIcon(iconBytes)
+	sourceName := a.config.GetSourceDisplayName()
+	header := "Claude Usage\n━━━━━━━━━━━━━━━━━━\n"
+	if tray.Accessible() {
+		header = "Claude usage\n"
+	}
+	a.tray.SetTooltip(a.decorateTooltip(header + e.explain(sourceName)))
+}
+
+// setFailing offers Retry Now while refreshes fail, err being the last
+// refresh's error, and with notify_refresh_errors notifies once when they
+// start failing.
+func (a *App) setFailing(err error) {
+	failing := err != nil
+	if a.failing.Swap(failing) == failing {
+		return
+	}
+	a.tray.SetRetry(failing)
+	if failing && a.config.NotifyRefreshErrors {
+		var e *refreshError
+		if !errors.As(err, &e) {
+			e = fetchError(err)
+		}
+		go a.notifyFailure(e.explain(a.config.GetSourceDisplayName()))
+	}
+}
+
+// notifyFailure shows a notification that refreshes fail, refreshing when
+// its Retry button is clicked.
+func (a *App) notifyFailure(body string) {
+	retry, err := notify.ShowWithAction("Refresh failed", body, "Retry now")
+	if err != nil {
+		log.Printf("Could not show notification: %v", err)
+		return
+	}
+	if retry {
+		log.Println("Retry triggered from the notification")
+		a.triggerRefresh()
+	}
+}
+
+// tooltipLayout returns the built-in tooltip layout chosen in cfg,
+// detecting it for the desktop when set to auto.
+func tooltipLayout(cfg *config.Config) tray.TooltipLayout {
+	switch cfg.TooltipLayout {
+	case config.TooltipFull:
+		return tray.LayoutFull
+	case config.TooltipMedium:
+		return tray.LayoutMedium
+	case config.TooltipCompact:
+		return tray.LayoutCompact
+	}
+	return tray.DetectTooltipLayout()
+}
+
+// configProblems collects the problems from loading the config file.
+// A load error (e.g. invalid JSON) is reported against the file as a whole.
+func configProblems(cfg *config.Config, err error) []config.FieldError {
+	if err != nil {
+		return []config.FieldError{{Field: "config.json", Reason: err.Error()}}
+	}
+	return cfg.Problems()
+}
+
+// setConfigProblems records config problems for the tooltip and logs them.
+func (a *App) setConfigProblems(problems []config.FieldError) {
+	for _, p := range problems {
+		log.Printf("Config error: %v", p)
+	}
+
+	a.configMu.Lock()
+	a.configProblems = problems
+	a.configMu.Unlock()
+}
+
+// currentConfig returns the config in effect. Only the refresh loop swaps
+// it, in applyConfig, and reads it directly; other goroutines must read the
+// config through currentConfig and change it through changeConfig.
+func (a *App) currentConfig() *config.Config {
+	a.configMu.RLock()
+	defer a.configMu.RUnlock()
+	return a.config
+}
+
+// decorateTooltip prefixes tooltip with the active profile name and, if the
+// config has problems, a config error line naming the offending field.
+func (a *App) decorateTooltip(tooltip string) string {
+	a.configMu.RLock()
+	problems := a.configProblems
+	authProblem := a.authProblem
+	credentialsExposed := a.credentialsExposed
+	profile := a.config.Profile
+	a.configMu.RUnlock()
+
+	if profile != "" {
+		tooltip = "Profile: " + profile + "\n" + tooltip
+	}
+	if credentialsExposed != "" {
+		tooltip = "Credentials file readable by others\n" + tooltip
+	}
+	if authProblem != "" {
+		tooltip = authProblem + "\n" + tooltip
+	}
+
+	if len(problems) == 0 {
+		return tooltip
+	}
+
+	line := "Config error: " + problems[0].Field
+	if len(problems) > 1 {
+		line += fmt.Sprintf(" (+%d more)", len(problems)-1)
+	}
+	return line + "\n" + tooltip
+}
+
+// authProblemFor explains a failed fetch that logging in again would fix:
+// the API rejecting the token for a missing scope, or any failure while
+// the credentials file lists scopes without the required ones. Returns ""
+// otherwise.
+func (a *App) authProblemFor(creds *stats.Credentials, err error) string {
+	if err == nil || !a.config.UsesOAuth() {
+		return ""
+	}
+	var scopeErr *api.ScopeError
+	if errors.As(err, &scopeErr) {
+		return "Re-login needed: missing " + scopeErr.Scope + " scope"
+	}
+	if creds == nil {
+		return ""
+	}
+	if missing := creds.ClaudeAiOauth.MissingScopes(api.RequiredScopes...); len(missing) > 0 {
+		return "Re-login needed: missing " + missing[0] + " scope"
+	}
+	return ""
+}
+
+// checkCredentialsPerms warns, in the tooltip and once in the log, when
+// other users can read cfg's credentials file. It is checked on every
+// refresh so the warning clears once the file is fixed.
+func (a *App) checkCredentialsPerms(cfg *config.Config) {
+	warning := usage.CredentialsWarning(cfg)
+
+	a.configMu.Lock()
+	changed := warning != a.credentialsExposed
+	a.credentialsExposed = warning
+	a.configMu.Unlock()
+
+	if changed && warning != "" {
+		log.Printf("Warning: %s; run 'chmod 600 %s'", warning, cfg.GetCredentialsPath())
+	}
+}
+
+// setAuthProblem records the token problem for the tooltip, logging it
+// when it first appears.
+func (a *App) setAuthProblem(problem string) {
+	a.configMu.Lock()
+	changed := problem != a.authProblem
+	a.authProblem = problem
+	a.configMu.Unlock()
+
+	if changed && problem != "" {
+		log.Printf("Error: %s; run '%s' and log in again", problem, a.config.GetSourceDisplayName())
+	}
+}
+
+// toggleSource switches between Claude Code and OpenCode credential sources.
+// applyConfig updates the menu item, re-creates the usage provider with the
+// new credentials and refreshes.
+func (a *App) toggleSource() {
+	a.changeConfig(func(cfg *config.Config) {
+		oldSource := cfg.GetSourceDisplayName()
+		cfg.ToggleSource()
+		log.Printf("Switching credential source from %s to %s", oldSource, cfg.GetSourceDisplayName())
+	})
+}
+
+// toggleTeamUsage shows or hides team usage in the tooltip and persists
+// the choice; applyConfig updates the menu and refreshes.
+func (a *App) toggleTeamUsage() {
+	a.changeConfig(func(cfg *config.Config) {
+		cfg.ShowTeamUsage = !cfg.ShowTeamUsage
+		log.Printf("Team usage: %v", cfg.ShowTeamUsage)
+	})
+}
+
+// setRefreshInterval persists a new refresh interval; applyConfig resets
+// the refresh timer and the menu.
+func (a *App) setRefreshInterval(d time.Duration) {
+	a.changeConfig(func(cfg *config.Config) { cfg.RefreshInterval = d })
+}
+
+// setIconMetric persists metric; applyConfig shows it on the icon.
+func (a *App) setIconMetric(metric string) {
+	a.changeConfig(func(cfg *config.Config) { cfg.IconMetric = metric })
+}
+
+// setAlertThresholds persists thresholds, leaving them out of config.json
+// when they are the default; applyConfig updates the menu and the
+// integrations pick them up from the ConfigChanged event.
+func (a *App) setAlertThresholds(thresholds []int) {
+	if slices.Equal(thresholds, config.DefaultAlertThresholds) {
+		thresholds = nil
+	}
+	a.changeConfig(func(cfg *config.Config) { cfg.AlertThresholds = thresholds })
+}
+
+// changeConfig applies change to a copy of the config, saves it and hands
+// it to the refresh loop, which swaps it in with applyConfig as it does a
+// reloaded config. Goroutines holding the old config never see it change.
+// A change the loop has not applied yet is built on, not lost.
+func (a *App) changeConfig(change func(cfg *config.Config)) {
+	a.changeMu.Lock()
+	defer a.changeMu.Unlock()
+
+	base := a.currentConfig()
+	select {
+	case pending := <-a.configCh:
+		base = pending
+	default:
+	}
+	cfg := *base
+	change(&cfg)
+	if err := cfg.Save(); err != nil {
+		log.Printf("Warning: could not save config: %v", err)
+	}
+
+	// A reload may have been queued meanwhile; this config is newer
+	for {
+		select {
+		case a.configCh <- &cfg:
+			return
+		case <-a.configCh:
+		}
+	}
+}
+
+// reloadConfig re-reads config.json and hands it to the refresh loop.
+// Called by the config file watcher.
+func (a *App) reloadConfig() {
+	cfg, err := config.Load()
+	a.setConfigProblems(configProblems(cfg, err))
+	if err != nil {
+		log.Printf("Warning: ignoring config change, could not load config: %v", err)
+		// Redraw to show the problem in the tooltip
+		a.redraw()
+		return
+	}
+
+	log.Println("Config file changed, reloading")
+
+	// Replace any pending reload with the latest one
+	select {
+	case <-a.configCh:
+	default:
+	}
+	a.configCh <- cfg
+}
+
+// applyConfig swaps in a reloaded config, resetting whatever depends on the
+// changed values. Returns true if the refresh interval changed.
+// Must be called from the refresh loop goroutine.
+func (a *App) applyConfig(cfg *config.Config) bool {
+	// Only this goroutine writes a.config, so it may read it unlocked
+	old := a.config
+	a.configMu.Lock()
+	a.config = cfg
+	a.configMu.Unlock()
+
+	// Credential source or paths changed: re-create the usage provider with the new token
+	if cfg.Source != old.Source || cfg.GetCredentialsPath() != old.GetCredentialsPath() {
+		log.Printf("Credential source is now %s (%s)", cfg.GetSourceDisplayName(), cfg.GetCredentialsPath())
+		a.resetProvider()
+		a.tray.UpdateSourceToggle(cfg.GetSourceDisplayName())
+	}
+	if cfg.ReadOnlyCredentials != old.ReadOnlyCredentials || cfg.TokenStoreKey != old.TokenStoreKey {
+		log.Printf("Rotated tokens are now kept in %s", tokenstore.Location(cfg))
+		a.resetProvider()
+	}
+
+	if cfg.UsageProvider != old.UsageProvider || cfg.UsageFilePath != old.UsageFilePath ||
+		cfg.RemoteURL != old.RemoteURL || cfg.RemoteToken != old.RemoteToken {
+		a.resetProvider()
+	}
+
+	if cfg.ProxyURL != old.ProxyURL || cfg.CABundlePath != old.CABundlePath {
+		configureHTTP(cfg)
+	}
+
+	if cfg.Debug != old.Debug {
+		log.Printf("Debug logging: %v", cfg.Debug)
+		logging.SetDebug(cfg.Debug)
+	}
+
+	stats.SetWeekStart(cfg.WeekStart())
+	planlimits.Apply(cfg)
+	format.SetLocale(cfg.Locale)
+	stats.SetModelNames(cfg.ModelNames)
+	tray.SetAbsoluteResetTimes(cfg.ResetTimeFormat == config.ResetAbsolute)
+	setTooltipTemplate(cfg.TooltipTemplate)
+	tray.SetASCIIBars(cfg.BarStyle == config.BarASCII || (cfg.BarStyle != config.BarUnicode && !tray.UnicodeBarsSupported()))
+	tray.SetAccessible(cfg.AccessibilityMode)
+	tray.SetTooltipLayout(tooltipLayout(cfg))
+
+	a.bus.Publish(events.Event{Kind: events.ConfigChanged, Config: cfg, Previous: old})
+
+	if cfg.TeamUsageURL != old.TeamUsageURL || cfg.ShowTeamUsage != old.ShowTeamUsage {
+		a.tray.SetTeamUsage(cfg.TeamUsageURL != "", cfg.ShowTeamUsage)
+	}
+	if cfg.Telemetry != old.Telemetry {
+		a.tray.SetTelemetry(cfg.TelemetryEnabled())
+	}
+	if cfg.NotifyRefreshErrors != old.NotifyRefreshErrors || cfg.NotifyWeekStart != old.NotifyWeekStart {
+		a.tray.SetNotifications(cfg.NotifyRefreshErrors, cfg.NotifyWeekStart)
+	}
+	if !slices.Equal(cfg.GetAlertThresholds(), old.GetAlertThresholds()) {
+		a.tray.SetAlertThresholds(cfg.GetAlertThresholds())
+	}
+
+	if cfg.TaskbarBadge != old.TaskbarBadge {
+		a.setTaskbarBadge(cfg.TaskbarBadge)
+	}
+
+	if cfg.Hotkey != old.Hotkey {
+		a.setHotkey(cfg.Hotkey)
+	}
+
+	if a.controlPort(cfg) != a.controlPort(old) || cfg.ControlHost() != old.ControlHost() {
+		a.startControl(a.controlPort(cfg))
+	}
+
+	if cfg.IconMetric != old.IconMetric {
+		a.tray.SetIconMetric(cfg.IconMetric)
+		a.redraw()
+	}
+
+	intervalChanged := cfg.RefreshInterval != old.RefreshInterval
+	if intervalChanged {
+		log.Printf("Refresh interval is now %s", cfg.RefreshInterval)
+	}
+	if intervalChanged || cfg.AccessibilityMode != old.AccessibilityMode {
+		// Relabels the interval items too
+		a.tray.SetRefreshInterval(cfg.RefreshInterval)
+	}
+
+	return intervalChanged
+}
+
+// publishMQTT publishes usage state to the MQTT broker.
+func publishMQTT(ctx context.Context, p *mqtt.Publisher, weeklyStats *stats.WeeklyStats) {
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+	if err := p.Publish(ctx, weeklyStats); err != nil && !errors.Is(err, context.Canceled) {
+		log.Printf("Warning: could not publish to MQTT: %v", err)
+	}
+}
+
+// thresholdsChanged reports whether the alert thresholds differ.
+func thresholdsChanged(old, cfg *config.Config) bool {
+	oldTokens, oldUSD := old.Budget()
+	tokens, usd := cfg.Budget()
+	return !slices.Equal(old.GetAlertThresholds(), cfg.GetAlertThresholds()) ||
+		!maps.EqualFunc(old.ModelAlertThresholds, cfg.ModelAlertThresholds, slices.Equal) ||
+		!slices.Equal(old.GetSpendAlerts(), cfg.GetSpendAlerts()) ||
+		tokens != oldTokens || usd != oldUSD
+}
+
+// mqttChanged reports whether the MQTT settings differ.
+func mqttChanged(old, cfg *config.Config) bool {
+	return cfg.MQTTBroker != old.MQTTBroker || cfg.MQTTUsername != old.MQTTUsername || cfg.MQTTPassword != old.MQTTPassword ||
+		cfg.GetMQTTTopic() != old.GetMQTTTopic() || cfg.MQTTHomeAssistant != old.MQTTHomeAssistant
+}
+
+// integrationsChanged reports whether the integration settings differ.
+func integrationsChanged(old, cfg *config.Config) bool {
+	return old.SlackWebhookURL != cfg.SlackWebhookURL ||
+		!maps.Equal(old.SlackTemplates, cfg.SlackTemplates) ||
+		!slices.Equal(old.SlackEvents, cfg.SlackEvents) ||
+		old.DiscordWebhookURL != cfg.DiscordWebhookURL ||
+		!maps.Equal(old.DiscordTemplates, cfg.DiscordTemplates) ||
+		!slices.Equal(old.DiscordEvents, cfg.DiscordEvents) ||
+		old.WebhookURL != cfg.WebhookURL ||
+		!maps.Equal(old.WebhookHeaders, cfg.WebhookHeaders) ||
+		old.WebhookTemplate != cfg.WebhookTemplate ||
+		!slices.Equal(old.WebhookEvents, cfg.WebhookEvents) ||
+		old.SMTPServer != cfg.SMTPServer ||
+		old.SMTPUsername != cfg.SMTPUsername ||
+		old.SMTPPassword != cfg.SMTPPassword ||
+		old.EmailFrom != cfg.EmailFrom ||
+		!slices.Equal(old.EmailTo, cfg.EmailTo) ||
+		!slices.Equal(old.EmailEvents, cfg.EmailEvents) ||
+		old.GetEmailMinThreshold() != cfg.GetEmailMinThreshold() ||
+		old.OnThreshold != cfg.OnThreshold ||
+		old.OnThrottled != cfg.OnThrottled ||
+		old.OnReset != cfg.OnReset
+}
+
+// configureHTTP applies the proxy and CA bundle settings to all HTTP clients.
+func configureHTTP(cfg *config.Config) {
+	if err := httpclient.Configure(cfg.ProxyURL, cfg.CABundlePath); err != nil {
+		log.Printf("Warning: could not apply network settings: %v", err)
+		return
+	}
+	if cfg.ProxyURL != "" {
+		// Don't log proxy credentials
+		if u, err := url.Parse(cfg.ProxyURL); err == nil {
+			log.Printf("Using proxy: %s", u.Redacted())
+		}
+	}
+	if cfg.CABundlePath != "" {
+		log.Printf("Trusting extra CA bundle: %s", cfg.CABundlePath)
+	}
+}
+
+// toggleAutostart enables or disables starting at login for the active profile.
+func (a *App) toggleAutostart() {
+	profile := a.currentConfig().Profile
+
+	var err error
+	if autostart.IsEnabled(profile) {
+		err = autostart.Disable(profile)
+	} else {
+		err = autostart.Enable(profile)
+	}
+	if err != nil {
+		log.Printf("Could not change autostart: %v", err)
+	}
+
+	enabled := autostart.IsEnabled(profile)
+	log.Printf("Start at login: %v", enabled)
+	a.tray.SetAutostart(enabled)
+}
+
+// editConfig opens config.json in the default editor, writing the current
+// settings first if the file doesn't exist yet.
+func (a *App) editConfig() {
+	configPath := config.GetConfigPath()
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		// Save a copy, as Save records what it saved in the config
+		cfg := *a.currentConfig()
+		if err := cfg.Save(); err != nil {
+			log.Printf("Could not create config file: %v", err)
+			return
+		}
+	}
+	if err := launch.Open(configPath); err != nil {
+		log.Printf("Could not open config file: %v", err)
+	}
+}
+
+// createRefreshTokenCallback creates a callback function to persist new refresh tokens.
+// This uses the current config to determine where they are kept.
+func (a *App) createRefreshTokenCallback() api.RefreshTokenCallback {
+	return func(accessToken, refreshToken string) {
+		if a.config.UsesTokenStore() {
+			log.Printf("Persisting new tokens to the token store...")
+		} else {
+			log.Printf("Persisting new refresh token to credentials file...")
+		}
+
+		if err := tokenstore.SaveRotated(a.config, accessToken, refreshToken); err != nil {
+			log.Printf("ERROR: Failed to save the new refresh token: %v", err)
+			log.Printf("The new refresh token is in memory but NOT saved. You may need to re-authenticate on restart.")
+		} else {
+			log.Printf("Successfully saved the new refresh token")
+		}
+	}
+}
+
+// GetStats returns the current weekly stats (thread-safe).
+func (a *App) GetStats() *stats.WeeklyStats {
+	if s := a.latest.Load(); s != nil {
+		return s.stats
+	}
+	return nil
+}
+
+// copyUsage copies a plain-text usage summary to the system clipboard.
+func (a *App) copyUsage() {
+	summary := tray.FormatSummary(a.GetStats())
+	if err := clipboard.WriteText(summary); err != nil {
+		log.Printf("Could not copy usage to clipboard: %v", err)
+		return
+	}
+	log.Println("Usage summary copied to clipboard")
+}
+
+// exportPeriod is how much usage Export Data and Export Chart save.
+const exportPeriod = 30 * 24 * time.Hour
+
+// exportData saves the last exportPeriod of usage samples as a CSV file in
+// the Downloads folder, or the home folder without one, and opens it.
+func (a *App) exportData() {
+	samples, err := history.LoadSamples(config.GetSamplesPath(a.currentConfig().Profile), time.Now().Add(-exportPeriod))
+	if err != nil {
+		log.Printf("Could not read usage samples: %v", err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := history.WriteSamples(&buf, history.FormatCSV, samples); err != nil {
+		log.Printf("Could not export usage: %v", err)
+		return
+	}
+	path := a.exportPath(".csv")
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		log.Printf("Could not export usage: %v", err)
+		return
+	}
+	log.Printf("Exported %d usage samples to %s", len(samples), path)
+	if err := launch.Open(path); err != nil {
+		log.Printf("Could not open %s: %v", path, err)
+	}
+}
+
+// exportChart saves a chart of the last exportPeriod of usage as a PNG
+// image next to exportData's files and opens it.
+func (a *App) exportChart() {
+	now := time.Now()
+	from := now.Add(-exportPeriod)
+	samples, err := history.LoadSamples(config.GetSamplesPath(a.currentConfig().Profile), from)
+	if err != nil {
+		log.Printf("Could not read usage samples: %v", err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := chart.WritePNG(&buf, samples, chart.Options{From: from, To: now}); err != nil {
+		log.Printf("Could not export chart: %v", err)
+		return
+	}
+	path := a.exportPath("-chart.png")
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		log.Printf("Could not export chart: %v", err)
+		return
+	}
+	log.Printf("Exported a chart of %d usage samples to %s", len(samples), path)
+	if err := launch.Open(path); err != nil {
+		log.Printf("Could not open %s: %v", path, err)
+	}
+}
+
+// exportPath returns the path of today's export ending in suffix, in the
+// Downloads folder or the home folder without one.
+func (a *App) exportPath(suffix string) string {
+	dir := filepath.Join(config.GetHomeDir(), "Downloads")
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		dir = config.GetHomeDir()
+	}
+	name := "claude-usage"
+	if profile := a.currentConfig().Profile; profile != "" {
+		name += "-" + profile
+	}
+	return filepath.Join(dir, name+"-"+time.Now().Format(time.DateOnly)+suffix)
+}
+
+// openConfigDir opens the app's config directory in the file manager,
+// creating it first so the file manager has something to show.
+func (a *App) openConfigDir() {
+	if err := config.EnsureConfigDir(); err != nil {
+		log.Printf("Could not create config directory: %v", err)
+		return
+	}
+	if err := launch.Open(config.GetConfigDir()); err != nil {
+		log.Printf("Could not open config directory: %v", err)
+	}
+}
+
+// checkForUpdate asks GitHub for the latest release, labels the Update menu
+// item accordingly, and reports whether a newer version is available.
+// The caller must hold updateMu.
+func (a *App) checkForUpdate() bool {
+	release, newer, err := update.CheckLatestRelease(a.version, a.updateChannel())
+	latest := release.Tag
+	if err != nil {
+		log.Printf("Could not check for updates: %v", err)
+		return false
+	}
+
+	if !newer {
+		log.Printf("Up to date (%s, latest %s)", a.version, latest)
+		a.tray.SetUpToDate()
+		return false
+	}
+
+	log.Printf("Update available: %s (running %s)", latest, a.version)
+	a.latestVersion = latest
+	a.latestSummary = update.Summary(release.Notes, maxSummaryLen)
+	a.bus.Publish(events.Event{Kind: events.UpdateAvailable, Version: latest, Summary: a.latestSummary})
+	return true
+}
+
+// updateProgress returns a progress callback that reflects the download
+// percentage in the tooltip and Update menu item, redrawing only when the
+// percentage changes.
+func (a *App) updateProgress() update.ProgressFunc {
+	lastPercent := -2
+	return func(downloaded, total int64) {
+		percent := -1
+		if total > 0 {
+			percent = int(downloaded * 100 / total)
+		}
+		if percent == lastPercent {
+			return
+		}
+		lastPercent = percent
+
+		a.tray.SetUpdateProgress(percent)
+		if percent >= 0 {
+			a.tray.SetTooltip("Downloading update… " + format.FormatPercent(percent))
+		}
+	}
+}
+
+// updateChannel returns the configured update channel.
+func (a *App) updateChannel() string {
+	a.configMu.RLock()
+	defer a.configMu.RUnlock()
+	return a.config.UpdateChannel
+}
+
+// performUpdate downloads and installs the latest version.
+// The caller must hold updateMu.
+func (a *App) performUpdate() {
+	// Stable updates use the "latest" download URL; beta updates need the
+	// exact pre-release tag
+	tag := ""
+	if a.updateChannel() == config.ChannelBeta {
+		tag = a.latestVersion
+	}
+
+	log.Printf("Starting update from %s", update.GetDownloadURL(tag))
+
+	// Show progress in tooltip
+	a.tray.SetTooltip("Downloading update...")
+
+	// Perform the update
+	result, err := update.Update(tag, a.updateProgress())
+	if err != nil {
+		log.Printf("Update failed: %v", err)
+		switch {
+		case errors.Is(err, update.ErrChecksumMismatch):
+			a.tray.SetTooltip("Update aborted: download doesn't match the published checksum")
+		case errors.Is(err, update.ErrSignatureInvalid):
+			a.tray.SetTooltip("Update aborted: release signature is invalid")
+		default:
+			a.tray.SetTooltip("Update failed: " + err.Error())
+		}
+		// Restore normal tooltip after a delay
+		go func() {
+			time.Sleep(5 * time.Second)
+			a.redraw()
+		}()
+		return
+	}
+
+	log.Printf("Update result: %s", result.Message)
+	a.finishInstall("Update installed. Click Restart Now to use the new version.")
+}
+
+// performRollback swaps back the version replaced by the last update.
+// The caller must hold updateMu.
+func (a *App) performRollback() {
+	result, err := update.Rollback()
+	if err != nil {
+		log.Printf("Rollback failed: %v", err)
+		a.tray.SetTooltip("Rollback failed: " + err.Error())
+		return
+	}
+
+	log.Printf("Rollback result: %s", result.Message)
+	a.finishInstall("Rolled back. Click Restart Now to use the previous version.")
+}
+
+// finishInstall restarts into a newly installed binary if auto_restart is
+// set, and otherwise offers Restart Now in the menu.
+// The caller must hold updateMu.
+func (a *App) finishInstall(tooltip string) {
+	a.updateInstalled = true
+	a.tray.SetRollbackAvailable(false)
+
+	a.configMu.RLock()
+	autoRestart := a.config.AutoRestart
+	a.configMu.RUnlock()
+	if autoRestart {
+		a.restart()
+		return
+	}
+
+	a.tray.SetTooltip(tooltip)
+
+	// Changes the Update menu item to "Restart Now"
+	a.tray.SetUpdateComplete()
+}
+
+// restart quits the app and asks main to start the updated binary.
+func (a *App) restart() {
+	log.Println("Restart requested")
+	a.restartRequested.Store(true)
+	a.stop()
+	a.tray.Quit()
+}
+
+// RestartRequested reports whether the app quit to restart into an update.
+func (a *App) RestartRequested() bool {
+	return a.restartRequested.Load()
+}
+
+// Shutdown quits the app as if Quit had been clicked, e.g. on SIGTERM.
+func (a *App) Shutdown() {
+	a.stop()
+	a.tray.Quit()
+}
+
+// stop signals background goroutines to exit, gives a running refresh
+// shutdownTimeout to finish, then cancels in-flight requests. Safe to call
+// more than once.
+func (a *App) stop() {
+	a.stopOnce.Do(func() {
+		close(a.stopCh)
+
+		done := make(chan struct{})
+		go func() {
+			a.loops.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(shutdownTimeout):
+			log.Println("Warning: refresh still running at shutdown, cancelling it")
+		}
+		a.cancel()
+		a.flash.stop()
+
+		a.controlMu.Lock()
+		a.control.Close()
+		a.control = nil
+		a.controlMu.Unlock()
+	})
+}
+
+// flasher alternates the tray icon between two frames in the background.
+type flasher struct {
+	mu     sync.Mutex
+	done   chan struct{}
+	exited chan struct{}
+}
+
+// start flashes between on and off until stop is called.
+func (f *flasher) start(t *tray.Tray, on, off []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.done != nil {
+		return
+	}
+	done, exited := make(chan struct{}), make(chan struct{})
+	f.done, f.exited = done, exited
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(flashInterval)
+		defer ticker.Stop()
+		dimmed := false
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				dimmed = !dimmed
+				if dimmed {
+					t.SetIcon(off)
+				} else {
+					t.SetIcon(on)
+				}
+			}
+		}
+	}()
+}
+
+// stop ends flashing and waits for the last frame to be set, so the
+// caller's next SetIcon wins.
+func (f *flasher) stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.done != nil {
+		close(f.done)
+		<-f.exited
+		f.done, f.exited = nil, nil
+	}
 }
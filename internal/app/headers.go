@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"claude-usage/internal/api"
+	"claude-usage/internal/config"
+	"claude-usage/internal/stats"
+)
+
+// headerProbeInterval is how often the probe check sends its request.
+// Each one uses a token of the quota it measures.
+const headerProbeInterval = 30 * time.Minute
+
+// headerMaxSkew is how far apart the usage endpoint and the headers may
+// have been read to be compared; usage moves on in between.
+const headerMaxSkew = 5 * time.Minute
+
+// checkRateLimitHeaders cross-checks usage, fresh from the usage
+// endpoint, against the rate limit headers of regular API responses, with
+// rate_limit_headers. While they disagree, weeklyStats holds both, and the
+// tooltip shows them. A failure to read the headers is logged once.
+func (a *App) checkRateLimitHeaders(ctx context.Context, provider api.UsageProvider, usage *api.RateLimitData, weeklyStats *stats.WeeklyStats) {
+	var headers *api.RateLimitData
+	var err error
+	switch a.config.RateLimitHeaders {
+	case config.RateLimitHeadersProbe:
+		prober, ok := provider.(api.HeaderProber)
+		if !ok || time.Since(a.headerProbedAt) < headerProbeInterval {
+			break
+		}
+		a.headerProbedAt = time.Now()
+		headers, err = prober.ProbeRateLimitHeaders(ctx)
+	case config.RateLimitHeadersFile:
+		headers, err = api.ReadHeaderFile(a.config.RateLimitHeadersFile)
+	default:
+		a.headers, a.headerMismatch = nil, false
+		return
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+	case err != nil:
+		if msg := err.Error(); msg != a.headerErr {
+			log.Printf("Warning: could not read rate limit headers: %v", err)
+			a.headerErr = msg
+		}
+	case headers != nil:
+		a.headerErr = ""
+		if skew := headers.FetchedAt.Sub(usage.FetchedAt).Abs(); skew <= headerMaxSkew {
+			a.compareRateLimitHeaders(usage, headers)
+		}
+	}
+
+	if a.headerMismatch {
+		weeklyStats.HeaderMismatch = true
+		weeklyStats.HeaderFiveHourUtilization = a.headers.FiveHourUtilization
+		weeklyStats.HeaderWeeklyUtilization = a.headers.WeeklyUtilization
+	}
+}
+
+// compareRateLimitHeaders records whether headers disagree with usage,
+// logging when that changes.
+func (a *App) compareRateLimitHeaders(usage, headers *api.RateLimitData) {
+	mismatch := api.HeadersDisagree(usage, headers)
+	if mismatch && !a.headerMismatch {
+		log.Printf("Warning: rate limit headers disagree with the usage endpoint: 5h %.0f%% vs %.0f%%, weekly %.0f%% vs %.0f%%",
+			headers.FiveHourUtilization*100, usage.FiveHourUtilization*100,
+			headers.WeeklyUtilization*100, usage.WeeklyUtilization*100)
+	} else if !mismatch && a.headerMismatch {
+		log.Println("Rate limit headers agree with the usage endpoint again")
+	}
+	a.headers, a.headerMismatch = headers, mismatch
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"log"
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/power"
+)
+
+// powerCheckInterval is how often powerLoop checks the power source.
+const powerCheckInterval = 30 * time.Second
+
+// powerSaverFactor is how many refresh intervals battery saver waits
+// between automatic refreshes.
+const powerSaverFactor = 3
+
+// powerLoop turns battery saver on and off as the power source and the
+// power_saver setting change.
+func (a *App) powerLoop() {
+	defer a.loops.Done()
+	ticker := time.NewTicker(powerCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case <-ticker.C:
+			a.checkPower()
+		}
+	}
+}
+
+// checkPower turns battery saver on or off and, when that changes, redraws
+// the tray so the tooltip shows the mode and the icon stops or resumes
+// flashing.
+func (a *App) checkPower() {
+	a.configMu.RLock()
+	mode := a.config.PowerSaver
+	a.configMu.RUnlock()
+
+	saving := false
+	switch mode {
+	case config.PowerSaverOn:
+		saving = true
+	case config.PowerSaverOff:
+	default:
+		onBattery, err := power.OnBattery()
+		if err != nil {
+			if !a.powerUnknown {
+				log.Printf("Note: cannot tell whether running on battery: %v", err)
+				a.powerUnknown = true
+			}
+			return
+		}
+		saving = onBattery
+	}
+
+	if a.powerSaving.Swap(saving) == saving {
+		return
+	}
+	if saving {
+		log.Printf("Battery saver on: refreshing every %d intervals, no icon flashing or transcript scans", powerSaverFactor)
+	} else {
+		log.Println("Battery saver off")
+	}
+	a.redraw()
+}
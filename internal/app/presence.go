@@ -0,0 +1,59 @@
+package app
+
+import (
+	"log"
+	"time"
+
+	"claude-usage/internal/presence"
+)
+
+// presenceCheckInterval is how often presenceLoop checks whether the user
+// is away, and so roughly how long after unlocking the refresh comes.
+const presenceCheckInterval = 15 * time.Second
+
+// presenceLoop pauses automatic refreshes while the screen is locked or
+// the user is idle, with pause_when_away, saving API calls and battery,
+// and refreshes as soon as they are back. Where the session's state
+// cannot be read, refreshes go on as usual.
+func (a *App) presenceLoop() {
+	defer a.loops.Done()
+	ticker := time.NewTicker(presenceCheckInterval)
+	defer ticker.Stop()
+
+	// warned is set once a failed check was logged; the cause, such as a
+	// missing logind, rarely goes away
+	warned := false
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		a.configMu.RLock()
+		enabled := a.config.PauseWhenAway
+		idleAfter := a.config.IdleAfter()
+		a.configMu.RUnlock()
+
+		state := presence.Present
+		if enabled {
+			s, err := presence.Check(idleAfter)
+			if err != nil && !warned {
+				log.Printf("Note: cannot tell whether the screen is locked, refreshing regardless: %v", err)
+				warned = true
+			}
+			state = s
+		}
+
+		away := state != presence.Present
+		if a.away.Swap(away) == away {
+			continue
+		}
+		if away {
+			log.Printf("Session %s; pausing automatic refreshes", state)
+		} else {
+			log.Println("User back; refreshing")
+			a.triggerRefresh()
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"claude-usage/internal/api"
+	"claude-usage/internal/icon"
+)
+
+// refreshError is a refresh failure that leaves no usage to show. Its kind
+// picks the error icon and the tooltip's explanation.
+type refreshError struct {
+	kind icon.ErrorKind
+	err  error
+}
+
+// Error implements the error interface.
+func (e *refreshError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *refreshError) Unwrap() error {
+	return e.err
+}
+
+// fetchError classifies an error from the usage provider.
+func fetchError(err error) *refreshError {
+	var netErr net.Error
+	var scopeErr *api.ScopeError
+	switch {
+	case errors.As(err, &netErr):
+		return &refreshError{icon.ErrorNetwork, err}
+	case errors.Is(err, api.ErrTokenExpired), errors.As(err, &scopeErr):
+		return &refreshError{icon.ErrorTokenExpired, err}
+	default:
+		return &refreshError{icon.ErrorAPI, err}
+	}
+}
+
+// explain returns the tooltip lines for the error; source names the
+// credential source.
+func (e *refreshError) explain(source string) string {
+	switch e.kind {
+	case icon.ErrorTokenExpired:
+		return "Token expired\nRun " + source + " and log in again"
+	case icon.ErrorNetwork:
+		return "Cannot reach the API\nCheck your connection or proxy"
+	case icon.ErrorAPI:
+		var statusErr *api.StatusError
+		if errors.As(e.err, &statusErr) {
+			return fmt.Sprintf("API error (HTTP %d)\nRetrying on the next refresh", statusErr.StatusCode)
+		}
+		return "API error\nRetrying on the next refresh"
+	default:
+		return "Error loading credentials\nMake sure " + source + " is installed\nand you are logged in"
+	}
+}
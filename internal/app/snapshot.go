@@ -0,0 +1,29 @@
+package app
+
+import (
+	"time"
+
+	"claude-usage/internal/api"
+	"claude-usage/internal/stats"
+)
+
+// snapshot is the usage state of a refresh. It is complete when published
+// and never modified afterwards, so it is read without locking.
+type snapshot struct {
+	stats   *stats.WeeklyStats
+	updated time.Time
+
+	// statistics are the lines for the Statistics submenu
+	statistics []string
+
+	// sparkline are the icon sparkline's columns, nil while it is off
+	sparkline []float64
+
+	// rateLimits is the last successful API response, served to remote
+	// viewers; nil before the first
+	rateLimits *api.RateLimitData
+
+	// cached is set for the cached rate limits shown while the first fetch
+	// is in flight
+	cached bool
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/notify"
+	"claude-usage/internal/tray"
+)
+
+// startupRetryInterval is how often a failing first refresh is retried
+// during the startup grace period.
+const startupRetryInterval = 5 * time.Second
+
+// How often a missing tray icon host is looked for during the startup
+// grace period, and after it.
+const (
+	trayHostGraceInterval = 2 * time.Second
+	trayHostInterval      = 30 * time.Second
+)
+
+// retryFirstRefresh refreshes every startupRetryInterval until a refresh
+// succeeds or graceEnd passes. Started at login, the app often runs before
+// the network is up; without this the icon would show an error until the
+// next tick.
+func (a *App) retryFirstRefresh(graceEnd time.Time) {
+	defer a.loops.Done()
+	ticker := time.NewTicker(startupRetryInterval)
+	defer ticker.Stop()
+
+	for time.Now().Before(graceEnd) && a.failing.Load() {
+		select {
+		case <-a.stopCh:
+			return
+		case <-ticker.C:
+		}
+		if a.failing.Load() {
+			log.Println("Refresh failed during startup; retrying")
+			a.triggerRefresh()
+		}
+	}
+}
+
+// watchTrayHost waits for a StatusNotifier host when the desktop has none,
+// as at login before the panel is up. Once one appears the tray is redrawn
+// for it; the tray registers its icon with the host's watcher by itself.
+// If none appears by graceEnd, it warns, as on GNOME without the
+// AppIndicator extension, where the icon is simply missing and the app
+// would look broken, and serves the dashboard instead.
+func (a *App) watchTrayHost(graceEnd time.Time) {
+	defer a.loops.Done()
+
+	// missing is set once the host was found missing; warned once that
+	// was reported
+	missing, warned := false, false
+	for {
+		hasHost, err := tray.HasStatusNotifierHost()
+		if err != nil {
+			log.Printf("Note: could not check for a tray icon host: %v", err)
+			return
+		}
+		if hasHost {
+			if missing {
+				log.Println("Tray icon host available; showing the icon")
+				a.redraw()
+			}
+			return
+		}
+
+		missing = true
+		interval := trayHostGraceInterval
+		if time.Now().After(graceEnd) {
+			if !warned {
+				a.warnNoTrayHost()
+				warned = true
+			}
+			interval = trayHostInterval
+		}
+		select {
+		case <-a.stopCh:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// warnNoTrayHost explains that the tray icon cannot be shown and serves
+// the dashboard on the default port unless control_port is set.
+func (a *App) warnNoTrayHost() {
+	a.noTrayHost.Store(true)
+	a.configMu.RLock()
+	cfg := a.config
+	a.configMu.RUnlock()
+
+	port := a.controlPort(cfg)
+	if cfg.ControlPort == 0 {
+		a.startControl(port)
+	}
+	log.Printf("Warning: no StatusNotifier host is running, so the tray icon cannot be shown. "+
+		"On GNOME, install the AppIndicator and KStatusNotifierItem Support extension. "+
+		"Until then usage is served at http://127.0.0.1:%d/?token=<token from %s>", port, config.GetControlTokenPath(cfg.Profile))
+	body := fmt.Sprintf("Your desktop can't show tray icons (on GNOME, install the AppIndicator extension). "+
+		"Usage is available at http://127.0.0.1:%d/ — see the log for details.", port)
+	if err := notify.Show("Claude Usage has no tray icon", body); err != nil {
+		log.Printf("Could not show notification: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/notify"
+	"claude-usage/internal/telemetry"
+)
+
+// askTelemetryConsent asks once whether anonymous statistics may be sent,
+// and records the answer. Anything but clicking Share, including ignoring
+// the notification, is a no.
+func (a *App) askTelemetryConsent() {
+	share, err := notify.ShowWithAction("Help improve Claude Usage?",
+		"Share a daily count of successful and failed refreshes, with the app version and OS. "+
+			"No usage, account or file details are sent. You can change this under Settings.", "Share")
+	if err != nil {
+		// Not asked, so ask again next time
+		log.Printf("Could not show notification: %v", err)
+		return
+	}
+
+	choice := config.TelemetryOff
+	if share {
+		choice = config.TelemetryOn
+	}
+	a.setTelemetry(choice)
+}
+
+// toggleTelemetry turns anonymous statistics on or off and persists it.
+func (a *App) toggleTelemetry() {
+	enabled := a.currentConfig().TelemetryEnabled()
+
+	if config.DoNotTrack() {
+		log.Println("Note: DO_NOT_TRACK is set, so anonymous statistics stay off")
+		a.tray.SetTelemetry(false)
+		return
+	}
+	choice := config.TelemetryOn
+	if enabled {
+		choice = config.TelemetryOff
+	}
+	a.setTelemetry(choice)
+}
+
+// setTelemetry records choice in config.json; applyConfig updates the
+// Settings menu.
+func (a *App) setTelemetry(choice string) {
+	log.Printf("Anonymous statistics: %s", choice)
+	a.changeConfig(func(cfg *config.Config) { cfg.Telemetry = choice })
+}
+
+// telemetryLoop sends anonymous statistics once a day while they are
+// enabled, or hourly after a failure.
+func (a *App) telemetryLoop() {
+	for {
+		a.configMu.RLock()
+		cfg := a.config
+		a.configMu.RUnlock()
+
+		// Re-read the setting every hour while disabled so agreeing takes
+		// effect without a restart
+		wait := time.Hour
+		if cfg.TelemetryEnabled() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			next, err := telemetry.Update(ctx, cfg, a.version)
+			cancel()
+			if err != nil {
+				log.Printf("Note: could not send anonymous statistics: %v", err)
+			}
+			wait = next
+		}
+
+		select {
+		case <-a.stopCh:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"log"
+	"time"
+)
+
+// watchdogFactor is how many refresh intervals may pass without the
+// refresh loop doing its work before the watchdog steps in.
+const watchdogFactor = 3
+
+// watchdogCheckInterval is how often the watchdog looks at the loop.
+const watchdogCheckInterval = 30 * time.Second
+
+// watchdogLoop restarts the refresh loop when it has not done its work for
+// watchdogFactor intervals, as with a hung request or a deadlock, so the
+// tray never silently shows hours-old data. The in-flight fetch is
+// cancelled and the icon is marked stale until a refresh completes.
+func (a *App) watchdogLoop() {
+	defer a.loops.Done()
+	ticker := time.NewTicker(watchdogCheckInterval)
+	defer ticker.Stop()
+
+	// restarted is set once the loop was restarted, until it recovers;
+	// restarting it again would only pile up stuck goroutines
+	restarted := false
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		a.configMu.RLock()
+		limit := watchdogFactor * a.config.RefreshInterval
+		a.configMu.RUnlock()
+
+		idle := time.Since(time.Unix(0, a.heartbeat.Load()))
+		if idle < limit {
+			if restarted {
+				log.Println("Refresh loop recovered")
+			}
+			restarted = false
+			continue
+		}
+		if restarted {
+			continue
+		}
+
+		log.Printf("Warning: no refresh completed in %v; restarting the refresh loop", idle.Round(time.Second))
+		a.cancelInFlight()
+		a.markStale()
+		gen := a.loopGen.Add(1)
+		a.loops.Add(1)
+		go a.refreshLoop(gen)
+		restarted = true
+	}
+}
+
+// beat records that the refresh loop did its work.
+func (a *App) beat() {
+	a.heartbeat.Store(time.Now().UnixNano())
+}
+
+// exclusive runs work unless a stuck refresh loop is still running its
+// own, and reports whether it did. Loops restarted by the watchdog thus
+// never touch the refresh loop's state alongside the stuck one.
+func (a *App) exclusive(work func()) bool {
+	if !a.loopMu.TryLock() {
+		log.Println("Warning: a stuck refresh is still running; skipping this one")
+		return false
+	}
+	defer a.loopMu.Unlock()
+	work()
+	a.beat()
+	return true
+}
+
+// markStale shows the latest stats as stale.
+func (a *App) markStale() {
+	s := a.latest.Load()
+	if s == nil || s.stats == nil {
+		return
+	}
+	staleStats := *s.stats
+	staleStats.APIDataStale = true
+	stale := *s
+	stale.stats = &staleStats
+	a.publishStats(&stale)
+}
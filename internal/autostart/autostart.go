@@ -0,0 +1,51 @@
+// Package autostart registers claude-usage to start at login.
+// - Linux: ~/.config/autostart/claude-usage.desktop
+// - macOS: ~/Library/LaunchAgents/com.github.utajum.claude-usage.plist
+// - Windows: HKCU\Software\Microsoft\Windows\CurrentVersion\Run
+//
+// Entry names match the ones created by the install scripts, so toggling
+// autostart from the app and from the installers stays in sync.
+// Each config profile gets its own entry that launches with --profile.
+package autostart
+
+import (
+	"fmt"
+
+	"claude-usage/internal/config"
+)
+
+// Enable registers the running executable to start at login for profile.
+func Enable(profile string) error {
+	exe, err := config.GetExecutablePath()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	return enable(profile, exe, launchArgs(profile))
+}
+
+// Disable removes the login entry for profile. It is not an error if none exists.
+func Disable(profile string) error {
+	return disable(profile)
+}
+
+// IsEnabled reports whether a login entry exists for profile.
+func IsEnabled(profile string) bool {
+	return isEnabled(profile)
+}
+
+// launchArgs returns the command-line arguments for the login entry.
+func launchArgs(profile string) []string {
+	if profile == "" {
+		return nil
+	}
+	return []string{"--profile=" + profile}
+}
+
+// entryName returns the base name of the login entry, e.g. "claude-usage-work".
+func entryName(profile string) string {
+	if profile == "" {
+		return "claude-usage"
+	}
+	return "claude-usage-" + profile
+}
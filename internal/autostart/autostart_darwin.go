@@ -0,0 +1,76 @@
+package autostart
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"claude-usage/internal/config"
+)
+
+// launchAgentTemplate mirrors assets/macos/com.github.utajum.claude-usage.plist.
+const launchAgentTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+    <key>Label</key>
+    <string>%s</string>
+    <key>ProgramArguments</key>
+    <array>
+%s    </array>
+    <key>RunAtLoad</key>
+    <true/>
+    <key>KeepAlive</key>
+    <false/>
+    <key>ProcessType</key>
+    <string>Interactive</string>
+</dict>
+</plist>
+`
+
+// launchAgentLabel returns the LaunchAgent label for profile.
+func launchAgentLabel(profile string) string {
+	if profile == "" {
+		return "com.github.utajum.claude-usage"
+	}
+	return "com.github.utajum.claude-usage." + profile
+}
+
+// entryPath returns the LaunchAgent plist path for profile.
+func entryPath(profile string) string {
+	return filepath.Join(config.GetHomeDir(), "Library", "LaunchAgents", launchAgentLabel(profile)+".plist")
+}
+
+func enable(profile, exe string, args []string) error {
+	path := entryPath(profile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create LaunchAgents directory: %w", err)
+	}
+
+	var programArgs strings.Builder
+	for _, arg := range append([]string{exe}, args...) {
+		programArgs.WriteString("        <string>" + html.EscapeString(arg) + "</string>\n")
+	}
+
+	content := fmt.Sprintf(launchAgentTemplate, launchAgentLabel(profile), programArgs.String())
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write LaunchAgent: %w", err)
+	}
+	return nil
+}
+
+// disable removes the plist. The agent is deliberately not unloaded, since
+// launchctl unload would terminate the running app.
+func disable(profile string) error {
+	if err := os.Remove(entryPath(profile)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove LaunchAgent: %w", err)
+	}
+	return nil
+}
+
+func isEnabled(profile string) bool {
+	_, err := os.Stat(entryPath(profile))
+	return err == nil
+}
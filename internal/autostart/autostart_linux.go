@@ -0,0 +1,74 @@
+package autostart
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"claude-usage/internal/config"
+)
+
+// desktopEntryTemplate mirrors assets/linux/claude-usage.desktop.
+const desktopEntryTemplate = `[Desktop Entry]
+Type=Application
+Name=Claude Usage
+GenericName=Token Usage Monitor
+Comment=Monitor Claude Code API token usage in system tray
+Exec=%s
+Icon=claude-usage
+Terminal=false
+Categories=Utility;Monitor;System;
+StartupNotify=false
+StartupWMClass=claude-usage
+X-GNOME-Autostart-enabled=true
+`
+
+// entryPath returns the XDG autostart .desktop path for profile.
+func entryPath(profile string) string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(config.GetHomeDir(), ".config")
+	}
+	return filepath.Join(configHome, "autostart", entryName(profile)+".desktop")
+}
+
+func enable(profile, exe string, args []string) error {
+	path := entryPath(profile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create autostart directory: %w", err)
+	}
+
+	execLine := quoteExecArg(exe)
+	for _, arg := range args {
+		execLine += " " + quoteExecArg(arg)
+	}
+
+	content := fmt.Sprintf(desktopEntryTemplate, execLine)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write autostart entry: %w", err)
+	}
+	return nil
+}
+
+func disable(profile string) error {
+	if err := os.Remove(entryPath(profile)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove autostart entry: %w", err)
+	}
+	return nil
+}
+
+func isEnabled(profile string) bool {
+	_, err := os.Stat(entryPath(profile))
+	return err == nil
+}
+
+// quoteExecArg quotes an argument for a .desktop Exec key if needed,
+// following the Desktop Entry Specification's quoting rules.
+func quoteExecArg(arg string) string {
+	if !strings.ContainsAny(arg, " \t\"'\\`$<>|&;*?#()") {
+		return arg
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", `$`, `\$`)
+	return `"` + replacer.Replace(arg) + `"`
+}
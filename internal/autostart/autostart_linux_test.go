@@ -0,0 +1,59 @@
+package autostart
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEnableDisable_Linux(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if IsEnabled("work") {
+		t.Fatal("Expected autostart disabled initially")
+	}
+
+	if err := Enable("work"); err != nil {
+		t.Fatalf("Enable failed: %v", err)
+	}
+	if !IsEnabled("work") {
+		t.Error("Expected autostart enabled after Enable")
+	}
+	if IsEnabled("") {
+		t.Error("Enabling a profile should not enable the default entry")
+	}
+
+	data, err := os.ReadFile(entryPath("work"))
+	if err != nil {
+		t.Fatalf("Failed to read entry: %v", err)
+	}
+	if !strings.Contains(string(data), "--profile=work") {
+		t.Errorf("Entry does not pass the profile:\n%s", data)
+	}
+
+	if err := Disable("work"); err != nil {
+		t.Fatalf("Disable failed: %v", err)
+	}
+	if IsEnabled("work") {
+		t.Error("Expected autostart disabled after Disable")
+	}
+	if err := Disable("work"); err != nil {
+		t.Errorf("Disable should be idempotent, got: %v", err)
+	}
+}
+
+func TestQuoteExecArg(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"/usr/bin/claude-usage", "/usr/bin/claude-usage"},
+		{"/home/me/my apps/claude-usage", `"/home/me/my apps/claude-usage"`},
+		{`/tmp/a"b`, `"/tmp/a\"b"`},
+	}
+	for _, tt := range tests {
+		if got := quoteExecArg(tt.input); got != tt.expected {
+			t.Errorf("quoteExecArg(%q) = %s, expected %s", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !linux && !darwin && !windows
+
+package autostart
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func enable(profile, exe string, args []string) error {
+	return fmt.Errorf("autostart is not supported on %s", runtime.GOOS)
+}
+
+func disable(profile string) error {
+	return nil
+}
+
+func isEnabled(profile string) bool {
+	return false
+}
@@ -0,0 +1,75 @@
+package autostart
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// runKeyPath is the per-user registry key for programs started at login.
+const runKeyPath = `Software\Microsoft\Windows\CurrentVersion\Run`
+
+// valueName returns the Run value name for profile.
+func valueName(profile string) string {
+	if profile == "" {
+		return "Claude Usage"
+	}
+	return "Claude Usage (" + profile + ")"
+}
+
+// startupShortcutPath returns the Startup folder shortcut created by install-windows.ps1.
+func startupShortcutPath(profile string) string {
+	return filepath.Join(os.Getenv("APPDATA"), "Microsoft", "Windows", "Start Menu", "Programs", "Startup", valueName(profile)+".lnk")
+}
+
+func enable(profile, exe string, args []string) error {
+	key, _, err := registry.CreateKey(registry.CURRENT_USER, runKeyPath, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("failed to open Run registry key: %w", err)
+	}
+	defer key.Close()
+
+	command := `"` + exe + `"`
+	if len(args) > 0 {
+		command += " " + strings.Join(args, " ")
+	}
+
+	if err := key.SetStringValue(valueName(profile), command); err != nil {
+		return fmt.Errorf("failed to write Run registry value: %w", err)
+	}
+	return nil
+}
+
+// disable removes both the registry value and the installer's Startup shortcut.
+func disable(profile string) error {
+	key, err := registry.OpenKey(registry.CURRENT_USER, runKeyPath, registry.SET_VALUE)
+	if err == nil {
+		defer key.Close()
+		if err := key.DeleteValue(valueName(profile)); err != nil && err != registry.ErrNotExist {
+			return fmt.Errorf("failed to delete Run registry value: %w", err)
+		}
+	}
+
+	if err := os.Remove(startupShortcutPath(profile)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove Startup shortcut: %w", err)
+	}
+	return nil
+}
+
+func isEnabled(profile string) bool {
+	if _, err := os.Stat(startupShortcutPath(profile)); err == nil {
+		return true
+	}
+
+	key, err := registry.OpenKey(registry.CURRENT_USER, runKeyPath, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	defer key.Close()
+
+	_, _, err = key.GetStringValue(valueName(profile))
+	return err == nil
+}
@@ -0,0 +1,202 @@
+// Package chart draws usage history as PNG images: the weekly window's
+// utilization as a filled area and the 5-hour window's as a line, over a
+// grid with a horizontal line every 25% and a vertical one every midnight.
+//
+// The standard library has no text rendering, so charts carry no labels;
+// callers show the period and scale next to them.
+package chart
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"sort"
+	"time"
+
+	"claude-usage/internal/history"
+)
+
+// Default chart size in pixels.
+const (
+	DefaultWidth  = 800
+	DefaultHeight = 240
+)
+
+// maxGap is the longest time between samples that is drawn as continuous;
+// longer gaps (the computer asleep, the app not running) are left empty.
+const maxGap = 2 * time.Hour
+
+// margin is the space around the plot area in pixels.
+const margin = 8
+
+// Colors, matching the dashboard.
+var (
+	backgroundColor = color.RGBA{0x0a, 0x0a, 0x12, 0xff}
+	gridColor       = color.RGBA{0x22, 0x22, 0x2e, 0xff}
+	weeklyColor     = color.RGBA{0x00, 0xff, 0x9c, 0xff}
+	weeklyFillColor = color.RGBA{0x00, 0x40, 0x27, 0x40} // weeklyColor at 25%, premultiplied
+	fiveHourColor   = color.RGBA{0xff, 0xb0, 0x00, 0xff}
+)
+
+// Options choose the size and period of a chart.
+type Options struct {
+	// Width and Height in pixels; zero means DefaultWidth and
+	// DefaultHeight
+	Width, Height int
+
+	// From and To bound the period shown; zero means the first and last
+	// sample
+	From, To time.Time
+}
+
+// Draw draws samples, which must be in time order, as a chart.
+func Draw(samples []history.Sample, opts Options) *image.RGBA {
+	width, height := opts.Width, opts.Height
+	if width <= 0 {
+		width = DefaultWidth
+	}
+	if height <= 0 {
+		height = DefaultHeight
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(backgroundColor), image.Point{}, draw.Src)
+
+	plot := image.Rect(margin, margin, width-margin, height-margin)
+	if plot.Dx() < 2 || plot.Dy() < 2 {
+		return img
+	}
+	from, to := opts.From, opts.To
+	if len(samples) > 0 {
+		if from.IsZero() {
+			from = samples[0].At
+		}
+		if to.IsZero() {
+			to = samples[len(samples)-1].At
+		}
+	}
+	c := canvas{img: img, plot: plot, from: from, to: to}
+	c.drawGrid()
+	if len(samples) == 0 || !to.After(from) {
+		return img
+	}
+
+	weekly := c.series(samples, func(s history.Sample) float64 { return s.WeeklyUtilization })
+	fiveHour := c.series(samples, func(s history.Sample) float64 { return s.FiveHourUtilization })
+	c.fillArea(weekly, weeklyFillColor)
+	c.drawLine(fiveHour, fiveHourColor)
+	c.drawLine(weekly, weeklyColor)
+	return img
+}
+
+// WritePNG draws samples as a chart and writes it to w as a PNG image.
+func WritePNG(w io.Writer, samples []history.Sample, opts Options) error {
+	return png.Encode(w, Draw(samples, opts))
+}
+
+// canvas maps a period and utilizations onto the plot area of img.
+type canvas struct {
+	img      *image.RGBA
+	plot     image.Rectangle
+	from, to time.Time
+}
+
+// drawGrid draws a line every 25% and, for periods of up to 60 days, one
+// every local midnight.
+func (c canvas) drawGrid() {
+	for _, v := range []float64{0, 0.25, 0.5, 0.75, 1} {
+		y := c.y(v)
+		for x := c.plot.Min.X; x < c.plot.Max.X; x++ {
+			c.img.SetRGBA(x, y, gridColor)
+		}
+	}
+	if !c.to.After(c.from) || c.to.Sub(c.from) > 60*24*time.Hour {
+		return
+	}
+	y, m, d := c.from.Date()
+	for day := time.Date(y, m, d+1, 0, 0, 0, 0, c.from.Location()); day.Before(c.to); day = day.AddDate(0, 0, 1) {
+		x := c.x(day)
+		for y := c.plot.Min.Y; y < c.plot.Max.Y; y++ {
+			c.img.SetRGBA(x, y, gridColor)
+		}
+	}
+}
+
+// x returns the column of t.
+func (c canvas) x(t time.Time) int {
+	span := c.to.Sub(c.from)
+	if span <= 0 {
+		return c.plot.Min.X
+	}
+	return c.plot.Min.X + int(float64(c.plot.Dx()-1)*float64(t.Sub(c.from))/float64(span))
+}
+
+// y returns the row of utilization v, clamped to the plot area.
+func (c canvas) y(v float64) int {
+	v = min(1, max(0, v))
+	return c.plot.Max.Y - 1 - int(float64(c.plot.Dy()-1)*v+0.5)
+}
+
+// series returns the row of each column of the plot area, interpolating
+// between the samples around the column's time, or -1 where there are
+// none within maxGap.
+func (c canvas) series(samples []history.Sample, value func(history.Sample) float64) []int {
+	rows := make([]int, c.plot.Dx())
+	span := c.to.Sub(c.from)
+	for col := range rows {
+		rows[col] = -1
+		t := c.from.Add(time.Duration(float64(span) * float64(col) / float64(len(rows)-1)))
+		i := sort.Search(len(samples), func(i int) bool { return !samples[i].At.Before(t) })
+		switch {
+		case i < len(samples) && samples[i].At.Equal(t):
+			rows[col] = c.y(value(samples[i]))
+		case i == 0 || i == len(samples):
+		default:
+			a, b := samples[i-1], samples[i]
+			gap := b.At.Sub(a.At)
+			if gap > maxGap {
+				continue
+			}
+			f := float64(t.Sub(a.At)) / float64(gap)
+			rows[col] = c.y(value(a) + (value(b)-value(a))*f)
+		}
+	}
+	return rows
+}
+
+// fillArea fills each column from the bottom of the plot area up to its
+// row in rows.
+func (c canvas) fillArea(rows []int, fill color.RGBA) {
+	src := image.NewUniform(fill)
+	for col, row := range rows {
+		if row < 0 {
+			continue
+		}
+		x := c.plot.Min.X + col
+		draw.Draw(c.img, image.Rect(x, row, x+1, c.plot.Max.Y), src, image.Point{}, draw.Over)
+	}
+}
+
+// drawLine draws rows as a line two pixels thick, joining neighbouring
+// columns so steep changes stay connected.
+func (c canvas) drawLine(rows []int, col color.RGBA) {
+	prev := -1
+	for i, row := range rows {
+		if row < 0 {
+			prev = -1
+			continue
+		}
+		top, bottom := row, row
+		if prev >= 0 {
+			top, bottom = min(top, prev), max(bottom, prev)
+		}
+		x := c.plot.Min.X + i
+		for y := top - 1; y <= bottom; y++ {
+			if y >= c.plot.Min.Y {
+				c.img.SetRGBA(x, y, col)
+			}
+		}
+		prev = row
+	}
+}
@@ -0,0 +1,83 @@
+package chart
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+
+	"claude-usage/internal/history"
+)
+
+var start = time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+
+// samplesEvery returns n samples step apart with the given weekly and
+// 5-hour utilizations.
+func samplesEvery(n int, step time.Duration, weekly, fiveHour float64) []history.Sample {
+	samples := make([]history.Sample, n)
+	for i := range samples {
+		samples[i] = history.Sample{At: start.Add(time.Duration(i) * step), WeeklyUtilization: weekly, FiveHourUtilization: fiveHour}
+	}
+	return samples
+}
+
+func TestDraw_Size(t *testing.T) {
+	if b := Draw(nil, Options{}).Bounds(); b.Dx() != DefaultWidth || b.Dy() != DefaultHeight {
+		t.Errorf("default size = %v", b)
+	}
+	if b := Draw(nil, Options{Width: 100, Height: 50}).Bounds(); b.Dx() != 100 || b.Dy() != 50 {
+		t.Errorf("size = %v, want 100x50", b)
+	}
+	// Too small for a plot area
+	Draw(samplesEvery(3, time.Hour, 0.5, 0.5), Options{Width: 10, Height: 10})
+}
+
+func TestDraw_Series(t *testing.T) {
+	samples := samplesEvery(13, 10*time.Minute, 0.5, 1)
+	c := canvas{plot: Draw(nil, Options{}).Bounds().Inset(margin)}
+	img := Draw(samples, Options{})
+
+	x := DefaultWidth / 2
+	if got := img.RGBAAt(x, c.y(0.5)); got != weeklyColor {
+		t.Errorf("weekly line at 50%%: got %v, want %v", got, weeklyColor)
+	}
+	if got := img.RGBAAt(x, c.y(1)); got != fiveHourColor {
+		t.Errorf("5-hour line at 100%%: got %v, want %v", got, fiveHourColor)
+	}
+	if got := img.RGBAAt(x, c.y(0.3)); got == backgroundColor || got == gridColor {
+		t.Errorf("weekly area not filled below the line: got %v", got)
+	}
+	if got := img.RGBAAt(x, c.y(0.6)); got != backgroundColor {
+		t.Errorf("above the weekly line: got %v, want background", got)
+	}
+}
+
+func TestDraw_Gap(t *testing.T) {
+	samples := append(samplesEvery(2, time.Hour, 0.5, 0),
+		history.Sample{At: start.Add(10 * time.Hour), WeeklyUtilization: 0.5},
+		history.Sample{At: start.Add(11 * time.Hour), WeeklyUtilization: 0.5})
+	c := canvas{plot: Draw(nil, Options{}).Bounds().Inset(margin)}
+	img := Draw(samples, Options{})
+
+	// Halfway through the period falls in the gap between hours 1 and 10
+	if got := img.RGBAAt(DefaultWidth/2, c.y(0.4)); got != backgroundColor {
+		t.Errorf("gap filled: got %v, want background", got)
+	}
+	if got := img.RGBAAt(margin+1, c.y(0.5)); got != weeklyColor {
+		t.Errorf("before the gap: got %v, want %v", got, weeklyColor)
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, samplesEvery(10, time.Hour, 0.2, 0.4), Options{Width: 200, Height: 80}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("not a PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 200 || b.Dy() != 80 {
+		t.Errorf("size = %v, want 200x80", b)
+	}
+}
@@ -0,0 +1,59 @@
+// Package clipboard provides a minimal cross-platform clipboard writer.
+// It shells out to the platform's clipboard tool instead of linking native APIs.
+package clipboard
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// WriteText copies text to the system clipboard.
+// - Linux: wl-copy (Wayland), xclip or xsel (X11)
+// - macOS: pbcopy
+// - Windows: PowerShell Set-Clipboard
+func WriteText(text string) error {
+	cmd, err := clipboardCommand()
+	if err != nil {
+		return err
+	}
+
+	cmd.Stdin = strings.NewReader(text)
+	hideWindow(cmd)
+
+	// Use Run rather than Output: xclip forks a child that keeps serving the
+	// selection, and capturing its stdout would block until it exits.
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", cmd.Path, err)
+	}
+	return nil
+}
+
+// clipboardCommand returns the command that reads stdin into the clipboard.
+func clipboardCommand() (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("pbcopy"), nil
+
+	case "windows":
+		// Read stdin as UTF-8 so bar glyphs and non-ASCII text survive
+		script := "[Console]::InputEncoding = [Text.Encoding]::UTF8; Set-Clipboard -Value ([Console]::In.ReadToEnd())"
+		return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script), nil
+
+	default: // Linux and other Unix-like systems
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			if path, err := exec.LookPath("wl-copy"); err == nil {
+				return exec.Command(path), nil
+			}
+		}
+		if path, err := exec.LookPath("xclip"); err == nil {
+			return exec.Command(path, "-selection", "clipboard"), nil
+		}
+		if path, err := exec.LookPath("xsel"); err == nil {
+			return exec.Command(path, "--clipboard", "--input"), nil
+		}
+		return nil, fmt.Errorf("no clipboard tool found (install wl-clipboard, xclip or xsel)")
+	}
+}
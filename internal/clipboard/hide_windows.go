@@ -0,0 +1,12 @@
+package clipboard
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// hideWindow prevents a console window from flashing when the GUI build
+// spawns a helper process.
+func hideWindow(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+}
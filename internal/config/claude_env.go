@@ -75,6 +75,18 @@ func GetClaudeUsageEndpoint() string {
 	return getConfigOrDefault("CLAUDE_USAGE_ENDPOINT", "https://api.anthropic.com/api/oauth/usage")
 }
 
+// GetClaudeMessagesEndpoint returns the Messages API endpoint, used to
+// read rate limit headers.
+func GetClaudeMessagesEndpoint() string {
+	return getConfigOrDefault("CLAUDE_MESSAGES_ENDPOINT", "https://api.anthropic.com/v1/messages")
+}
+
+// GetClaudeProbeModel returns the model of the one-token request that
+// reads rate limit headers: the cheapest one.
+func GetClaudeProbeModel() string {
+	return getConfigOrDefault("CLAUDE_PROBE_MODEL", "claude-haiku-4-5")
+}
+
 // GetClaudeAnthropicBeta returns the required anthropic-beta header value.
 func GetClaudeAnthropicBeta() string {
 	return getConfigOrDefault("CLAUDE_ANTHROPIC_BETA", "oauth-2025-04-20")
@@ -95,3 +107,20 @@ func GetClaudeUserAgent() string {
 func GetUpdateURL() string {
 	return getConfigOrDefault("CLAUDE_UPDATE_URL", "")
 }
+
+// GetReleasesAPIURL returns the GitHub API URL listing releases.
+// Append "/latest" for the latest stable release.
+func GetReleasesAPIURL() string {
+	return getConfigOrDefault("CLAUDE_RELEASES_API_URL", "https://api.github.com/repos/utajum/claude-usage/releases")
+}
+
+// GetPlanLimitsURL returns the URL of the published plan limits table.
+func GetPlanLimitsURL() string {
+	return getConfigOrDefault("CLAUDE_PLAN_LIMITS_URL", "https://raw.githubusercontent.com/utajum/claude-usage/main/plan-limits.json")
+}
+
+// GetReleaseDownloadURL returns the base URL for files of a specific release.
+// The full URL is constructed by appending the tag and file name.
+func GetReleaseDownloadURL() string {
+	return getConfigOrDefault("CLAUDE_RELEASE_DOWNLOAD_URL", "https://github.com/utajum/claude-usage/releases/download")
+}
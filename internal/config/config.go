@@ -1,21 +1,177 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
+
+	// Embedded so week_timezone works without a system zoneinfo database,
+	// as on Windows
+	_ "time/tzdata"
 )
 
 // DefaultWeeklyBudget is the default weekly token budget (5 million tokens).
 const DefaultWeeklyBudget int64 = 5_000_000
 
+// DefaultUpdateCheckHours is how often to check for a new release by default.
+const DefaultUpdateCheckHours = 24
+
+// DefaultStaleAfterMinutes is how old API data may get by default before
+// the icon marks it stale.
+const DefaultStaleAfterMinutes = 30
+
+// DefaultStartupGraceSeconds is how long after starting the first refresh
+// is retried and a missing tray icon host waited for by default.
+const DefaultStartupGraceSeconds = 60
+
+// DefaultIdleMinutes is how long without keyboard or mouse input counts as
+// away by default.
+const DefaultIdleMinutes = 15
+
+// DefaultAlertThresholds are the usage percentages that trigger integration
+// events when alert_thresholds is not set.
+var DefaultAlertThresholds = []int{50, 80, 90}
+
+// DefaultSpendAlerts are the percentages of the monthly extra usage credit
+// limit that trigger alerts when spend_alerts is not set.
+var DefaultSpendAlerts = []int{50, 80, 100}
+
+// Integration event kinds, as used in message templates and *_events lists.
+const (
+	EventThreshold   = "threshold"
+	EventThrottled   = "throttled"
+	EventUnthrottled = "unthrottled"
+	EventReset       = "reset"
+	EventSpend       = "spend"
+)
+
+// DefaultEmailMinThreshold is the lowest alert threshold that is emailed
+// when email_min_threshold is not set.
+const DefaultEmailMinThreshold = 90
+
+// EventKinds lists every integration event kind.
+var EventKinds = []string{EventThreshold, EventThrottled, EventUnthrottled, EventReset, EventSpend}
+
+// Models with their own weekly windows, as keys of model_alert_thresholds.
+const (
+	ModelOpus   = "opus"
+	ModelSonnet = "sonnet"
+)
+
+// DefaultMQTTTopic is the base MQTT topic when mqtt_topic is not set.
+const DefaultMQTTTopic = "claude-usage"
+
+// DefaultControlAddress is the control API's address when control_address
+// is not set: loopback only.
+const DefaultControlAddress = "127.0.0.1"
+
+// Reset time formats.
+const (
+	ResetRelative = "relative" // Countdowns such as "2h 10m"
+	ResetAbsolute = "absolute" // Local clock times such as "Fri 14:00"
+)
+
+// Budget modes.
+const (
+	BudgetOff    = "off"    // The icon and alerts follow the plan's limits
+	BudgetTokens = "tokens" // They follow weekly_budget_tokens
+	BudgetCost   = "cost"   // They follow weekly_budget_usd at API prices
+)
+
+// Icon metrics, the number shown on the tray icon.
+const (
+	IconWeekly   = "weekly"    // The weekly window, or the personal budget in budget mode
+	IconFiveHour = "five_hour" // The 5-hour window
+	IconOpus     = "opus"      // The Opus weekly window
+	IconSonnet   = "sonnet"    // The Sonnet weekly window
+	IconCost     = "cost"      // The week's cost at API prices, in whole dollars
+)
+
+// IconMetrics lists every icon metric, in menu order.
+var IconMetrics = []string{IconWeekly, IconFiveHour, IconOpus, IconSonnet, IconCost}
+
+// Progress bar styles.
+const (
+	BarAuto    = "auto"    // Unicode unless the tooltip font lacks the characters
+	BarUnicode = "unicode" // Block characters such as "▕███░░░▏"
+	BarASCII   = "ascii"   // Plain characters such as "[###---]"
+)
+
+// Tooltip layouts.
+const (
+	TooltipAuto    = "auto"    // Picked for the platform and desktop environment
+	TooltipFull    = "full"    // Every window, pace, cost and week-over-week change
+	TooltipMedium  = "medium"  // The windows with shorter bars; pace and cost on one line
+	TooltipCompact = "compact" // Only the 5-hour and weekly windows
+)
+
+// Anonymous statistics choices. Empty means not asked yet, and nothing is
+// sent.
+const (
+	TelemetryOn  = "on"  // Agreed to send reports
+	TelemetryOff = "off" // Declined, or turned off
+)
+
+// Battery saver modes.
+const (
+	PowerSaverAuto = "auto" // On while running on battery
+	PowerSaverOn   = "on"   // Always on
+	PowerSaverOff  = "off"  // Never on
+)
+
+// Rate limit header checks, which cross-check the usage endpoint against
+// the anthropic-ratelimit-unified-* headers of regular API responses.
+// Probe sends a one-token Messages API request for them; file reads those
+// a local proxy saved to RateLimitHeadersFile.
+const (
+	RateLimitHeadersOff   = "off"
+	RateLimitHeadersProbe = "probe"
+	RateLimitHeadersFile  = "file"
+)
+
+// Update channels. Beta also offers GitHub pre-releases.
+const (
+	ChannelStable = "stable"
+	ChannelBeta   = "beta"
+)
+
+// Usage providers. OAuth queries the Claude usage endpoint with the
+// credentials file's tokens; file reads rate limits from UsageFilePath;
+// remote reads them from an agent's control API at RemoteURL (see
+// "claude-usage agent"); demo generates fake data (see --demo).
+const (
+	ProviderOAuth  = "oauth"
+	ProviderFile   = "file"
+	ProviderRemote = "remote"
+	ProviderDemo   = "demo"
+)
+
+// Token store key sources (see TokenStoreKey).
+const (
+	TokenKeyFile       = "file"
+	TokenKeyKeyring    = "keyring"
+	TokenKeyPassphrase = "passphrase"
+)
+
 // Source constants for credential sources.
 const (
 	SourceClaude   = "claude"
 	SourceOpenCode = "opencode"
 )
 
+// ModelPrice is a model's API price in USD per million tokens.
+type ModelPrice struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cache_read"`
+	CacheWrite float64 `json:"cache_write"`
+}
+
 // Config holds the application configuration.
 type Config struct {
 	// RefreshInterval is how often to refresh stats.
@@ -24,11 +180,28 @@ type Config struct {
 	// RefreshIntervalSeconds is the JSON-serializable version.
 	RefreshIntervalSeconds int `json:"refresh_interval_seconds"`
 
-	// WeeklyBudgetTokens is the user's weekly token budget for percentage calculation.
-	// The icon will show percentage = (used / budget) * 100.
-	// Default is 5 million tokens.
+	// WeeklyBudgetTokens is the user's weekly token budget, used when
+	// BudgetMode is BudgetTokens. Default is 5 million tokens.
 	WeeklyBudgetTokens int64 `json:"weekly_budget_tokens"`
 
+	// WeeklyBudgetUSD is the user's weekly budget in dollars at API prices,
+	// used when BudgetMode is BudgetCost.
+	WeeklyBudgetUSD float64 `json:"weekly_budget_usd,omitempty"`
+
+	// BudgetMode makes the icon and threshold alerts follow a personal
+	// weekly budget instead of the plan's limits: BudgetOff (the default),
+	// BudgetTokens or BudgetCost. The tooltip shows both.
+	BudgetMode string `json:"budget_mode,omitempty"`
+
+	// PlanLimits override the estimated weekly token limits, keyed by rate
+	// limit tier (e.g. "default_claude_max_5x") or subscription type (e.g.
+	// "pro"). They only matter when usage is estimated from token counts.
+	PlanLimits map[string]int64 `json:"plan_limits,omitempty"`
+
+	// FetchPlanLimits downloads an updated table of estimated plan limits
+	// once a day. When false, the table bundled with the release is used.
+	FetchPlanLimits bool `json:"fetch_plan_limits"`
+
 	// ClaudeStatsPath is the path to Claude's stats-cache.json.
 	// If empty, uses the default path.
 	ClaudeStatsPath string `json:"claude_stats_path,omitempty"`
@@ -37,10 +210,323 @@ type Config struct {
 	// If empty, uses the default path.
 	ClaudeCredentialsPath string `json:"claude_credentials_path,omitempty"`
 
+	// ReadOnlyCredentials never writes to the credentials file: tokens
+	// rotated by the server are kept in the app's encrypted token store
+	// instead (see GetTokenStorePath).
+	ReadOnlyCredentials bool `json:"read_only_credentials,omitempty"`
+
+	// TokenStoreKey is where the token store's key comes from: "file"
+	// (default), "keyring" for the OS keyring or "passphrase" to derive it
+	// from CLAUDE_USAGE_TOKEN_PASSPHRASE.
+	TokenStoreKey string `json:"token_store_key,omitempty"`
+
 	// Source is the credential source: "claude" or "opencode".
 	// OpenCode is only supported on Linux.
 	// If empty, auto-detects based on available credential files.
 	Source string `json:"source,omitempty"`
+
+	// UsageProvider selects where rate limits come from: "oauth" (default),
+	// "file", "remote" or "demo".
+	UsageProvider string `json:"usage_provider,omitempty"`
+
+	// Demo shows generated usage data, as the "demo" usage provider does,
+	// for this run only. It is set by --demo and never saved.
+	Demo bool `json:"-"`
+
+	// UsageFilePath is the JSON file read by the "file" usage provider.
+	UsageFilePath string `json:"usage_file_path,omitempty"`
+
+	// RemoteURL is the control API of the agent read by the "remote" usage
+	// provider, e.g. "http://build-box:8765", and RemoteToken its token.
+	RemoteURL   string `json:"remote_url,omitempty"`
+	RemoteToken string `json:"remote_token,omitempty"`
+
+	// TeamUsageURL is an https:// organization usage endpoint answering in
+	// the format of the personal one, for team and enterprise plans. It is
+	// sent the OAuth access token. Empty disables team usage.
+	TeamUsageURL string `json:"team_usage_url,omitempty"`
+
+	// ShowTeamUsage shows team-wide usage next to personal usage; toggled
+	// from the Settings menu.
+	ShowTeamUsage bool `json:"show_team_usage,omitempty"`
+
+	// RateLimitHeaders cross-checks the usage endpoint against rate limit
+	// response headers: RateLimitHeadersOff (default), RateLimitHeadersProbe
+	// or RateLimitHeadersFile. The tooltip shows both when they disagree.
+	RateLimitHeaders string `json:"rate_limit_headers,omitempty"`
+
+	// RateLimitHeadersFile holds the headers of an API response, one
+	// "Name: value" per line as curl -D writes them, for the "file" check.
+	RateLimitHeadersFile string `json:"rate_limit_headers_file,omitempty"`
+
+	// Telemetry is whether anonymous statistics (counts of successful and
+	// failed refreshes, the version and platform) are sent once a day:
+	// TelemetryOn or TelemetryOff. Empty means the user has not been asked
+	// yet. DO_NOT_TRACK turns it off regardless; see TelemetryEnabled.
+	Telemetry string `json:"telemetry,omitempty"`
+
+	// TelemetryURL replaces the endpoint anonymous statistics are sent to.
+	TelemetryURL string `json:"telemetry_url,omitempty"`
+
+	// ProxyURL is an explicit HTTP(S) proxy for all network calls, e.g.
+	// "http://proxy.corp:3128". If empty, HTTP_PROXY/HTTPS_PROXY/NO_PROXY
+	// from the environment are used.
+	ProxyURL string `json:"proxy_url,omitempty"`
+
+	// CABundlePath is a PEM file of extra CA certificates to trust, for
+	// proxies that intercept TLS.
+	CABundlePath string `json:"ca_bundle_path,omitempty"`
+
+	// UpdateCheckHours is how often to check GitHub for a new release.
+	// Zero disables background checks.
+	UpdateCheckHours int `json:"update_check_hours"`
+
+	// UpdateChannel is "stable" (default) or "beta", which includes
+	// pre-releases.
+	UpdateChannel string `json:"update_channel,omitempty"`
+
+	// AutoUpdate installs new releases found by background checks without
+	// asking. When false, a notification is shown instead.
+	AutoUpdate bool `json:"auto_update"`
+
+	// AutoRestart restarts into the new version right after an update is
+	// installed. When false, the Update menu item offers "Restart Now".
+	AutoRestart bool `json:"auto_restart"`
+
+	// StaleAfterMinutes is how old the last successful fetch may get before
+	// the icon shows a stale dot and the tooltip its age. Zero means
+	// DefaultStaleAfterMinutes; see StaleAfter.
+	StaleAfterMinutes int `json:"stale_after_minutes,omitempty"`
+
+	// StartupGraceSeconds is how long after starting a failing first
+	// refresh is retried every few seconds and a missing tray icon host
+	// waited for, since at login the network and the desktop's panel may
+	// come up after the app. Zero means DefaultStartupGraceSeconds; see
+	// StartupGrace.
+	StartupGraceSeconds int `json:"startup_grace_seconds,omitempty"`
+
+	// PauseWhenAway skips automatic refreshes while the screen is locked
+	// or the user is idle, and refreshes as soon as they are back.
+	PauseWhenAway bool `json:"pause_when_away"`
+
+	// IdleMinutes is how long without keyboard or mouse input counts as
+	// away. Zero means DefaultIdleMinutes; see IdleAfter.
+	IdleMinutes int `json:"idle_minutes,omitempty"`
+
+	// PowerSaver is when battery saver is on: PowerSaverAuto (the default,
+	// on battery), PowerSaverOn or PowerSaverOff. It refreshes less often,
+	// stops the icon flashing and skips scanning transcripts.
+	PowerSaver string `json:"power_saver,omitempty"`
+
+	// ShowDailyUsage adds a "Today: 2.1M, Yesterday: 5.4M" line to the
+	// tooltip. Not shown with the compact layout, as on Windows, where
+	// tooltips are limited to 127 characters.
+	ShowDailyUsage bool `json:"show_daily_usage,omitempty"`
+
+	// ResetTimeFormat is how reset times are shown: ResetRelative (the
+	// default) or ResetAbsolute.
+	ResetTimeFormat string `json:"reset_time_format,omitempty"`
+
+	// TooltipLayout is how much the tooltip shows: TooltipAuto (the
+	// default; compact on Windows and GNOME, medium on KDE Plasma),
+	// TooltipFull, TooltipMedium or TooltipCompact. TooltipTemplate
+	// overrides it.
+	TooltipLayout string `json:"tooltip_layout,omitempty"`
+
+	// BarStyle is how progress bars are drawn: BarAuto (the default),
+	// BarUnicode or BarASCII.
+	BarStyle string `json:"bar_style,omitempty"`
+
+	// AccessibilityMode writes the tooltip, menu labels and notifications
+	// as plain sentences for screen readers, e.g. "Weekly usage 42 percent,
+	// resets in 3 days", without bars or symbols.
+	AccessibilityMode bool `json:"accessibility_mode,omitempty"`
+
+	// Locale is how token counts and percentages are written, e.g. "de-DE"
+	// for "2,1 M" and "42 %". Empty uses the system's locale.
+	Locale string `json:"locale,omitempty"`
+
+	// ModelNames are display names for model IDs, overriding the names
+	// derived from the IDs. Keys are model IDs or patterns such as
+	// "claude-opus-5*".
+	ModelNames map[string]string `json:"model_names,omitempty"`
+
+	// WeekStartDay is the day the estimated week starts on when the API's
+	// rolling 7-day window is unknown, e.g. "sunday". Empty means Monday.
+	WeekStartDay string `json:"week_start_day,omitempty"`
+
+	// WeekTimezone is the timezone calendar days and weeks are counted in:
+	// "UTC", "Local" or an IANA name such as "Europe/Berlin". Empty means UTC.
+	WeekTimezone string `json:"week_timezone,omitempty"`
+
+	// NotifyRefreshErrors shows a notification, with a "Retry now" button
+	// where supported, when refreshes start failing.
+	NotifyRefreshErrors bool `json:"notify_refresh_errors,omitempty"`
+
+	// NotifyWeekStart shows a notification with last week's usage when the
+	// weekly window resets.
+	NotifyWeekStart bool `json:"notify_week_start,omitempty"`
+
+	// PaceAlertMargin shows a notification once per weekly window when
+	// weekly usage gets this many percentage points ahead of the share of
+	// the week elapsed (e.g. 20: 60% used with 40% of the week gone).
+	// Zero disables it.
+	PaceAlertMargin int `json:"pace_alert_margin,omitempty"`
+
+	// AlertThresholds are the usage percentages of the 5-hour and weekly
+	// windows that trigger integration events. Empty means
+	// DefaultAlertThresholds.
+	AlertThresholds []int `json:"alert_thresholds,omitempty"`
+
+	// ModelAlertThresholds are usage percentages of the per-model weekly
+	// windows that trigger integration events, keyed by ModelOpus or
+	// ModelSonnet. Models without thresholds are not alerted on.
+	ModelAlertThresholds map[string][]int `json:"model_alert_thresholds,omitempty"`
+
+	// SpendAlerts are percentages of the monthly extra usage credit limit
+	// that trigger a notification and a spend event when crossed. Empty
+	// means DefaultSpendAlerts. Only used with extra usage and a limit.
+	SpendAlerts []int `json:"spend_alerts,omitempty"`
+
+	// SlackWebhookURL is a Slack incoming webhook that receives threshold
+	// and throttling events. Empty disables Slack.
+	SlackWebhookURL string `json:"slack_webhook_url,omitempty"`
+
+	// SlackTemplates overrides the Slack message per event kind (see
+	// EventKinds), as Go text/template strings.
+	SlackTemplates map[string]string `json:"slack_templates,omitempty"`
+
+	// SlackEvents limits the event kinds sent to Slack. Empty means all but
+	// resets.
+	SlackEvents []string `json:"slack_events,omitempty"`
+
+	// DiscordWebhookURL, DiscordTemplates and DiscordEvents configure a
+	// Discord webhook like the Slack settings.
+	DiscordWebhookURL string            `json:"discord_webhook_url,omitempty"`
+	DiscordTemplates  map[string]string `json:"discord_templates,omitempty"`
+	DiscordEvents     []string          `json:"discord_events,omitempty"`
+
+	// WebhookURL receives events as a JSON POST. The body is the event as
+	// JSON unless WebhookTemplate (a Go text/template) is set.
+	WebhookURL      string            `json:"webhook_url,omitempty"`
+	WebhookHeaders  map[string]string `json:"webhook_headers,omitempty"`
+	WebhookTemplate string            `json:"webhook_template,omitempty"`
+
+	// WebhookEvents limits the event kinds sent to WebhookURL. Empty means
+	// all.
+	WebhookEvents []string `json:"webhook_events,omitempty"`
+
+	// AttachChart adds a PNG chart of the last week's usage to webhook and
+	// Discord notifications: base64-encoded as chart_png in the default
+	// webhook body, and as an image attachment on Discord.
+	AttachChart bool `json:"attach_chart,omitempty"`
+
+	// SMTPServer is the URL of a mail server to email events through, e.g.
+	// "smtp://mail.example.com:587" or "smtps://mail.example.com". Empty
+	// disables email.
+	SMTPServer   string `json:"smtp_server,omitempty"`
+	SMTPUsername string `json:"smtp_username,omitempty"`
+	SMTPPassword string `json:"smtp_password,omitempty"`
+
+	// EmailFrom is the sender address; EmailTo are the recipients.
+	EmailFrom string   `json:"email_from,omitempty"`
+	EmailTo   []string `json:"email_to,omitempty"`
+
+	// EmailEvents limits the event kinds emailed. Empty means thresholds
+	// and throttling.
+	EmailEvents []string `json:"email_events,omitempty"`
+
+	// EmailMinThreshold is the lowest alert threshold whose crossing is
+	// emailed. Zero means DefaultEmailMinThreshold.
+	EmailMinThreshold int `json:"email_min_threshold,omitempty"`
+
+	// OnThreshold, OnThrottled and OnReset are shell commands run when an
+	// alert threshold is crossed, throttling starts or a window resets.
+	// The event is passed as JSON on stdin and in CLAUDE_USAGE_*
+	// environment variables.
+	OnThreshold string `json:"on_threshold,omitempty"`
+	OnThrottled string `json:"on_throttled,omitempty"`
+	OnReset     string `json:"on_reset,omitempty"`
+
+	// MQTTBroker is the URL of an MQTT broker to publish usage to, e.g.
+	// "tcp://homeassistant.local:1883" or "ssl://broker:8883". Empty
+	// disables MQTT.
+	MQTTBroker   string `json:"mqtt_broker,omitempty"`
+	MQTTUsername string `json:"mqtt_username,omitempty"`
+	MQTTPassword string `json:"mqtt_password,omitempty"`
+
+	// MQTTTopic is the base topic for usage state. Empty means
+	// DefaultMQTTTopic, with the profile name appended when one is active.
+	MQTTTopic string `json:"mqtt_topic,omitempty"`
+
+	// MQTTHomeAssistant publishes Home Assistant discovery messages so the
+	// sensors appear automatically.
+	MQTTHomeAssistant bool `json:"mqtt_home_assistant,omitempty"`
+
+	// TooltipTemplate replaces the tooltip layout with a Go text/template
+	// executed with tray.TooltipData. Empty means the built-in layout.
+	TooltipTemplate string `json:"tooltip_template,omitempty"`
+
+	// TaskbarBadge also shows usage on a taskbar button, as an overlay
+	// badge and in its label, for users who hide the tray (Windows only).
+	TaskbarBadge bool `json:"taskbar_badge,omitempty"`
+
+	// Hotkey is a global keyboard shortcut such as "Ctrl+Alt+U" that shows
+	// a usage summary as a notification, for users who hide the tray
+	// (Windows, and Linux desktops with the GlobalShortcuts portal).
+	// Empty means none.
+	Hotkey string `json:"hotkey,omitempty"`
+
+	// FlashIcon flashes the tray icon while the limiting window is nearly
+	// exhausted.
+	FlashIcon bool `json:"flash_icon,omitempty"`
+
+	// IconMetric is what the icon's number shows: IconWeekly (the
+	// default), IconFiveHour, IconOpus, IconSonnet or IconCost. The icon's
+	// color always follows the limiting window.
+	IconMetric string `json:"icon_metric,omitempty"`
+
+	// IconSparkline draws the last 24 hours of the limiting window's usage
+	// as a sparkline in place of the icon's bottom pins.
+	IconSparkline bool `json:"icon_sparkline,omitempty"`
+
+	// StatusFilePath is written with a small JSON status after every
+	// refresh, for tools such as conky or Rainmeter. Empty disables it.
+	StatusFilePath string `json:"status_file_path,omitempty"`
+
+	// ControlPort serves the local control API (GET /status, POST /refresh,
+	// POST /pause, POST /resume) on ControlAddress at this port. Zero
+	// disables it. Requests need the token stored next to the config file.
+	ControlPort int `json:"control_port,omitempty"`
+
+	// ControlAddress is the IP address the control API listens on. Empty
+	// means 127.0.0.1; "0.0.0.0" exposes it to the network, for viewers
+	// using the "remote" usage provider.
+	ControlAddress string `json:"control_address,omitempty"`
+
+	// Pricing overrides or extends the built-in per-model prices used for
+	// cost estimates. Keys match any model ID containing them, e.g.
+	// "sonnet-4".
+	Pricing map[string]ModelPrice `json:"pricing,omitempty"`
+
+	// Debug logs HTTP exchanges (status, latency, rate-limit headers) and
+	// retry decisions. Tokens are never logged.
+	Debug bool `json:"debug,omitempty"`
+
+	// Profiles holds named sets of settings that override the top-level
+	// values when selected with --profile or CLAUDE_USAGE_PROFILE.
+	Profiles map[string]json.RawMessage `json:"profiles,omitempty"`
+
+	// Profile is the active profile name (empty for top-level settings).
+	Profile string `json:"-"`
+
+	// problems holds validation errors found by Load.
+	problems []FieldError
+
+	// base holds the settings config.json set, before environment and
+	// command-line overrides, and loaded the settings Load returned, so
+	// Save can tell what changed since. Both are nil unless from Load.
+	base, loaded map[string]json.RawMessage
 }
 
 // Default returns a Config with sensible defaults.
@@ -52,6 +538,9 @@ func Default() *Config {
 		ClaudeStatsPath:        "",
 		ClaudeCredentialsPath:  "",
 		Source:                 detectDefaultSource(),
+		UpdateCheckHours:       DefaultUpdateCheckHours,
+		FetchPlanLimits:        true,
+		PauseWhenAway:          true,
 	}
 }
 
@@ -83,25 +572,35 @@ func fileExists(path string) bool {
 }
 
 // Load reads configuration from the config file.
-// If the file doesn't exist, returns defaults.
+// If the file doesn't exist, defaults are used.
+// Environment variables (see EnvRefreshInterval etc.) override file values,
+// and overrides registered with SetOverrides take precedence over both.
 func Load() (*Config, error) {
 	cfg := Default()
 
 	configPath := GetConfigPath()
 	data, err := os.ReadFile(configPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return cfg, nil
-		}
+	if err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
+	if err == nil {
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, err
+		}
+	}
 
-	if err := json.Unmarshal(data, cfg); err != nil {
+	// Overlay the selected profile, if any
+	if profile := ActiveProfile(); profile != "" {
+		cfg.Profile = profile
+		cfg.problems = cfg.applyProfile(profile)
+	}
+	if cfg.base, err = cfg.settings(); err != nil {
 		return nil, err
 	}
 
-	// Convert seconds to duration
-	cfg.RefreshInterval = time.Duration(cfg.RefreshIntervalSeconds) * time.Second
+	// Layer environment and command-line overrides over file values
+	cfg.problems = append(cfg.problems, cfg.applyEnvOverrides()...)
+	cfg.applyOverrides()
 
 	// Expand paths
 	if cfg.ClaudeStatsPath != "" {
@@ -110,6 +609,21 @@ func Load() (*Config, error) {
 	if cfg.ClaudeCredentialsPath != "" {
 		cfg.ClaudeCredentialsPath = ExpandPath(cfg.ClaudeCredentialsPath)
 	}
+	if cfg.CABundlePath != "" {
+		cfg.CABundlePath = ExpandPath(cfg.CABundlePath)
+	}
+	if cfg.UsageFilePath != "" {
+		cfg.UsageFilePath = ExpandPath(cfg.UsageFilePath)
+	}
+	if cfg.StatusFilePath != "" {
+		cfg.StatusFilePath = ExpandPath(cfg.StatusFilePath)
+	}
+	if cfg.RateLimitHeadersFile != "" {
+		cfg.RateLimitHeadersFile = ExpandPath(cfg.RateLimitHeadersFile)
+	}
+
+	// Validate and normalize values (also converts seconds to duration)
+	cfg.problems = append(cfg.problems, cfg.Validate()...)
 
 	// If source is empty (old config file), auto-detect
 	if cfg.Source == "" {
@@ -121,10 +635,16 @@ func Load() (*Config, error) {
 		cfg.Source = SourceClaude
 	}
 
+	if cfg.loaded, err = cfg.settings(); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
-// Save writes the configuration to the config file.
+// Save writes the settings changed since Load to the config file.
+// Environment variables, overrides and anything else Load worked out
+// itself are per-run and left as the file has them.
+// When a profile is active, only that profile's section is updated.
 func (c *Config) Save() error {
 	if err := EnsureConfigDir(); err != nil {
 		return err
@@ -133,12 +653,92 @@ func (c *Config) Save() error {
 	// Update seconds from duration
 	c.RefreshIntervalSeconds = int(c.RefreshInterval.Seconds())
 
-	data, err := json.MarshalIndent(c, "", "  ")
+	// Settings changed while running a profile are saved to that profile
+	current, err := c.settings()
 	if err != nil {
 		return err
 	}
+	var data []byte
+	if c.Profile != "" {
+		data, err = c.marshalProfile(current)
+	} else {
+		data, err = c.marshalChanges(current)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(GetConfigPath(), data, 0600); err != nil {
+		return err
+	}
+	c.loaded = current
+	return nil
+}
+
+// settings returns the config's values as saved, by JSON key, without the
+// nested profiles.
+func (c *Config) settings() (map[string]json.RawMessage, error) {
+	s := *c
+	s.Profiles = nil
+	data, err := json.Marshal(&s)
+	if err != nil {
+		return nil, err
+	}
+	settings := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
 
-	return os.WriteFile(GetConfigPath(), data, 0644)
+// mergeChanges sets the values in current that differ from those Load
+// returned into dst, and removes the ones that have been cleared since.
+func (c *Config) mergeChanges(dst, current map[string]json.RawMessage) {
+	for key, value := range current {
+		if old, ok := c.loaded[key]; !ok || !bytes.Equal(old, value) {
+			dst[key] = value
+		}
+	}
+	for key := range c.loaded {
+		if _, ok := current[key]; !ok {
+			delete(dst, key)
+		}
+	}
+}
+
+// marshalChanges returns the config file contents with the settings
+// changed since Load written over the existing ones. A new file starts
+// from the settings config.json would have set.
+func (c *Config) marshalChanges(current map[string]json.RawMessage) ([]byte, error) {
+	file, err := readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		file = make(map[string]json.RawMessage)
+		for key, value := range c.base {
+			file[key] = value
+		}
+	}
+	c.mergeChanges(file, current)
+	return json.MarshalIndent(file, "", "  ")
+}
+
+// readConfigFile returns the top-level values in the config file by key,
+// or nil if there is no file.
+func readConfigFile() (map[string]json.RawMessage, error) {
+	data, err := os.ReadFile(GetConfigPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	file := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse existing config: %w", err)
+	}
+	return file, nil
 }
 
 // GetStatsPath returns the effective stats path (config or default).
@@ -149,6 +749,86 @@ func (c *Config) GetStatsPath() string {
 	return GetClaudeStatsPath()
 }
 
+// GetEmailMinThreshold returns the effective lowest emailed threshold
+// (config or default).
+func (c *Config) GetEmailMinThreshold() int {
+	if c.EmailMinThreshold > 0 {
+		return c.EmailMinThreshold
+	}
+	return DefaultEmailMinThreshold
+}
+
+// GetAlertThresholds returns the effective alert thresholds (config or default).
+func (c *Config) GetAlertThresholds() []int {
+	if len(c.AlertThresholds) > 0 {
+		return c.AlertThresholds
+	}
+	return DefaultAlertThresholds
+}
+
+// GetSpendAlerts returns the effective spend alerts (config or default).
+func (c *Config) GetSpendAlerts() []int {
+	if len(c.SpendAlerts) > 0 {
+		return c.SpendAlerts
+	}
+	return DefaultSpendAlerts
+}
+
+// GetMQTTTopic returns the effective base MQTT topic (config or default).
+func (c *Config) GetMQTTTopic() string {
+	if c.MQTTTopic != "" {
+		return c.MQTTTopic
+	}
+	if c.Profile != "" {
+		return DefaultMQTTTopic + "/" + c.Profile
+	}
+	return DefaultMQTTTopic
+}
+
+// Budget returns the personal weekly budget of BudgetMode: a token count
+// or a dollar amount, the other one zero. Both are zero when budget mode
+// is off.
+func (c *Config) Budget() (tokens int64, usd float64) {
+	switch c.BudgetMode {
+	case BudgetTokens:
+		return c.WeeklyBudgetTokens, 0
+	case BudgetCost:
+		return 0, c.WeeklyBudgetUSD
+	}
+	return 0, 0
+}
+
+// WeekStart returns the configured start day and timezone of calendar weeks.
+// Values should already be validated.
+func (c *Config) WeekStart() (time.Weekday, *time.Location) {
+	day, ok := parseWeekday(c.WeekStartDay)
+	if !ok {
+		day = time.Monday
+	}
+	loc, err := time.LoadLocation(c.WeekTimezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return day, loc
+}
+
+// parseWeekday parses an English day name such as "monday", ignoring case.
+// An empty name is not valid.
+func parseWeekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(name, d.String()) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// GetProjectsPath returns the Claude Code projects folder holding session
+// transcripts. It sits next to the stats cache.
+func (c *Config) GetProjectsPath() string {
+	return filepath.Join(filepath.Dir(c.GetStatsPath()), "projects")
+}
+
 // GetCredentialsPath returns the effective credentials path (config or default).
 // If Source is "opencode" and we're on Linux, returns OpenCode path.
 func (c *Config) GetCredentialsPath() string {
@@ -179,6 +859,79 @@ func (c *Config) ToggleSource() {
 	}
 }
 
+// UsesTokenStore reports whether tokens rotated by the server are kept in
+// the encrypted token store: with read_only_credentials, or when the tokens
+// come from the environment and have no file to go back to.
+func (c *Config) UsesTokenStore() bool {
+	return c.ReadOnlyCredentials || UsesEnvCredentials()
+}
+
+// TelemetryEnabled reports whether anonymous statistics may be sent: the
+// user agreed, and DO_NOT_TRACK is not set.
+func (c *Config) TelemetryEnabled() bool {
+	return c.Telemetry == TelemetryOn && !DoNotTrack()
+}
+
+// DoNotTrack reports whether the DO_NOT_TRACK environment variable asks
+// for no telemetry (https://consoledonottrack.com).
+func DoNotTrack() bool {
+	v := os.Getenv("DO_NOT_TRACK")
+	return v != "" && v != "0" && v != "false"
+}
+
+// StartupGrace returns the startup grace period: StartupGraceSeconds, or
+// DefaultStartupGraceSeconds if unset.
+func (c *Config) StartupGrace() time.Duration {
+	if c.StartupGraceSeconds > 0 {
+		return time.Duration(c.StartupGraceSeconds) * time.Second
+	}
+	return DefaultStartupGraceSeconds * time.Second
+}
+
+// IdleAfter returns how long without input counts as away: IdleMinutes,
+// or DefaultIdleMinutes if unset.
+func (c *Config) IdleAfter() time.Duration {
+	if c.IdleMinutes > 0 {
+		return time.Duration(c.IdleMinutes) * time.Minute
+	}
+	return DefaultIdleMinutes * time.Minute
+}
+
+// StaleAfter returns how old API data may get before it is shown as stale:
+// StaleAfterMinutes, but never less than two refresh intervals, so data is
+// not flagged just for waiting on the next refresh.
+func (c *Config) StaleAfter() time.Duration {
+	minutes := c.StaleAfterMinutes
+	if minutes == 0 {
+		minutes = DefaultStaleAfterMinutes
+	}
+	return max(time.Duration(minutes)*time.Minute, 2*c.RefreshInterval)
+}
+
+// ControlHost returns the address the control API listens on.
+func (c *Config) ControlHost() string {
+	if c.ControlAddress == "" {
+		return DefaultControlAddress
+	}
+	return c.ControlAddress
+}
+
+// GetUsageProvider returns the effective usage provider: ProviderDemo in
+// demo mode, the configured one otherwise.
+func (c *Config) GetUsageProvider() string {
+	if c.Demo {
+		return ProviderDemo
+	}
+	return c.UsageProvider
+}
+
+// UsesOAuth reports whether rate limits come from the OAuth usage endpoint,
+// which needs the credentials file's access token.
+func (c *Config) UsesOAuth() bool {
+	provider := c.GetUsageProvider()
+	return provider != ProviderFile && provider != ProviderRemote && provider != ProviderDemo
+}
+
 // GetSourceDisplayName returns a human-readable name for the current source.
 func (c *Config) GetSourceDisplayName() string {
 	if c.Source == SourceOpenCode {
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables that override config.json values.
+// Useful for containerized or scripted deployments that can't write files.
+// They last for the run: Save leaves the values in config.json as they are.
+const (
+	EnvRefreshInterval = "CLAUDE_USAGE_REFRESH_INTERVAL" // Duration ("2m") or seconds ("120")
+	EnvWeeklyBudget    = "CLAUDE_USAGE_WEEKLY_BUDGET"    // Tokens
+	EnvStatsPath       = "CLAUDE_USAGE_STATS_PATH"
+	EnvCredentialsPath = "CLAUDE_USAGE_CREDENTIALS_PATH"
+	EnvSource          = "CLAUDE_USAGE_SOURCE" // "claude" or "opencode"
+	EnvDebug           = "CLAUDE_USAGE_DEBUG"  // "1", "true", "0", "false", ...
+)
+
+// Environment variables holding secrets. They are read where needed and
+// never copied into the config, so they cannot end up in a saved file.
+const (
+	EnvAccessToken     = "CLAUDE_USAGE_ACCESS_TOKEN"  // OAuth access token, instead of a credentials file
+	EnvRefreshToken    = "CLAUDE_USAGE_REFRESH_TOKEN" // Its refresh token, optional
+	EnvTokenPassphrase = "CLAUDE_USAGE_TOKEN_PASSPHRASE"
+)
+
+// EnvSupervised is set to "1" by the systemd and launchd services from the
+// service command, which restart the app when it exits with a failure.
+// Autostart entries are started by the same managers but not restarted,
+// so only this marker tells the two apart.
+const EnvSupervised = "CLAUDE_USAGE_SUPERVISED"
+
+// UsesEnvCredentials reports whether OAuth tokens come from EnvAccessToken
+// rather than a credentials file.
+func UsesEnvCredentials() bool {
+	_, ok := lookupEnv(EnvAccessToken)
+	return ok
+}
+
+// applyEnvOverrides layers environment variables over the config.
+// Unparseable values are reported and ignored.
+func (c *Config) applyEnvOverrides() []FieldError {
+	var problems []FieldError
+
+	if v, ok := lookupEnv(EnvRefreshInterval); ok {
+		d, err := ParseInterval(v)
+		if err != nil {
+			problems = append(problems, FieldError{EnvRefreshInterval, err.Error()})
+		} else {
+			c.RefreshIntervalSeconds = int(d.Seconds())
+		}
+	}
+
+	if v, ok := lookupEnv(EnvWeeklyBudget); ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			problems = append(problems, FieldError{EnvWeeklyBudget, fmt.Sprintf("not a number: %q", v)})
+		} else {
+			c.WeeklyBudgetTokens = n
+		}
+	}
+
+	if v, ok := lookupEnv(EnvStatsPath); ok {
+		c.ClaudeStatsPath = v
+	}
+	if v, ok := lookupEnv(EnvCredentialsPath); ok {
+		c.ClaudeCredentialsPath = v
+	}
+	if v, ok := lookupEnv(EnvSource); ok {
+		c.Source = strings.ToLower(v)
+	}
+
+	if v, ok := lookupEnv(EnvDebug); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			problems = append(problems, FieldError{EnvDebug, fmt.Sprintf("not a boolean: %q", v)})
+		} else {
+			c.Debug = b
+		}
+	}
+
+	return problems
+}
+
+// lookupEnv returns the trimmed value of an environment variable, if set and non-empty.
+func lookupEnv(key string) (string, bool) {
+	v := strings.TrimSpace(os.Getenv(key))
+	return v, v != ""
+}
+
+// ParseInterval parses a refresh interval given either as a Go duration
+// ("90s", "2m", "1h") or as a plain number of seconds ("120").
+func ParseInterval(s string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(s); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval %q (use e.g. \"120\" or \"2m\")", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+	}{
+		{"120", 120 * time.Second},
+		{"2m", 2 * time.Minute},
+		{"90s", 90 * time.Second},
+		{"1h", time.Hour},
+	}
+
+	for _, tt := range tests {
+		d, err := ParseInterval(tt.input)
+		if err != nil {
+			t.Errorf("ParseInterval(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if d != tt.expected {
+			t.Errorf("ParseInterval(%q) = %s, expected %s", tt.input, d, tt.expected)
+		}
+	}
+
+	if _, err := ParseInterval("soon"); err == nil {
+		t.Error("Expected error for invalid interval, got nil")
+	}
+}
+
+func TestApplyEnvOverrides(t *testing.T) {
+	t.Setenv(EnvRefreshInterval, "2m")
+	t.Setenv(EnvWeeklyBudget, "1000")
+	t.Setenv(EnvCredentialsPath, "/tmp/creds.json")
+	t.Setenv(EnvSource, "OpenCode")
+	t.Setenv(EnvDebug, "true")
+
+	cfg := Default()
+	if problems := cfg.applyEnvOverrides(); len(problems) != 0 {
+		t.Fatalf("Unexpected problems: %v", problems)
+	}
+
+	if cfg.RefreshIntervalSeconds != 120 {
+		t.Errorf("RefreshIntervalSeconds = %d, expected 120", cfg.RefreshIntervalSeconds)
+	}
+	if cfg.WeeklyBudgetTokens != 1000 {
+		t.Errorf("WeeklyBudgetTokens = %d, expected 1000", cfg.WeeklyBudgetTokens)
+	}
+	if cfg.ClaudeCredentialsPath != "/tmp/creds.json" {
+		t.Errorf("ClaudeCredentialsPath = %q, expected /tmp/creds.json", cfg.ClaudeCredentialsPath)
+	}
+	if cfg.Source != SourceOpenCode {
+		t.Errorf("Source = %q, expected %q", cfg.Source, SourceOpenCode)
+	}
+	if !cfg.Debug {
+		t.Error("Debug = false, expected true")
+	}
+}
+
+func TestApplyEnvOverrides_InvalidValues(t *testing.T) {
+	t.Setenv(EnvRefreshInterval, "often")
+	t.Setenv(EnvWeeklyBudget, "lots")
+
+	cfg := Default()
+	problems := cfg.applyEnvOverrides()
+	if len(problems) != 2 {
+		t.Errorf("Expected 2 problems, got: %v", problems)
+	}
+	if cfg.RefreshIntervalSeconds != 300 {
+		t.Errorf("Invalid override should be ignored, got %d", cfg.RefreshIntervalSeconds)
+	}
+}
+
+func TestSave_KeepsEnvOverridesOut(t *testing.T) {
+	writeTestConfig(t, `{"refresh_interval_seconds": 300, "weekly_budget_tokens": 1000000}`)
+	before := readTestConfig(t)
+
+	t.Setenv(EnvRefreshInterval, "2m")
+	t.Setenv(EnvWeeklyBudget, "1000")
+	t.Setenv(EnvStatsPath, "/tmp/stats.json")
+	t.Setenv(EnvDebug, "true")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.WeeklyBudgetTokens != 1000 {
+		t.Fatalf("Environment not applied: budget = %d", cfg.WeeklyBudgetTokens)
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if after := readTestConfig(t); !reflect.DeepEqual(after, before) {
+		t.Errorf("Save changed config.json:\n got %v\nwant %v", after, before)
+	}
+}
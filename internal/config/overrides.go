@@ -0,0 +1,62 @@
+package config
+
+import (
+	"sync"
+	"time"
+)
+
+// Overrides holds per-run values (e.g. from command-line flags) that take
+// precedence over both config.json and environment variables.
+// Zero values mean "not set".
+type Overrides struct {
+	Profile            string
+	RefreshInterval    time.Duration
+	WeeklyBudgetTokens int64
+	StatsPath          string
+	CredentialsPath    string
+	Source             string
+	Demo               bool
+	Debug              bool
+}
+
+var (
+	overrides   Overrides
+	overridesMu sync.RWMutex
+)
+
+// SetOverrides registers per-run overrides applied by every subsequent Load,
+// including live reloads.
+func SetOverrides(o Overrides) {
+	overridesMu.Lock()
+	overrides = o
+	overridesMu.Unlock()
+}
+
+// applyOverrides layers the registered per-run overrides over the config.
+func (c *Config) applyOverrides() {
+	overridesMu.RLock()
+	o := overrides
+	overridesMu.RUnlock()
+
+	if o.RefreshInterval != 0 {
+		c.RefreshIntervalSeconds = int(o.RefreshInterval.Seconds())
+	}
+	if o.WeeklyBudgetTokens != 0 {
+		c.WeeklyBudgetTokens = o.WeeklyBudgetTokens
+	}
+	if o.StatsPath != "" {
+		c.ClaudeStatsPath = o.StatsPath
+	}
+	if o.CredentialsPath != "" {
+		c.ClaudeCredentialsPath = o.CredentialsPath
+	}
+	if o.Source != "" {
+		c.Source = o.Source
+	}
+	if o.Demo {
+		c.Demo = true
+	}
+	if o.Debug {
+		c.Debug = true
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// readTestConfig returns the values in config.json by key.
+func readTestConfig(t *testing.T) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile(GetConfigPath())
+	if err != nil {
+		t.Fatalf("Failed to read saved config: %v", err)
+	}
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		t.Fatalf("Failed to parse saved config: %v", err)
+	}
+	return values
+}
+
+func TestSave_KeepsOverridesOut(t *testing.T) {
+	writeTestConfig(t, `{
+		"refresh_interval_seconds": 300,
+		"source": "claude",
+		"claude_credentials_path": "~/.claude/.credentials.json"
+	}`)
+	before := readTestConfig(t)
+
+	SetOverrides(Overrides{
+		RefreshInterval: time.Minute,
+		CredentialsPath: "/tmp/creds.json",
+		Source:          SourceOpenCode,
+		Debug:           true,
+	})
+	t.Cleanup(func() { SetOverrides(Overrides{}) })
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.RefreshInterval != time.Minute || cfg.Source != SourceOpenCode {
+		t.Fatalf("Overrides not applied: refresh %s, source %q", cfg.RefreshInterval, cfg.Source)
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if after := readTestConfig(t); !reflect.DeepEqual(after, before) {
+		t.Errorf("Save changed config.json:\n got %v\nwant %v", after, before)
+	}
+
+	// Settings changed since Load are saved; the overrides still are not
+	cfg.IconMetric = IconFiveHour
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	after := readTestConfig(t)
+	if after["icon_metric"] != IconFiveHour {
+		t.Errorf("icon_metric = %v, expected %s", after["icon_metric"], IconFiveHour)
+	}
+	if after["refresh_interval_seconds"] != float64(300) || after["source"] != "claude" || after["debug"] != nil {
+		t.Errorf("Overrides saved: %v", after)
+	}
+}
+
+func TestSave_DemoIsRuntimeOnly(t *testing.T) {
+	writeTestConfig(t, `{"usage_provider": "file", "usage_file_path": "/tmp/usage.json"}`)
+	before := readTestConfig(t)
+
+	SetOverrides(Overrides{Demo: true})
+	t.Cleanup(func() { SetOverrides(Overrides{}) })
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.GetUsageProvider() != ProviderDemo || cfg.UsesOAuth() {
+		t.Errorf("Demo mode not applied: provider %q", cfg.GetUsageProvider())
+	}
+	if cfg.UsageProvider != ProviderFile {
+		t.Errorf("UsageProvider = %q, expected the configured %q", cfg.UsageProvider, ProviderFile)
+	}
+
+	cfg.IconMetric = IconFiveHour
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	after := readTestConfig(t)
+	if after["usage_provider"] != before["usage_provider"] {
+		t.Errorf("usage_provider = %v, expected %v", after["usage_provider"], before["usage_provider"])
+	}
+}
@@ -80,6 +80,123 @@ func GetConfigPath() string {
 	return filepath.Join(GetConfigDir(), "config.json")
 }
 
+// GetInstanceSocketPath returns the path of the single-instance socket.
+// Each profile gets its own socket so profiles can run side by side.
+func GetInstanceSocketPath(profile string) string {
+	if profile != "" {
+		return filepath.Join(GetConfigDir(), "claude-usage-"+profile+".sock")
+	}
+	return filepath.Join(GetConfigDir(), "claude-usage.sock")
+}
+
+// GetLogPath returns the path of the log file.
+// Each profile gets its own file so side-by-side instances don't rotate
+// each other's logs.
+func GetLogPath(profile string) string {
+	if profile != "" {
+		return filepath.Join(GetConfigDir(), "claude-usage-"+profile+".log")
+	}
+	return filepath.Join(GetConfigDir(), "claude-usage.log")
+}
+
+// GetRateLimitCachePath returns the path of the last-known rate limit data.
+// Each profile gets its own file since profiles may use different accounts.
+func GetRateLimitCachePath(profile string) string {
+	if profile != "" {
+		return filepath.Join(GetConfigDir(), "rate-limits-"+profile+".json")
+	}
+	return filepath.Join(GetConfigDir(), "rate-limits.json")
+}
+
+// GetHistoryPath returns the path of the week-over-week usage history.
+// Each profile gets its own file since profiles may use different accounts.
+func GetHistoryPath(profile string) string {
+	if profile != "" {
+		return filepath.Join(GetConfigDir(), "history-"+profile+".json")
+	}
+	return filepath.Join(GetConfigDir(), "history.json")
+}
+
+// GetSamplesPath returns the path of the per-refresh usage log kept for
+// exports. Each profile gets its own file since profiles may use different
+// accounts.
+func GetSamplesPath(profile string) string {
+	if profile != "" {
+		return filepath.Join(GetConfigDir(), "samples-"+profile+".jsonl")
+	}
+	return filepath.Join(GetConfigDir(), "samples.jsonl")
+}
+
+// GetStatusPath returns the path of the status file kept for the prompt
+// command. Each profile gets its own file since profiles may use different
+// accounts.
+func GetStatusPath(profile string) string {
+	if profile != "" {
+		return filepath.Join(GetConfigDir(), "status-"+profile+".json")
+	}
+	return filepath.Join(GetConfigDir(), "status.json")
+}
+
+// GetControlTokenPath returns the path of the control API token.
+// Each profile gets its own token since each runs its own control API.
+func GetControlTokenPath(profile string) string {
+	if profile != "" {
+		return filepath.Join(GetConfigDir(), "control-token-"+profile)
+	}
+	return filepath.Join(GetConfigDir(), "control-token")
+}
+
+// GetTokenStorePath returns the path of the encrypted store for rotated
+// OAuth tokens used with read_only_credentials or environment credentials.
+// Each profile gets its own store since profiles may use different accounts.
+func GetTokenStorePath(profile string) string {
+	if profile != "" {
+		return filepath.Join(GetConfigDir(), "tokens-"+profile+".enc")
+	}
+	return filepath.Join(GetConfigDir(), "tokens.enc")
+}
+
+// GetTokenKeyPath returns the path of the key encrypting the token stores,
+// shared by all profiles.
+func GetTokenKeyPath() string {
+	return filepath.Join(GetConfigDir(), "token-key")
+}
+
+// GetTokenProtectedKeyPath returns the path of the DPAPI-protected key used
+// for token_store_key "keyring" on Windows.
+func GetTokenProtectedKeyPath() string {
+	return filepath.Join(GetConfigDir(), "token-key.dpapi")
+}
+
+// GetTokenSaltPath returns the path of the salt used to derive the key for
+// token_store_key "passphrase".
+func GetTokenSaltPath() string {
+	return filepath.Join(GetConfigDir(), "token-salt")
+}
+
+// GetPlanLimitsPath returns the path of the cached plan limits table.
+func GetPlanLimitsPath() string {
+	return filepath.Join(GetConfigDir(), "plan-limits.json")
+}
+
+// GetTelemetryPath returns the path recording when anonymous statistics
+// were last sent. Each profile reports on its own API fetches.
+func GetTelemetryPath(profile string) string {
+	if profile != "" {
+		return filepath.Join(GetConfigDir(), "telemetry-"+profile+".json")
+	}
+	return filepath.Join(GetConfigDir(), "telemetry.json")
+}
+
+// GetExecutablePath returns the resolved path of the running executable.
+func GetExecutablePath() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
 // ExpandPath expands ~ to the user's home directory.
 func ExpandPath(path string) string {
 	if strings.HasPrefix(path, "~/") {
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// EnvProfile selects a named profile when --profile is not given.
+const EnvProfile = "CLAUDE_USAGE_PROFILE"
+
+// ActiveProfile returns the profile selected via overrides or environment.
+func ActiveProfile() string {
+	overridesMu.RLock()
+	profile := overrides.Profile
+	overridesMu.RUnlock()
+
+	if profile != "" {
+		return profile
+	}
+	profile, _ = lookupEnv(EnvProfile)
+	return profile
+}
+
+// applyProfile overlays the named profile's values onto the config.
+// Only fields present in the profile are changed.
+func (c *Config) applyProfile(name string) []FieldError {
+	raw, ok := c.Profiles[name]
+	if !ok {
+		return []FieldError{{"profile", fmt.Sprintf("no profile named %q in config.json (available: %v)", name, c.ProfileNames())}}
+	}
+
+	if err := json.Unmarshal(raw, c); err != nil {
+		return []FieldError{{"profiles." + name, err.Error()}}
+	}
+	return nil
+}
+
+// ProfileNames returns the names of all profiles in the config, sorted.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// marshalProfile returns the config file contents with the settings changed
+// since Load written into the active profile, leaving top-level values and
+// other profiles untouched. Values the profile inherits stay inherited.
+func (c *Config) marshalProfile(current map[string]json.RawMessage) ([]byte, error) {
+	file, err := readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		file = make(map[string]json.RawMessage)
+	}
+
+	profiles := make(map[string]json.RawMessage)
+	if raw, ok := file["profiles"]; ok {
+		if err := json.Unmarshal(raw, &profiles); err != nil {
+			return nil, fmt.Errorf("failed to parse profiles: %w", err)
+		}
+	}
+
+	settings := make(map[string]json.RawMessage)
+	if raw, ok := profiles[c.Profile]; ok {
+		if err := json.Unmarshal(raw, &settings); err != nil {
+			return nil, fmt.Errorf("failed to parse profile %q: %w", c.Profile, err)
+		}
+	}
+	c.mergeChanges(settings, current)
+	profileData, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	profiles[c.Profile] = profileData
+
+	profilesData, err := json.Marshal(profiles)
+	if err != nil {
+		return nil, err
+	}
+	file["profiles"] = profilesData
+
+	return json.MarshalIndent(file, "", "  ")
+}
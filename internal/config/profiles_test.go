@@ -0,0 +1,136 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// writeTestConfig points the config dir at a temp directory and writes data as config.json.
+func writeTestConfig(t *testing.T, data string) {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("config dir override via XDG_CONFIG_HOME is Linux-only")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := EnsureConfigDir(); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(GetConfigPath(), []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+}
+
+func TestLoad_Profile(t *testing.T) {
+	writeTestConfig(t, `{
+		"refresh_interval_seconds": 300,
+		"weekly_budget_tokens": 1000000,
+		"profiles": {
+			"work": {"refresh_interval_seconds": 60}
+		}
+	}`)
+	t.Setenv(EnvProfile, "work")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.Profile != "work" {
+		t.Errorf("Profile = %q, expected work", cfg.Profile)
+	}
+	if cfg.RefreshIntervalSeconds != 60 {
+		t.Errorf("Profile value not applied: refresh = %d, expected 60", cfg.RefreshIntervalSeconds)
+	}
+	if cfg.WeeklyBudgetTokens != 1000000 {
+		t.Errorf("Top-level value not inherited: budget = %d, expected 1000000", cfg.WeeklyBudgetTokens)
+	}
+}
+
+func TestLoad_UnknownProfile(t *testing.T) {
+	writeTestConfig(t, `{"refresh_interval_seconds": 300}`)
+	t.Setenv(EnvProfile, "missing")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	problems := cfg.Problems()
+	if len(problems) == 0 || problems[0].Field != "profile" {
+		t.Errorf("Expected a profile problem, got: %v", problems)
+	}
+}
+
+func TestSave_Profile(t *testing.T) {
+	writeTestConfig(t, `{
+		"refresh_interval_seconds": 300,
+		"profiles": {
+			"work": {"refresh_interval_seconds": 60},
+			"personal": {"refresh_interval_seconds": 600}
+		}
+	}`)
+	t.Setenv(EnvProfile, "work")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	cfg.RefreshInterval = 2 * time.Minute
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(GetConfigDir(), "config.json"))
+	if err != nil {
+		t.Fatalf("Failed to read saved config: %v", err)
+	}
+
+	var saved struct {
+		RefreshIntervalSeconds int                       `json:"refresh_interval_seconds"`
+		Profiles               map[string]map[string]any `json:"profiles"`
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("Failed to parse saved config: %v", err)
+	}
+
+	if saved.RefreshIntervalSeconds != 300 {
+		t.Errorf("Top-level value changed: %d, expected 300", saved.RefreshIntervalSeconds)
+	}
+	if got := saved.Profiles["work"]["refresh_interval_seconds"]; got != float64(120) {
+		t.Errorf("Active profile not updated: %v, expected 120", got)
+	}
+	if got := saved.Profiles["personal"]["refresh_interval_seconds"]; got != float64(600) {
+		t.Errorf("Other profile changed: %v, expected 600", got)
+	}
+}
+
+func TestSave_ProfileOnlyChanges(t *testing.T) {
+	writeTestConfig(t, `{
+		"refresh_interval_seconds": 300,
+		"weekly_budget_tokens": 1000000,
+		"profiles": {
+			"work": {"refresh_interval_seconds": 60}
+		}
+	}`)
+	t.Setenv(EnvProfile, "work")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	cfg.IconMetric = IconFiveHour
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	profiles, _ := readTestConfig(t)["profiles"].(map[string]any)
+	want := map[string]any{"refresh_interval_seconds": float64(60), "icon_metric": IconFiveHour}
+	if !reflect.DeepEqual(profiles["work"], want) {
+		t.Errorf("Saved profile = %v, expected %v", profiles["work"], want)
+	}
+}
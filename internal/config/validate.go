@@ -0,0 +1,531 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"claude-usage/internal/hotkey"
+	"claude-usage/internal/tooltiptmpl"
+	"claude-usage/pkg/format"
+)
+
+// Refresh interval bounds. Anything faster risks hammering the usage endpoint.
+const (
+	MinRefreshIntervalSeconds = 30
+	MaxRefreshIntervalSeconds = 24 * 60 * 60
+)
+
+// FieldError describes an invalid config value and how it was handled.
+type FieldError struct {
+	// Field is the JSON field name, e.g. "refresh_interval_seconds".
+	Field string
+
+	// Reason explains what is wrong and what value is used instead.
+	Reason string
+}
+
+// Error implements the error interface.
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Reason
+}
+
+// Validate checks config values, replacing invalid ones with usable values
+// where possible. It returns every problem found; nil means the config is valid.
+// Paths should already be expanded.
+func (c *Config) Validate() []FieldError {
+	var problems []FieldError
+
+	// Refresh interval
+	defaultSeconds := int(Default().RefreshInterval.Seconds())
+	switch {
+	case c.RefreshIntervalSeconds <= 0:
+		problems = append(problems, FieldError{"refresh_interval_seconds",
+			fmt.Sprintf("must be positive, got %d; using %d", c.RefreshIntervalSeconds, defaultSeconds)})
+		c.RefreshIntervalSeconds = defaultSeconds
+	case c.RefreshIntervalSeconds < MinRefreshIntervalSeconds:
+		problems = append(problems, FieldError{"refresh_interval_seconds",
+			fmt.Sprintf("must be at least %d, got %d; using %d", MinRefreshIntervalSeconds, c.RefreshIntervalSeconds, MinRefreshIntervalSeconds)})
+		c.RefreshIntervalSeconds = MinRefreshIntervalSeconds
+	case c.RefreshIntervalSeconds > MaxRefreshIntervalSeconds:
+		problems = append(problems, FieldError{"refresh_interval_seconds",
+			fmt.Sprintf("must be at most %d, got %d; using %d", MaxRefreshIntervalSeconds, c.RefreshIntervalSeconds, MaxRefreshIntervalSeconds)})
+		c.RefreshIntervalSeconds = MaxRefreshIntervalSeconds
+	}
+	c.RefreshInterval = time.Duration(c.RefreshIntervalSeconds) * time.Second
+
+	// Weekly budget
+	if c.WeeklyBudgetTokens <= 0 {
+		problems = append(problems, FieldError{"weekly_budget_tokens",
+			fmt.Sprintf("must be positive, got %d; using %d", c.WeeklyBudgetTokens, DefaultWeeklyBudget)})
+		c.WeeklyBudgetTokens = DefaultWeeklyBudget
+	}
+
+	// Source (empty means auto-detect)
+	switch c.Source {
+	case "", SourceClaude, SourceOpenCode:
+	default:
+		problems = append(problems, FieldError{"source",
+			fmt.Sprintf("must be %q or %q, got %q; auto-detecting", SourceClaude, SourceOpenCode, c.Source)})
+		c.Source = ""
+	}
+
+	// Update checks (zero disables them)
+	if c.UpdateCheckHours < 0 {
+		problems = append(problems, FieldError{"update_check_hours",
+			fmt.Sprintf("must not be negative, got %d; using %d", c.UpdateCheckHours, DefaultUpdateCheckHours)})
+		c.UpdateCheckHours = DefaultUpdateCheckHours
+	}
+
+	// Stale data age (zero means the default)
+	if c.StaleAfterMinutes < 0 {
+		problems = append(problems, FieldError{"stale_after_minutes",
+			fmt.Sprintf("must not be negative, got %d; using %d", c.StaleAfterMinutes, DefaultStaleAfterMinutes)})
+		c.StaleAfterMinutes = 0
+	}
+
+	// Startup grace period (zero means the default)
+	if c.StartupGraceSeconds < 0 {
+		problems = append(problems, FieldError{"startup_grace_seconds",
+			fmt.Sprintf("must not be negative, got %d; using %d", c.StartupGraceSeconds, DefaultStartupGraceSeconds)})
+		c.StartupGraceSeconds = 0
+	}
+
+	// Idle time (zero means the default)
+	if c.IdleMinutes < 0 {
+		problems = append(problems, FieldError{"idle_minutes",
+			fmt.Sprintf("must not be negative, got %d; using %d", c.IdleMinutes, DefaultIdleMinutes)})
+		c.IdleMinutes = 0
+	}
+
+	// Battery saver (empty means auto)
+	switch c.PowerSaver {
+	case "", PowerSaverAuto, PowerSaverOn, PowerSaverOff:
+	default:
+		problems = append(problems, FieldError{"power_saver",
+			fmt.Sprintf("must be %q, %q or %q, got %q; using %q", PowerSaverAuto, PowerSaverOn, PowerSaverOff, c.PowerSaver, PowerSaverAuto)})
+		c.PowerSaver = PowerSaverAuto
+	}
+
+	// Update channel (empty means stable)
+	switch c.UpdateChannel {
+	case "", ChannelStable, ChannelBeta:
+	default:
+		problems = append(problems, FieldError{"update_channel",
+			fmt.Sprintf("must be %q or %q, got %q; using %q", ChannelStable, ChannelBeta, c.UpdateChannel, ChannelStable)})
+		c.UpdateChannel = ChannelStable
+	}
+
+	// Usage provider (empty means OAuth)
+	switch c.UsageProvider {
+	case "", ProviderOAuth, ProviderDemo:
+	case ProviderFile:
+		if c.UsageFilePath == "" {
+			problems = append(problems, FieldError{"usage_file_path",
+				fmt.Sprintf("required by the %q usage provider; using %q", ProviderFile, ProviderOAuth)})
+			c.UsageProvider = ProviderOAuth
+		}
+	case ProviderRemote:
+		if c.RemoteURL == "" {
+			problems = append(problems, FieldError{"remote_url",
+				fmt.Sprintf("required by the %q usage provider; using %q", ProviderRemote, ProviderOAuth)})
+			c.UsageProvider = ProviderOAuth
+		}
+	default:
+		problems = append(problems, FieldError{"usage_provider",
+			fmt.Sprintf("must be %q, %q, %q or %q, got %q; using %q", ProviderOAuth, ProviderFile, ProviderRemote, ProviderDemo, c.UsageProvider, ProviderOAuth)})
+		c.UsageProvider = ProviderOAuth
+	}
+
+	// Token store key (empty means a key file)
+	switch c.TokenStoreKey {
+	case "", TokenKeyFile, TokenKeyKeyring:
+	case TokenKeyPassphrase:
+		if _, ok := lookupEnv(EnvTokenPassphrase); !ok {
+			problems = append(problems, FieldError{"token_store_key",
+				fmt.Sprintf("%q needs %s to be set; using %q", TokenKeyPassphrase, EnvTokenPassphrase, TokenKeyFile)})
+			c.TokenStoreKey = TokenKeyFile
+		}
+	default:
+		problems = append(problems, FieldError{"token_store_key",
+			fmt.Sprintf("must be %q, %q or %q, got %q; using %q", TokenKeyFile, TokenKeyKeyring, TokenKeyPassphrase, c.TokenStoreKey, TokenKeyFile)})
+		c.TokenStoreKey = TokenKeyFile
+	}
+
+	// Reset time format (empty means relative)
+	switch c.ResetTimeFormat {
+	case "", ResetRelative, ResetAbsolute:
+	default:
+		problems = append(problems, FieldError{"reset_time_format",
+			fmt.Sprintf("must be %q or %q, got %q; using %q", ResetRelative, ResetAbsolute, c.ResetTimeFormat, ResetRelative)})
+		c.ResetTimeFormat = ResetRelative
+	}
+
+	// Progress bar style (empty means auto)
+	switch c.BarStyle {
+	case "", BarAuto, BarUnicode, BarASCII:
+	default:
+		problems = append(problems, FieldError{"bar_style",
+			fmt.Sprintf("must be %q, %q or %q, got %q; using %q", BarAuto, BarUnicode, BarASCII, c.BarStyle, BarAuto)})
+		c.BarStyle = BarAuto
+	}
+
+	// Tooltip layout (empty means auto)
+	switch c.TooltipLayout {
+	case "", TooltipAuto, TooltipFull, TooltipMedium, TooltipCompact:
+	default:
+		problems = append(problems, FieldError{"tooltip_layout",
+			fmt.Sprintf("must be %q, %q, %q or %q, got %q; using %q", TooltipAuto, TooltipFull, TooltipMedium, TooltipCompact, c.TooltipLayout, TooltipAuto)})
+		c.TooltipLayout = TooltipAuto
+	}
+
+	// Icon metric (empty means weekly)
+	switch c.IconMetric {
+	case "", IconWeekly, IconFiveHour, IconOpus, IconSonnet, IconCost:
+	default:
+		problems = append(problems, FieldError{"icon_metric",
+			fmt.Sprintf("must be %q, %q, %q, %q or %q, got %q; using %q", IconWeekly, IconFiveHour, IconOpus, IconSonnet, IconCost, c.IconMetric, IconWeekly)})
+		c.IconMetric = IconWeekly
+	}
+
+	// Personal budget (empty means off)
+	switch c.BudgetMode {
+	case "", BudgetOff, BudgetTokens:
+	case BudgetCost:
+		if c.WeeklyBudgetUSD <= 0 {
+			problems = append(problems, FieldError{"weekly_budget_usd",
+				fmt.Sprintf("must be positive for budget_mode %q, got %g; turning budget mode off", BudgetCost, c.WeeklyBudgetUSD)})
+			c.BudgetMode = BudgetOff
+		}
+	default:
+		problems = append(problems, FieldError{"budget_mode",
+			fmt.Sprintf("must be %q, %q or %q, got %q; using %q", BudgetOff, BudgetTokens, BudgetCost, c.BudgetMode, BudgetOff)})
+		c.BudgetMode = BudgetOff
+	}
+
+	// Plan limit overrides
+	for plan, limit := range c.PlanLimits {
+		if limit <= 0 {
+			problems = append(problems, FieldError{"plan_limits",
+				fmt.Sprintf("limit for %q must be positive, got %d; ignoring it", plan, limit)})
+			delete(c.PlanLimits, plan)
+		}
+	}
+
+	// Global hotkey (empty means none)
+	if c.Hotkey != "" {
+		if _, err := hotkey.Parse(c.Hotkey); err != nil {
+			problems = append(problems, FieldError{"hotkey",
+				fmt.Sprintf("must be a shortcut such as \"Ctrl+Alt+U\": %v; not registering one", err)})
+			c.Hotkey = ""
+		}
+	}
+
+	// Number locale (empty means the system's)
+	if c.Locale != "" && !format.ValidLocale(c.Locale) {
+		problems = append(problems, FieldError{"locale",
+			fmt.Sprintf("must be a locale such as \"de-DE\", got %q; using the system locale", c.Locale)})
+		c.Locale = ""
+	}
+
+	// Model display names
+	for id, name := range c.ModelNames {
+		if _, err := path.Match(id, ""); err != nil || name == "" {
+			problems = append(problems, FieldError{"model_names",
+				fmt.Sprintf("%q needs a valid ID or pattern and a name; ignoring it", id)})
+			delete(c.ModelNames, id)
+		}
+	}
+
+	// Calendar week (empty means Monday, UTC)
+	if c.WeekStartDay != "" {
+		if _, ok := parseWeekday(c.WeekStartDay); !ok {
+			problems = append(problems, FieldError{"week_start_day",
+				fmt.Sprintf("must be a day name such as \"monday\", got %q; using monday", c.WeekStartDay)})
+			c.WeekStartDay = ""
+		}
+	}
+	if c.WeekTimezone != "" {
+		if _, err := time.LoadLocation(c.WeekTimezone); err != nil {
+			problems = append(problems, FieldError{"week_timezone",
+				fmt.Sprintf("unknown timezone %q; using UTC", c.WeekTimezone)})
+			c.WeekTimezone = ""
+		}
+	}
+
+	// Tooltip template
+	if c.TooltipTemplate != "" {
+		if _, err := tooltiptmpl.Parse(c.TooltipTemplate); err != nil {
+			problems = append(problems, FieldError{"tooltip_template",
+				fmt.Sprintf("does not parse: %v; using the built-in layout", err)})
+			c.TooltipTemplate = ""
+		}
+	}
+
+	// Integrations
+	var thresholds []int
+	for _, t := range c.AlertThresholds {
+		if t < 1 || t > 100 {
+			problems = append(problems, FieldError{"alert_thresholds",
+				fmt.Sprintf("must be between 1 and 100, got %d; ignoring it", t)})
+			continue
+		}
+		thresholds = append(thresholds, t)
+	}
+	c.AlertThresholds = thresholds
+	var spendAlerts []int
+	for _, t := range c.SpendAlerts {
+		if t < 1 || t > 100 {
+			problems = append(problems, FieldError{"spend_alerts",
+				fmt.Sprintf("must be between 1 and 100, got %d; ignoring it", t)})
+			continue
+		}
+		spendAlerts = append(spendAlerts, t)
+	}
+	c.SpendAlerts = spendAlerts
+	for model, values := range c.ModelAlertThresholds {
+		if model != ModelOpus && model != ModelSonnet {
+			problems = append(problems, FieldError{"model_alert_thresholds",
+				fmt.Sprintf("unknown model %q, must be %q or %q; ignoring it", model, ModelOpus, ModelSonnet)})
+			delete(c.ModelAlertThresholds, model)
+			continue
+		}
+		var kept []int
+		for _, t := range values {
+			if t < 1 || t > 100 {
+				problems = append(problems, FieldError{"model_alert_thresholds",
+					fmt.Sprintf("%s: must be between 1 and 100, got %d; ignoring it", model, t)})
+				continue
+			}
+			kept = append(kept, t)
+		}
+		c.ModelAlertThresholds[model] = kept
+	}
+	if c.PaceAlertMargin < 0 || c.PaceAlertMargin > 100 {
+		problems = append(problems, FieldError{"pace_alert_margin",
+			fmt.Sprintf("must be between 1 and 100, got %d; pace alerts disabled", c.PaceAlertMargin)})
+		c.PaceAlertMargin = 0
+	}
+	if c.SlackWebhookURL != "" && !isHTTPSURL(c.SlackWebhookURL) {
+		problems = append(problems, FieldError{"slack_webhook_url",
+			"must be an https:// URL; Slack notifications disabled"})
+		c.SlackWebhookURL = ""
+	}
+	if c.DiscordWebhookURL != "" && !isHTTPSURL(c.DiscordWebhookURL) {
+		problems = append(problems, FieldError{"discord_webhook_url",
+			"must be an https:// URL; Discord notifications disabled"})
+		c.DiscordWebhookURL = ""
+	}
+	if c.WebhookURL != "" {
+		if u, err := url.Parse(c.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, FieldError{"webhook_url",
+				"must be an http:// or https:// URL; webhook disabled"})
+			c.WebhookURL = ""
+		}
+	}
+	problems = append(problems, validateTemplates("slack_templates", c.SlackTemplates)...)
+	problems = append(problems, validateTemplates("discord_templates", c.DiscordTemplates)...)
+	if c.WebhookTemplate != "" {
+		if _, err := template.New("webhook").Parse(c.WebhookTemplate); err != nil {
+			problems = append(problems, FieldError{"webhook_template",
+				fmt.Sprintf("does not parse: %v; sending the event as JSON", err)})
+			c.WebhookTemplate = ""
+		}
+	}
+	var p []FieldError
+	c.SlackEvents, p = validateEvents("slack_events", c.SlackEvents)
+	problems = append(problems, p...)
+	c.DiscordEvents, p = validateEvents("discord_events", c.DiscordEvents)
+	problems = append(problems, p...)
+	c.WebhookEvents, p = validateEvents("webhook_events", c.WebhookEvents)
+	problems = append(problems, p...)
+
+	// Email
+	if c.SMTPServer != "" {
+		if u, err := url.Parse(c.SMTPServer); err != nil || (u.Scheme != "smtp" && u.Scheme != "smtps") || u.Host == "" {
+			problems = append(problems, FieldError{"smtp_server",
+				"must be an smtp:// or smtps:// URL; email disabled"})
+			c.SMTPServer = ""
+		} else if c.EmailFrom == "" || len(c.EmailTo) == 0 {
+			problems = append(problems, FieldError{"smtp_server",
+				"email_from and email_to are required; email disabled"})
+			c.SMTPServer = ""
+		}
+	}
+	c.EmailEvents, p = validateEvents("email_events", c.EmailEvents)
+	problems = append(problems, p...)
+	if c.EmailMinThreshold < 0 || c.EmailMinThreshold > 100 {
+		problems = append(problems, FieldError{"email_min_threshold",
+			fmt.Sprintf("must be between 1 and 100, got %d; using %d", c.EmailMinThreshold, DefaultEmailMinThreshold)})
+		c.EmailMinThreshold = 0
+	}
+
+	// MQTT
+	if c.MQTTBroker != "" {
+		u, err := url.Parse(c.MQTTBroker)
+		if err != nil || u.Host == "" || !slices.Contains([]string{"tcp", "mqtt", "ssl", "tls", "mqtts"}, u.Scheme) {
+			problems = append(problems, FieldError{"mqtt_broker",
+				fmt.Sprintf("must be a tcp://, mqtt://, ssl://, tls:// or mqtts:// URL, got %q; MQTT disabled", c.MQTTBroker)})
+			c.MQTTBroker = ""
+		}
+	}
+	if strings.ContainsAny(c.MQTTTopic, "+#") {
+		problems = append(problems, FieldError{"mqtt_topic",
+			fmt.Sprintf("must not contain wildcards, got %q; using %q", c.MQTTTopic, DefaultMQTTTopic)})
+		c.MQTTTopic = ""
+	}
+
+	// Status file; it must not overwrite the file usage is read from
+	if c.StatusFilePath != "" && c.StatusFilePath == c.UsageFilePath {
+		problems = append(problems, FieldError{"status_file_path",
+			"must differ from usage_file_path; status file disabled"})
+		c.StatusFilePath = ""
+	}
+
+	// Control API (zero disables it)
+	if c.ControlPort < 0 || c.ControlPort > 65535 {
+		problems = append(problems, FieldError{"control_port",
+			fmt.Sprintf("must be between 1 and 65535, got %d; control API disabled", c.ControlPort)})
+		c.ControlPort = 0
+	}
+	if c.ControlAddress != "" && net.ParseIP(c.ControlAddress) == nil {
+		problems = append(problems, FieldError{"control_address",
+			fmt.Sprintf("must be an IP address, got %q; using %q", c.ControlAddress, DefaultControlAddress)})
+		c.ControlAddress = ""
+	}
+
+	// Proxy URL
+	if c.ProxyURL != "" {
+		u, err := url.Parse(c.ProxyURL)
+		if err != nil || u.Host == "" {
+			problems = append(problems, FieldError{"proxy_url",
+				fmt.Sprintf("not a valid URL: %q; using environment proxy settings", c.ProxyURL)})
+			c.ProxyURL = ""
+		} else if u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5" {
+			problems = append(problems, FieldError{"proxy_url",
+				fmt.Sprintf("scheme must be http, https or socks5, got %q; using environment proxy settings", u.Scheme)})
+			c.ProxyURL = ""
+		}
+	}
+
+	// Remote agent
+	if c.RemoteURL != "" {
+		if u, err := url.Parse(c.RemoteURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, FieldError{"remote_url",
+				"must be an http:// or https:// URL; remote usage provider disabled"})
+			c.RemoteURL = ""
+			if c.UsageProvider == ProviderRemote {
+				c.UsageProvider = ProviderOAuth
+			}
+		}
+	}
+
+	// Team usage endpoint; it is sent the OAuth token
+	if c.TeamUsageURL != "" && !isHTTPSURL(c.TeamUsageURL) {
+		problems = append(problems, FieldError{"team_usage_url",
+			"must be an https:// URL; team usage disabled"})
+		c.TeamUsageURL = ""
+	}
+
+	// Rate limit header check (empty means off)
+	switch c.RateLimitHeaders {
+	case "", RateLimitHeadersOff, RateLimitHeadersProbe:
+	case RateLimitHeadersFile:
+		if c.RateLimitHeadersFile == "" {
+			problems = append(problems, FieldError{"rate_limit_headers_file",
+				fmt.Sprintf("required by the %q rate limit header check; using %q", RateLimitHeadersFile, RateLimitHeadersOff)})
+			c.RateLimitHeaders = RateLimitHeadersOff
+		}
+	default:
+		problems = append(problems, FieldError{"rate_limit_headers",
+			fmt.Sprintf("must be %q, %q or %q, got %q; using %q", RateLimitHeadersOff, RateLimitHeadersProbe, RateLimitHeadersFile, c.RateLimitHeaders, RateLimitHeadersOff)})
+		c.RateLimitHeaders = RateLimitHeadersOff
+	}
+
+	// Anonymous statistics (empty means not asked yet)
+	switch c.Telemetry {
+	case "", TelemetryOn, TelemetryOff:
+	default:
+		problems = append(problems, FieldError{"telemetry",
+			fmt.Sprintf("must be %q or %q, got %q; using %q", TelemetryOn, TelemetryOff, c.Telemetry, TelemetryOff)})
+		c.Telemetry = TelemetryOff
+	}
+	if c.TelemetryURL != "" {
+		if u, err := url.Parse(c.TelemetryURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, FieldError{"telemetry_url",
+				"must be an http:// or https:// URL; using the default endpoint"})
+			c.TelemetryURL = ""
+		}
+	}
+
+	// Pricing overrides
+	for model, p := range c.Pricing {
+		if p.Input < 0 || p.Output < 0 || p.CacheRead < 0 || p.CacheWrite < 0 {
+			problems = append(problems, FieldError{"pricing",
+				fmt.Sprintf("prices for %q must not be negative; using the built-in prices", model)})
+			delete(c.Pricing, model)
+		}
+	}
+
+	// Paths are kept as-is so the user can see what they configured,
+	// but a missing file is almost certainly a typo.
+	if c.ClaudeCredentialsPath != "" && !fileExists(c.ClaudeCredentialsPath) {
+		problems = append(problems, FieldError{"claude_credentials_path",
+			fmt.Sprintf("file not found: %s", c.ClaudeCredentialsPath)})
+	}
+	if c.ClaudeStatsPath != "" && !fileExists(c.ClaudeStatsPath) {
+		problems = append(problems, FieldError{"claude_stats_path",
+			fmt.Sprintf("file not found: %s", c.ClaudeStatsPath)})
+	}
+	if c.CABundlePath != "" && !fileExists(c.CABundlePath) {
+		problems = append(problems, FieldError{"ca_bundle_path",
+			fmt.Sprintf("file not found: %s", c.CABundlePath)})
+	}
+
+	return problems
+}
+
+// isHTTPSURL reports whether s is an absolute https:// URL.
+func isHTTPSURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme == "https" && u.Host != ""
+}
+
+// validateTemplates removes message templates that do not parse, so the
+// defaults are used instead.
+func validateTemplates(field string, templates map[string]string) []FieldError {
+	var problems []FieldError
+	for kind, text := range templates {
+		if _, err := template.New(kind).Parse(text); err != nil {
+			problems = append(problems, FieldError{field,
+				fmt.Sprintf("template for %q does not parse: %v; using the default", kind, err)})
+			delete(templates, kind)
+		}
+	}
+	return problems
+}
+
+// validateEvents returns the known event kinds in events.
+func validateEvents(field string, events []string) ([]string, []FieldError) {
+	var known []string
+	var problems []FieldError
+	for _, e := range events {
+		if !slices.Contains(EventKinds, e) {
+			problems = append(problems, FieldError{field,
+				fmt.Sprintf("unknown event %q; ignoring it", e)})
+			continue
+		}
+		known = append(known, e)
+	}
+	return known, problems
+}
+
+// Problems returns the validation errors found when the config was loaded.
+func (c *Config) Problems() []FieldError {
+	return c.problems
+}
@@ -0,0 +1,450 @@
+package config
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestValidate_ValidConfig(t *testing.T) {
+	cfg := Default()
+	cfg.Source = SourceClaude
+
+	if problems := cfg.Validate(); len(problems) != 0 {
+		t.Errorf("Expected no problems for default config, got: %v", problems)
+	}
+}
+
+func TestValidate_RefreshInterval(t *testing.T) {
+	tests := []struct {
+		seconds  int
+		expected int
+	}{
+		{-5, 300},
+		{0, 300},
+		{10, MinRefreshIntervalSeconds},
+		{MaxRefreshIntervalSeconds + 1, MaxRefreshIntervalSeconds},
+	}
+
+	for _, tt := range tests {
+		cfg := Default()
+		cfg.RefreshIntervalSeconds = tt.seconds
+
+		problems := cfg.Validate()
+		if len(problems) != 1 || problems[0].Field != "refresh_interval_seconds" {
+			t.Errorf("seconds=%d: expected one refresh_interval_seconds problem, got: %v", tt.seconds, problems)
+		}
+		if cfg.RefreshIntervalSeconds != tt.expected {
+			t.Errorf("seconds=%d: normalized to %d, expected %d", tt.seconds, cfg.RefreshIntervalSeconds, tt.expected)
+		}
+		if cfg.RefreshInterval != time.Duration(tt.expected)*time.Second {
+			t.Errorf("seconds=%d: RefreshInterval is %s, expected %ds", tt.seconds, cfg.RefreshInterval, tt.expected)
+		}
+	}
+}
+
+func TestValidate_BudgetSourceAndPaths(t *testing.T) {
+	cfg := Default()
+	cfg.WeeklyBudgetTokens = 0
+	cfg.Source = "chatgpt"
+	cfg.ClaudeCredentialsPath = "/nonexistent/path/credentials.json"
+
+	problems := cfg.Validate()
+
+	fields := make(map[string]bool)
+	for _, p := range problems {
+		fields[p.Field] = true
+	}
+	for _, field := range []string{"weekly_budget_tokens", "source", "claude_credentials_path"} {
+		if !fields[field] {
+			t.Errorf("Expected a problem for %s, got: %v", field, problems)
+		}
+	}
+
+	if cfg.WeeklyBudgetTokens != DefaultWeeklyBudget {
+		t.Errorf("WeeklyBudgetTokens not reset to default, got %d", cfg.WeeklyBudgetTokens)
+	}
+	if cfg.Source != "" {
+		t.Errorf("Invalid source should be cleared for auto-detection, got %q", cfg.Source)
+	}
+}
+
+func TestValidate_UsageProvider(t *testing.T) {
+	cfg := Default()
+	cfg.UsageProvider = "admin"
+	if problems := cfg.Validate(); len(problems) != 1 || problems[0].Field != "usage_provider" {
+		t.Errorf("Expected one usage_provider problem, got: %v", problems)
+	}
+	if cfg.UsageProvider != ProviderOAuth {
+		t.Errorf("UsageProvider normalized to %q, expected %q", cfg.UsageProvider, ProviderOAuth)
+	}
+
+	cfg = Default()
+	cfg.UsageProvider = ProviderFile
+	if problems := cfg.Validate(); len(problems) != 1 || problems[0].Field != "usage_file_path" {
+		t.Errorf("Expected one usage_file_path problem, got: %v", problems)
+	}
+	if !cfg.UsesOAuth() {
+		t.Error("Expected a fallback to OAuth without a usage file path")
+	}
+
+	cfg = Default()
+	cfg.UsageProvider = ProviderRemote
+	cfg.RemoteURL = "build-box:8765"
+	if problems := cfg.Validate(); len(problems) != 1 || problems[0].Field != "remote_url" {
+		t.Errorf("Expected one remote_url problem, got: %v", problems)
+	}
+	if !cfg.UsesOAuth() {
+		t.Error("Expected a fallback to OAuth without a valid remote URL")
+	}
+
+	cfg = Default()
+	cfg.UsageProvider = ProviderRemote
+	cfg.RemoteURL = "http://build-box:8765"
+	cfg.ControlAddress = "build-box"
+	if problems := cfg.Validate(); len(problems) != 1 || problems[0].Field != "control_address" {
+		t.Errorf("Expected one control_address problem, got: %v", problems)
+	}
+	if cfg.UsesOAuth() || cfg.ControlHost() != DefaultControlAddress {
+		t.Errorf("Expected the remote provider on %s, got %q on %s", DefaultControlAddress, cfg.UsageProvider, cfg.ControlHost())
+	}
+}
+
+func TestValidate_TokenStoreKey(t *testing.T) {
+	cfg := Default()
+	cfg.TokenStoreKey = "vault"
+	if problems := cfg.Validate(); len(problems) != 1 || problems[0].Field != "token_store_key" {
+		t.Errorf("Expected one token_store_key problem, got: %v", problems)
+	}
+	if cfg.TokenStoreKey != TokenKeyFile {
+		t.Errorf("TokenStoreKey normalized to %q, expected %q", cfg.TokenStoreKey, TokenKeyFile)
+	}
+
+	t.Setenv(EnvTokenPassphrase, "correct horse")
+	cfg = Default()
+	cfg.TokenStoreKey = TokenKeyPassphrase
+	if problems := cfg.Validate(); len(problems) != 0 {
+		t.Errorf("Expected no problems with %s set, got: %v", EnvTokenPassphrase, problems)
+	}
+}
+
+func TestValidate_StaleAfter(t *testing.T) {
+	cfg := Default()
+	cfg.StaleAfterMinutes = -5
+	if problems := cfg.Validate(); len(problems) != 1 || problems[0].Field != "stale_after_minutes" {
+		t.Errorf("Expected one stale_after_minutes problem, got: %v", problems)
+	}
+	if got := cfg.StaleAfter(); got != DefaultStaleAfterMinutes*time.Minute {
+		t.Errorf("StaleAfter() = %v, expected the default", got)
+	}
+
+	// Never less than two refresh intervals
+	cfg.StaleAfterMinutes = 5
+	cfg.RefreshInterval = 10 * time.Minute
+	if got := cfg.StaleAfter(); got != 20*time.Minute {
+		t.Errorf("StaleAfter() = %v, expected 20m", got)
+	}
+}
+
+func TestValidate_StartupGrace(t *testing.T) {
+	cfg := Default()
+	cfg.StartupGraceSeconds = -10
+	if problems := cfg.Validate(); len(problems) != 1 || problems[0].Field != "startup_grace_seconds" {
+		t.Errorf("Expected one startup_grace_seconds problem, got: %v", problems)
+	}
+	if got := cfg.StartupGrace(); got != DefaultStartupGraceSeconds*time.Second {
+		t.Errorf("StartupGrace() = %v, expected the default", got)
+	}
+
+	cfg.StartupGraceSeconds = 120
+	if got := cfg.StartupGrace(); got != 2*time.Minute {
+		t.Errorf("StartupGrace() = %v, expected 2m", got)
+	}
+}
+
+func TestValidate_IdleMinutes(t *testing.T) {
+	cfg := Default()
+	cfg.IdleMinutes = -1
+	if problems := cfg.Validate(); len(problems) != 1 || problems[0].Field != "idle_minutes" {
+		t.Errorf("Expected one idle_minutes problem, got: %v", problems)
+	}
+	if got := cfg.IdleAfter(); got != DefaultIdleMinutes*time.Minute {
+		t.Errorf("IdleAfter() = %v, expected the default", got)
+	}
+
+	cfg.IdleMinutes = 5
+	if got := cfg.IdleAfter(); got != 5*time.Minute {
+		t.Errorf("IdleAfter() = %v, expected 5m", got)
+	}
+}
+
+func TestValidate_PowerSaver(t *testing.T) {
+	cfg := Default()
+	cfg.PowerSaver = PowerSaverOn
+	if problems := cfg.Validate(); len(problems) != 0 {
+		t.Fatalf("Expected no problems, got: %v", problems)
+	}
+
+	cfg.PowerSaver = "battery"
+	if problems := cfg.Validate(); len(problems) != 1 || problems[0].Field != "power_saver" {
+		t.Errorf("Expected one power_saver problem, got: %v", problems)
+	}
+	if cfg.PowerSaver != PowerSaverAuto {
+		t.Errorf("PowerSaver normalized to %q, expected %q", cfg.PowerSaver, PowerSaverAuto)
+	}
+}
+
+func TestValidate_RateLimitHeaders(t *testing.T) {
+	cfg := Default()
+	cfg.RateLimitHeaders = RateLimitHeadersFile
+	problems := cfg.Validate()
+	if len(problems) != 1 || problems[0].Field != "rate_limit_headers_file" {
+		t.Errorf("Expected a rate_limit_headers_file problem, got: %v", problems)
+	}
+	if cfg.RateLimitHeaders != RateLimitHeadersOff {
+		t.Errorf("Expected the file check without a file to be turned off, got %q", cfg.RateLimitHeaders)
+	}
+
+	cfg = Default()
+	cfg.RateLimitHeaders = "always"
+	if problems := cfg.Validate(); len(problems) != 1 || problems[0].Field != "rate_limit_headers" {
+		t.Errorf("Expected a rate_limit_headers problem, got: %v", problems)
+	}
+
+	cfg = Default()
+	cfg.RateLimitHeaders = RateLimitHeadersProbe
+	if problems := cfg.Validate(); len(problems) != 0 {
+		t.Errorf("Expected no problems, got: %v", problems)
+	}
+}
+
+func TestValidate_Telemetry(t *testing.T) {
+	cfg := Default()
+	cfg.Telemetry = "yes"
+	cfg.TelemetryURL = "ftp://example.com"
+	problems := cfg.Validate()
+	if len(problems) != 2 || problems[0].Field != "telemetry" || problems[1].Field != "telemetry_url" {
+		t.Errorf("Expected telemetry and telemetry_url problems, got: %v", problems)
+	}
+	if cfg.Telemetry != TelemetryOff || cfg.TelemetryURL != "" {
+		t.Errorf("Telemetry normalized to %q, %q; expected off and no URL", cfg.Telemetry, cfg.TelemetryURL)
+	}
+}
+
+func TestTelemetryEnabled(t *testing.T) {
+	cfg := Default()
+	t.Setenv("DO_NOT_TRACK", "")
+	if cfg.TelemetryEnabled() {
+		t.Error("enabled without being asked")
+	}
+	cfg.Telemetry = TelemetryOn
+	if !cfg.TelemetryEnabled() {
+		t.Error("not enabled after agreeing")
+	}
+	t.Setenv("DO_NOT_TRACK", "1")
+	if cfg.TelemetryEnabled() {
+		t.Error("enabled despite DO_NOT_TRACK")
+	}
+}
+
+func TestValidate_TooltipLayout(t *testing.T) {
+	cfg := Default()
+	cfg.TooltipLayout = "tiny"
+	if problems := cfg.Validate(); len(problems) != 1 || problems[0].Field != "tooltip_layout" {
+		t.Errorf("Expected one tooltip_layout problem, got: %v", problems)
+	}
+	if cfg.TooltipLayout != TooltipAuto {
+		t.Errorf("TooltipLayout normalized to %q, expected %q", cfg.TooltipLayout, TooltipAuto)
+	}
+}
+
+func TestValidate_Hotkey(t *testing.T) {
+	cfg := Default()
+	cfg.Hotkey = "ctrl+alt+u"
+	if problems := cfg.Validate(); len(problems) != 0 {
+		t.Fatalf("Expected no problems, got: %v", problems)
+	}
+
+	cfg.Hotkey = "U"
+	if problems := cfg.Validate(); len(problems) != 1 || problems[0].Field != "hotkey" {
+		t.Errorf("Expected one hotkey problem, got: %v", problems)
+	}
+	if cfg.Hotkey != "" {
+		t.Errorf("Hotkey normalized to %q, expected none", cfg.Hotkey)
+	}
+}
+
+func TestValidate_IconMetric(t *testing.T) {
+	cfg := Default()
+	cfg.IconMetric = IconSonnet
+	if problems := cfg.Validate(); len(problems) != 0 {
+		t.Fatalf("Expected no problems, got: %v", problems)
+	}
+
+	cfg.IconMetric = "haiku"
+	if problems := cfg.Validate(); len(problems) != 1 || problems[0].Field != "icon_metric" {
+		t.Errorf("Expected one icon_metric problem, got: %v", problems)
+	}
+	if cfg.IconMetric != IconWeekly {
+		t.Errorf("IconMetric normalized to %q, expected %q", cfg.IconMetric, IconWeekly)
+	}
+}
+
+func TestValidate_Budget(t *testing.T) {
+	cfg := Default()
+	cfg.BudgetMode = BudgetTokens
+	if problems := cfg.Validate(); len(problems) != 0 {
+		t.Fatalf("Expected no problems, got: %v", problems)
+	}
+	if tokens, usd := cfg.Budget(); tokens != DefaultWeeklyBudget || usd != 0 {
+		t.Errorf("Expected the token budget, got %d tokens, $%g", tokens, usd)
+	}
+
+	cfg = Default()
+	cfg.BudgetMode = BudgetCost
+	if problems := cfg.Validate(); len(problems) != 1 {
+		t.Errorf("Expected one problem for a missing dollar budget, got: %v", problems)
+	}
+	if tokens, usd := cfg.Budget(); tokens != 0 || usd != 0 {
+		t.Errorf("Expected budget mode off, got %d tokens, $%g", tokens, usd)
+	}
+
+	cfg = Default()
+	cfg.BudgetMode = "dollars"
+	if problems := cfg.Validate(); len(problems) != 1 || cfg.BudgetMode != BudgetOff {
+		t.Errorf("Expected one problem and budget mode off, got: %v, %q", problems, cfg.BudgetMode)
+	}
+}
+
+func TestValidate_PlanLimits(t *testing.T) {
+	cfg := Default()
+	cfg.PlanLimits = map[string]int64{"pro": 50_000_000, "max_5x": 0}
+	if problems := cfg.Validate(); len(problems) != 1 {
+		t.Errorf("Expected one problem, got: %v", problems)
+	}
+	if len(cfg.PlanLimits) != 1 || cfg.PlanLimits["pro"] != 50_000_000 {
+		t.Errorf("Unexpected plan limits: %v", cfg.PlanLimits)
+	}
+}
+
+func TestValidate_Locale(t *testing.T) {
+	for _, locale := range []string{"de-DE", "fr_FR.UTF-8", "pt_BR", "es-419", "sr-Latn-RS", "C"} {
+		cfg := Default()
+		cfg.Locale = locale
+		if problems := cfg.Validate(); len(problems) != 0 {
+			t.Errorf("%q: expected no problems, got: %v", locale, problems)
+		}
+	}
+
+	cfg := Default()
+	cfg.Locale = "German"
+	if problems := cfg.Validate(); len(problems) != 1 {
+		t.Errorf("Expected one problem, got: %v", problems)
+	}
+	if cfg.Locale != "" {
+		t.Errorf("Expected the system locale, got %q", cfg.Locale)
+	}
+}
+
+func TestValidate_ModelNames(t *testing.T) {
+	cfg := Default()
+	cfg.ModelNames = map[string]string{
+		"claude-opus-5*": "Opus 5",
+		"claude-[opus":   "Broken",
+		"claude-haiku-5": "",
+	}
+	if problems := cfg.Validate(); len(problems) != 2 {
+		t.Errorf("Expected two problems, got: %v", problems)
+	}
+	if len(cfg.ModelNames) != 1 || cfg.ModelNames["claude-opus-5*"] != "Opus 5" {
+		t.Errorf("Unexpected model names: %v", cfg.ModelNames)
+	}
+}
+
+func TestValidate_WeekStart(t *testing.T) {
+	cfg := Default()
+	cfg.WeekStartDay = "Sunday"
+	cfg.WeekTimezone = "America/New_York"
+	if problems := cfg.Validate(); len(problems) != 0 {
+		t.Fatalf("Expected no problems, got: %v", problems)
+	}
+	day, loc := cfg.WeekStart()
+	if day != time.Sunday {
+		t.Errorf("Expected Sunday, got %v", day)
+	}
+	if loc.String() != "America/New_York" {
+		t.Errorf("Unexpected location %v", loc)
+	}
+
+	cfg = Default()
+	cfg.WeekStartDay = "funday"
+	cfg.WeekTimezone = "Mars/Olympus"
+	if problems := cfg.Validate(); len(problems) != 2 {
+		t.Errorf("Expected two problems, got: %v", problems)
+	}
+	if day, loc := cfg.WeekStart(); day != time.Monday || loc != time.UTC {
+		t.Errorf("Expected Monday, UTC, got %v, %v", day, loc)
+	}
+}
+
+func TestValidate_Integrations(t *testing.T) {
+	cfg := Default()
+	cfg.AlertThresholds = []int{0, 80, 101}
+	cfg.SlackWebhookURL = "http://hooks.slack.com/services/x"
+	cfg.SlackTemplates = map[string]string{"threshold": "{{.Percent}}%", "throttled": "{{.Percent"}
+	cfg.PaceAlertMargin = -5
+	cfg.ModelAlertThresholds = map[string][]int{"opus": {80, 120}, "haiku": {50}}
+	cfg.SMTPServer = "smtp://mail.example.com"
+	cfg.TeamUsageURL = "http://claude.example.com/usage"
+	cfg.SpendAlerts = []int{75, 150}
+
+	problems := cfg.Validate()
+	if len(problems) != 10 {
+		t.Errorf("Expected ten problems, got: %v", problems)
+	}
+	if !slices.Equal(cfg.GetSpendAlerts(), []int{75}) {
+		t.Errorf("Expected spend alerts [75], got %v", cfg.GetSpendAlerts())
+	}
+	if cfg.SMTPServer != "" {
+		t.Errorf("Expected email without recipients to be disabled, got %q", cfg.SMTPServer)
+	}
+	if cfg.TeamUsageURL != "" {
+		t.Errorf("Expected a non-https team usage URL to be dropped, got %q", cfg.TeamUsageURL)
+	}
+	if got := cfg.ModelAlertThresholds; len(got) != 1 || !slices.Equal(got["opus"], []int{80}) {
+		t.Errorf("Expected model thresholds {opus: [80]}, got %v", got)
+	}
+	if cfg.PaceAlertMargin != 0 {
+		t.Errorf("Expected an invalid pace margin to disable pace alerts, got %d", cfg.PaceAlertMargin)
+	}
+	if got := cfg.GetAlertThresholds(); len(got) != 1 || got[0] != 80 {
+		t.Errorf("Expected thresholds [80], got %v", got)
+	}
+	if cfg.SlackWebhookURL != "" {
+		t.Errorf("Expected a non-https webhook to be dropped, got %q", cfg.SlackWebhookURL)
+	}
+	if _, ok := cfg.SlackTemplates["throttled"]; ok || len(cfg.SlackTemplates) != 1 {
+		t.Errorf("Expected only the broken template to be dropped, got %v", cfg.SlackTemplates)
+	}
+}
+
+func TestValidate_TooltipTemplate(t *testing.T) {
+	cfg := Default()
+	cfg.TooltipTemplate = `{{bar .Weekly.Percent 10}} {{tokens .Tokens}}`
+	if problems := cfg.Validate(); len(problems) != 0 {
+		t.Errorf("Expected a template using tray functions to be valid, got: %v", problems)
+	}
+
+	cfg.TooltipTemplate = `{{cost .CostUSD}}`
+	if problems := cfg.Validate(); len(problems) != 1 || problems[0].Field != "tooltip_template" {
+		t.Errorf("Expected a template using an unknown function to be rejected, got: %v", problems)
+	}
+
+	cfg.TooltipTemplate = `{{.Weekly.Percent`
+	if problems := cfg.Validate(); len(problems) != 1 || problems[0].Field != "tooltip_template" {
+		t.Errorf("Expected one tooltip_template problem, got: %v", problems)
+	}
+	if cfg.TooltipTemplate != "" {
+		t.Errorf("Expected the broken template to be dropped, got %q", cfg.TooltipTemplate)
+	}
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchInterval is how often WatchFile polls for changes when file
+// notifications are unavailable.
+const DefaultWatchInterval = 2 * time.Second
+
+// watchSettle is how long WatchFile waits for a burst of events, such as
+// an editor's write, chmod and rename, to end before calling onChange once.
+const watchSettle = 100 * time.Millisecond
+
+// WatchFile calls onChange when the file at path is written, created,
+// removed or replaced. It blocks until stopCh is closed.
+//
+// The file's directory is watched rather than the file, so editors that
+// save by writing a new file and renaming it over the old one are caught.
+// Where file notifications are unavailable, such as when the directory
+// does not exist yet, the file is polled every interval instead.
+func WatchFile(path string, interval time.Duration, stopCh <-chan struct{}, onChange func()) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("Warning: could not watch %s, polling it instead: %v", path, err)
+		pollFile(path, interval, stopCh, onChange)
+		return
+	}
+	defer watcher.Close()
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		log.Printf("Warning: could not watch %s, polling it instead: %v", path, err)
+		pollFile(path, interval, stopCh, onChange)
+		return
+	}
+
+	path = filepath.Clean(path)
+	settle := time.NewTimer(watchSettle)
+	settle.Stop()
+	defer settle.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != path || event.Op == fsnotify.Chmod {
+				continue
+			}
+			settle.Reset(watchSettle)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("Warning: watching %s: %v", path, err)
+		case <-settle.C:
+			onChange()
+		}
+	}
+}
+
+// pollFile polls path every interval and calls onChange when the file's
+// modification time or size changes (including creation and deletion).
+// It blocks until stopCh is closed.
+func pollFile(path string, interval time.Duration, stopCh <-chan struct{}, onChange func()) {
+	lastMod, lastSize, lastExists := statFile(path)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			mod, size, exists := statFile(path)
+			if mod.Equal(lastMod) && size == lastSize && exists == lastExists {
+				continue
+			}
+			lastMod, lastSize, lastExists = mod, size, exists
+			onChange()
+		}
+	}
+}
+
+// statFile returns the modification time and size of path, and whether it exists.
+func statFile(path string) (time.Time, int64, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return info.ModTime(), info.Size(), true
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// watchChanges starts WatchFile on path and returns a channel receiving
+// its onChange calls.
+func watchChanges(t *testing.T, path string, interval time.Duration) <-chan struct{} {
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	changed := make(chan struct{}, 1)
+	go WatchFile(path, interval, stopCh, func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	return changed
+}
+
+// waitChange saves the file with save until the watcher reports a change,
+// as the watcher may not be set up when the first save happens.
+func waitChange(t *testing.T, changed <-chan struct{}, save func()) {
+	deadline := time.After(5 * time.Second)
+	for {
+		save()
+		select {
+		case <-changed:
+			return
+		case <-time.After(200 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("change not reported")
+		}
+	}
+}
+
+func TestWatchFile_RenameOver(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A long interval, so only file notifications can report the change
+	changed := watchChanges(t, path, time.Hour)
+	waitChange(t, changed, func() {
+		tmp := filepath.Join(dir, "config.json.tmp")
+		if err := os.WriteFile(tmp, []byte(`{"debug": true}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestWatchFile_PollsMissingDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "claude-usage")
+	path := filepath.Join(dir, "config.json")
+
+	changed := watchChanges(t, path, 10*time.Millisecond)
+	waitChange(t, changed, func() {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(`{}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestWatchFile_IgnoresOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	changed := watchChanges(t, path, time.Hour)
+
+	// Let the watcher start, then touch a neighbour only
+	time.Sleep(100 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(dir, "history.json"), []byte(`{}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changed:
+		t.Error("change reported for another file")
+	case <-time.After(300 * time.Millisecond):
+	}
+}
@@ -0,0 +1,66 @@
+package control
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+
+	"claude-usage/internal/status"
+	"claude-usage/pkg/format"
+)
+
+// DefaultPort is the control API port used when the dashboard is needed
+// but control_port is not set.
+const DefaultPort = 8765
+
+// dashboardTemplate is a minimal page showing the status and a chart of
+// the last week. It reloads every minute, keeping the token from the URL.
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="60">
+<title>Claude Usage {{.WeeklyPercent}}%</title>
+<style>
+body { background: #0a0a12; color: #00ff9c; font-family: monospace; margin: 3em; }
+h1 { font-size: 1.4em; }
+td { padding: 0.2em 1.5em 0.2em 0; }
+.dim { color: #667; }
+.five-hour { color: #ffb000; }
+img { display: block; max-width: 100%; margin: 1.5em 0 0.5em; }
+</style>
+</head>
+<body>
+<h1>&gt; CLAUDE USAGE</h1>
+<table>
+<tr><td>Weekly</td><td>{{if .Estimated}}~{{end}}{{.WeeklyPercent}}%</td><td class="dim">{{.WeeklyReset}}</td></tr>
+{{if not .Estimated}}<tr><td>5-hour</td><td>{{.FiveHourPercent}}%</td><td class="dim">{{.FiveHourReset}}</td></tr>{{end}}
+<tr><td>Tokens this week</td><td>{{.Tokens}}</td><td></td></tr>
+{{if .Throttled}}<tr><td>Status</td><td>THROTTLED</td><td></td></tr>{{end}}
+</table>
+<img src="chart.png?token={{.Token}}" width="800" height="240" alt="Usage over the last 7 days">
+<p class="dim">Last 7 days, one line every 25% and every midnight: weekly window{{if not .Estimated}}, <span class="five-hour">5-hour window</span>{{end}}</p>
+<p class="dim">Updated {{.UpdatedAt.Format "2006-01-02 15:04:05"}}{{with .DataAge}} · data from {{.}} ago{{end}}{{if .Stale}} (API unreachable, showing cached data){{end}}{{if .Paused}} · automatic refreshes paused{{end}}</p>
+</body>
+</html>
+`))
+
+// dashboardData is the status shown by dashboardTemplate, with the age of
+// its usage data (e.g. "3m"), empty before the first refresh, and the token
+// the chart is fetched with.
+type dashboardData struct {
+	status.Status
+	DataAge string
+	Token   string
+}
+
+// serveDashboard writes the status as an HTML page.
+func serveDashboard(w http.ResponseWriter, s status.Status, token string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	data := dashboardData{Status: s, Token: token}
+	if !s.UpdatedAt.IsZero() {
+		data.DataAge = format.FormatDuration(int64(s.Age(time.Now()).Seconds()))
+	}
+	dashboardTemplate.Execute(w, data)
+}
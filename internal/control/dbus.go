@@ -0,0 +1,26 @@
+package control
+
+import "strings"
+
+// DBusInterface is the D-Bus interface, and the bus name without a profile.
+const DBusInterface = "org.claudeusage.Monitor"
+
+// DBusName returns the bus name for profile. Each profile gets its own
+// name so profiles can run side by side.
+func DBusName(profile string) string {
+	if profile == "" {
+		return DBusInterface
+	}
+	// Bus name elements may only contain [A-Za-z0-9_-] and must not
+	// start with a digit
+	element := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, profile)
+	if element[0] >= '0' && element[0] <= '9' {
+		element = "_" + element
+	}
+	return DBusInterface + "." + element
+}
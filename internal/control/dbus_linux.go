@@ -0,0 +1,143 @@
+package control
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+
+	"claude-usage/internal/stats"
+)
+
+// dbusPath is the object path of the D-Bus service.
+const dbusPath = dbus.ObjectPath("/org/claudeusage/Monitor")
+
+// DBusService exports the org.claudeusage.Monitor interface on the session
+// bus with read-only properties
+//
+//	WeeklyUtilization   d  weekly window usage (0.0-1.0)
+//	FiveHourUtilization d  5-hour window usage (0.0-1.0)
+//	Throttled           b  whether requests are rate limited
+//
+// and a Refresh() method. Property changes are announced with
+// org.freedesktop.DBus.Properties.PropertiesChanged. A nil DBusService
+// does nothing.
+type DBusService struct {
+	conn  *dbus.Conn
+	props *prop.Properties
+}
+
+// dbusMethods are the methods exported on DBusInterface.
+type dbusMethods struct {
+	c Controller
+}
+
+// Refresh requests an immediate refresh.
+func (m dbusMethods) Refresh() *dbus.Error {
+	m.c.Refresh()
+	return nil
+}
+
+// ExportDBus claims name on the session bus and exports the service.
+// It fails if another process already owns name.
+func ExportDBus(name string, c Controller) (*DBusService, error) {
+	// A private connection, so closing it leaves the tray's connection alone
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := exportDBus(conn, name, c)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// exportDBus exports the service on conn and claims name.
+func exportDBus(conn *dbus.Conn, name string, c Controller) (*DBusService, error) {
+	if err := conn.Export(dbusMethods{c}, dbusPath, DBusInterface); err != nil {
+		return nil, err
+	}
+
+	readOnly := func(v any) *prop.Prop {
+		return &prop.Prop{Value: v, Emit: prop.EmitTrue}
+	}
+	props, err := prop.Export(conn, dbusPath, prop.Map{
+		DBusInterface: {
+			"WeeklyUtilization":   readOnly(0.0),
+			"FiveHourUtilization": readOnly(0.0),
+			"Throttled":           readOnly(false),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	node := &introspect.Node{
+		Name: string(dbusPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{
+				Name:       DBusInterface,
+				Methods:    []introspect.Method{{Name: "Refresh"}},
+				Properties: props.Introspection(DBusInterface),
+			},
+		},
+	}
+	if err := conn.Export(introspect.NewIntrospectable(node), dbusPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.RequestName(name, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return nil, err
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return nil, fmt.Errorf("%s is already owned by another process", name)
+	}
+	return &DBusService{conn: conn, props: props}, nil
+}
+
+// Update publishes weeklyStats, emitting PropertiesChanged for each
+// property whose value changed. Without API data the utilizations are zero.
+func (s *DBusService) Update(weeklyStats *stats.WeeklyStats) {
+	if s == nil || weeklyStats == nil {
+		return
+	}
+	var fiveHour, weekly float64
+	if weeklyStats.HasAPIData {
+		fiveHour = weeklyStats.FiveHourUtilization
+		weekly = weeklyStats.WeeklyUtilization
+	}
+	s.set("WeeklyUtilization", weekly)
+	s.set("FiveHourUtilization", fiveHour)
+	s.set("Throttled", weeklyStats.IsThrottled())
+}
+
+// set updates a property if its value changed.
+func (s *DBusService) set(property string, v any) {
+	if s.props.GetMust(DBusInterface, property) == v {
+		return
+	}
+	// SetMust panics when the signal can't be sent, e.g. after the bus
+	// went away; the property itself is updated either way
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Warning: could not announce D-Bus property %s: %v", property, r)
+		}
+	}()
+	s.props.SetMust(DBusInterface, property, v)
+}
+
+// Close closes the connection, releasing the bus name.
+func (s *DBusService) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
@@ -0,0 +1,55 @@
+package control
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+
+	"claude-usage/internal/stats"
+)
+
+func TestDBusService(t *testing.T) {
+	client, err := dbus.ConnectSessionBus()
+	if err != nil {
+		t.Skipf("no session bus: %v", err)
+	}
+	defer client.Close()
+
+	c := &fakeController{}
+	name := DBusName(fmt.Sprintf("test%d", os.Getpid()))
+	s, err := ExportDBus(name, c)
+	if err != nil {
+		t.Fatalf("ExportDBus: %v", err)
+	}
+	defer s.Close()
+
+	if _, err := ExportDBus(name, c); err == nil {
+		t.Error("expected a second export of the same name to fail")
+	}
+
+	obj := client.Object(name, dbusPath)
+	if err := obj.Call(DBusInterface+".Refresh", 0).Err; err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if n := c.refreshes.Load(); n != 1 {
+		t.Errorf("refreshes = %d, want 1", n)
+	}
+
+	s.Update(&stats.WeeklyStats{HasAPIData: true, WeeklyUtilization: 0.42, FiveHourUtilization: 0.1, RateLimitStatus: "throttled"})
+	var weekly float64
+	if err := obj.StoreProperty(DBusInterface+".WeeklyUtilization", &weekly); err != nil {
+		t.Fatalf("WeeklyUtilization: %v", err)
+	}
+	if weekly != 0.42 {
+		t.Errorf("WeeklyUtilization = %v, want 0.42", weekly)
+	}
+	var throttled bool
+	if err := obj.StoreProperty(DBusInterface+".Throttled", &throttled); err != nil {
+		t.Fatalf("Throttled: %v", err)
+	}
+	if !throttled {
+		t.Error("Throttled = false, want true")
+	}
+}
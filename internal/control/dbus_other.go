@@ -0,0 +1,21 @@
+//go:build !linux
+
+package control
+
+import "claude-usage/internal/stats"
+
+// DBusService is only available on Linux. A nil DBusService does nothing.
+type DBusService struct{}
+
+// ExportDBus does nothing outside Linux.
+func ExportDBus(name string, c Controller) (*DBusService, error) {
+	return nil, nil
+}
+
+// Update does nothing outside Linux.
+func (s *DBusService) Update(weeklyStats *stats.WeeklyStats) {}
+
+// Close does nothing outside Linux.
+func (s *DBusService) Close() error {
+	return nil
+}
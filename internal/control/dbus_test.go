@@ -0,0 +1,17 @@
+package control
+
+import "testing"
+
+func TestDBusName(t *testing.T) {
+	tests := map[string]string{
+		"":         "org.claudeusage.Monitor",
+		"work":     "org.claudeusage.Monitor.work",
+		"my.corp":  "org.claudeusage.Monitor.my_corp",
+		"2nd-acct": "org.claudeusage.Monitor._2nd-acct",
+	}
+	for profile, want := range tests {
+		if got := DBusName(profile); got != want {
+			t.Errorf("DBusName(%q) = %q, want %q", profile, got, want)
+		}
+	}
+}
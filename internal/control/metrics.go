@@ -0,0 +1,45 @@
+package control
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"claude-usage/internal/history"
+	"claude-usage/internal/status"
+)
+
+// serveMetrics writes the status and API fetch statistics in the
+// Prometheus text exposition format.
+func serveMetrics(w http.ResponseWriter, s status.Status, f history.FetchSummary) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	gauge(w, "claude_usage_weekly_percent", "Weekly window usage in percent.", float64(s.WeeklyPercent))
+	gauge(w, "claude_usage_five_hour_percent", "5-hour window usage in percent.", float64(s.FiveHourPercent))
+	gauge(w, "claude_usage_throttled", "1 while requests are throttled.", boolValue(s.Throttled))
+	gauge(w, "claude_usage_estimated", "1 while usage is estimated from local stats.", boolValue(s.Estimated))
+	gauge(w, "claude_usage_tokens", "Tokens used this week.", float64(s.Tokens))
+
+	gauge(w, "claude_usage_api_fetches_last_hour", "API fetches in the last hour.", float64(f.Fetches))
+	gauge(w, "claude_usage_api_errors_last_hour", "Failed API fetches in the last hour.", float64(f.Errors))
+	gauge(w, "claude_usage_api_network_errors_last_hour", "API fetches in the last hour that never reached the API.", float64(f.NetworkErrors))
+	if f.Last != nil {
+		gauge(w, "claude_usage_api_last_fetch_seconds", "Duration of the last API fetch, including retries.", f.Last.Latency().Seconds())
+		gauge(w, "claude_usage_api_last_fetch_success", "1 if the last API fetch succeeded.", boolValue(f.Last.OK))
+		gauge(w, "claude_usage_api_last_fetch_timestamp_seconds", "Unix time of the last API fetch.", float64(f.Last.At.Unix()))
+	}
+}
+
+// gauge writes one gauge with its help text.
+func gauge(w io.Writer, name, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", name, help, name, name, value)
+}
+
+// boolValue returns 1 for true and 0 for false.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,216 @@
+// Package control serves a small REST API, on the loopback interface by
+// default, so scripts and Stream Deck buttons can read usage and trigger
+// actions:
+//
+//	GET  /             current usage as a small HTML dashboard
+//	GET  /chart.png    usage history as a PNG chart (see package chart)
+//	GET  /status       current usage as JSON (see package status)
+//	GET  /metrics      usage and API fetch statistics for Prometheus
+//	GET  /rate-limits  the last API response, read by api.RemoteProvider
+//	POST /refresh      refresh now
+//	POST /pause        pause automatic refreshes
+//	POST /resume       resume automatic refreshes
+//
+// Every request must carry the local token, either as
+// "Authorization: Bearer <token>" or as a token query parameter.
+//
+// On Linux, usage and Refresh are also exported on the D-Bus session bus
+// (see DBusService).
+package control
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"claude-usage/internal/api"
+	"claude-usage/internal/chart"
+	"claude-usage/internal/history"
+	"claude-usage/internal/redact"
+	"claude-usage/internal/status"
+)
+
+// Controller is the part of the app driven by the API. Its methods are
+// called from HTTP handlers and godbus's goroutines, so must be safe for
+// concurrent use.
+type Controller interface {
+	Status() status.Status
+	Fetches() history.FetchSummary
+
+	// RateLimits returns the last successful API response, or nil before
+	// the first
+	RateLimits() *api.RateLimitData
+
+	// Samples returns the usage samples recorded since since
+	Samples(since time.Time) ([]history.Sample, error)
+
+	// Refresh asks the refresh loop for a refresh and returns without
+	// waiting for it
+	Refresh()
+	SetPaused(paused bool)
+}
+
+// Server is a running control API.
+type Server struct {
+	srv *http.Server
+}
+
+// Listen starts the API on host:port in the background.
+func Listen(host string, port int, token string, c Controller) (*Server, error) {
+	ln, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{
+		Handler:           Handler(token, c),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Warning: control API stopped: %v", err)
+		}
+	}()
+	return &Server{srv: srv}, nil
+}
+
+// Close stops the API. A nil Server does nothing.
+func (s *Server) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.srv.Close()
+}
+
+// Handler returns the API handler, rejecting requests without token.
+func Handler(token string, c Controller) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		serveDashboard(w, c.Status(), token)
+	})
+	mux.HandleFunc("GET /chart.png", func(w http.ResponseWriter, r *http.Request) {
+		serveChart(w, r, c)
+	})
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		writeStatus(w, http.StatusOK, c.Status())
+	})
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		serveMetrics(w, c.Status(), c.Fetches())
+	})
+	mux.HandleFunc("GET /rate-limits", func(w http.ResponseWriter, r *http.Request) {
+		data := c.RateLimits()
+		if data == nil {
+			http.Error(w, "no rate limits fetched yet", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(data)
+	})
+	mux.HandleFunc("POST /refresh", func(w http.ResponseWriter, r *http.Request) {
+		c.Refresh()
+		writeStatus(w, http.StatusAccepted, c.Status())
+	})
+	mux.HandleFunc("POST /pause", func(w http.ResponseWriter, r *http.Request) {
+		c.SetPaused(true)
+		writeStatus(w, http.StatusOK, c.Status())
+	})
+	mux.HandleFunc("POST /resume", func(w http.ResponseWriter, r *http.Request) {
+		c.SetPaused(false)
+		writeStatus(w, http.StatusOK, c.Status())
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r, token) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// authorized reports whether r carries token.
+func authorized(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		got = r.URL.Query().Get("token")
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// writeStatus writes s as the JSON response body.
+func writeStatus(w http.ResponseWriter, code int, s status.Status) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(s)
+}
+
+// LoadOrCreateToken returns the token stored at path, generating and saving
+// a random one on first use. Only the current user can read the file. The
+// token is redacted from logs.
+func LoadOrCreateToken(path string) (string, error) {
+	if b, err := os.ReadFile(path); err == nil {
+		if token := strings.TrimSpace(string(b)); token != "" {
+			redact.Secret(token)
+			return token, nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+	redact.Secret(token)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// chartDays is the period of /chart.png unless the days parameter is set.
+const chartDays = 7
+
+// serveChart writes a chart of the usage samples of the last chartDays, or
+// of the days query parameter (1 to the sample retention), as a PNG image.
+func serveChart(w http.ResponseWriter, r *http.Request, c Controller) {
+	days := chartDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > int(history.SampleRetention/(24*time.Hour)) {
+			http.Error(w, "invalid days", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+	now := time.Now()
+	from := now.AddDate(0, 0, -days)
+	samples, err := c.Samples(from)
+	if err != nil {
+		http.Error(w, "could not read usage samples", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	chart.WritePNG(w, samples, chart.Options{From: from, To: now})
+}
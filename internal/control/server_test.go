@@ -0,0 +1,188 @@
+package control
+
+import (
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"claude-usage/internal/api"
+	"claude-usage/internal/history"
+	"claude-usage/internal/status"
+)
+
+// fakeController records the calls made by the API. Refresh is counted
+// atomically, as D-Bus calls it from godbus's goroutines.
+type fakeController struct {
+	refreshes  atomic.Int32
+	paused     bool
+	rateLimits *api.RateLimitData
+}
+
+func (f *fakeController) Status() status.Status {
+	return status.Status{WeeklyPercent: 42, Paused: f.paused}
+}
+
+func (f *fakeController) Fetches() history.FetchSummary {
+	return history.FetchSummary{
+		Last:    &history.Fetch{At: time.Unix(1700000000, 0), LatencyMS: 320, OK: true},
+		Fetches: 12,
+		Errors:  2,
+	}
+}
+
+func (f *fakeController) RateLimits() *api.RateLimitData { return f.rateLimits }
+
+func (f *fakeController) Samples(since time.Time) ([]history.Sample, error) {
+	return []history.Sample{
+		{At: since.Add(time.Hour), WeeklyUtilization: 0.3},
+		{At: since.Add(2 * time.Hour), WeeklyUtilization: 0.4},
+	}, nil
+}
+
+func (f *fakeController) Refresh() { f.refreshes.Add(1) }
+
+func (f *fakeController) SetPaused(paused bool) { f.paused = paused }
+
+func serve(h http.Handler, method, target, auth string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler_RequiresToken(t *testing.T) {
+	c := &fakeController{}
+	h := Handler("secret", c)
+
+	for _, tc := range []struct {
+		name, target, auth string
+		want               int
+	}{
+		{"no token", "/status", "", http.StatusUnauthorized},
+		{"wrong token", "/status", "Bearer nope", http.StatusUnauthorized},
+		{"bearer", "/status", "Bearer secret", http.StatusOK},
+		{"query", "/status?token=secret", "", http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if rec := serve(h, http.MethodGet, tc.target, tc.auth); rec.Code != tc.want {
+				t.Errorf("status = %d, want %d", rec.Code, tc.want)
+			}
+		})
+	}
+
+	// An empty token must never match
+	if rec := serve(Handler("", c), http.MethodGet, "/status", "Bearer "); rec.Code != http.StatusUnauthorized {
+		t.Errorf("empty token: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestHandler_Actions(t *testing.T) {
+	c := &fakeController{}
+	h := Handler("secret", c)
+
+	rec := serve(h, http.MethodGet, "/status", "Bearer secret")
+	var s status.Status
+	if err := json.Unmarshal(rec.Body.Bytes(), &s); err != nil {
+		t.Fatalf("decoding status: %v", err)
+	}
+	if s.WeeklyPercent != 42 {
+		t.Errorf("weekly_percent = %d, want 42", s.WeeklyPercent)
+	}
+
+	if rec := serve(h, http.MethodPost, "/refresh", "Bearer secret"); rec.Code != http.StatusAccepted || c.refreshes.Load() != 1 {
+		t.Errorf("refresh: status = %d, refreshes = %d", rec.Code, c.refreshes.Load())
+	}
+	if rec := serve(h, http.MethodGet, "/refresh", "Bearer secret"); rec.Code != http.StatusMethodNotAllowed || c.refreshes.Load() != 1 {
+		t.Errorf("GET refresh: status = %d, refreshes = %d", rec.Code, c.refreshes.Load())
+	}
+
+	rec = serve(h, http.MethodGet, "/?token=secret", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "42%") {
+		t.Errorf("dashboard: status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `src="chart.png?token=secret"`) {
+		t.Errorf("dashboard: no chart in %q", rec.Body.String())
+	}
+
+	rec = serve(h, http.MethodGet, "/chart.png?days=30", "Bearer secret")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("chart: status = %d, content type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if _, err := png.Decode(rec.Body); err != nil {
+		t.Errorf("chart: not a PNG: %v", err)
+	}
+	if rec := serve(h, http.MethodGet, "/chart.png?days=0", "Bearer secret"); rec.Code != http.StatusBadRequest {
+		t.Errorf("chart with days=0: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	rec = serve(h, http.MethodGet, "/metrics", "Bearer secret")
+	for _, want := range []string{
+		"claude_usage_weekly_percent 42\n",
+		"claude_usage_api_errors_last_hour 2\n",
+		"claude_usage_api_last_fetch_seconds 0.32\n",
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("metrics: missing %q in %q", want, rec.Body.String())
+		}
+	}
+
+	if rec := serve(h, http.MethodGet, "/rate-limits", "Bearer secret"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("rate limits before a fetch: status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	c.rateLimits = &api.RateLimitData{WeeklyUtilization: 0.42, FetchedAt: time.Unix(1700000000, 0)}
+	rec = serve(h, http.MethodGet, "/rate-limits", "Bearer secret")
+	var data api.RateLimitData
+	if err := json.Unmarshal(rec.Body.Bytes(), &data); err != nil || data.WeeklyUtilization != 0.42 {
+		t.Errorf("rate limits: status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+
+	serve(h, http.MethodPost, "/pause", "Bearer secret")
+	if !c.paused {
+		t.Error("pause did not pause")
+	}
+	serve(h, http.MethodPost, "/resume", "Bearer secret")
+	if c.paused {
+		t.Error("resume did not resume")
+	}
+}
+
+func TestLoadOrCreateToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "control-token")
+
+	first, err := LoadOrCreateToken(path)
+	if err != nil {
+		t.Fatalf("LoadOrCreateToken: %v", err)
+	}
+	if len(first) != 64 {
+		t.Errorf("token length = %d, want 64", len(first))
+	}
+	second, err := LoadOrCreateToken(path)
+	if err != nil {
+		t.Fatalf("LoadOrCreateToken: %v", err)
+	}
+	if second != first {
+		t.Error("token changed between loads")
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("permissions = %o, want 600", perm)
+	}
+}
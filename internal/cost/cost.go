@@ -0,0 +1,191 @@
+// Package cost estimates what token usage would cost at API prices, from
+// the per-day token counts in Claude Code's stats cache.
+//
+// The stats cache only records a daily total per model, not the split
+// between input, output and cache tokens. Each model's all-time usage
+// (ModelUsage) supplies that mix: its cost divided by its input and output
+// tokens gives a blended price per daily token, which also accounts for
+// cache reads and writes. Session transcripts record the exact mix per
+// message and are priced directly where available.
+package cost
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/stats"
+	"claude-usage/internal/transcripts"
+)
+
+// DefaultPricing holds API prices in USD per million tokens, keyed by a
+// substring of the model ID. The longest matching key wins, so
+// "opus-4-5" takes precedence over "opus-4".
+var DefaultPricing = map[string]config.ModelPrice{
+	"opus-4-5":   {Input: 5, Output: 25, CacheRead: 0.5, CacheWrite: 6.25},
+	"opus-4":     {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+	"sonnet-4":   {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"haiku-4-5":  {Input: 1, Output: 5, CacheRead: 0.1, CacheWrite: 1.25},
+	"3-7-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"3-5-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"3-5-haiku":  {Input: 0.8, Output: 4, CacheRead: 0.08, CacheWrite: 1},
+	"3-opus":     {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+	"3-haiku":    {Input: 0.25, Output: 1.25, CacheRead: 0.03, CacheWrite: 0.3},
+}
+
+// Pricing returns DefaultPricing with overrides (from config) applied.
+func Pricing(overrides map[string]config.ModelPrice) map[string]config.ModelPrice {
+	pricing := make(map[string]config.ModelPrice, len(DefaultPricing)+len(overrides))
+	for k, v := range DefaultPricing {
+		pricing[k] = v
+	}
+	for k, v := range overrides {
+		pricing[k] = v
+	}
+	return pricing
+}
+
+// PriceFor returns the price for a model ID: an exact key match, otherwise
+// the longest key contained in the ID.
+func PriceFor(pricing map[string]config.ModelPrice, model string) (config.ModelPrice, bool) {
+	if p, ok := pricing[model]; ok {
+		return p, true
+	}
+
+	keys := make([]string, 0, len(pricing))
+	for k := range pricing {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	for _, k := range keys {
+		if strings.Contains(model, k) {
+			return pricing[k], true
+		}
+	}
+	return config.ModelPrice{}, false
+}
+
+// tokenRate returns the estimated USD cost of one daily token for a model.
+func tokenRate(cache *stats.StatsCache, pricing map[string]config.ModelPrice, model string) float64 {
+	price, known := PriceFor(pricing, model)
+
+	if usage, ok := cache.ModelUsage[model]; ok {
+		if tokens := usage.InputTokens + usage.OutputTokens; tokens > 0 {
+			// Prefer the cost Claude Code recorded; it is zero for
+			// subscription plans, so fall back to list prices
+			total := usage.CostUSD
+			if total == 0 && known {
+				total = (float64(usage.InputTokens)*price.Input +
+					float64(usage.OutputTokens)*price.Output +
+					float64(usage.CacheReadInputTokens)*price.CacheRead +
+					float64(usage.CacheCreationInputTokens)*price.CacheWrite) / 1e6
+			}
+			return total / float64(tokens)
+		}
+	}
+
+	// No usage mix recorded: assume an even input/output split
+	if known {
+		return (price.Input + price.Output) / 2 / 1e6
+	}
+	return 0
+}
+
+// Estimate returns the estimated cost of tokens used on days from start to
+// end inclusive. Unknown models count as free.
+func Estimate(cache *stats.StatsCache, pricing map[string]config.ModelPrice, start, end time.Time) float64 {
+	return estimateDays(cache, pricing, start, end, nil)
+}
+
+// estimateDays is Estimate, skipping the dates in skip.
+func estimateDays(cache *stats.StatsCache, pricing map[string]config.ModelPrice, start, end time.Time, skip map[string]bool) float64 {
+	if cache == nil {
+		return 0
+	}
+
+	rates := make(map[string]float64)
+	var total float64
+	for _, daily := range cache.DailyModelTokens {
+		date, err := stats.ParseDate(daily.Date)
+		if err != nil || date.Before(start) || date.After(end) || skip[daily.Date] {
+			continue
+		}
+		for model, tokens := range daily.TokensByModel {
+			rate, ok := rates[model]
+			if !ok {
+				rate = tokenRate(cache, pricing, model)
+				rates[model] = rate
+			}
+			total += float64(tokens) * rate
+		}
+	}
+	return total
+}
+
+// Apply fills in the week and month cost estimates on weeklyStats.
+func Apply(weeklyStats *stats.WeeklyStats, cache *stats.StatsCache, overrides map[string]config.ModelPrice) {
+	if weeklyStats == nil || cache == nil {
+		return
+	}
+	pricing := Pricing(overrides)
+	monthStart, monthEnd := stats.GetMonthBounds()
+	weeklyStats.WeekCostUSD = Estimate(cache, pricing, weekStartDay(weeklyStats), weeklyStats.WeekEnd)
+	weeklyStats.MonthCostUSD = Estimate(cache, pricing, monthStart, monthEnd)
+}
+
+// EntryCost returns the cost of one transcript entry: the cost Claude Code
+// recorded, otherwise its exact token mix at list prices.
+func EntryCost(pricing map[string]config.ModelPrice, e transcripts.Entry) float64 {
+	if e.CostUSD > 0 {
+		return e.CostUSD
+	}
+	price, ok := PriceFor(pricing, e.Model)
+	if !ok {
+		return 0
+	}
+	return (float64(e.Usage.InputTokens)*price.Input +
+		float64(e.Usage.OutputTokens)*price.Output +
+		float64(e.Usage.CacheReadTokens)*price.CacheRead +
+		float64(e.Usage.CacheCreationTokens)*price.CacheWrite) / 1e6
+}
+
+// EstimateEntries is Estimate for a cache merged with transcripts (see
+// transcripts.Merge). Days covered by entries are priced exactly from them;
+// the remaining days are estimated from the cache.
+func EstimateEntries(cache *stats.StatsCache, entries []transcripts.Entry, pricing map[string]config.ModelPrice, start, end time.Time) float64 {
+	covered := make(map[string]bool)
+	for _, e := range entries {
+		covered[stats.FormatDate(e.Time)] = true
+	}
+	total := estimateDays(cache, pricing, start, end, covered)
+	for _, e := range transcripts.Between(entries, start, end) {
+		total += EntryCost(pricing, e)
+	}
+	return total
+}
+
+// ApplyEntries is Apply for a cache merged with transcripts, using
+// EstimateEntries.
+func ApplyEntries(weeklyStats *stats.WeeklyStats, cache *stats.StatsCache, entries []transcripts.Entry, overrides map[string]config.ModelPrice) {
+	if weeklyStats == nil {
+		return
+	}
+	pricing := Pricing(overrides)
+	monthStart, monthEnd := stats.GetMonthBounds()
+	weeklyStats.WeekCostUSD = EstimateEntries(cache, entries, pricing, weekStartDay(weeklyStats), weeklyStats.WeekEnd)
+	weeklyStats.MonthCostUSD = EstimateEntries(cache, entries, pricing, monthStart, monthEnd)
+}
+
+// weekStartDay returns the start of the first day of the weekly window. The
+// cache only has daily totals, so a window starting mid-day counts its
+// first day in full, as stats.CalculateWindowStats does.
+func weekStartDay(weeklyStats *stats.WeeklyStats) time.Time {
+	start := weeklyStats.WeekStart.In(stats.Location())
+	return time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+}
@@ -0,0 +1,89 @@
+package cost
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/stats"
+	"claude-usage/internal/transcripts"
+)
+
+func TestPriceFor(t *testing.T) {
+	pricing := Pricing(nil)
+	tests := []struct {
+		model string
+		want  float64 // input price
+		ok    bool
+	}{
+		{"claude-opus-4-5-20251101", 5, true},
+		{"claude-opus-4-1-20250805", 15, true},
+		{"claude-sonnet-4-5-20250929", 3, true},
+		{"claude-3-5-haiku-20241022", 0.8, true},
+		{"gpt-4o", 0, false},
+	}
+	for _, tt := range tests {
+		p, ok := PriceFor(pricing, tt.model)
+		if ok != tt.ok || p.Input != tt.want {
+			t.Errorf("PriceFor(%q) = %v, %v; want input %v, %v", tt.model, p, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPricingOverrides(t *testing.T) {
+	pricing := Pricing(map[string]config.ModelPrice{"sonnet-4": {Input: 1, Output: 2}})
+	if p, _ := PriceFor(pricing, "claude-sonnet-4-5-20250929"); p.Input != 1 || p.Output != 2 {
+		t.Errorf("override not applied: %v", p)
+	}
+	if DefaultPricing["sonnet-4"].Input != 3 {
+		t.Error("Pricing modified DefaultPricing")
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	cache := &stats.StatsCache{
+		DailyModelTokens: []stats.DailyModelTokens{
+			{Date: "2026-01-05", TokensByModel: map[string]int64{"claude-sonnet-4-5-20250929": 1_000_000, "claude-opus-4-5-20251101": 2_000_000}},
+			{Date: "2026-01-06", TokensByModel: map[string]int64{"claude-sonnet-4-5-20250929": 1_000_000}},
+			{Date: "2025-12-31", TokensByModel: map[string]int64{"claude-sonnet-4-5-20250929": 9_000_000}},
+		},
+		ModelUsage: map[string]stats.ModelUsage{
+			// Recorded cost: $10 over 1M input + 1M output tokens
+			"claude-sonnet-4-5-20250929": {InputTokens: 1_000_000, OutputTokens: 1_000_000, CostUSD: 10},
+			// No recorded cost: priced from the table, 1M input + 1M cache reads
+			"claude-opus-4-5-20251101": {InputTokens: 1_000_000, CacheReadInputTokens: 1_000_000},
+		},
+	}
+
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 1, 11, 0, 0, 0, 0, time.UTC)
+
+	// Sonnet: $5/M tokens * 2M = $10; Opus: ($5 + $0.50)/M * 2M = $11
+	if got := Estimate(cache, Pricing(nil), start, end); math.Abs(got-21) > 1e-9 {
+		t.Errorf("Estimate() = %v, want 21", got)
+	}
+	if got := Estimate(nil, Pricing(nil), start, end); got != 0 {
+		t.Errorf("Estimate(nil) = %v, want 0", got)
+	}
+}
+
+func TestEntryCost(t *testing.T) {
+	pricing := Pricing(nil)
+	e := transcripts.Entry{
+		Model: "claude-sonnet-4-5",
+		Usage: transcripts.Usage{InputTokens: 1e6, OutputTokens: 1e6, CacheReadTokens: 1e6, CacheCreationTokens: 1e6},
+	}
+	if got, want := EntryCost(pricing, e), 3+15+0.3+3.75; math.Abs(got-want) > 1e-9 {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	e.CostUSD = 1.5
+	if got := EntryCost(pricing, e); got != 1.5 {
+		t.Errorf("recorded cost: got %v, want 1.5", got)
+	}
+
+	if got := EntryCost(pricing, transcripts.Entry{Model: "unknown", Usage: transcripts.Usage{InputTokens: 1e6}}); got != 0 {
+		t.Errorf("unknown model: got %v, want 0", got)
+	}
+}
@@ -0,0 +1,263 @@
+// Package doctor runs self-diagnostics for "claude-usage doctor": it checks
+// the credentials, the usage API, the stats cache and the tray backend, and
+// explains how to fix whatever is wrong.
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"claude-usage/internal/api"
+	"claude-usage/internal/config"
+	"claude-usage/internal/httpclient"
+	"claude-usage/internal/perms"
+	"claude-usage/internal/stats"
+	"claude-usage/internal/tokenstore"
+)
+
+// Status is the outcome of a check.
+type Status int
+
+const (
+	OK Status = iota
+	Warn
+	Fail
+)
+
+// String returns the label printed in front of a finding.
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return "OK"
+	case Warn:
+		return "WARN"
+	default:
+		return "FAIL"
+	}
+}
+
+// Finding is the result of a single check.
+type Finding struct {
+	Check  string
+	Status Status
+	Detail string
+
+	// Fix suggests what to do about a warning or failure.
+	Fix string
+}
+
+// apiTimeout bounds the usage API check, including retries.
+const apiTimeout = 20 * time.Second
+
+// staleStatsAge is how old the stats cache may be before it is reported.
+const staleStatsAge = 7 * 24 * time.Hour
+
+// Run performs every check against cfg and returns the findings in order.
+func Run(ctx context.Context, cfg *config.Config) []Finding {
+	var findings []Finding
+
+	for _, p := range cfg.Problems() {
+		findings = append(findings, Finding{"Config", Warn, p.Error(), "Edit " + config.GetConfigPath()})
+	}
+
+	creds, credFindings := checkCredentials(cfg)
+	findings = append(findings, credFindings...)
+	findings = append(findings, checkTokenStore(cfg, creds))
+	findings = append(findings, checkAppFiles(cfg))
+	findings = append(findings, checkAPI(ctx, cfg, creds))
+	findings = append(findings, checkStatsCache(cfg))
+	findings = append(findings, checkTray())
+
+	return findings
+}
+
+// checkCredentials checks the credentials file, or the environment's
+// tokens, and the token it holds. It returns the parsed credentials, or nil
+// if they are unusable.
+func checkCredentials(cfg *config.Config) (*stats.Credentials, []Finding) {
+	const check = "Credentials"
+	if config.UsesEnvCredentials() {
+		return checkToken(cfg, tokenstore.EnvCredentials(), config.EnvAccessToken, nil)
+	}
+	path := cfg.GetCredentialsPath()
+
+	_, err := os.Stat(path)
+	if err != nil {
+		fix := "Run 'claude' and log in, or set claude_credentials_path"
+		if !cfg.UsesOAuth() {
+			return nil, []Finding{{check, OK, "not required by the " + cfg.GetUsageProvider() + " usage provider", ""}}
+		}
+		return nil, []Finding{{check, Fail, err.Error(), fix}}
+	}
+
+	var findings []Finding
+	if warning := perms.CheckCredentials(path); warning != "" {
+		findings = append(findings, Finding{check, Warn, warning, "chmod 600 " + path})
+	}
+
+	var creds *stats.Credentials
+	if cfg.IsOpenCode() {
+		creds, err = stats.ParseOpenCodeCredentials(path)
+	} else {
+		creds, err = stats.ParseCredentials(path)
+	}
+	if err != nil {
+		return nil, append(findings, Finding{check, Fail, fmt.Sprintf("%s: %v", path, err), "Log in again to recreate the file"})
+	}
+	return checkToken(cfg, creds, path, findings)
+}
+
+// checkToken checks the token of creds, read from source, after the
+// findings so far.
+func checkToken(cfg *config.Config, creds *stats.Credentials, source string, findings []Finding) (*stats.Credentials, []Finding) {
+	oauth := creds.ClaudeAiOauth
+	switch {
+	case oauth.AccessToken == "":
+		return nil, append(findings, Finding{"Token", Fail, "no access token in " + source, "Log in again to get a new token"})
+	case oauth.ExpiresAt > 0 && time.Now().After(time.UnixMilli(oauth.ExpiresAt)):
+		if oauth.RefreshToken == "" {
+			findings = append(findings, Finding{"Token", Fail, "access token expired and there is no refresh token", "Log in again to get a new token"})
+		} else {
+			findings = append(findings, Finding{"Token", Warn,
+				"access token expired " + time.UnixMilli(oauth.ExpiresAt).Format(time.RFC1123) + "; it will be refreshed on the next fetch", ""})
+		}
+	default:
+		detail := "access token present"
+		if oauth.ExpiresAt > 0 {
+			detail += ", expires " + time.UnixMilli(oauth.ExpiresAt).Format(time.RFC1123)
+		}
+		findings = append(findings, Finding{"Token", OK, detail, ""})
+	}
+	if missing := oauth.MissingScopes(api.RequiredScopes...); len(missing) > 0 && cfg.UsesOAuth() {
+		findings = append(findings, Finding{"Token", Fail,
+			"missing scope " + strings.Join(missing, ", ") + " (has " + strings.Join(oauth.Scopes, ", ") + ")",
+			"Log in again to get a token with the usage scope"})
+	}
+
+	return creds, append([]Finding{{"Credentials", OK, source, ""}}, findings...)
+}
+
+// checkTokenStore reports where rotated tokens are kept. With
+// read_only_credentials or environment credentials, tokens from the token
+// store replace those of creds.
+func checkTokenStore(cfg *config.Config, creds *stats.Credentials) Finding {
+	const check = "Token store"
+	if !cfg.UsesTokenStore() {
+		return Finding{check, OK, "rotated tokens are written to " + cfg.GetCredentialsPath(), ""}
+	}
+
+	mode, source := "read-only credentials", "the credentials file's"
+	if config.UsesEnvCredentials() {
+		mode, source = "environment credentials", "the environment's"
+	}
+	path := config.GetTokenStorePath(cfg.Profile)
+	key := tokenstore.KeyFor(cfg)
+	stored, err := tokenstore.Load(path, key)
+	switch {
+	case err != nil:
+		return Finding{check, Fail, err.Error(),
+			"Delete " + path + "; " + source + " tokens are used until the next rotation"}
+	case stored == nil:
+		return Finding{check, OK, mode + "; no rotated tokens in " + path + " yet (" + key.String() + ")", ""}
+	case creds != nil && !stored.Apply(creds):
+		return Finding{check, OK, mode + "; " + path + " predates the current tokens, so " + source + " tokens are used", ""}
+	}
+	return Finding{check, OK, mode + "; using tokens rotated " +
+		stored.SavedAt.Format(time.RFC1123) + " from " + path + " (" + key.String() + ")", ""}
+}
+
+// checkAppFiles reports app files other users can access. They are only
+// reported here; the app restricts them when it starts.
+func checkAppFiles(cfg *config.Config) Finding {
+	const check = "App files"
+	var exposed []string
+	for _, path := range perms.AppFiles(cfg.Profile) {
+		if _, ok := perms.Exposed(path); ok {
+			exposed = append(exposed, path)
+		}
+	}
+	if len(exposed) == 0 {
+		return Finding{check, OK, "only readable by you", ""}
+	}
+	return Finding{check, Warn, "readable by other users: " + strings.Join(exposed, ", "),
+		"chmod 600 " + strings.Join(exposed, " ")}
+}
+
+// checkAPI fetches rate limits once. The token is not refreshed, since a
+// rotated refresh token would have to be written back to the credentials file.
+func checkAPI(ctx context.Context, cfg *config.Config, creds *stats.Credentials) Finding {
+	const check = "Usage API"
+	if !cfg.UsesOAuth() {
+		return Finding{check, OK, "skipped, using the " + cfg.GetUsageProvider() + " usage provider", ""}
+	}
+	if creds == nil {
+		return Finding{check, Warn, "skipped, no usable credentials", ""}
+	}
+
+	if err := httpclient.Configure(cfg.ProxyURL, cfg.CABundlePath); err != nil {
+		return Finding{check, Fail, err.Error(), "Check proxy_url and ca_bundle_path in " + config.GetConfigPath()}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	start := time.Now()
+	data, err := api.NewClient(creds.ClaudeAiOauth.AccessToken).FetchRateLimits(ctx)
+	if err != nil {
+		var statusErr *api.StatusError
+		var scopeErr *api.ScopeError
+		switch {
+		case errors.As(err, &scopeErr):
+			return Finding{check, Fail, err.Error(), "Log in again to get a token with the " + scopeErr.Scope + " scope"}
+		case errors.As(err, &statusErr) && statusErr.StatusCode == 403:
+			return Finding{check, Fail, err.Error(), "The token lacks the usage scope; log in again"}
+		case errors.Is(err, context.DeadlineExceeded):
+			return Finding{check, Fail, "no response within " + apiTimeout.String(), "Check your network, or set proxy_url if you are behind a proxy"}
+		default:
+			return Finding{check, Fail, err.Error(), "Check your network and proxy settings; if the token expired, run 'claude' once"}
+		}
+	}
+	return Finding{check, OK, fmt.Sprintf("reachable in %s: 5h %d%%, weekly %d%%",
+		time.Since(start).Round(time.Millisecond), data.GetFiveHourPercentage(), data.GetWeeklyPercentage()), ""}
+}
+
+// checkStatsCache checks that the stats cache exists and is recent. It is
+// optional, so problems are only warnings.
+func checkStatsCache(cfg *config.Config) Finding {
+	const check = "Stats cache"
+	path := cfg.GetStatsPath()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Finding{check, Warn, err.Error(), "Only needed for estimates when the API is unavailable; run 'claude' once to create it"}
+	}
+	if _, err := stats.ParseStatsCache(path); err != nil {
+		return Finding{check, Warn, fmt.Sprintf("%s: %v", path, err), "Delete it and let Claude Code recreate it"}
+	}
+	age := time.Since(info.ModTime())
+	if age > staleStatsAge {
+		return Finding{check, Warn, fmt.Sprintf("%s was last updated %s ago", path, age.Round(time.Hour)), "Estimates will be out of date until Claude Code is used again"}
+	}
+	return Finding{check, OK, fmt.Sprintf("%s (updated %s ago)", path, age.Round(time.Minute)), ""}
+}
+
+// Print writes findings to w, one per line with fixes indented below, and
+// returns the number of failures.
+func Print(w io.Writer, findings []Finding) int {
+	failures := 0
+	for _, f := range findings {
+		fmt.Fprintf(w, "[%-4s] %s: %s\n", f.Status, f.Check, f.Detail)
+		if f.Fix != "" && f.Status != OK {
+			fmt.Fprintf(w, "       -> %s\n", f.Fix)
+		}
+		if f.Status == Fail {
+			failures++
+		}
+	}
+	return failures
+}
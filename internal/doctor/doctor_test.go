@@ -0,0 +1,99 @@
+package doctor
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"claude-usage/internal/config"
+)
+
+func writeCredentials(t *testing.T, body string, mode os.FileMode) *config.Config {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".credentials.json")
+	if err := os.WriteFile(path, []byte(body), mode); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+	cfg.Source = config.SourceClaude
+	cfg.ClaudeCredentialsPath = path
+	return cfg
+}
+
+func statuses(findings []Finding) map[string]Status {
+	m := make(map[string]Status)
+	for _, f := range findings {
+		if s, ok := m[f.Check]; !ok || f.Status > s {
+			m[f.Check] = f.Status
+		}
+	}
+	return m
+}
+
+func TestCheckCredentials(t *testing.T) {
+	future := time.Now().Add(time.Hour).UnixMilli()
+	past := time.Now().Add(-time.Hour).UnixMilli()
+
+	tests := []struct {
+		name       string
+		body       string
+		wantCreds  Status
+		wantToken  Status
+		wantParsed bool
+	}{
+		{"valid", `{"claudeAiOauth":{"accessToken":"a","refreshToken":"r","expiresAt":` + strconv.FormatInt(future, 10) + `}}`, OK, OK, true},
+		{"expired with refresh", `{"claudeAiOauth":{"accessToken":"a","refreshToken":"r","expiresAt":` + strconv.FormatInt(past, 10) + `}}`, OK, Warn, true},
+		{"expired without refresh", `{"claudeAiOauth":{"accessToken":"a","expiresAt":` + strconv.FormatInt(past, 10) + `}}`, OK, Fail, true},
+		{"no token", `{"claudeAiOauth":{}}`, -1, Fail, false},
+		{"invalid json", `{`, Fail, -1, false},
+	}
+
+	for _, tt := range tests {
+		cfg := writeCredentials(t, tt.body, 0600)
+		creds, findings := checkCredentials(cfg)
+		got := statuses(findings)
+		if tt.wantCreds >= 0 && got["Credentials"] != tt.wantCreds {
+			t.Errorf("%s: Credentials = %v, want %v (%v)", tt.name, got["Credentials"], tt.wantCreds, findings)
+		}
+		if tt.wantToken >= 0 && got["Token"] != tt.wantToken {
+			t.Errorf("%s: Token = %v, want %v (%v)", tt.name, got["Token"], tt.wantToken, findings)
+		}
+		if (creds != nil) != tt.wantParsed {
+			t.Errorf("%s: parsed credentials = %v, want %v", tt.name, creds != nil, tt.wantParsed)
+		}
+	}
+}
+
+func TestCheckCredentialsMissing(t *testing.T) {
+	cfg := config.Default()
+	cfg.Source = config.SourceClaude
+	cfg.ClaudeCredentialsPath = filepath.Join(t.TempDir(), "missing.json")
+
+	if _, findings := checkCredentials(cfg); statuses(findings)["Credentials"] != Fail {
+		t.Errorf("missing file: %v, want a failure", findings)
+	}
+
+	cfg.UsageProvider = config.ProviderDemo
+	if _, findings := checkCredentials(cfg); statuses(findings)["Credentials"] != OK {
+		t.Errorf("missing file with demo provider: %v, want OK", findings)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	var buf bytes.Buffer
+	failures := Print(&buf, []Finding{
+		{"Credentials", OK, "/path", ""},
+		{"Usage API", Fail, "timeout", "Check your network"},
+	})
+	if failures != 1 {
+		t.Errorf("Print() = %d failures, want 1", failures)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "[OK  ] Credentials: /path") || !strings.Contains(out, "-> Check your network") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
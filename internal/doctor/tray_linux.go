@@ -0,0 +1,25 @@
+package doctor
+
+import (
+	"os"
+
+	"claude-usage/internal/tray"
+)
+
+// checkTray checks that the desktop has a StatusNotifier host.
+func checkTray() Finding {
+	const check = "Tray"
+	fix := "On GNOME, install the AppIndicator and KStatusNotifierItem Support extension"
+
+	hasHost, err := tray.HasStatusNotifierHost()
+	if err != nil {
+		if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+			return Finding{check, Warn, "no D-Bus session bus (not in a desktop session?)", "Run from a desktop session to show the tray icon"}
+		}
+		return Finding{check, Fail, err.Error(), fix}
+	}
+	if !hasHost {
+		return Finding{check, Fail, "no StatusNotifier host is running; the icon will not be shown", fix}
+	}
+	return Finding{check, OK, "StatusNotifier host available", ""}
+}
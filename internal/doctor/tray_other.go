@@ -0,0 +1,8 @@
+//go:build !linux
+
+package doctor
+
+// checkTray reports the tray backend. Windows and macOS always have one.
+func checkTray() Finding {
+	return Finding{"Tray", OK, "built-in system tray", ""}
+}
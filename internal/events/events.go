@@ -0,0 +1,132 @@
+// Package events is a small publish/subscribe bus between the app's
+// subsystems. The refresh loop, the update checker and the config watcher
+// publish; the tray, notifiers, integrations and history subscribe, so a
+// new integration needs a subscription rather than another callback
+// threaded through the app.
+package events
+
+import (
+	"log"
+	"slices"
+	"sync"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/integrations"
+	"claude-usage/internal/stats"
+)
+
+// Kind identifies what happened.
+type Kind int
+
+// Event kinds.
+const (
+	StatsUpdated     Kind = iota + 1 // A refresh completed; Stats is set
+	Throttled                        // Throttling started or ended; Usage is set
+	ThresholdCrossed                 // A window or extra usage spend crossed an alert threshold; Usage is set
+	WindowReset                      // A usage window reset; Usage is set
+	UpdateAvailable                  // A newer release was found; Version and Summary are set
+	ConfigChanged                    // The config was reloaded; Config and Previous are set
+	Redraw                           // The latest stats should be shown again, as display settings changed; nothing is set
+)
+
+// String returns the kind's name for log messages.
+func (k Kind) String() string {
+	switch k {
+	case StatsUpdated:
+		return "StatsUpdated"
+	case Throttled:
+		return "Throttled"
+	case ThresholdCrossed:
+		return "ThresholdCrossed"
+	case WindowReset:
+		return "WindowReset"
+	case UpdateAvailable:
+		return "UpdateAvailable"
+	case ConfigChanged:
+		return "ConfigChanged"
+	case Redraw:
+		return "Redraw"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event is a message on the bus. Which fields are set depends on Kind.
+// Subscribers must not modify what the fields point to.
+type Event struct {
+	Kind Kind
+
+	// Stats is the refreshed usage. Cached is set when it holds the
+	// cached rate limits shown while the first fetch is in flight;
+	// subscribers acting on changes in usage should skip it.
+	Stats  *stats.WeeklyStats
+	Cached bool
+
+	// Usage describes a Throttled, ThresholdCrossed or WindowReset event
+	Usage integrations.Event
+
+	// Version and Summary describe the release of an UpdateAvailable event
+	Version string
+	Summary string
+
+	// Config is the reloaded config and Previous the one it replaced
+	Config   *config.Config
+	Previous *config.Config
+}
+
+// FromUsage wraps an integration event in a bus event of the matching kind.
+func FromUsage(e integrations.Event) Event {
+	kind := WindowReset
+	switch e.Kind {
+	case integrations.KindThreshold, integrations.KindSpend:
+		kind = ThresholdCrossed
+	case integrations.KindThrottled, integrations.KindUnthrottled:
+		kind = Throttled
+	}
+	return Event{Kind: kind, Usage: e}
+}
+
+// queueSize is how many events a subscriber may fall behind by before
+// further events are dropped for it.
+const queueSize = 32
+
+// subscription is one subscriber's queue and the kinds it wants.
+type subscription struct {
+	kinds []Kind
+	ch    chan Event
+}
+
+// Bus delivers published events to subscribers. The zero value is ready
+// to use; it is safe for concurrent use.
+type Bus struct {
+	mu   sync.Mutex
+	subs []subscription
+}
+
+// Subscribe returns a channel receiving the events of the given kinds
+// published from now on.
+func (b *Bus) Subscribe(kinds ...Kind) <-chan Event {
+	ch := make(chan Event, queueSize)
+	b.mu.Lock()
+	b.subs = append(b.subs, subscription{kinds: kinds, ch: ch})
+	b.mu.Unlock()
+	return ch
+}
+
+// Publish hands e to every subscriber of its kind. It never blocks: a
+// subscriber that has fallen queueSize events behind misses e.
+func (b *Bus) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, sub := range b.subs {
+		if !slices.Contains(sub.kinds, e.Kind) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			log.Printf("Warning: dropping %s event for a subscriber that is falling behind", e.Kind)
+		}
+	}
+}
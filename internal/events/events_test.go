@@ -0,0 +1,55 @@
+package events
+
+import (
+	"testing"
+
+	"claude-usage/internal/integrations"
+)
+
+func TestBus_DeliversSubscribedKinds(t *testing.T) {
+	var b Bus
+	usage := b.Subscribe(ThresholdCrossed, Throttled)
+	updates := b.Subscribe(UpdateAvailable)
+
+	b.Publish(FromUsage(integrations.Event{Kind: integrations.KindThreshold, Threshold: 80}))
+	b.Publish(Event{Kind: UpdateAvailable, Version: "v1.2.0"})
+	b.Publish(Event{Kind: StatsUpdated})
+
+	if e := <-usage; e.Kind != ThresholdCrossed || e.Usage.Threshold != 80 {
+		t.Errorf("usage subscriber got %v %+v", e.Kind, e.Usage)
+	}
+	if e := <-updates; e.Version != "v1.2.0" {
+		t.Errorf("update subscriber got %+v", e)
+	}
+	for name, ch := range map[string]<-chan Event{"usage": usage, "updates": updates} {
+		select {
+		case e := <-ch:
+			t.Errorf("%s subscriber got unsubscribed %v event", name, e.Kind)
+		default:
+		}
+	}
+}
+
+func TestBus_PublishNeverBlocks(t *testing.T) {
+	var b Bus
+	ch := b.Subscribe(StatsUpdated)
+	for i := 0; i < queueSize+5; i++ {
+		b.Publish(Event{Kind: StatsUpdated})
+	}
+	if len(ch) != queueSize {
+		t.Errorf("queued %d events, want %d", len(ch), queueSize)
+	}
+}
+
+func TestFromUsage(t *testing.T) {
+	for kind, want := range map[integrations.Kind]Kind{
+		integrations.KindThreshold:   ThresholdCrossed,
+		integrations.KindThrottled:   Throttled,
+		integrations.KindUnthrottled: Throttled,
+		integrations.KindReset:       WindowReset,
+	} {
+		if got := FromUsage(integrations.Event{Kind: kind}).Kind; got != want {
+			t.Errorf("FromUsage(%s) = %v, want %v", kind, got, want)
+		}
+	}
+}
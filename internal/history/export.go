@@ -0,0 +1,81 @@
+package history
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Export formats.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// WriteSamples writes samples to w in format. CSV gives utilization as
+// percentages so the columns chart without conversion; JSON keeps the
+// stored fields.
+func WriteSamples(w io.Writer, format string, samples []Sample) error {
+	if format == FormatJSON {
+		if samples == nil {
+			samples = []Sample{}
+		}
+		return writeJSON(w, samples)
+	}
+	rows := [][]string{{"time", "five_hour_percent", "weekly_percent", "tokens", "cost_usd"}}
+	for _, s := range samples {
+		rows = append(rows, []string{
+			s.At.Format(time.RFC3339),
+			percent(s.FiveHourUtilization),
+			percent(s.WeeklyUtilization),
+			strconv.FormatInt(s.Tokens, 10),
+			strconv.FormatFloat(s.CostUSD, 'f', 2, 64),
+		})
+	}
+	return writeCSV(w, format, rows)
+}
+
+// WriteDays writes daily aggregates to w in format, like WriteSamples.
+func WriteDays(w io.Writer, format string, days []Day) error {
+	if format == FormatJSON {
+		if days == nil {
+			days = []Day{}
+		}
+		return writeJSON(w, days)
+	}
+	rows := [][]string{{"date", "samples", "five_hour_peak_percent", "weekly_peak_percent", "weekly_end_percent", "tokens", "cost_usd"}}
+	for _, d := range days {
+		rows = append(rows, []string{
+			d.Date,
+			strconv.Itoa(d.Samples),
+			percent(d.FiveHourPeak),
+			percent(d.WeeklyPeak),
+			percent(d.WeeklyEnd),
+			strconv.FormatInt(d.Tokens, 10),
+			strconv.FormatFloat(d.CostUSD, 'f', 2, 64),
+		})
+	}
+	return writeCSV(w, format, rows)
+}
+
+// percent formats a utilization (0.0-1.0) as a percentage.
+func percent(utilization float64) string {
+	return strconv.FormatFloat(utilization*100, 'f', 1, 64)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeCSV(w io.Writer, format string, rows [][]string) error {
+	if format != FormatCSV {
+		return fmt.Errorf("unknown export format %q", format)
+	}
+	cw := csv.NewWriter(w)
+	return cw.WriteAll(rows)
+}
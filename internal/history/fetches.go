@@ -0,0 +1,87 @@
+package history
+
+import "time"
+
+// FetchRetention is how long API fetch records are kept.
+const FetchRetention = 24 * time.Hour
+
+// Fetch records one API fetch, to tell API problems from local ones.
+type Fetch struct {
+	At time.Time `json:"at"`
+
+	// LatencyMS is how long the fetch took, including retries
+	LatencyMS int64 `json:"latency_ms"`
+
+	// OK is set when the fetch succeeded. StatusCode is the HTTP status of
+	// a failed fetch, or zero when the API was not reached at all.
+	OK         bool `json:"ok"`
+	StatusCode int  `json:"status_code,omitempty"`
+}
+
+// Latency returns LatencyMS as a duration.
+func (f Fetch) Latency() time.Duration {
+	return time.Duration(f.LatencyMS) * time.Millisecond
+}
+
+// AddFetch appends fe, dropping records older than FetchRetention.
+func (f *File) AddFetch(fe Fetch) {
+	cutoff := fe.At.Add(-FetchRetention)
+	kept := f.Fetches[:0]
+	for _, old := range f.Fetches {
+		if old.At.After(cutoff) {
+			kept = append(kept, old)
+		}
+	}
+	f.Fetches = append(kept, fe)
+}
+
+// RecordFetch loads the history at path, adds fe and saves it, returning
+// the fetches kept.
+func RecordFetch(path string, fe Fetch) ([]Fetch, error) {
+	updateMu.Lock()
+	defer updateMu.Unlock()
+
+	f, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	f.AddFetch(fe)
+	return f.Fetches, f.Save(path)
+}
+
+// FetchSummary sums up recent API fetches.
+type FetchSummary struct {
+	// Last is the latest fetch, nil before the first one
+	Last *Fetch
+
+	// Fetches and Errors count the fetches in the last hour;
+	// NetworkErrors are the errors that never reached the API
+	Fetches       int
+	Errors        int
+	NetworkErrors int
+}
+
+// Summarize sums up fetches, oldest first, as of now.
+func Summarize(fetches []Fetch, now time.Time) FetchSummary {
+	var s FetchSummary
+	if len(fetches) == 0 {
+		return s
+	}
+	last := fetches[len(fetches)-1]
+	s.Last = &last
+
+	cutoff := now.Add(-time.Hour)
+	for _, fe := range fetches {
+		if !fe.At.After(cutoff) {
+			continue
+		}
+		s.Fetches++
+		if !fe.OK {
+			s.Errors++
+			if fe.StatusCode == 0 {
+				s.NetworkErrors++
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,86 @@
+package history
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecordFetch_DropsOldFetches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	now := time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)
+
+	for _, fe := range []Fetch{
+		{At: now.Add(-25 * time.Hour), LatencyMS: 100, OK: true},
+		{At: now.Add(-2 * time.Hour), LatencyMS: 200, OK: true},
+		{At: now, LatencyMS: 300, OK: true},
+	} {
+		if _, err := RecordFetch(path, fe); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Fetches) != 2 || f.Fetches[0].LatencyMS != 200 {
+		t.Errorf("saved fetches: %+v", f.Fetches)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	now := time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)
+	fetches := []Fetch{
+		{At: now.Add(-2 * time.Hour), StatusCode: 529},
+		{At: now.Add(-40 * time.Minute), OK: true},
+		{At: now.Add(-30 * time.Minute), StatusCode: 529},
+		{At: now.Add(-20 * time.Minute)},
+		{At: now.Add(-10 * time.Minute), LatencyMS: 320, OK: true},
+	}
+
+	s := Summarize(fetches, now)
+	if s.Last == nil || s.Last.Latency() != 320*time.Millisecond {
+		t.Errorf("last fetch: %+v", s.Last)
+	}
+	if s.Fetches != 4 || s.Errors != 2 || s.NetworkErrors != 1 {
+		t.Errorf("got %d fetches, %d errors, %d network errors; want 4, 2, 1", s.Fetches, s.Errors, s.NetworkErrors)
+	}
+
+	if s := Summarize(nil, now); s.Last != nil || s.Fetches != 0 {
+		t.Errorf("empty summary: %+v", s)
+	}
+}
+
+func TestRecordFetch_ConcurrentWithRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	now := time.Now()
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := RecordFetch(path, Fetch{At: now, OK: true}); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := Record(path, Week{End: now, Utilization: 0.5}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Fetches) != n || f.Current == nil {
+		t.Errorf("got %d fetches and current week %v, want %d fetches and a week", len(f.Fetches), f.Current, n)
+	}
+}
@@ -0,0 +1,136 @@
+// Package history keeps a week-over-week record of usage, and the latency
+// and outcome of recent API fetches.
+//
+// The current weekly window is snapshotted on every refresh. When the API
+// reports a later weekly reset, the window has rolled over and its last
+// snapshot is moved into the list of finished weeks. Weeks from before the
+// app was installed can be imported from ccusage or session transcripts.
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"claude-usage/internal/stats"
+)
+
+// updateMu serializes Record, RecordFetch and ImportWeeks, which load,
+// change and save the same file from different goroutines.
+var updateMu sync.Mutex
+
+// MaxWeeks is how many finished weeks are kept (about two years).
+const MaxWeeks = 104
+
+// rolloverThreshold is how far the weekly reset must move to count as a new
+// week. Reset times reported by the API jitter by a few seconds; a real
+// rollover moves them by days.
+const rolloverThreshold = 24 * time.Hour
+
+// Week is the usage of one weekly window.
+type Week struct {
+	// End is when the window reset
+	End time.Time `json:"end"`
+
+	// Utilization is the weekly window usage (0.0-1.0) reported by the API
+	Utilization float64 `json:"utilization"`
+
+	// Tokens and CostUSD are local totals for the calendar week
+	Tokens  int64   `json:"tokens"`
+	CostUSD float64 `json:"cost_usd,omitempty"`
+
+	// Imported weeks come from another tool's records (see Import); their
+	// End is the end of the calendar week and Utilization is unknown
+	Imported bool `json:"imported,omitempty"`
+}
+
+// Percentage returns Utilization as a whole percentage.
+func (w Week) Percentage() int {
+	return int(w.Utilization * 100)
+}
+
+// File is the on-disk history.
+type File struct {
+	// Weeks are finished weeks, oldest first
+	Weeks []Week `json:"weeks"`
+
+	// Current is the latest snapshot of the running week
+	Current *Week `json:"current,omitempty"`
+
+	// Fetches are the API fetches of the last FetchRetention, oldest first
+	Fetches []Fetch `json:"fetches,omitempty"`
+}
+
+// FromStats snapshots the running week from weeklyStats, which must hold
+// API data.
+func FromStats(weeklyStats *stats.WeeklyStats) Week {
+	return Week{
+		End:         weeklyStats.WeeklyReset,
+		Utilization: weeklyStats.WeeklyUtilization,
+		Tokens:      weeklyStats.TotalTokens,
+		CostUSD:     weeklyStats.WeekCostUSD,
+	}
+}
+
+// Load reads the history at path. A missing file yields an empty history.
+func Load(path string) (*File, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Save writes the history to path, replacing it atomically.
+func (f *File) Save(path string) error {
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Update records cur as the running week. If the previous snapshot belongs
+// to an earlier window, that window is finished and returned.
+func (f *File) Update(cur Week) (finished *Week) {
+	if prev := f.Current; prev != nil && cur.End.Sub(prev.End) > rolloverThreshold {
+		f.Weeks = append(f.Weeks, *prev)
+		if len(f.Weeks) > MaxWeeks {
+			f.Weeks = f.Weeks[len(f.Weeks)-MaxWeeks:]
+		}
+		finished = prev
+	}
+	f.Current = &cur
+	return finished
+}
+
+// Record loads the history at path, updates it with cur and saves it,
+// returning the week that just finished, if any.
+func Record(path string, cur Week) (*Week, error) {
+	updateMu.Lock()
+	defer updateMu.Unlock()
+
+	f, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	finished := f.Update(cur)
+	return finished, f.Save(path)
+}
@@ -0,0 +1,61 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	reset := time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)
+
+	finished, err := Record(path, Week{End: reset, Utilization: 0.5, Tokens: 100})
+	if err != nil || finished != nil {
+		t.Fatalf("first snapshot: got %v, %v", finished, err)
+	}
+
+	// Same window, jittered reset time
+	finished, err = Record(path, Week{End: reset.Add(2 * time.Second), Utilization: 0.78, Tokens: 310})
+	if err != nil || finished != nil {
+		t.Fatalf("same window: got %v, %v", finished, err)
+	}
+
+	// The reset passed: the window moved by a week
+	finished, err = Record(path, Week{End: reset.AddDate(0, 0, 7), Utilization: 0.01, Tokens: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if finished == nil || finished.Percentage() != 78 || finished.Tokens != 310 {
+		t.Fatalf("rollover: got %+v", finished)
+	}
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Weeks) != 1 || f.Current == nil || f.Current.Tokens != 2 {
+		t.Errorf("saved history: %+v", f)
+	}
+}
+
+func TestUpdate_KeepsMaxWeeks(t *testing.T) {
+	f := &File{}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < MaxWeeks+5; i++ {
+		f.Update(Week{End: start.AddDate(0, 0, 7*i), Tokens: int64(i)})
+	}
+	if len(f.Weeks) != MaxWeeks {
+		t.Fatalf("got %d weeks, want %d", len(f.Weeks), MaxWeeks)
+	}
+	if f.Weeks[len(f.Weeks)-1].Tokens != MaxWeeks+3 {
+		t.Errorf("newest finished week: got %d tokens", f.Weeks[len(f.Weeks)-1].Tokens)
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	f, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || f == nil || len(f.Weeks) != 0 {
+		t.Fatalf("got %+v, %v", f, err)
+	}
+}
@@ -0,0 +1,133 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"time"
+)
+
+// DayTotal is one day of local usage read from another tool.
+type DayTotal struct {
+	// Date is midnight of the day
+	Date time.Time
+
+	Tokens  int64
+	CostUSD float64
+}
+
+// ccusageEntry is a row of ccusage's daily or weekly JSON report.
+type ccusageEntry struct {
+	Date        string  `json:"date"`
+	Week        string  `json:"week"`
+	TotalTokens int64   `json:"totalTokens"`
+	TotalCost   float64 `json:"totalCost"`
+	CostUSD     float64 `json:"costUSD"` // Older releases
+}
+
+// ParseCCUsage reads the output of `ccusage daily --json` or `ccusage
+// weekly --json`. Dates are read as calendar days in loc; a weekly row
+// counts as usage on the first day of its week.
+func ParseCCUsage(r io.Reader, loc *time.Location) ([]DayTotal, error) {
+	var report struct {
+		Daily  []ccusageEntry `json:"daily"`
+		Weekly []ccusageEntry `json:"weekly"`
+	}
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, err
+	}
+	entries := append(report.Daily, report.Weekly...)
+	if len(entries) == 0 {
+		return nil, errors.New("no daily or weekly usage found; expected the output of ccusage daily --json")
+	}
+
+	days := make([]DayTotal, 0, len(entries))
+	for _, e := range entries {
+		date := e.Date
+		if date == "" {
+			date = e.Week
+		}
+		t, err := time.ParseInLocation(time.DateOnly, date, loc)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, DayTotal{Date: t, Tokens: e.TotalTokens, CostUSD: max(e.TotalCost, e.CostUSD)})
+	}
+	return days, nil
+}
+
+// WeeksFromDays sums days into the calendar weeks returned by bounds,
+// oldest first. Each week ends when the next one starts and is marked
+// Imported.
+func WeeksFromDays(days []DayTotal, bounds func(time.Time) (start, end time.Time)) []Week {
+	byStart := make(map[time.Time]*Week)
+	for _, d := range days {
+		start, _ := bounds(d.Date)
+		w := byStart[start]
+		if w == nil {
+			w = &Week{End: start.AddDate(0, 0, 7), Imported: true}
+			byStart[start] = w
+		}
+		w.Tokens += d.Tokens
+		w.CostUSD += d.CostUSD
+	}
+
+	weeks := make([]Week, 0, len(byStart))
+	for _, w := range byStart {
+		weeks = append(weeks, *w)
+	}
+	sort.Slice(weeks, func(i, j int) bool { return weeks[i].End.Before(weeks[j].End) })
+	return weeks
+}
+
+// Import merges imported weeks, oldest first, into the finished weeks and
+// returns how many were added. Weeks from an earlier import are replaced.
+// Weeks that had not finished by now, or that overlap weeks recorded by
+// the app, are skipped: recorded weeks also carry the API's utilization.
+func (f *File) Import(weeks []Week, now time.Time) int {
+	var recorded []Week
+	for _, w := range f.Weeks {
+		if !w.Imported {
+			recorded = append(recorded, w)
+		}
+	}
+
+	// Allow a day of overlap between calendar weeks and the API's window
+	cutoff := now
+	first := f.Current
+	if len(recorded) > 0 {
+		first = &recorded[0]
+	}
+	if first != nil {
+		cutoff = first.End.AddDate(0, 0, -7).Add(rolloverThreshold)
+	}
+
+	var added []Week
+	for _, w := range weeks {
+		if !w.End.After(cutoff) {
+			w.Imported = true
+			added = append(added, w)
+		}
+	}
+
+	f.Weeks = append(added, recorded...)
+	if len(f.Weeks) > MaxWeeks {
+		f.Weeks = f.Weeks[len(f.Weeks)-MaxWeeks:]
+	}
+	return len(added)
+}
+
+// ImportWeeks loads the history at path, imports weeks and saves it,
+// returning how many weeks were added.
+func ImportWeeks(path string, weeks []Week, now time.Time) (int, error) {
+	updateMu.Lock()
+	defer updateMu.Unlock()
+
+	f, err := Load(path)
+	if err != nil {
+		return 0, err
+	}
+	n := f.Import(weeks, now)
+	return n, f.Save(path)
+}
@@ -0,0 +1,64 @@
+package history
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// mondayWeek returns the Monday-to-Sunday UTC week containing t.
+func mondayWeek(t time.Time) (start, end time.Time) {
+	start = t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+	return start, start.AddDate(0, 0, 7).Add(-time.Nanosecond)
+}
+
+func TestParseCCUsage(t *testing.T) {
+	report := `{
+		"daily": [
+			{"date": "2026-03-02", "totalTokens": 1000, "totalCost": 1.5},
+			{"date": "2026-03-04", "totalTokens": 500, "costUSD": 0.5},
+			{"date": "2026-03-10", "totalTokens": 200, "totalCost": 0.25}
+		],
+		"totals": {"totalTokens": 1700}
+	}`
+	days, err := ParseCCUsage(strings.NewReader(report), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weeks := WeeksFromDays(days, mondayWeek)
+	if len(weeks) != 2 {
+		t.Fatalf("got %d weeks, want 2: %+v", len(weeks), weeks)
+	}
+	if w := weeks[0]; !w.End.Equal(time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)) || w.Tokens != 1500 || w.CostUSD != 2 || !w.Imported {
+		t.Errorf("first week: %+v", w)
+	}
+
+	if _, err := ParseCCUsage(strings.NewReader(`{"monthly": []}`), time.UTC); err == nil {
+		t.Error("a report without daily or weekly rows was accepted")
+	}
+}
+
+func TestImport_KeepsRecordedWeeks(t *testing.T) {
+	week := func(end string, tokens int64) Week {
+		t, _ := time.Parse(time.DateOnly, end)
+		return Week{End: t, Tokens: tokens}
+	}
+	recorded := week("2026-03-18", 70)
+	recorded.Utilization = 0.7
+	f := &File{Weeks: []Week{week("2026-03-02", 1), recorded}}
+	f.Weeks[0].Imported = true
+
+	imported := []Week{week("2026-03-02", 10), week("2026-03-09", 20), week("2026-03-16", 30)}
+	now := time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)
+	if n := f.Import(imported, now); n != 2 {
+		t.Errorf("imported %d weeks, want 2", n)
+	}
+
+	var tokens []int64
+	for _, w := range f.Weeks {
+		tokens = append(tokens, w.Tokens)
+	}
+	if len(tokens) != 3 || tokens[0] != 10 || tokens[1] != 20 || tokens[2] != 70 {
+		t.Errorf("weeks after import: %v", tokens)
+	}
+}
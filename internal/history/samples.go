@@ -0,0 +1,199 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"claude-usage/internal/stats"
+)
+
+// SampleRetention is how long per-refresh samples are kept.
+const SampleRetention = 90 * 24 * time.Hour
+
+// pruneSlack is how far past SampleRetention the oldest sample may get
+// before the log is rewritten, so pruning happens about once a week rather
+// than on every refresh.
+const pruneSlack = 7 * 24 * time.Hour
+
+// Sample is the usage seen by one refresh.
+type Sample struct {
+	At time.Time `json:"at"`
+
+	// FiveHourUtilization and WeeklyUtilization are the window usage
+	// (0.0-1.0) reported by the API
+	FiveHourUtilization float64 `json:"five_hour_utilization"`
+	WeeklyUtilization   float64 `json:"weekly_utilization"`
+
+	// Tokens and CostUSD are local totals for the calendar week so far
+	Tokens  int64   `json:"tokens"`
+	CostUSD float64 `json:"cost_usd,omitempty"`
+}
+
+// SampleFromStats samples weeklyStats, which must hold API data, at at.
+func SampleFromStats(weeklyStats *stats.WeeklyStats, at time.Time) Sample {
+	return Sample{
+		At:                  at,
+		FiveHourUtilization: weeklyStats.FiveHourUtilization,
+		WeeklyUtilization:   weeklyStats.WeeklyUtilization,
+		Tokens:              weeklyStats.TotalTokens,
+		CostUSD:             weeklyStats.WeekCostUSD,
+	}
+}
+
+// AppendSample appends s to the sample log at path, one JSON object per
+// line. Samples older than SampleRetention are pruned now and then.
+func AppendSample(path string, s Sample) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return pruneSamples(path, s.At)
+}
+
+// pruneSamples rewrites the log at path without samples older than
+// SampleRetention once its oldest sample is pruneSlack past that.
+func pruneSamples(path string, now time.Time) error {
+	cutoff := now.Add(-SampleRetention)
+	oldest, err := firstSample(path)
+	if err != nil || !oldest.At.Before(cutoff.Add(-pruneSlack)) {
+		return err
+	}
+
+	kept, err := LoadSamples(path, cutoff)
+	if err != nil {
+		return err
+	}
+	var b []byte
+	for _, s := range kept {
+		line, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		b = append(append(b, line...), '\n')
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// firstSample reads the oldest sample of the log at path without reading
+// the rest of it.
+func firstSample(path string) (Sample, error) {
+	var s Sample
+	f, err := os.Open(path)
+	if err != nil {
+		return s, err
+	}
+	defer f.Close()
+
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil {
+		return s, err
+	}
+	return s, json.Unmarshal(line, &s)
+}
+
+// LoadSamples reads the samples taken at or after since from the log at
+// path, oldest first. A missing log yields no samples; lines that do not
+// parse, such as one cut short by a crash, are skipped.
+func LoadSamples(path string, since time.Time) ([]Sample, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var samples []Sample
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var s Sample
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil || s.At.Before(since) {
+			continue
+		}
+		samples = append(samples, s)
+	}
+	return samples, scanner.Err()
+}
+
+// Day aggregates the samples of one calendar day.
+type Day struct {
+	// Date is the day in YYYY-MM-DD form
+	Date string `json:"date"`
+
+	// Samples is how many refreshes the day saw
+	Samples int `json:"samples"`
+
+	// FiveHourPeak and WeeklyPeak are the highest window usage (0.0-1.0)
+	// of the day; WeeklyEnd is the weekly usage at the day's last refresh
+	FiveHourPeak float64 `json:"five_hour_peak"`
+	WeeklyPeak   float64 `json:"weekly_peak"`
+	WeeklyEnd    float64 `json:"weekly_end"`
+
+	// Tokens and CostUSD are the week's local totals at the day's last
+	// refresh
+	Tokens  int64   `json:"tokens"`
+	CostUSD float64 `json:"cost_usd,omitempty"`
+}
+
+// Daily aggregates samples, oldest first, into one Day per calendar day
+// in loc.
+func Daily(samples []Sample, loc *time.Location) []Day {
+	var days []Day
+	for _, s := range samples {
+		date := s.At.In(loc).Format(time.DateOnly)
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, Day{Date: date})
+		}
+		d := &days[len(days)-1]
+		d.Samples++
+		d.FiveHourPeak = max(d.FiveHourPeak, s.FiveHourUtilization)
+		d.WeeklyPeak = max(d.WeeklyPeak, s.WeeklyUtilization)
+		d.WeeklyEnd = s.WeeklyUtilization
+		d.Tokens = s.Tokens
+		d.CostUSD = s.CostUSD
+	}
+	return days
+}
+
+// Sparkline buckets the samples taken in the span before now into n
+// columns, oldest first, and returns each column's peak utilization of the
+// fuller window, or -1 for columns without samples.
+func Sparkline(samples []Sample, now time.Time, span time.Duration, n int) []float64 {
+	columns := make([]float64, n)
+	for i := range columns {
+		columns[i] = -1
+	}
+	start := now.Add(-span)
+	for _, s := range samples {
+		if s.At.Before(start) || s.At.After(now) {
+			continue
+		}
+		i := min(int(s.At.Sub(start)*time.Duration(n)/span), n-1)
+		columns[i] = max(columns[i], s.FiveHourUtilization, s.WeeklyUtilization)
+	}
+	return columns
+}
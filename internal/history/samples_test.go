@@ -0,0 +1,101 @@
+package history
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendSample_PrunesOldSamples(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "samples.jsonl")
+	now := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+
+	for _, s := range []Sample{
+		{At: now.Add(-SampleRetention - pruneSlack - time.Hour), WeeklyUtilization: 0.1},
+		{At: now.Add(-time.Hour), WeeklyUtilization: 0.2},
+		{At: now, WeeklyUtilization: 0.3},
+	} {
+		if err := AppendSample(path, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	samples, err := LoadSamples(path, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 2 || samples[0].WeeklyUtilization != 0.2 {
+		t.Errorf("saved samples: %+v", samples)
+	}
+}
+
+func TestLoadSamples_SkipsBrokenLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "samples.jsonl")
+	log := `{"at":"2026-04-01T09:00:00Z","weekly_utilization":0.2}
+{"at":"2026-04-02T09:00:00Z","weekly_utilization":0.3}
+{"at":"2026-04-02T09:05:0`
+	if err := os.WriteFile(path, []byte(log), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err := LoadSamples(path, time.Date(2026, 4, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 1 || samples[0].WeeklyUtilization != 0.3 {
+		t.Errorf("got %+v", samples)
+	}
+}
+
+func TestDaily(t *testing.T) {
+	day := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	days := Daily([]Sample{
+		{At: day.Add(9 * time.Hour), FiveHourUtilization: 0.4, WeeklyUtilization: 0.5, Tokens: 10},
+		{At: day.Add(12 * time.Hour), FiveHourUtilization: 0.9, WeeklyUtilization: 0.6, Tokens: 20},
+		{At: day.Add(33 * time.Hour), FiveHourUtilization: 0.1, WeeklyUtilization: 0.02, Tokens: 1},
+	}, time.UTC)
+
+	if len(days) != 2 {
+		t.Fatalf("got %d days, want 2", len(days))
+	}
+	if d := days[0]; d.Date != "2026-04-01" || d.Samples != 2 || d.FiveHourPeak != 0.9 || d.WeeklyEnd != 0.6 || d.Tokens != 20 {
+		t.Errorf("first day: %+v", d)
+	}
+}
+
+func TestWriteSamples_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	at := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+	err := WriteSamples(&buf, FormatCSV, []Sample{{At: at, FiveHourUtilization: 0.425, WeeklyUtilization: 0.6, Tokens: 1200, CostUSD: 3.5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "time,five_hour_percent,weekly_percent,tokens,cost_usd\n2026-04-01T09:00:00Z,42.5,60.0,1200,3.50\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	if err := WriteSamples(&buf, "xml", nil); err == nil || !strings.Contains(err.Error(), "xml") {
+		t.Errorf("unknown format: got %v", err)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	now := time.Date(2026, 4, 2, 12, 0, 0, 0, time.UTC)
+	columns := Sparkline([]Sample{
+		{At: now.Add(-30 * time.Hour), WeeklyUtilization: 0.9},
+		{At: now.Add(-23 * time.Hour), FiveHourUtilization: 0.2, WeeklyUtilization: 0.1},
+		{At: now.Add(-22*time.Hour - 30*time.Minute), FiveHourUtilization: 0.4, WeeklyUtilization: 0.1},
+		{At: now, FiveHourUtilization: 0.1, WeeklyUtilization: 0.5},
+	}, now, 24*time.Hour, 4)
+
+	want := []float64{0.4, -1, -1, 0.5}
+	for i := range want {
+		if columns[i] != want[i] {
+			t.Fatalf("got %v, want %v", columns, want)
+		}
+	}
+}
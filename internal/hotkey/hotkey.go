@@ -0,0 +1,109 @@
+// Package hotkey registers a global keyboard shortcut, for users who keep
+// the tray hidden: RegisterHotKey on Windows and the desktop portal's
+// GlobalShortcuts interface on Linux. Other platforms are not supported.
+package hotkey
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Modifiers is a set of modifier keys.
+type Modifiers uint8
+
+const (
+	Ctrl Modifiers = 1 << iota
+	Alt
+	Shift
+	// Super is the Windows or Command key
+	Super
+)
+
+// modifierNames are the names Parse accepts for each modifier, the first
+// being the one String uses.
+var modifierNames = []struct {
+	mod   Modifiers
+	names []string
+}{
+	{Ctrl, []string{"Ctrl", "Control"}},
+	{Alt, []string{"Alt", "Option"}},
+	{Shift, []string{"Shift"}},
+	{Super, []string{"Super", "Win", "Cmd", "Meta"}},
+}
+
+// Hotkey is a key pressed together with one or more modifiers.
+type Hotkey struct {
+	Mods Modifiers
+	// Key is a letter "A"-"Z", a digit "0"-"9" or a function key "F1"-"F24"
+	Key string
+}
+
+// Parse parses a hotkey such as "Ctrl+Alt+U" or "super+shift+f5".
+// Modifier and key names are case-insensitive; at least one modifier is
+// required, so the key keeps working in other applications.
+func Parse(s string) (Hotkey, error) {
+	parts := strings.Split(s, "+")
+	var hk Hotkey
+	for _, part := range parts[:len(parts)-1] {
+		mod, ok := parseModifier(strings.TrimSpace(part))
+		if !ok {
+			return Hotkey{}, fmt.Errorf("unknown modifier %q", part)
+		}
+		if hk.Mods&mod != 0 {
+			return Hotkey{}, fmt.Errorf("modifier %q given twice", part)
+		}
+		hk.Mods |= mod
+	}
+	key := strings.ToUpper(strings.TrimSpace(parts[len(parts)-1]))
+	if !validKey(key) {
+		return Hotkey{}, fmt.Errorf("unsupported key %q: use a letter, a digit or F1-F24", parts[len(parts)-1])
+	}
+	if hk.Mods == 0 {
+		return Hotkey{}, fmt.Errorf("%q needs a modifier such as Ctrl or Alt", s)
+	}
+	hk.Key = key
+	return hk, nil
+}
+
+// parseModifier returns the modifier named name.
+func parseModifier(name string) (Modifiers, bool) {
+	for _, m := range modifierNames {
+		for _, n := range m.names {
+			if strings.EqualFold(name, n) {
+				return m.mod, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// validKey reports whether key, in upper case, is a supported key.
+func validKey(key string) bool {
+	if len(key) == 1 {
+		return key[0] >= 'A' && key[0] <= 'Z' || key[0] >= '0' && key[0] <= '9'
+	}
+	n, ok := functionKey(key)
+	return ok && n >= 1 && n <= 24
+}
+
+// functionKey returns n for a key "Fn".
+func functionKey(key string) (int, bool) {
+	digits, ok := strings.CutPrefix(key, "F")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(digits)
+	return n, err == nil
+}
+
+// String formats hk as Parse accepts it, e.g. "Ctrl+Alt+U".
+func (hk Hotkey) String() string {
+	var parts []string
+	for _, m := range modifierNames {
+		if hk.Mods&m.mod != 0 {
+			parts = append(parts, m.names[0])
+		}
+	}
+	return strings.Join(append(parts, hk.Key), "+")
+}
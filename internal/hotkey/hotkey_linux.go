@@ -0,0 +1,227 @@
+package hotkey
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// The desktop portal's GlobalShortcuts interface, implemented by KDE
+// Plasma 6 and GNOME 48 and later. X11 key grabs would need Xlib, and
+// Wayland has no other way for applications to register shortcuts.
+const (
+	portalName      = "org.freedesktop.portal.Desktop"
+	portalPath      = dbus.ObjectPath("/org/freedesktop/portal/desktop")
+	shortcutsIface  = "org.freedesktop.portal.GlobalShortcuts"
+	requestIface    = "org.freedesktop.portal.Request"
+	sessionIface    = "org.freedesktop.portal.Session"
+	responseSignal  = requestIface + ".Response"
+	activatedSignal = shortcutsIface + ".Activated"
+
+	// shortcutID identifies the shortcut within the session; there is one
+	shortcutID = "show-usage"
+)
+
+// createTimeout is how long the portal has to create a session. Binding
+// the shortcut may wait for the user to confirm it, so it is not waited for.
+const createTimeout = 10 * time.Second
+
+// shortcut is a (sa{sv}) shortcut as BindShortcuts takes it.
+type shortcut struct {
+	ID      string
+	Options map[string]dbus.Variant
+}
+
+// Listener calls a function when its hotkey is pressed. A nil Listener
+// does nothing.
+type Listener struct {
+	conn    *dbus.Conn
+	session dbus.ObjectPath
+}
+
+// Register asks the desktop to bind hk for the whole desktop and calls fn,
+// on its own goroutine, whenever it is pressed. The desktop may ask the
+// user to confirm the shortcut or pick another one; if they decline, a
+// warning is logged.
+func Register(hk Hotkey, fn func()) (*Listener, error) {
+	// A private connection, so closing it leaves the tray's connection alone
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to the D-Bus session bus: %w", err)
+	}
+	l := &Listener{conn: conn}
+	signals := make(chan *dbus.Signal, 8)
+	conn.Signal(signals)
+
+	if err := l.createSession(signals); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	bindRequest, err := l.bind(hk)
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	if err := conn.AddMatchSignal(dbus.WithMatchInterface(shortcutsIface), dbus.WithMatchMember("Activated")); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("could not subscribe to shortcuts: %w", err)
+	}
+
+	go l.run(hk, fn, signals, bindRequest)
+	return l, nil
+}
+
+// createSession creates the portal session the shortcut belongs to.
+func (l *Listener) createSession(signals <-chan *dbus.Signal) error {
+	request, err := l.expectResponse("claudeusage_create")
+	if err != nil {
+		return err
+	}
+	options := map[string]dbus.Variant{
+		"handle_token":         dbus.MakeVariant("claudeusage_create"),
+		"session_handle_token": dbus.MakeVariant("claudeusage"),
+	}
+	call := l.conn.Object(portalName, portalPath).Call(shortcutsIface+".CreateSession", 0, options)
+	if call.Err != nil {
+		return fmt.Errorf("no GlobalShortcuts desktop portal (needs KDE Plasma 6 or GNOME 48): %w", call.Err)
+	}
+
+	timeout := time.After(createTimeout)
+	for {
+		select {
+		case s, ok := <-signals:
+			if !ok {
+				return errors.New("D-Bus connection closed")
+			}
+			if s.Path != request || s.Name != responseSignal {
+				continue
+			}
+			code, results, err := parseResponse(s)
+			if err != nil {
+				return err
+			}
+			if code != 0 {
+				return fmt.Errorf("the desktop portal refused a shortcuts session (response %d)", code)
+			}
+			handle, ok := results["session_handle"].Value().(string)
+			if !ok {
+				return errors.New("the desktop portal returned no session handle")
+			}
+			l.session = dbus.ObjectPath(handle)
+			return nil
+		case <-timeout:
+			return errors.New("timed out creating a shortcuts session")
+		}
+	}
+}
+
+// bind asks the portal to bind hk and returns the request whose response
+// tells whether it did.
+func (l *Listener) bind(hk Hotkey) (dbus.ObjectPath, error) {
+	request, err := l.expectResponse("claudeusage_bind")
+	if err != nil {
+		return "", err
+	}
+	shortcuts := []shortcut{{
+		ID: shortcutID,
+		Options: map[string]dbus.Variant{
+			"description":       dbus.MakeVariant("Show Claude usage"),
+			"preferred_trigger": dbus.MakeVariant(portalTrigger(hk)),
+		},
+	}}
+	options := map[string]dbus.Variant{"handle_token": dbus.MakeVariant("claudeusage_bind")}
+	call := l.conn.Object(portalName, portalPath).Call(shortcutsIface+".BindShortcuts", 0, l.session, shortcuts, "", options)
+	if call.Err != nil {
+		return "", fmt.Errorf("could not bind %s: %w", hk, call.Err)
+	}
+	return request, nil
+}
+
+// expectResponse subscribes to the Response signal of the request the
+// next call with handle_token token creates, before the call, so the
+// response cannot be missed.
+func (l *Listener) expectResponse(token string) (dbus.ObjectPath, error) {
+	names := l.conn.Names()
+	if len(names) == 0 {
+		return "", errors.New("no unique name on the session bus")
+	}
+	request := requestPath(names[0], token)
+	if err := l.conn.AddMatchSignal(dbus.WithMatchObjectPath(request), dbus.WithMatchInterface(requestIface), dbus.WithMatchMember("Response")); err != nil {
+		return "", fmt.Errorf("could not subscribe to portal responses: %w", err)
+	}
+	return request, nil
+}
+
+// run calls fn on each activation of the shortcut until the connection
+// is closed.
+func (l *Listener) run(hk Hotkey, fn func(), signals <-chan *dbus.Signal, bindRequest dbus.ObjectPath) {
+	for s := range signals {
+		switch {
+		case s.Path == bindRequest && s.Name == responseSignal:
+			if code, _, err := parseResponse(s); err != nil || code != 0 {
+				log.Printf("Warning: the desktop did not bind the hotkey %s", hk)
+			}
+		case s.Name == activatedSignal && len(s.Body) >= 2:
+			session, _ := s.Body[0].(dbus.ObjectPath)
+			id, _ := s.Body[1].(string)
+			if session == l.session && id == shortcutID {
+				go fn()
+			}
+		}
+	}
+}
+
+// Close removes the shortcut.
+func (l *Listener) Close() {
+	if l == nil {
+		return
+	}
+	if l.session != "" {
+		l.conn.Object(portalName, l.session).Call(sessionIface+".Close", 0)
+	}
+	l.conn.Close()
+}
+
+// parseResponse returns the response code and results of a Response
+// signal; 0 is success, 1 cancelled by the user.
+func parseResponse(s *dbus.Signal) (uint32, map[string]dbus.Variant, error) {
+	if len(s.Body) != 2 {
+		return 0, nil, errors.New("malformed portal response")
+	}
+	code, ok := s.Body[0].(uint32)
+	results, ok2 := s.Body[1].(map[string]dbus.Variant)
+	if !ok || !ok2 {
+		return 0, nil, errors.New("malformed portal response")
+	}
+	return code, results, nil
+}
+
+// requestPath returns the object path of the request the portal creates
+// for the caller with unique name sender and handle_token token.
+func requestPath(sender, token string) dbus.ObjectPath {
+	sender = strings.ReplaceAll(strings.TrimPrefix(sender, ":"), ".", "_")
+	return dbus.ObjectPath("/org/freedesktop/portal/desktop/request/" + sender + "/" + token)
+}
+
+// portalTrigger formats hk as in the XDG shortcuts specification, e.g.
+// "CTRL+ALT+u": modifiers in capitals, then the key's keysym name.
+func portalTrigger(hk Hotkey) string {
+	var parts []string
+	for _, m := range []struct {
+		mod  Modifiers
+		name string
+	}{{Ctrl, "CTRL"}, {Alt, "ALT"}, {Shift, "SHIFT"}, {Super, "LOGO"}} {
+		if hk.Mods&m.mod != 0 {
+			parts = append(parts, m.name)
+		}
+	}
+	key := hk.Key
+	if len(key) == 1 {
+		key = strings.ToLower(key)
+	}
+	return strings.Join(append(parts, key), "+")
+}
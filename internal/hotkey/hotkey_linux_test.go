@@ -0,0 +1,25 @@
+package hotkey
+
+import "testing"
+
+func TestPortalTrigger(t *testing.T) {
+	tests := []struct {
+		hk   Hotkey
+		want string
+	}{
+		{Hotkey{Ctrl | Alt, "U"}, "CTRL+ALT+u"},
+		{Hotkey{Super | Shift, "F5"}, "SHIFT+LOGO+F5"},
+		{Hotkey{Ctrl, "1"}, "CTRL+1"},
+	}
+	for _, tt := range tests {
+		if got := portalTrigger(tt.hk); got != tt.want {
+			t.Errorf("portalTrigger(%v) = %q, want %q", tt.hk, got, tt.want)
+		}
+	}
+}
+
+func TestRequestPath(t *testing.T) {
+	if got := requestPath(":1.42", "claudeusage_bind"); got != "/org/freedesktop/portal/desktop/request/1_42/claudeusage_bind" {
+		t.Errorf("requestPath = %q", got)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !windows && !linux
+
+package hotkey
+
+import "errors"
+
+// Listener is only available on Windows and Linux. A nil Listener does
+// nothing.
+type Listener struct{}
+
+// Register fails outside Windows and Linux.
+func Register(hk Hotkey, fn func()) (*Listener, error) {
+	return nil, errors.New("global hotkeys are only available on Windows and Linux")
+}
+
+// Close does nothing outside Windows and Linux.
+func (l *Listener) Close() {}
@@ -0,0 +1,46 @@
+package hotkey
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Hotkey
+	}{
+		{"Ctrl+Alt+U", Hotkey{Ctrl | Alt, "U"}},
+		{"super+shift+f5", Hotkey{Super | Shift, "F5"}},
+		{"Control + Option + 1", Hotkey{Ctrl | Alt, "1"}},
+		{"Cmd+F24", Hotkey{Super, "F24"}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "U", "Ctrl+", "Ctrl+Ctrl+U", "Hyper+U", "Ctrl+Space", "Ctrl+F0", "Ctrl+F25", "Ctrl+UU"} {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q): expected an error", in)
+		}
+	}
+}
+
+func TestHotkey_String(t *testing.T) {
+	for _, in := range []string{"Ctrl+Alt+U", "Ctrl+Alt+Shift+Super+F12"} {
+		hk, err := Parse(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hk.String(); got != in {
+			t.Errorf("String() = %q, want %q", got, in)
+		}
+	}
+	if got := (Hotkey{Super | Ctrl, "K"}).String(); got != "Ctrl+Super+K" {
+		t.Errorf("String() = %q, want modifiers in order", got)
+	}
+}
@@ -0,0 +1,126 @@
+package hotkey
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32 = windows.NewLazySystemDLL("user32.dll")
+
+	procRegisterHotKey     = user32.NewProc("RegisterHotKey")
+	procUnregisterHotKey   = user32.NewProc("UnregisterHotKey")
+	procGetMessageW        = user32.NewProc("GetMessageW")
+	procPostThreadMessageW = user32.NewProc("PostThreadMessageW")
+)
+
+// Window messages and RegisterHotKey flags.
+const (
+	wmQuit   = 0x0012
+	wmHotkey = 0x0312
+
+	modAlt      = 0x0001
+	modControl  = 0x0002
+	modShift    = 0x0004
+	modWin      = 0x0008
+	modNoRepeat = 0x4000
+
+	vkF1 = 0x70
+
+	// hotkeyID identifies the hotkey among the thread's; there is one
+	hotkeyID = 1
+)
+
+type msg struct {
+	hwnd    windows.HWND
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      struct{ x, y int32 }
+}
+
+// Listener calls a function when its hotkey is pressed. A nil Listener
+// does nothing.
+type Listener struct {
+	// threadID is the thread pumping the hotkey's messages
+	threadID uint32
+}
+
+// Register registers hk for the whole desktop and calls fn, on its own
+// goroutine, whenever it is pressed. It fails if another application
+// already registered hk.
+func Register(hk Hotkey, fn func()) (*Listener, error) {
+	l := &Listener{}
+	errCh := make(chan error, 1)
+	go l.run(hk, fn, errCh)
+	if err := <-errCh; err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// run registers the hotkey on a dedicated thread, whose message queue
+// receives it, and pumps messages until Close.
+func (l *Listener) run(hk Hotkey, fn func(), errCh chan<- error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	l.threadID = windows.GetCurrentThreadId()
+	r, _, err := procRegisterHotKey.Call(0, hotkeyID, uintptr(modifiers(hk.Mods)|modNoRepeat), uintptr(virtualKey(hk.Key)))
+	if r == 0 {
+		errCh <- fmt.Errorf("could not register %s (already used by another application?): %w", hk, err)
+		return
+	}
+	defer procUnregisterHotKey.Call(0, hotkeyID)
+	errCh <- nil
+
+	var m msg
+	for {
+		r, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+		if int32(r) <= 0 {
+			return
+		}
+		if m.message == wmHotkey && m.wParam == hotkeyID {
+			go fn()
+		}
+	}
+}
+
+// Close unregisters the hotkey.
+func (l *Listener) Close() {
+	if l == nil {
+		return
+	}
+	procPostThreadMessageW.Call(uintptr(l.threadID), wmQuit, 0, 0)
+}
+
+// modifiers returns RegisterHotKey's flags for mods.
+func modifiers(mods Modifiers) uint32 {
+	var flags uint32
+	if mods&Ctrl != 0 {
+		flags |= modControl
+	}
+	if mods&Alt != 0 {
+		flags |= modAlt
+	}
+	if mods&Shift != 0 {
+		flags |= modShift
+	}
+	if mods&Super != 0 {
+		flags |= modWin
+	}
+	return flags
+}
+
+// virtualKey returns the virtual-key code of key. Letters and digits are
+// their ASCII codes.
+func virtualKey(key string) uint32 {
+	if n, ok := functionKey(key); ok && len(key) > 1 {
+		return vkF1 + uint32(n) - 1
+	}
+	return uint32(key[0])
+}
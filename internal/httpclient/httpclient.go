@@ -0,0 +1,142 @@
+// Package httpclient builds the HTTP clients used for all network calls, so
+// proxy and CA settings apply to both usage fetches and updates.
+//
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY are respected by default. An explicit
+// proxy URL and an extra CA bundle (for TLS-intercepting corporate proxies)
+// can be set with Configure.
+//
+// With debug logging enabled, every exchange is logged with its status,
+// latency and rate-limit headers. Credentials are never logged.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"claude-usage/internal/logging"
+)
+
+var (
+	transportMu sync.RWMutex
+	transport   http.RoundTripper = newTransport(nil, nil)
+)
+
+// New returns an HTTP client with the given timeout. Clients pick up later
+// Configure calls, so long-lived clients need not be re-created.
+func New(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: roundTripper{},
+	}
+}
+
+// Configure sets an explicit proxy URL (empty to use the environment) and a
+// PEM CA bundle trusted in addition to the system roots (empty for none).
+func Configure(proxyURL, caBundlePath string) error {
+	var proxy *url.URL
+	if proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q", proxyURL)
+		}
+		proxy = u
+	}
+
+	var roots *x509.CertPool
+	if caBundlePath != "" {
+		pem, err := os.ReadFile(caBundlePath)
+		if err != nil {
+			return fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		roots, err = x509.SystemCertPool()
+		if err != nil || roots == nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in CA bundle %s", caBundlePath)
+		}
+	}
+
+	transportMu.Lock()
+	transport = newTransport(proxy, roots)
+	transportMu.Unlock()
+	return nil
+}
+
+// newTransport clones the default transport with the given proxy and roots.
+// A nil proxy uses the environment; nil roots use the system pool.
+func newTransport(proxy *url.URL, roots *x509.CertPool) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != nil {
+		t.Proxy = http.ProxyURL(proxy)
+	} else {
+		t.Proxy = http.ProxyFromEnvironment
+	}
+	if roots != nil {
+		t.TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+	return t
+}
+
+// roundTripper forwards requests to the currently configured transport.
+type roundTripper struct{}
+
+// RoundTrip implements http.RoundTripper.
+func (roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	transportMu.RLock()
+	t := transport
+	transportMu.RUnlock()
+
+	if !logging.DebugEnabled() {
+		return t.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := t.RoundTrip(req)
+	latency := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		logging.Debugf("HTTP %s %s failed after %s: %v", req.Method, redactURL(req.URL), latency, err)
+		return resp, err
+	}
+	logging.Debugf("HTTP %s %s -> %d in %s%s", req.Method, redactURL(req.URL), resp.StatusCode, latency, rateLimitHeaders(resp.Header))
+	return resp, nil
+}
+
+// redactURL returns u without credentials or query parameters, which may
+// carry tokens.
+func redactURL(u *url.URL) string {
+	r := *u
+	r.User = nil
+	if r.RawQuery != "" {
+		r.RawQuery = "…"
+	}
+	r.Fragment = ""
+	return r.String()
+}
+
+// rateLimitHeaders formats the response headers useful for diagnosing rate
+// limiting and caching, e.g. " [Retry-After=30 Etag=\"v1\"]". Returns "" if
+// there are none.
+func rateLimitHeaders(h http.Header) string {
+	var parts []string
+	for name, values := range h {
+		lower := strings.ToLower(name)
+		if strings.Contains(lower, "ratelimit") || lower == "retry-after" ||
+			lower == "etag" || lower == "last-modified" || lower == "request-id" {
+			parts = append(parts, name+"="+strings.Join(values, ","))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	sort.Strings(parts)
+	return " [" + strings.Join(parts, " ") + "]"
+}
@@ -36,3 +36,24 @@ func GetColorForTokens(tokens int64) color.RGBA {
 		return ColorNeonPurple
 	}
 }
+
+// Usage thresholds, in percent of the limiting window, for color changes
+const (
+	UsageMedium   = 50
+	UsageHigh     = 75
+	UsageCritical = 90
+)
+
+// GetColorForPercentage returns the appropriate color for rate limit usage.
+func GetColorForPercentage(percentage int) color.RGBA {
+	switch {
+	case percentage < UsageMedium:
+		return ColorNeonGreen
+	case percentage < UsageHigh:
+		return ColorNeonYellow
+	case percentage < UsageCritical:
+		return ColorNeonOrange
+	default:
+		return ColorNeonRed
+	}
+}
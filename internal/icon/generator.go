@@ -1,12 +1,24 @@
 package icon
 
 import (
+	"image"
+	"time"
+
 	"claude-usage/internal/stats"
 )
 
 // Generator creates icons based on usage statistics.
 type Generator struct {
 	Size int
+
+	// StaleAfter is how old API data may get before the icon shows a stale
+	// dot (see StaleAge); zero disables it.
+	StaleAfter time.Duration
+
+	// Budget is set when the percentage shown is of a personal budget
+	// rather than the plan's limits: the color then follows it, and the
+	// 5-hour marker is left out.
+	Budget bool
 }
 
 // DefaultGenerator returns a generator with the default icon size.
@@ -15,10 +27,32 @@ func DefaultGenerator() *Generator {
 }
 
 // GenerateWithPercentage creates an icon with percentage text overlay.
-func (g *Generator) GenerateWithPercentage(weeklyStats *stats.WeeklyStats, percentage int) ([]byte, error) {
+// With API data its color follows the limiting window, and a marker
+// shows when that is the 5-hour window rather than the weekly one. A
+// non-nil sparkline replaces the bottom pins (see DrawSparkline).
+func (g *Generator) GenerateWithPercentage(weeklyStats *stats.WeeklyStats, percentage int, sparkline []float64) ([]byte, error) {
+	return encodeForPlatform(g.render(weeklyStats, percentage, sparkline))
+}
+
+// GenerateDimmed creates the icon from GenerateWithPercentage at half
+// opacity, the second frame when flashing.
+func (g *Generator) GenerateDimmed(weeklyStats *stats.WeeklyStats, percentage int, sparkline []float64) ([]byte, error) {
+	img := g.render(weeklyStats, percentage, sparkline)
+	DimImage(img)
+	return encodeForPlatform(img)
+}
+
+// render draws the icon for GenerateWithPercentage.
+func (g *Generator) render(weeklyStats *stats.WeeklyStats, percentage int, sparkline []float64) *image.RGBA {
 	c := ColorGray
 	if weeklyStats != nil {
-		c = GetColorForTokens(weeklyStats.TotalTokens)
+		if g.Budget {
+			c = GetColorForPercentage(percentage)
+		} else if weeklyStats.HasAPIData {
+			c = GetColorForPercentage(weeklyStats.LimitingPercentage())
+		} else {
+			c = GetColorForTokens(weeklyStats.TotalTokens)
+		}
 	}
 
 	if percentage < 0 {
@@ -28,10 +62,69 @@ func (g *Generator) GenerateWithPercentage(weeklyStats *stats.WeeklyStats, perce
 		percentage = 99
 	}
 
-	return RenderNeonOrbWithText(c, g.Size, percentage)
+	img := RenderChipImage(c, g.Size, percentage)
+	if weeklyStats != nil && weeklyStats.HasAPIData && weeklyStats.IsLimitedByFiveHour() && !g.Budget {
+		MarkFiveHour(img, c)
+	}
+	if sparkline != nil {
+		DrawSparkline(img, sparkline)
+	}
+	if weeklyStats != nil && weeklyStats.APIDataStale {
+		// Cached data: dim the icon so it doesn't pass for live usage
+		DimImage(img)
+	}
+	if g.StaleAge(weeklyStats, time.Now()) > 0 {
+		MarkStale(img)
+	}
+	return img
+}
+
+// StaleAge returns how old the API data of weeklyStats is at now, when
+// older than StaleAfter, or zero.
+func (g *Generator) StaleAge(weeklyStats *stats.WeeklyStats, now time.Time) time.Duration {
+	if g.StaleAfter <= 0 || weeklyStats == nil || !weeklyStats.HasAPIData || weeklyStats.APIFetchedAt.IsZero() {
+		return 0
+	}
+	if age := now.Sub(weeklyStats.APIFetchedAt); age > g.StaleAfter {
+		return age
+	}
+	return 0
 }
 
-// GenerateError creates an icon indicating an error state.
-func (g *Generator) GenerateError() ([]byte, error) {
-	return RenderNeonOrbWithText(ColorNeonPurple, g.Size, 0)
+// NearlyExhausted reports whether the limiting window has reached
+// UsageCritical, as reported by the API.
+func NearlyExhausted(weeklyStats *stats.WeeklyStats) bool {
+	if weeklyStats == nil || !weeklyStats.HasAPIData || weeklyStats.APIDataStale {
+		return false
+	}
+	return weeklyStats.LimitingPercentage() >= UsageCritical
+}
+
+// ErrorKind is why no usage can be shown, picking the error icon.
+type ErrorKind int
+
+const (
+	// ErrorNoCredentials: no credentials file or token ("?", purple)
+	ErrorNoCredentials ErrorKind = iota
+	// ErrorTokenExpired: the token was rejected and could not be
+	// refreshed, so logging in again is needed ("!", orange)
+	ErrorTokenExpired
+	// ErrorNetwork: the API could not be reached ("X", gray)
+	ErrorNetwork
+	// ErrorAPI: the API answered with an error ("E", red)
+	ErrorAPI
+)
+
+// GenerateError creates an icon for an error state of the given kind.
+func (g *Generator) GenerateError(kind ErrorKind) ([]byte, error) {
+	switch kind {
+	case ErrorTokenExpired:
+		return encodeForPlatform(renderChip(ColorNeonOrange, g.Size, "!"))
+	case ErrorNetwork:
+		return encodeForPlatform(renderChip(ColorGray, g.Size, "X"))
+	case ErrorAPI:
+		return encodeForPlatform(renderChip(ColorNeonRed, g.Size, "E"))
+	default:
+		return encodeForPlatform(renderChip(ColorNeonPurple, g.Size, "?"))
+	}
 }
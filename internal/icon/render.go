@@ -20,7 +20,7 @@ var (
 	whiteText  = color.RGBA{R: 255, G: 255, B: 255, A: 255} // White text
 )
 
-// Large bold pixel patterns for digits 0-9 (7x9 pixels)
+// Large bold pixel patterns for digits 0-9 and error glyphs (7x9 pixels)
 var digitPatterns = map[rune][9][7]int{
 	'0': {{0, 1, 1, 1, 1, 1, 0}, {1, 1, 1, 1, 1, 1, 1}, {1, 1, 0, 0, 0, 1, 1}, {1, 1, 0, 0, 0, 1, 1}, {1, 1, 0, 0, 0, 1, 1}, {1, 1, 0, 0, 0, 1, 1}, {1, 1, 0, 0, 0, 1, 1}, {1, 1, 1, 1, 1, 1, 1}, {0, 1, 1, 1, 1, 1, 0}},
 	'1': {{0, 0, 0, 1, 1, 0, 0}, {0, 0, 1, 1, 1, 0, 0}, {0, 1, 1, 1, 1, 0, 0}, {0, 0, 0, 1, 1, 0, 0}, {0, 0, 0, 1, 1, 0, 0}, {0, 0, 0, 1, 1, 0, 0}, {0, 0, 0, 1, 1, 0, 0}, {0, 1, 1, 1, 1, 1, 1}, {0, 1, 1, 1, 1, 1, 1}},
@@ -32,6 +32,12 @@ var digitPatterns = map[rune][9][7]int{
 	'7': {{1, 1, 1, 1, 1, 1, 1}, {1, 1, 1, 1, 1, 1, 1}, {0, 0, 0, 0, 0, 1, 1}, {0, 0, 0, 0, 1, 1, 0}, {0, 0, 0, 1, 1, 0, 0}, {0, 0, 1, 1, 0, 0, 0}, {0, 0, 1, 1, 0, 0, 0}, {0, 0, 1, 1, 0, 0, 0}, {0, 0, 1, 1, 0, 0, 0}},
 	'8': {{0, 1, 1, 1, 1, 1, 0}, {1, 1, 1, 1, 1, 1, 1}, {1, 1, 0, 0, 0, 1, 1}, {0, 1, 1, 1, 1, 1, 0}, {0, 1, 1, 1, 1, 1, 0}, {1, 1, 0, 0, 0, 1, 1}, {1, 1, 0, 0, 0, 1, 1}, {1, 1, 1, 1, 1, 1, 1}, {0, 1, 1, 1, 1, 1, 0}},
 	'9': {{0, 1, 1, 1, 1, 1, 0}, {1, 1, 1, 1, 1, 1, 1}, {1, 1, 0, 0, 0, 1, 1}, {1, 1, 0, 0, 0, 1, 1}, {0, 1, 1, 1, 1, 1, 1}, {0, 0, 0, 0, 0, 1, 1}, {0, 0, 0, 0, 0, 1, 1}, {1, 1, 1, 1, 1, 1, 1}, {0, 1, 1, 1, 1, 1, 0}},
+
+	// Error glyphs (see ErrorKind)
+	'?': {{0, 1, 1, 1, 1, 1, 0}, {1, 1, 1, 1, 1, 1, 1}, {1, 1, 0, 0, 0, 1, 1}, {0, 0, 0, 0, 1, 1, 1}, {0, 0, 0, 1, 1, 1, 0}, {0, 0, 1, 1, 1, 0, 0}, {0, 0, 0, 0, 0, 0, 0}, {0, 0, 1, 1, 1, 0, 0}, {0, 0, 1, 1, 1, 0, 0}},
+	'!': {{0, 0, 1, 1, 1, 0, 0}, {0, 0, 1, 1, 1, 0, 0}, {0, 0, 1, 1, 1, 0, 0}, {0, 0, 1, 1, 1, 0, 0}, {0, 0, 1, 1, 1, 0, 0}, {0, 0, 1, 1, 1, 0, 0}, {0, 0, 0, 0, 0, 0, 0}, {0, 0, 1, 1, 1, 0, 0}, {0, 0, 1, 1, 1, 0, 0}},
+	'X': {{1, 1, 0, 0, 0, 1, 1}, {1, 1, 0, 0, 0, 1, 1}, {0, 1, 1, 0, 1, 1, 0}, {0, 0, 1, 1, 1, 0, 0}, {0, 0, 1, 1, 1, 0, 0}, {0, 0, 1, 1, 1, 0, 0}, {0, 1, 1, 0, 1, 1, 0}, {1, 1, 0, 0, 0, 1, 1}, {1, 1, 0, 0, 0, 1, 1}},
+	'E': {{1, 1, 1, 1, 1, 1, 1}, {1, 1, 1, 1, 1, 1, 1}, {1, 1, 0, 0, 0, 0, 0}, {1, 1, 1, 1, 1, 1, 0}, {1, 1, 1, 1, 1, 1, 0}, {1, 1, 0, 0, 0, 0, 0}, {1, 1, 0, 0, 0, 0, 0}, {1, 1, 1, 1, 1, 1, 1}, {1, 1, 1, 1, 1, 1, 1}},
 }
 
 // drawChar draws a single character at the given position
@@ -53,7 +59,7 @@ func drawChar(img *image.RGBA, char rune, startX, startY int, textColor color.RG
 	}
 }
 
-// drawText draws digits centered at the given position
+// drawText draws digits and glyphs centered at the given position
 func drawText(img *image.RGBA, text string, centerX, centerY int, textColor color.RGBA) {
 	charWidth := 8 // 7 pixels + 1 spacing
 	charHeight := 9
@@ -65,8 +71,24 @@ func drawText(img *image.RGBA, text string, centerX, centerY int, textColor colo
 	}
 }
 
-// RenderChipImage creates the chip icon image (without encoding)
+// RenderChipImage creates the chip icon image (without encoding).
+// The pins are drawn in c, so the icon color follows usage.
 func RenderChipImage(c color.RGBA, size int, percentage int) *image.RGBA {
+	// Format text - just the number
+	var text string
+	if percentage >= 100 {
+		text = "99"
+	} else if percentage < 0 {
+		text = "0"
+	} else {
+		text = fmt.Sprintf("%d", percentage)
+	}
+	return renderChip(c, size, text)
+}
+
+// renderChip creates the chip icon image with text, in digits or error
+// glyphs, and pins in c.
+func renderChip(c color.RGBA, size int, text string) *image.RGBA {
 	img := image.NewRGBA(image.Rect(0, 0, size, size))
 
 	// Main body - neon violet chip
@@ -82,44 +104,34 @@ func RenderChipImage(c color.RGBA, size int, percentage int) *image.RGBA {
 	img.SetRGBA(2, size-3, color.RGBA{0, 0, 0, 0})
 	img.SetRGBA(size-3, size-3, color.RGBA{0, 0, 0, 0})
 
-	// Draw cyan pins on all sides
+	// Draw pins on all sides
 	// Top pins
 	for i := 5; i < size-5; i += 3 {
-		img.SetRGBA(i, 0, cyanAccent)
-		img.SetRGBA(i, 1, cyanAccent)
-		img.SetRGBA(i+1, 0, cyanAccent)
-		img.SetRGBA(i+1, 1, cyanAccent)
+		img.SetRGBA(i, 0, c)
+		img.SetRGBA(i, 1, c)
+		img.SetRGBA(i+1, 0, c)
+		img.SetRGBA(i+1, 1, c)
 	}
 	// Bottom pins
 	for i := 5; i < size-5; i += 3 {
-		img.SetRGBA(i, size-1, cyanAccent)
-		img.SetRGBA(i, size-2, cyanAccent)
-		img.SetRGBA(i+1, size-1, cyanAccent)
-		img.SetRGBA(i+1, size-2, cyanAccent)
+		img.SetRGBA(i, size-1, c)
+		img.SetRGBA(i, size-2, c)
+		img.SetRGBA(i+1, size-1, c)
+		img.SetRGBA(i+1, size-2, c)
 	}
 	// Left pins
 	for i := 5; i < size-5; i += 3 {
-		img.SetRGBA(0, i, cyanAccent)
-		img.SetRGBA(1, i, cyanAccent)
-		img.SetRGBA(0, i+1, cyanAccent)
-		img.SetRGBA(1, i+1, cyanAccent)
+		img.SetRGBA(0, i, c)
+		img.SetRGBA(1, i, c)
+		img.SetRGBA(0, i+1, c)
+		img.SetRGBA(1, i+1, c)
 	}
 	// Right pins
 	for i := 5; i < size-5; i += 3 {
-		img.SetRGBA(size-1, i, cyanAccent)
-		img.SetRGBA(size-2, i, cyanAccent)
-		img.SetRGBA(size-1, i+1, cyanAccent)
-		img.SetRGBA(size-2, i+1, cyanAccent)
-	}
-
-	// Format text - just the number
-	var text string
-	if percentage >= 100 {
-		text = "99"
-	} else if percentage < 0 {
-		text = "0"
-	} else {
-		text = fmt.Sprintf("%d", percentage)
+		img.SetRGBA(size-1, i, c)
+		img.SetRGBA(size-2, i, c)
+		img.SetRGBA(size-1, i+1, c)
+		img.SetRGBA(size-2, i+1, c)
 	}
 
 	// Draw white text in center (bigger 7x9 font)
@@ -255,8 +267,12 @@ func EncodePNG(img *image.RGBA) ([]byte, error) {
 // RenderNeonOrbWithText creates a chip icon with percentage text
 // Returns ICO format on Windows, PNG on other platforms
 func RenderNeonOrbWithText(c color.RGBA, size int, percentage int) ([]byte, error) {
-	img := RenderChipImage(c, size, percentage)
+	return encodeForPlatform(RenderChipImage(c, size, percentage))
+}
 
+// encodeForPlatform encodes a tray icon image.
+// Returns ICO format on Windows, PNG on other platforms
+func encodeForPlatform(img *image.RGBA) ([]byte, error) {
 	// On Windows, return ICO format for system tray compatibility
 	if runtime.GOOS == "windows" {
 		return EncodeICO(img)
@@ -266,6 +282,35 @@ func RenderNeonOrbWithText(c color.RGBA, size int, percentage int) ([]byte, erro
 	return EncodePNG(img)
 }
 
+// MarkFiveHour draws a marker in the chip's top-left corner, in c, to
+// show that the icon's color follows the 5-hour window.
+func MarkFiveHour(img *image.RGBA, c color.RGBA) {
+	for y := 3; y < 5; y++ {
+		for x := 3; x < 5; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// MarkStale draws a dot in the top right corner of img, shown while the
+// data is old. It is drawn at full opacity, even over a dimmed icon.
+func MarkStale(img *image.RGBA) {
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Min.Y+3; y++ {
+		for x := bounds.Max.X - 3; x < bounds.Max.X; x++ {
+			img.SetRGBA(x, y, ColorNeonOrange)
+		}
+	}
+}
+
+// DimImage fades img to half opacity in place.
+// image.RGBA is alpha-premultiplied, so every channel is scaled alike.
+func DimImage(img *image.RGBA) {
+	for i := range img.Pix {
+		img.Pix[i] /= 2
+	}
+}
+
 // RenderAppIcon creates an application icon (without percentage text)
 // Returns the appropriate format for the current platform
 func RenderAppIcon(size int) ([]byte, error) {
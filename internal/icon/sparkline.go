@@ -0,0 +1,51 @@
+package icon
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// sparklineRows is how many of the icon's bottom rows the sparkline takes.
+const sparklineRows = 2
+
+// sparklineLevels is how many heights a sparkline column can show: two
+// rows, each at half or full intensity.
+const sparklineLevels = 2 * sparklineRows
+
+// DrawSparkline replaces the bottom pins of img with a sparkline of values,
+// one column per pixel from the left. Values are utilizations (0.0-1.0)
+// drawn in the color of their usage level; negative values are gaps.
+func DrawSparkline(img *image.RGBA, values []float64) {
+	bounds := img.Bounds()
+	for y := bounds.Max.Y - sparklineRows; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			img.SetRGBA(x, y, color.RGBA{})
+		}
+	}
+
+	for i, v := range values {
+		x := bounds.Min.X + i
+		if x >= bounds.Max.X || v < 0 {
+			continue
+		}
+		c := GetColorForPercentage(int(v * 100))
+		// Any sample shows, however low, so gaps stand out
+		level := max(1, min(sparklineLevels, int(math.Round(v*sparklineLevels))))
+		for row := 0; row < sparklineRows; row++ {
+			y := bounds.Max.Y - 1 - row
+			switch fill := level - 2*row; {
+			case fill >= 2:
+				img.SetRGBA(x, y, c)
+			case fill == 1:
+				img.SetRGBA(x, y, halfIntensity(c))
+			}
+		}
+	}
+}
+
+// halfIntensity returns c at half opacity. image.RGBA is
+// alpha-premultiplied, so every channel is scaled alike.
+func halfIntensity(c color.RGBA) color.RGBA {
+	return color.RGBA{R: c.R / 2, G: c.G / 2, B: c.B / 2, A: c.A / 2}
+}
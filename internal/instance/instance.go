@@ -0,0 +1,115 @@
+// Package instance enforces a single running instance per profile using a
+// local socket, and lets later launches send commands to the running instance.
+package instance
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrAlreadyRunning is returned by Acquire when another instance holds the lock.
+var ErrAlreadyRunning = errors.New("another instance is already running")
+
+// CommandRefresh asks the running instance to refresh immediately.
+const CommandRefresh = "refresh"
+
+// ioTimeout bounds how long either side waits on the socket.
+const ioTimeout = 5 * time.Second
+
+// Lock is held by the running instance for as long as it listens on the socket.
+type Lock struct {
+	listener net.Listener
+	path     string
+}
+
+// Acquire takes the single-instance lock by listening on a Unix socket at path.
+// Returns ErrAlreadyRunning if another instance is listening. A stale socket
+// left behind by a crashed instance is removed and taken over.
+func Acquire(path string) (*Lock, error) {
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		// Socket file exists - is anyone still listening on it?
+		if conn, dialErr := net.DialTimeout("unix", path, ioTimeout); dialErr == nil {
+			conn.Close()
+			return nil, ErrAlreadyRunning
+		}
+
+		// Stale socket from a previous run
+		os.Remove(path)
+		listener, err = net.Listen("unix", path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+		}
+	}
+
+	// Only the current user may send commands
+	if err := os.Chmod(path, 0600); err != nil {
+		log.Printf("Warning: could not restrict socket permissions: %v", err)
+	}
+
+	return &Lock{listener: listener, path: path}, nil
+}
+
+// Serve handles commands from later launches in the background,
+// calling handler with each command received.
+func (l *Lock) Serve(handler func(command string)) {
+	go func() {
+		for {
+			conn, err := l.listener.Accept()
+			if err != nil {
+				// Listener closed
+				return
+			}
+			go handleConn(conn, handler)
+		}
+	}()
+}
+
+// Close releases the lock and removes the socket file.
+func (l *Lock) Close() error {
+	return l.listener.Close()
+}
+
+// handleConn reads a single newline-terminated command and acknowledges it.
+func handleConn(conn net.Conn, handler func(command string)) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(ioTimeout))
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return
+	}
+
+	handler(strings.TrimSpace(line))
+	fmt.Fprintln(conn, "ok")
+}
+
+// Send delivers a command to the instance listening at path and waits for
+// its acknowledgement.
+func Send(path, command string) error {
+	conn, err := net.DialTimeout("unix", path, ioTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to running instance: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(ioTimeout))
+
+	if _, err := fmt.Fprintln(conn, command); err != nil {
+		return fmt.Errorf("failed to send command: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("no reply from running instance: %w", err)
+	}
+	if strings.TrimSpace(reply) != "ok" {
+		return fmt.Errorf("unexpected reply from running instance: %q", reply)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package instance
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestAcquire_SecondInstance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sock")
+
+	lock, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("First Acquire failed: %v", err)
+	}
+	defer lock.Close()
+
+	received := make(chan string, 1)
+	lock.Serve(func(command string) {
+		received <- command
+	})
+
+	if _, err := Acquire(path); !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("Expected ErrAlreadyRunning, got: %v", err)
+	}
+
+	if err := Send(path, CommandRefresh); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if got := <-received; got != CommandRefresh {
+		t.Errorf("Received %q, expected %q", got, CommandRefresh)
+	}
+}
+
+func TestAcquire_AfterClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sock")
+
+	lock, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("First Acquire failed: %v", err)
+	}
+	lock.Close()
+
+	lock, err = Acquire(path)
+	if err != nil {
+		t.Fatalf("Acquire after Close failed: %v", err)
+	}
+	lock.Close()
+}
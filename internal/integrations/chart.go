@@ -0,0 +1,33 @@
+package integrations
+
+import (
+	"bytes"
+	"log"
+	"time"
+
+	"claude-usage/internal/chart"
+	"claude-usage/internal/history"
+)
+
+// chartPeriod is how much usage the attached chart shows.
+const chartPeriod = 7 * 24 * time.Hour
+
+// usageChart returns a function drawing the last chartPeriod of the sample
+// log at path as a PNG image, or returning nil when the log cannot be read.
+func usageChart(path string) func() []byte {
+	return func() []byte {
+		now := time.Now()
+		from := now.Add(-chartPeriod)
+		samples, err := history.LoadSamples(path, from)
+		if err != nil {
+			log.Printf("Warning: could not read usage samples for the chart: %v", err)
+			return nil
+		}
+		var buf bytes.Buffer
+		if err := chart.WritePNG(&buf, samples, chart.Options{From: from, To: now}); err != nil {
+			log.Printf("Warning: could not draw the usage chart: %v", err)
+			return nil
+		}
+		return buf.Bytes()
+	}
+}
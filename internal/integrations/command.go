@@ -0,0 +1,73 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Command runs a shell command for each event, an escape hatch for
+// services without a built-in integration. The event is passed as the
+// webhook JSON on stdin and in CLAUDE_USAGE_* environment variables.
+type Command struct {
+	command string
+}
+
+// NewCommand creates a notifier running command with the system shell
+// (sh -c, or cmd /C on Windows).
+func NewCommand(command string) *Command {
+	return &Command{command: command}
+}
+
+// Name implements Notifier.
+func (c *Command) Name() string {
+	return "hook command"
+}
+
+// Notify implements Notifier. The command is killed when ctx is done.
+func (c *Command) Notify(ctx context.Context, e Event) error {
+	body, err := eventJSON(e)
+	if err != nil {
+		return err
+	}
+
+	cmd := shellCommand(ctx, c.command)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(cmd.Environ(), eventEnv(e)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%q: %w: %s", c.command, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// shellCommand returns a command running command with the system shell.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "/bin/sh", "-c", command)
+}
+
+// eventEnv returns e as environment variables for hook commands.
+func eventEnv(e Event) []string {
+	message, _ := Render(nil, e)
+	env := []string{
+		"CLAUDE_USAGE_EVENT=" + string(e.Kind),
+		"CLAUDE_USAGE_WINDOW=" + e.Window,
+		"CLAUDE_USAGE_PERCENT=" + strconv.Itoa(e.Percent),
+		"CLAUDE_USAGE_THRESHOLD=" + strconv.Itoa(e.Threshold),
+		"CLAUDE_USAGE_PREVIOUS_PERCENT=" + strconv.Itoa(e.PreviousPercent),
+		"CLAUDE_USAGE_FIVE_HOUR_PERCENT=" + strconv.Itoa(e.FiveHourPercent),
+		"CLAUDE_USAGE_WEEKLY_PERCENT=" + strconv.Itoa(e.WeeklyPercent),
+		"CLAUDE_USAGE_MESSAGE=" + message,
+	}
+	if !e.Reset.IsZero() {
+		env = append(env, "CLAUDE_USAGE_RESET="+e.Reset.UTC().Format(time.RFC3339))
+	}
+	return env
+}
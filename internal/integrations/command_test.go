@@ -0,0 +1,51 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCommand_PassesEvent(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	dir := t.TempDir()
+	e := Event{Kind: KindThreshold, Window: WindowWeekly, Percent: 91, Threshold: 90, Reset: testReset, Time: testNow}
+
+	c := NewCommand("cd " + dir + ` && cat > stdin.json && echo "$CLAUDE_USAGE_EVENT $CLAUDE_USAGE_WINDOW $CLAUDE_USAGE_PERCENT $CLAUDE_USAGE_RESET" > env.txt`)
+	if err := c.Notify(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+
+	env, err := os.ReadFile(filepath.Join(dir, "env.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "threshold weekly 91 2026-01-14T14:00:00Z"; strings.TrimSpace(string(env)) != want {
+		t.Errorf("environment: got %q, want %q", env, want)
+	}
+
+	stdin, err := os.ReadFile(filepath.Join(dir, "stdin.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(stdin, &body); err != nil || body["threshold"] != float64(90) {
+		t.Errorf("stdin: got %s (%v)", stdin, err)
+	}
+}
+
+func TestCommand_ReportsFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	err := NewCommand("echo broken >&2; exit 3").Notify(context.Background(), Event{Kind: KindReset})
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("got %v, want an error with the command output", err)
+	}
+}
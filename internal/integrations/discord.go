@@ -0,0 +1,42 @@
+package integrations
+
+import (
+	"context"
+	"net/http"
+)
+
+// Discord posts events to a Discord webhook.
+type Discord struct {
+	url       string
+	templates map[string]string
+	client    *http.Client
+
+	// chart renders the chart to attach, or is nil for none
+	chart func() []byte
+}
+
+// NewDiscord creates a notifier for the webhook at url. templates override
+// DefaultTemplates per event kind and may be nil.
+func NewDiscord(url string, templates map[string]string) *Discord {
+	return &Discord{url: url, templates: templates, client: newHTTPClient()}
+}
+
+// Name implements Notifier.
+func (d *Discord) Name() string {
+	return "Discord"
+}
+
+// Notify implements Notifier.
+func (d *Discord) Notify(ctx context.Context, e Event) error {
+	content, err := Render(d.templates, e)
+	if err != nil {
+		return err
+	}
+	payload := map[string]string{"content": content}
+	if d.chart != nil {
+		if png := d.chart(); png != nil {
+			return postMultipart(ctx, d.client, d.url, payload, "claude-usage.png", png)
+		}
+	}
+	return postJSON(ctx, d.client, d.url, payload, nil)
+}
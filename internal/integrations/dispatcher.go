@@ -0,0 +1,170 @@
+package integrations
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/stats"
+)
+
+// Notifier delivers events to one service.
+type Notifier interface {
+	// Name identifies the service in log messages
+	Name() string
+
+	Notify(ctx context.Context, e Event) error
+}
+
+// Detector finds the events between consecutive refreshes. It is not safe
+// for concurrent use.
+type Detector struct {
+	thresholds []int
+
+	// modelThresholds are the per-model thresholds (see DetectModels)
+	modelThresholds map[string][]int
+
+	// spendThresholds are the extra usage spend alerts (see DetectSpend)
+	spendThresholds []int
+
+	// budgetTokens and budgetUSD are the personal budget (see DetectBudget)
+	budgetTokens int64
+	budgetUSD    float64
+
+	// prev is the last fresh refresh, prevLocal the last one of any kind
+	prev, prevLocal *stats.WeeklyStats
+}
+
+// NewDetector creates a detector reporting crossings of thresholds and
+// per-model modelThresholds (percentages).
+func NewDetector(thresholds []int, modelThresholds map[string][]int) *Detector {
+	return &Detector{thresholds: thresholds, modelThresholds: modelThresholds}
+}
+
+// DetectorFromConfig creates a detector for the alert thresholds and spend
+// alerts in cfg, which also watches the personal budget in budget mode.
+func DetectorFromConfig(cfg *config.Config) *Detector {
+	d := NewDetector(cfg.GetAlertThresholds(), cfg.ModelAlertThresholds)
+	d.budgetTokens, d.budgetUSD = cfg.Budget()
+	d.spendThresholds = cfg.GetSpendAlerts()
+	return d
+}
+
+// Observe compares cur with the previous fresh refresh and returns the
+// events between them.
+func (d *Detector) Observe(cur *stats.WeeklyStats, now time.Time) []Event {
+	events := Detect(d.prev, cur, d.thresholds, now)
+	events = append(events, DetectModels(d.prev, cur, d.modelThresholds, now)...)
+	events = append(events, DetectSpend(d.prev, cur, d.spendThresholds, now)...)
+	events = append(events, DetectBudget(d.prevLocal, cur, d.budgetTokens, d.budgetUSD, d.thresholds, now)...)
+	if fresh(cur) {
+		d.prev = cur
+	}
+	d.prevLocal = cur
+	return events
+}
+
+// Dispatcher hands events to notifiers. A nil Dispatcher does nothing.
+type Dispatcher struct {
+	notifiers []Notifier
+}
+
+// NewDispatcher creates a dispatcher sending events to notifiers.
+func NewDispatcher(notifiers ...Notifier) *Dispatcher {
+	return &Dispatcher{notifiers: notifiers}
+}
+
+// chatEvents are the events sent to chat services by default; resets of
+// the 5-hour window would be too chatty.
+var chatEvents = []string{config.EventThreshold, config.EventThrottled, config.EventUnthrottled, config.EventSpend}
+
+// emailEvents are the events emailed by default: throttling, spend alerts
+// and thresholds of at least email_min_threshold.
+var emailEvents = []string{config.EventThreshold, config.EventThrottled, config.EventSpend}
+
+// FromConfig creates a dispatcher for the integrations enabled in cfg, or
+// returns nil if there are none.
+func FromConfig(cfg *config.Config) *Dispatcher {
+	var notifiers []Notifier
+	if cfg.SlackWebhookURL != "" {
+		notifiers = append(notifiers, Filter(NewSlack(cfg.SlackWebhookURL, cfg.SlackTemplates), orDefault(cfg.SlackEvents, chatEvents)))
+	}
+	var chart func() []byte
+	if cfg.AttachChart {
+		chart = usageChart(config.GetSamplesPath(cfg.Profile))
+	}
+	if cfg.DiscordWebhookURL != "" {
+		discord := NewDiscord(cfg.DiscordWebhookURL, cfg.DiscordTemplates)
+		discord.chart = chart
+		notifiers = append(notifiers, Filter(discord, orDefault(cfg.DiscordEvents, chatEvents)))
+	}
+	if cfg.WebhookURL != "" {
+		webhook := NewWebhook(cfg.WebhookURL, cfg.WebhookHeaders, cfg.WebhookTemplate)
+		webhook.chart = chart
+		notifiers = append(notifiers, Filter(webhook, orDefault(cfg.WebhookEvents, config.EventKinds)))
+	}
+	if cfg.SMTPServer != "" {
+		email := NewEmail(cfg.SMTPServer, cfg.SMTPUsername, cfg.SMTPPassword, cfg.EmailFrom, cfg.EmailTo)
+		notifiers = append(notifiers, Filter(minThreshold{email, cfg.GetEmailMinThreshold()}, orDefault(cfg.EmailEvents, emailEvents)))
+	}
+	for _, hook := range []struct{ command, kind string }{
+		{cfg.OnThreshold, config.EventThreshold},
+		{cfg.OnThrottled, config.EventThrottled},
+		{cfg.OnReset, config.EventReset},
+	} {
+		if hook.command != "" {
+			notifiers = append(notifiers, Filter(NewCommand(hook.command), []string{hook.kind}))
+		}
+	}
+	if len(notifiers) == 0 {
+		return nil
+	}
+	return NewDispatcher(notifiers...)
+}
+
+// orDefault returns events, or def if events is empty.
+func orDefault(events, def []string) []string {
+	if len(events) == 0 {
+		return def
+	}
+	return events
+}
+
+// filter passes only some event kinds to a notifier.
+type filter struct {
+	Notifier
+	kinds []string
+}
+
+// Filter wraps n so it only receives events of the given kinds.
+func Filter(n Notifier, kinds []string) Notifier {
+	return filter{Notifier: n, kinds: kinds}
+}
+
+// Notify implements Notifier.
+func (f filter) Notify(ctx context.Context, e Event) error {
+	if !slices.Contains(f.kinds, string(e.Kind)) {
+		return nil
+	}
+	return f.Notifier.Notify(ctx, e)
+}
+
+// Notify sends e to every notifier in the background. Sends are abandoned
+// when ctx is cancelled.
+func (d *Dispatcher) Notify(ctx context.Context, e Event) {
+	if d == nil {
+		return
+	}
+
+	for _, n := range d.notifiers {
+		go func() {
+			ctx, cancel := context.WithTimeout(ctx, postTimeout)
+			defer cancel()
+			if err := n.Notify(ctx, e); err != nil {
+				log.Printf("Warning: could not post %s event to %s: %v", e.Kind, n.Name(), err)
+			}
+		}()
+	}
+}
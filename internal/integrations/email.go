@@ -0,0 +1,157 @@
+package integrations
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Email sends events by SMTP, for machines where nobody watches the tray.
+type Email struct {
+	server   string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+// NewEmail creates a notifier mailing events from one address to others
+// through server, a URL such as "smtp://mail.example.com:587" (STARTTLS
+// when offered) or "smtps://mail.example.com" (TLS). Without username no
+// authentication is attempted.
+func NewEmail(server, username, password, from string, to []string) *Email {
+	return &Email{server: server, username: username, password: password, from: from, to: to}
+}
+
+// Name implements Notifier.
+func (m *Email) Name() string {
+	return "email"
+}
+
+// Notify implements Notifier. The rendered default message is both the
+// subject and the body.
+func (m *Email) Notify(ctx context.Context, e Event) error {
+	text, err := Render(nil, e)
+	if err != nil {
+		return err
+	}
+	return m.send(ctx, emailMessage(m.from, m.to, text, e.Time))
+}
+
+// emailMessage formats a plain text message.
+func emailMessage(from string, to []string, text string, date time.Time) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", from)
+	fmt.Fprintf(&sb, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&sb, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", text))
+	fmt.Fprintf(&sb, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(text)
+	sb.WriteString("\r\n")
+	return []byte(sb.String())
+}
+
+// send delivers msg through the server.
+func (m *Email) send(ctx context.Context, msg []byte) error {
+	u, err := url.Parse(m.server)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid SMTP server URL %q", m.server)
+	}
+
+	var useTLS bool
+	switch u.Scheme {
+	case "smtp":
+	case "smtps":
+		useTLS = true
+	default:
+		return fmt.Errorf("unsupported SMTP scheme %q", u.Scheme)
+	}
+
+	host := u.Hostname()
+	addr := u.Host
+	if u.Port() == "" {
+		port := "587"
+		if useTLS {
+			port = "465"
+		}
+		addr = net.JoinHostPort(host, port)
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	if useTLS {
+		d := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}
+		conn, err = d.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok && !useTLS {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	// PlainAuth refuses to send the password over an unencrypted
+	// connection to anything but localhost
+	if m.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.username, m.password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(m.from); err != nil {
+		return err
+	}
+	for _, to := range m.to {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// minThreshold drops threshold events below a threshold, so a notifier
+// only gets the critical ones.
+type minThreshold struct {
+	Notifier
+	min int
+}
+
+// Notify implements Notifier.
+func (f minThreshold) Notify(ctx context.Context, e Event) error {
+	if e.Kind == KindThreshold && e.Threshold < f.min {
+		return nil
+	}
+	return f.Notifier.Notify(ctx, e)
+}
@@ -0,0 +1,108 @@
+package integrations
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeSMTP accepts one message without TLS or authentication and returns
+// the envelope recipients and the message data.
+func fakeSMTP(t *testing.T) (addr string, received chan []string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received = make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+		var got []string
+		reply("220 fake ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 fake")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				got = append(got, strings.TrimSpace(line))
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				got = append(got, data.String())
+				reply("250 OK")
+			case cmd == "QUIT":
+				reply("221 bye")
+				received <- got
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestEmail_Notify(t *testing.T) {
+	addr, received := fakeSMTP(t)
+	m := NewEmail("smtp://"+addr, "", "", "monitor@example.com", []string{"me@example.com"})
+
+	e := Event{Kind: KindThrottled, Window: WindowWeekly, Reset: testReset, Time: testNow}
+	if err := m.Notify(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	got := <-received
+	if len(got) != 2 || got[0] != "RCPT TO:<me@example.com>" {
+		t.Fatalf("got %q", got)
+	}
+	for _, want := range []string{"From: monitor@example.com\r\n", "Subject: Claude usage is throttled by the weekly limit", "\r\n\r\nClaude usage is throttled"} {
+		if !strings.Contains(got[1], want) {
+			t.Errorf("message lacks %q:\n%s", want, got[1])
+		}
+	}
+}
+
+// collector is a notifier remembering the events it gets.
+type collector struct {
+	events []Event
+}
+
+func (c *collector) Name() string { return "collector" }
+
+func (c *collector) Notify(ctx context.Context, e Event) error {
+	c.events = append(c.events, e)
+	return nil
+}
+
+func TestMinThreshold(t *testing.T) {
+	rec := &collector{}
+	n := minThreshold{rec, 95}
+	n.Notify(context.Background(), Event{Kind: KindThreshold, Threshold: 90})
+	n.Notify(context.Background(), Event{Kind: KindThreshold, Threshold: 95})
+	n.Notify(context.Background(), Event{Kind: KindThrottled})
+	if len(rec.events) != 2 || rec.events[0].Threshold != 95 {
+		t.Errorf("got %v", rec.events)
+	}
+}
@@ -0,0 +1,266 @@
+// Package integrations posts usage events, such as a usage threshold being
+// crossed or throttling starting, to chat and automation services.
+package integrations
+
+import (
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/stats"
+	"claude-usage/pkg/format"
+)
+
+// Kind identifies what happened.
+type Kind string
+
+// Event kinds.
+const (
+	KindThreshold   Kind = config.EventThreshold   // A window's usage crossed a threshold
+	KindThrottled   Kind = config.EventThrottled   // Requests started being throttled
+	KindUnthrottled Kind = config.EventUnthrottled // Throttling ended
+	KindReset       Kind = config.EventReset       // A window reset
+	KindSpend       Kind = config.EventSpend       // Extra usage spend crossed a share of its limit
+)
+
+// Windows named in events.
+const (
+	WindowFiveHour = "5-hour"
+	WindowWeekly   = "weekly"
+	WindowOpus     = "weekly Opus"
+	WindowSonnet   = "weekly Sonnet"
+	WindowBudget   = "weekly budget"
+	WindowExtra    = "extra usage"
+)
+
+// Event is a change in usage worth telling others about. Templates can use
+// every field and method.
+type Event struct {
+	Kind Kind
+
+	// Window is the window the event is about: the one crossing a
+	// threshold or resetting, or the limiting one for throttle events
+	Window string
+
+	// Percent is the usage of Window; Threshold is the threshold crossed
+	Percent   int
+	Threshold int
+
+	// PreviousPercent is the last usage seen before Window reset
+	PreviousPercent int
+
+	// Reset is when Window resets
+	Reset time.Time
+
+	// Usage of both windows at the time of the event
+	FiveHourPercent int
+	WeeklyPercent   int
+
+	// Extra usage credits spent this month and their monthly limit, in
+	// dollars, for spend events
+	ExtraUsed  float64
+	ExtraLimit float64
+
+	Time time.Time
+}
+
+// ResetIn returns the time until Window resets, e.g. "2h 10m".
+func (e Event) ResetIn() string {
+	return format.FormatDuration(int64(e.Reset.Sub(e.Time).Seconds()))
+}
+
+// Spend returns the extra usage spend of a spend event, e.g.
+// "$31 / $50 (62%)".
+func (e Event) Spend() string {
+	return format.FormatDollars(e.ExtraUsed) + " / " + format.FormatDollars(e.ExtraLimit) +
+		" (" + format.FormatPercent(e.Percent) + ")"
+}
+
+// windowChange is how far a reset time must move for the window to count as
+// a new one; reset times reported by the API jitter by a few seconds.
+const windowChange = time.Hour
+
+// Detect compares two consecutive refreshes and returns the events between
+// them. Only fresh API data is compared, so nothing is reported on the first
+// refresh or while the API is unreachable. A window resets when its reset
+// time moves forward. A threshold is crossed when the
+// previous usage of a window was below it and the current usage is at or
+// above it; after a window resets its previous usage counts as zero.
+func Detect(prev, cur *stats.WeeklyStats, thresholds []int, now time.Time) []Event {
+	if !fresh(prev) || !fresh(cur) {
+		return nil
+	}
+
+	base := baseEvent(cur, now)
+
+	var events []Event
+	windows := []struct {
+		name                string
+		prevUtil, curUtil   float64
+		prevReset, curReset time.Time
+	}{
+		{WindowFiveHour, prev.FiveHourUtilization, cur.FiveHourUtilization, prev.FiveHourReset, cur.FiveHourReset},
+		{WindowWeekly, prev.WeeklyUtilization, cur.WeeklyUtilization, prev.WeeklyReset, cur.WeeklyReset},
+	}
+	for _, w := range windows {
+		before, after := percent(w.prevUtil), percent(w.curUtil)
+		if !w.prevReset.IsZero() && w.curReset.Sub(w.prevReset) > windowChange {
+			e := base
+			e.Kind = KindReset
+			e.Window = w.name
+			e.Percent = after
+			e.PreviousPercent = before
+			e.Reset = w.curReset
+			events = append(events, e)
+			before = 0
+		}
+		if crossed := highestCrossed(before, after, thresholds); crossed > 0 {
+			e := base
+			e.Kind = KindThreshold
+			e.Window = w.name
+			e.Percent = after
+			e.Threshold = crossed
+			e.Reset = w.curReset
+			events = append(events, e)
+		}
+	}
+
+	if prev.IsThrottled() != cur.IsThrottled() {
+		e := base
+		e.Kind = KindUnthrottled
+		if cur.IsThrottled() {
+			e.Kind = KindThrottled
+		}
+		e.Window, e.Percent, e.Reset = WindowWeekly, base.WeeklyPercent, cur.WeeklyReset
+		if cur.IsLimitedByFiveHour() {
+			e.Window, e.Percent, e.Reset = WindowFiveHour, base.FiveHourPercent, cur.FiveHourReset
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+// DetectModels is Detect for the per-model weekly windows, which only
+// report threshold crossings. thresholds holds each model's thresholds,
+// keyed by config.ModelOpus and config.ModelSonnet; models without any
+// are not checked.
+func DetectModels(prev, cur *stats.WeeklyStats, thresholds map[string][]int, now time.Time) []Event {
+	if len(thresholds) == 0 || !fresh(prev) || !fresh(cur) {
+		return nil
+	}
+
+	base := baseEvent(cur, now)
+
+	var events []Event
+	windows := []struct {
+		model, name         string
+		prevUtil, curUtil   float64
+		prevReset, curReset time.Time
+	}{
+		{config.ModelOpus, WindowOpus, prev.OpusUtilization, cur.OpusUtilization, prev.OpusReset, cur.OpusReset},
+		{config.ModelSonnet, WindowSonnet, prev.SonnetUtilization, cur.SonnetUtilization, prev.SonnetReset, cur.SonnetReset},
+	}
+	for _, w := range windows {
+		before, after := percent(w.prevUtil), percent(w.curUtil)
+		if !w.prevReset.IsZero() && w.curReset.Sub(w.prevReset) > windowChange {
+			before = 0
+		}
+		if crossed := highestCrossed(before, after, thresholds[w.model]); crossed > 0 {
+			e := base
+			e.Kind = KindThreshold
+			e.Window = w.name
+			e.Percent = after
+			e.Threshold = crossed
+			e.Reset = w.curReset
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// DetectBudget is Detect for a personal weekly budget of tokens or usd (see
+// config.Budget), reporting threshold crossings only. The budget's usage
+// comes from local token counts and cost estimates, so the API data need
+// not be fresh; its week starts anew when WeekStart moves forward.
+func DetectBudget(prev, cur *stats.WeeklyStats, tokens int64, usd float64, thresholds []int, now time.Time) []Event {
+	if (tokens <= 0 && usd <= 0) || prev == nil || cur == nil {
+		return nil
+	}
+
+	before := int(prev.BudgetPercentage(tokens, usd))
+	after := int(cur.BudgetPercentage(tokens, usd))
+	if cur.WeekStart.Sub(prev.WeekStart) > windowChange {
+		before = 0
+	}
+	crossed := highestCrossed(before, after, thresholds)
+	if crossed == 0 {
+		return nil
+	}
+
+	e := baseEvent(cur, now)
+	e.Kind = KindThreshold
+	e.Window = WindowBudget
+	e.Percent = after
+	e.Threshold = crossed
+	e.Reset = cur.WeekEnd
+	return []Event{e}
+}
+
+// DetectSpend reports a spend event when extra usage spend crosses one of
+// thresholds (percentages of the monthly credit limit) between two fresh
+// refreshes. Spend going down means a new month, which starts over from
+// zero.
+func DetectSpend(prev, cur *stats.WeeklyStats, thresholds []int, now time.Time) []Event {
+	if !fresh(prev) || !fresh(cur) || !cur.ExtraUsageEnabled || cur.ExtraUsageLimit <= 0 {
+		return nil
+	}
+
+	before, after := prev.ExtraUsagePercent(), cur.ExtraUsagePercent()
+	if cur.ExtraUsageUsed < prev.ExtraUsageUsed {
+		before = 0
+	}
+	crossed := highestCrossed(before, after, thresholds)
+	if crossed == 0 {
+		return nil
+	}
+
+	e := baseEvent(cur, now)
+	e.Kind = KindSpend
+	e.Window = WindowExtra
+	e.Percent = after
+	e.Threshold = crossed
+	e.ExtraUsed = cur.ExtraUsageUsed
+	e.ExtraLimit = cur.ExtraUsageLimit
+	return []Event{e}
+}
+
+// baseEvent returns an event with the fields shared by every event at now.
+func baseEvent(cur *stats.WeeklyStats, now time.Time) Event {
+	return Event{
+		FiveHourPercent: percent(cur.FiveHourUtilization),
+		WeeklyPercent:   percent(cur.WeeklyUtilization),
+		Time:            now,
+	}
+}
+
+// highestCrossed returns the highest of thresholds that usage went from
+// below (before) to at or above (after), or 0 if none was crossed. Only the
+// highest is reported when a jump crosses several.
+func highestCrossed(before, after int, thresholds []int) int {
+	crossed := 0
+	for _, t := range thresholds {
+		if before < t && after >= t && t > crossed {
+			crossed = t
+		}
+	}
+	return crossed
+}
+
+// fresh reports whether s holds current API data.
+func fresh(s *stats.WeeklyStats) bool {
+	return s != nil && s.HasAPIData && !s.APIDataStale
+}
+
+// percent converts a utilization (0.0-1.0) to a whole percentage.
+func percent(utilization float64) int {
+	return int(utilization * 100)
+}
@@ -0,0 +1,198 @@
+package integrations
+
+import (
+	"testing"
+	"time"
+
+	"claude-usage/internal/stats"
+)
+
+var (
+	testNow   = time.Date(2026, 1, 14, 12, 0, 0, 0, time.UTC)
+	testReset = testNow.Add(2 * time.Hour)
+)
+
+func apiStats(fiveHour, weekly float64, status string) *stats.WeeklyStats {
+	return &stats.WeeklyStats{
+		HasAPIData:          true,
+		FiveHourUtilization: fiveHour,
+		WeeklyUtilization:   weekly,
+		FiveHourReset:       testReset,
+		WeeklyReset:         testReset.AddDate(0, 0, 3),
+		RateLimitStatus:     status,
+		RepresentativeClaim: "five_hour",
+	}
+}
+
+func TestDetect_Thresholds(t *testing.T) {
+	thresholds := []int{50, 80, 90}
+
+	// Jumping over two thresholds reports the highest only
+	events := Detect(apiStats(0.45, 0.1, "allowed"), apiStats(0.85, 0.1, "allowed"), thresholds, testNow)
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1: %+v", len(events), events)
+	}
+	e := events[0]
+	if e.Kind != KindThreshold || e.Window != WindowFiveHour || e.Threshold != 80 || e.Percent != 85 {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if e.ResetIn() != "2h" {
+		t.Errorf("ResetIn: got %q, want 2h", e.ResetIn())
+	}
+
+	// Staying above a threshold is not a new crossing
+	if events := Detect(apiStats(0.85, 0.1, "allowed"), apiStats(0.88, 0.1, "allowed"), thresholds, testNow); len(events) != 0 {
+		t.Errorf("got events without a crossing: %+v", events)
+	}
+}
+
+func TestDetect_WindowReset(t *testing.T) {
+	prev := apiStats(0.95, 0.1, "allowed")
+	cur := apiStats(0.6, 0.1, "allowed")
+	cur.FiveHourReset = prev.FiveHourReset.Add(5 * time.Hour)
+
+	// A new window starts from zero, so 60% crosses 50% again
+	events := Detect(prev, cur, []int{50}, testNow)
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(events), events)
+	}
+	if e := events[0]; e.Kind != KindReset || e.Window != WindowFiveHour || e.PreviousPercent != 95 || e.Percent != 60 {
+		t.Errorf("unexpected reset event: %+v", e)
+	}
+	if e := events[1]; e.Kind != KindThreshold || e.Threshold != 50 {
+		t.Errorf("unexpected threshold event: %+v", e)
+	}
+}
+
+func TestDetect_Throttling(t *testing.T) {
+	events := Detect(apiStats(0.99, 0.5, "allowed"), apiStats(1.0, 0.5, "throttled"), nil, testNow)
+	if len(events) != 1 || events[0].Kind != KindThrottled || events[0].Window != WindowFiveHour {
+		t.Fatalf("got %+v", events)
+	}
+
+	events = Detect(apiStats(1.0, 0.5, "throttled"), apiStats(0.1, 0.5, "allowed"), nil, testNow)
+	if len(events) != 1 || events[0].Kind != KindUnthrottled {
+		t.Fatalf("got %+v", events)
+	}
+}
+
+func TestDetect_NeedsFreshData(t *testing.T) {
+	stale := apiStats(0.9, 0.1, "allowed")
+	stale.APIDataStale = true
+	if events := Detect(nil, apiStats(0.9, 0.1, "allowed"), []int{50}, testNow); events != nil {
+		t.Errorf("first refresh: got %+v", events)
+	}
+	if events := Detect(apiStats(0.1, 0.1, "allowed"), stale, []int{50}, testNow); events != nil {
+		t.Errorf("stale data: got %+v", events)
+	}
+}
+
+func TestDetectModels(t *testing.T) {
+	prev, cur := apiStats(0.1, 0.5, "allowed"), apiStats(0.1, 0.5, "allowed")
+	prev.OpusUtilization, cur.OpusUtilization = 0.7, 0.85
+	prev.SonnetUtilization, cur.SonnetUtilization = 0.7, 0.85
+	thresholds := map[string][]int{"opus": {50, 80}}
+
+	events := DetectModels(prev, cur, thresholds, testNow)
+	if len(events) != 1 {
+		t.Fatalf("expected one event, got %v", events)
+	}
+	if e := events[0]; e.Kind != KindThreshold || e.Window != WindowOpus || e.Threshold != 80 || e.Percent != 85 {
+		t.Errorf("unexpected event: %+v", e)
+	}
+
+	// A reset starts the window over from zero
+	prev.OpusReset = testReset
+	cur.OpusReset = testReset.AddDate(0, 0, 7)
+	events = DetectModels(prev, cur, thresholds, testNow)
+	if len(events) != 1 || events[0].Threshold != 80 {
+		t.Errorf("expected the highest threshold after a reset, got %v", events)
+	}
+
+	if events := DetectModels(prev, cur, nil, testNow); events != nil {
+		t.Errorf("expected no events without thresholds, got %v", events)
+	}
+}
+
+func TestDetectSpend(t *testing.T) {
+	prev, cur := apiStats(0.1, 0.5, "allowed"), apiStats(0.1, 0.5, "allowed")
+	for _, s := range []*stats.WeeklyStats{prev, cur} {
+		s.ExtraUsageEnabled, s.ExtraUsageLimit = true, 50
+	}
+	prev.ExtraUsageUsed, cur.ExtraUsageUsed = 20, 31
+
+	events := DetectSpend(prev, cur, []int{50, 80, 100}, testNow)
+	if len(events) != 1 {
+		t.Fatalf("expected one event, got %v", events)
+	}
+	e := events[0]
+	if e.Kind != KindSpend || e.Window != WindowExtra || e.Threshold != 50 || e.Percent != 62 {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if got, want := e.Spend(), "$31 / $50 (62%)"; got != want {
+		t.Errorf("Spend() = %q, want %q", got, want)
+	}
+
+	// Spend going down is a new month, starting over from zero
+	prev.ExtraUsageUsed, cur.ExtraUsageUsed = 45, 26
+	events = DetectSpend(prev, cur, []int{50, 80, 100}, testNow)
+	if len(events) != 1 || events[0].Threshold != 50 {
+		t.Errorf("expected the 50%% alert in a new month, got %v", events)
+	}
+
+	cur.ExtraUsageLimit = 0
+	if events := DetectSpend(prev, cur, []int{50}, testNow); events != nil {
+		t.Errorf("expected no events without a limit, got %v", events)
+	}
+}
+
+func TestDetectBudget(t *testing.T) {
+	start := testNow.AddDate(0, 0, -2)
+	prev := &stats.WeeklyStats{WeekStart: start, TotalTokens: 3_500_000, WeekCostUSD: 20}
+	cur := &stats.WeeklyStats{WeekStart: start, TotalTokens: 4_200_000, WeekCostUSD: 45}
+
+	events := DetectBudget(prev, cur, 5_000_000, 0, []int{50, 80}, testNow)
+	if len(events) != 1 {
+		t.Fatalf("expected one event, got %v", events)
+	}
+	if e := events[0]; e.Kind != KindThreshold || e.Window != WindowBudget || e.Threshold != 80 || e.Percent != 84 {
+		t.Errorf("unexpected event: %+v", e)
+	}
+
+	// A dollar budget crosses on cost
+	events = DetectBudget(prev, cur, 0, 50, []int{50, 80}, testNow)
+	if len(events) != 1 || events[0].Threshold != 80 {
+		t.Errorf("expected the 80%% threshold of the dollar budget, got %v", events)
+	}
+
+	// A new week starts the budget over from zero
+	cur.WeekStart = start.AddDate(0, 0, 7)
+	events = DetectBudget(prev, cur, 10_000_000, 0, []int{20, 40}, testNow)
+	if len(events) != 1 || events[0].Threshold != 40 {
+		t.Errorf("expected the highest threshold in a new week, got %v", events)
+	}
+
+	if events := DetectBudget(prev, cur, 0, 0, []int{50}, testNow); events != nil {
+		t.Errorf("expected no events without a budget, got %v", events)
+	}
+}
+
+func TestDetector_ComparesWithLastFreshRefresh(t *testing.T) {
+	d := NewDetector([]int{80}, nil)
+
+	if events := d.Observe(apiStats(0.5, 0.1, "allowed"), testNow); len(events) != 0 {
+		t.Fatalf("first refresh: got %+v", events)
+	}
+
+	// Stale data is skipped and does not replace the previous refresh
+	stale := apiStats(0.9, 0.1, "allowed")
+	stale.APIDataStale = true
+	if events := d.Observe(stale, testNow); len(events) != 0 {
+		t.Fatalf("stale refresh: got %+v", events)
+	}
+
+	events := d.Observe(apiStats(0.85, 0.1, "allowed"), testNow)
+	if len(events) != 1 || events[0].Threshold != 80 {
+		t.Errorf("got %+v, want one 80%% threshold event", events)
+	}
+}
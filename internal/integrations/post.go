@@ -0,0 +1,95 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"time"
+
+	"claude-usage/internal/httpclient"
+)
+
+// postTimeout bounds each webhook request.
+const postTimeout = 15 * time.Second
+
+// postJSON POSTs body, encoded as JSON, to rawURL.
+func postJSON(ctx context.Context, client *http.Client, rawURL string, body any, headers map[string]string) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return post(ctx, client, rawURL, b, headers)
+}
+
+// post POSTs a JSON body to rawURL with extra headers.
+func post(ctx context.Context, client *http.Client, rawURL string, body []byte, headers map[string]string) error {
+	return postBody(ctx, client, rawURL, "application/json", body, headers)
+}
+
+// postMultipart POSTs payload, encoded as JSON in a payload_json field, with
+// a PNG file attached as files[0], the form Discord takes attachments in.
+func postMultipart(ctx context.Context, client *http.Client, rawURL string, payload any, filename string, png []byte) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if err := mw.WriteField("payload_json", string(b)); err != nil {
+		return err
+	}
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Disposition": {fmt.Sprintf(`form-data; name="files[0]"; filename=%q`, filename)},
+		"Content-Type":        {"image/png"},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(png); err != nil {
+		return err
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+	return postBody(ctx, client, rawURL, mw.FormDataContentType(), body.Bytes(), nil)
+}
+
+// postBody POSTs body to rawURL with extra headers. Webhook URLs embed
+// their secret, so errors never include the URL.
+func postBody(ctx context.Context, client *http.Client, rawURL, contentType string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("invalid webhook URL")
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// newHTTPClient returns the client used for webhooks.
+func newHTTPClient() *http.Client {
+	return httpclient.New(postTimeout)
+}
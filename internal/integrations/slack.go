@@ -0,0 +1,33 @@
+package integrations
+
+import (
+	"context"
+	"net/http"
+)
+
+// Slack posts events to a Slack incoming webhook.
+type Slack struct {
+	url       string
+	templates map[string]string
+	client    *http.Client
+}
+
+// NewSlack creates a notifier for the incoming webhook at url. templates
+// override DefaultTemplates per event kind and may be nil.
+func NewSlack(url string, templates map[string]string) *Slack {
+	return &Slack{url: url, templates: templates, client: newHTTPClient()}
+}
+
+// Name implements Notifier.
+func (s *Slack) Name() string {
+	return "Slack"
+}
+
+// Notify implements Notifier.
+func (s *Slack) Notify(ctx context.Context, e Event) error {
+	text, err := Render(s.templates, e)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, s.client, s.url, map[string]string{"text": text}, nil)
+}
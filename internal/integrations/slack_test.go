@@ -0,0 +1,66 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	e := Event{Kind: KindThreshold, Window: WindowWeekly, Percent: 81, Threshold: 80, Reset: testReset, Time: testNow}
+
+	got, err := Render(nil, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Claude usage: weekly window at 81% (crossed 80%), resets in 2h"; got != want {
+		t.Errorf("default template: got %q, want %q", got, want)
+	}
+
+	got, err = Render(map[string]string{"threshold": "{{.Window}} {{.Percent}}%"}, e)
+	if err != nil || got != "weekly 81%" {
+		t.Errorf("custom template: got %q, %v", got, err)
+	}
+
+	if _, err := Render(map[string]string{"threshold": "{{.Nope}}"}, e); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
+
+func TestSlack_Notify(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type: got %q", ct)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	e := Event{Kind: KindUnthrottled, Window: WindowFiveHour, Percent: 12}
+	if err := NewSlack(srv.URL, nil).Notify(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got["text"], "no longer throttled") {
+		t.Errorf("got payload %v", got)
+	}
+}
+
+func TestSlack_ErrorHidesURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	url := srv.URL + "/services/T000/B000/secret"
+	err := NewSlack(url, nil).Notify(context.Background(), Event{Kind: KindThrottled})
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("got %v, want a 403 error", err)
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("error leaks the webhook URL: %v", err)
+	}
+}
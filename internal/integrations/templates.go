@@ -0,0 +1,55 @@
+package integrations
+
+import (
+	"encoding/json"
+	"strings"
+	"text/template"
+)
+
+// DefaultTemplates are the message templates used when none is configured
+// for an event kind. They are Go text/template strings executed with the
+// Event.
+var DefaultTemplates = map[Kind]string{
+	KindThreshold:   "Claude usage: {{.Window}} window at {{.Percent}}% (crossed {{.Threshold}}%), resets in {{.ResetIn}}",
+	KindThrottled:   "Claude usage is throttled by the {{.Window}} limit, resets in {{.ResetIn}}",
+	KindUnthrottled: "Claude usage is no longer throttled ({{.Window}} window at {{.Percent}}%)",
+	KindReset:       "Claude {{.Window}} window reset (was at {{.PreviousPercent}}%)",
+	KindSpend:       "Claude extra usage: {{.Spend}} of the monthly credit limit spent (crossed {{.Threshold}}%)",
+}
+
+// templateFuncs are available in templates. json encodes a value as JSON,
+// for building webhook bodies.
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// ParseTemplate parses a message template, reporting syntax errors.
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("message").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// execute runs a template text with e.
+func execute(text string, e Event) (string, error) {
+	tmpl, err := ParseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, e); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// Render formats e with the template configured for its kind in templates
+// (keyed by kind name), or the default template.
+func Render(templates map[string]string, e Event) (string, error) {
+	text, ok := templates[string(e.Kind)]
+	if !ok {
+		text = DefaultTemplates[e.Kind]
+	}
+	return execute(text, e)
+}
@@ -0,0 +1,104 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Webhook posts events as JSON to any URL, for home automation and scripts.
+type Webhook struct {
+	url      string
+	headers  map[string]string
+	template string
+	client   *http.Client
+
+	// chart renders the chart for the default body, or is nil for none
+	chart func() []byte
+}
+
+// NewWebhook creates a notifier posting to url with extra headers (such as
+// Authorization). The body is the event as JSON, or the output of template
+// when it is set.
+func NewWebhook(url string, headers map[string]string, template string) *Webhook {
+	return &Webhook{url: url, headers: headers, template: template, client: newHTTPClient()}
+}
+
+// Name implements Notifier.
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+// webhookBody is the default JSON body.
+type webhookBody struct {
+	Kind            Kind      `json:"kind"`
+	Window          string    `json:"window"`
+	Percent         int       `json:"percent"`
+	Threshold       int       `json:"threshold,omitempty"`
+	PreviousPercent int       `json:"previous_percent,omitempty"`
+	Reset           time.Time `json:"reset"`
+	FiveHourPercent int       `json:"five_hour_percent"`
+	WeeklyPercent   int       `json:"weekly_percent"`
+	ExtraUsed       float64   `json:"extra_used,omitempty"`
+	ExtraLimit      float64   `json:"extra_limit,omitempty"`
+	Time            time.Time `json:"time"`
+	Message         string    `json:"message"`
+	ChartPNG        []byte    `json:"chart_png,omitempty"`
+}
+
+// Notify implements Notifier.
+func (w *Webhook) Notify(ctx context.Context, e Event) error {
+	var body []byte
+	if w.template != "" {
+		out, err := execute(w.template, e)
+		if err != nil {
+			return err
+		}
+		body = []byte(out)
+	} else {
+		b, err := eventBody(e)
+		if err != nil {
+			return err
+		}
+		if w.chart != nil {
+			b.ChartPNG = w.chart()
+		}
+		if body, err = json.Marshal(b); err != nil {
+			return err
+		}
+	}
+	return post(ctx, w.client, w.url, body, w.headers)
+}
+
+// eventJSON returns e as the default webhook body, also passed to hook
+// commands.
+func eventJSON(e Event) ([]byte, error) {
+	b, err := eventBody(e)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(b)
+}
+
+// eventBody returns e as the default webhook body, without a chart.
+func eventBody(e Event) (webhookBody, error) {
+	message, err := Render(nil, e)
+	if err != nil {
+		return webhookBody{}, err
+	}
+	return webhookBody{
+		Kind:            e.Kind,
+		Window:          e.Window,
+		Percent:         e.Percent,
+		Threshold:       e.Threshold,
+		PreviousPercent: e.PreviousPercent,
+		Reset:           e.Reset,
+		FiveHourPercent: e.FiveHourPercent,
+		WeeklyPercent:   e.WeeklyPercent,
+		ExtraUsed:       e.ExtraUsed,
+		ExtraLimit:      e.ExtraLimit,
+		Time:            e.Time,
+		Message:         message,
+	}, nil
+}
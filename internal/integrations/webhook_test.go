@@ -0,0 +1,135 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recorder is a test server remembering the last request body and headers.
+type recorder struct {
+	*httptest.Server
+	body   []byte
+	header http.Header
+}
+
+func newRecorder(t *testing.T) *recorder {
+	r := &recorder{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.body, _ = io.ReadAll(req.Body)
+		r.header = req.Header
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func TestWebhook_DefaultBody(t *testing.T) {
+	srv := newRecorder(t)
+	e := Event{Kind: KindThreshold, Window: WindowWeekly, Percent: 91, Threshold: 90, Reset: testReset, Time: testNow}
+
+	w := NewWebhook(srv.URL, map[string]string{"Authorization": "Bearer abc"}, "")
+	if err := w.Notify(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.header.Get("Authorization"); got != "Bearer abc" {
+		t.Errorf("Authorization header: got %q", got)
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(srv.body, &body); err != nil {
+		t.Fatalf("body is not JSON: %s", srv.body)
+	}
+	if body["kind"] != "threshold" || body["window"] != "weekly" || body["percent"] != float64(91) || body["message"] == "" {
+		t.Errorf("unexpected body: %s", srv.body)
+	}
+}
+
+func TestWebhook_Template(t *testing.T) {
+	srv := newRecorder(t)
+	e := Event{Kind: KindThrottled, Window: `5-hour "quoted"`, Percent: 100}
+
+	w := NewWebhook(srv.URL, nil, `{"state": {{json .Kind}}, "window": {{json .Window}}}`)
+	if err := w.Notify(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"state": "throttled", "window": "5-hour \"quoted\""}`; string(srv.body) != want {
+		t.Errorf("got %s, want %s", srv.body, want)
+	}
+}
+
+func TestDiscord_Notify(t *testing.T) {
+	srv := newRecorder(t)
+	e := Event{Kind: KindReset, Window: WindowWeekly, PreviousPercent: 78}
+
+	if err := NewDiscord(srv.URL, nil).Notify(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	var body map[string]string
+	json.Unmarshal(srv.body, &body)
+	if want := "Claude weekly window reset (was at 78%)"; body["content"] != want {
+		t.Errorf("got %q, want %q", body["content"], want)
+	}
+}
+
+func TestWebhook_Chart(t *testing.T) {
+	srv := newRecorder(t)
+	w := NewWebhook(srv.URL, nil, "")
+	w.chart = func() []byte { return []byte("PNG") }
+
+	if err := w.Notify(context.Background(), Event{Kind: KindThrottled, Window: WindowFiveHour}); err != nil {
+		t.Fatal(err)
+	}
+	var body struct {
+		ChartPNG []byte `json:"chart_png"`
+	}
+	if err := json.Unmarshal(srv.body, &body); err != nil || string(body.ChartPNG) != "PNG" {
+		t.Errorf("chart_png: got %q, %v in %s", body.ChartPNG, err, srv.body)
+	}
+}
+
+func TestDiscord_Chart(t *testing.T) {
+	srv := newRecorder(t)
+	d := NewDiscord(srv.URL, nil)
+	d.chart = func() []byte { return []byte("PNG") }
+
+	if err := d.Notify(context.Background(), Event{Kind: KindThrottled, Window: WindowFiveHour}); err != nil {
+		t.Fatal(err)
+	}
+	_, params, err := mime.ParseMediaType(srv.header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	form, err := multipart.NewReader(bytes.NewReader(srv.body), params["boundary"]).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal([]byte(form.Value["payload_json"][0]), &payload); err != nil || !strings.Contains(payload["content"], "throttled") {
+		t.Errorf("payload_json: got %v, %v", form.Value["payload_json"], err)
+	}
+	files := form.File["files[0]"]
+	if len(files) != 1 || files[0].Filename != "claude-usage.png" || files[0].Size != 3 {
+		t.Errorf("attachment: got %v", files)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	srv := newRecorder(t)
+	n := Filter(NewDiscord(srv.URL, nil), []string{"throttled"})
+
+	n.Notify(context.Background(), Event{Kind: KindReset})
+	if srv.body != nil {
+		t.Errorf("filtered event was sent: %s", srv.body)
+	}
+	n.Notify(context.Background(), Event{Kind: KindThrottled})
+	if srv.body == nil {
+		t.Error("allowed event was not sent")
+	}
+}
@@ -0,0 +1,34 @@
+// Package launch opens URLs, files, and folders with the platform's default handler.
+package launch
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// Open opens a URL, file, or directory with the default application.
+// - Linux: xdg-open
+// - macOS: open
+// - Windows: rundll32 url.dll,FileProtocolHandler
+func Open(target string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		// rundll32 is a GUI program, so no console window flashes up
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default: // Linux and other Unix-like systems
+		cmd = exec.Command("xdg-open", target)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open %s: %w", target, err)
+	}
+
+	// Reap the helper process in the background
+	go cmd.Wait()
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"claude-usage/internal/redact"
+)
+
+// Rotation limits for the log file.
+const (
+	// MaxSize is the size at which the log file is rotated.
+	MaxSize = 5 * 1024 * 1024
+
+	// MaxBackups is how many rotated files are kept.
+	MaxBackups = 3
+
+	// MaxAge is how long rotated files are kept.
+	MaxAge = 14 * 24 * time.Hour
+)
+
+// RotatingFile is an io.WriteCloser that appends to a file and rotates it
+// when it grows past MaxSize. Rotated files are named path.1 (newest) to
+// path.N and are removed once there are more than MaxBackups of them or they
+// are older than MaxAge.
+type RotatingFile struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenRotatingFile opens path for appending, creating it and its directory
+// if needed.
+func OpenRotatingFile(path string) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	r := &RotatingFile{path: path}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	r.removeOldBackups()
+	return r, nil
+}
+
+// open opens the log file for appending. The caller must hold r.mu or have
+// exclusive access.
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// Write implements io.Writer, rotating first if p would push the file past
+// MaxSize.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.size > 0 && r.size+int64(len(p)) > MaxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts path.N-1 to path.N, ..., path to path.1 and starts a new
+// file. The caller must hold r.mu.
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+
+	for i := MaxBackups - 1; i >= 1; i-- {
+		os.Rename(backupName(r.path, i), backupName(r.path, i+1))
+	}
+	if err := os.Rename(r.path, backupName(r.path, 1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.removeOldBackups()
+	return nil
+}
+
+// removeOldBackups deletes rotated files beyond MaxBackups or older than MaxAge.
+func (r *RotatingFile) removeOldBackups() {
+	matches, _ := filepath.Glob(r.path + ".*")
+	sort.Strings(matches)
+	for _, m := range matches {
+		var n int
+		if _, err := fmt.Sscanf(strings.TrimPrefix(m, r.path+"."), "%d", &n); err != nil {
+			continue
+		}
+		info, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		if n > MaxBackups || time.Since(info.ModTime()) > MaxAge {
+			os.Remove(m)
+		}
+	}
+}
+
+// Close closes the log file.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// backupName returns the name of the n-th rotated file.
+func backupName(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// SetupFile sends the standard logger's output to a rotating file at path.
+// When console is true, output is also written to stderr. Tokens are
+// redacted from both. The returned closer flushes and closes the file.
+func SetupFile(path string, console bool) (io.Closer, error) {
+	f, err := OpenRotatingFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if console {
+		log.SetOutput(redact.NewWriter(io.MultiWriter(f, os.Stderr)))
+	} else {
+		log.SetOutput(redact.NewWriter(f))
+	}
+	return f, nil
+}
+
+// IsConsole reports whether stderr is attached to a terminal, i.e. the app
+// was started from a shell rather than a desktop session.
+func IsConsole() bool {
+	info, err := os.Stderr.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	r, err := OpenRotatingFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// Each chunk is a bit over a third of MaxSize, so every third write rotates
+	chunk := bytes.Repeat([]byte("x"), MaxSize/3+1)
+	for i := 0; i < 3*(MaxBackups+2); i++ {
+		if _, err := r.Write(chunk); err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() > MaxSize {
+		t.Errorf("log file is %d bytes, want at most %d", info.Size(), MaxSize)
+	}
+	for i := 1; i <= MaxBackups; i++ {
+		if _, err := os.Stat(backupName(path, i)); err != nil {
+			t.Errorf("backup %d missing: %v", i, err)
+		}
+	}
+	if _, err := os.Stat(backupName(path, MaxBackups+1)); !os.IsNotExist(err) {
+		t.Errorf("backup %d should have been removed", MaxBackups+1)
+	}
+}
+
+func TestRotatingFileRemovesExpiredBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	old := backupName(path, 1)
+	if err := os.WriteFile(old, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	expired := time.Now().Add(-MaxAge - time.Hour)
+	if err := os.Chtimes(old, expired, expired); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenRotatingFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("expired backup was not removed")
+	}
+}
@@ -0,0 +1,47 @@
+// Package logging adds a debug level on top of the standard logger and a
+// size-rotated log file, since tray apps started from a desktop session
+// have nowhere to write stderr.
+//
+// Debug output is off by default and is enabled with the "debug" config
+// setting, CLAUDE_USAGE_DEBUG or --debug. It must never include tokens or
+// other credentials; log output is also passed through package redact as a
+// safety net.
+package logging
+
+import (
+	"log"
+	"os"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+var debugEnabled atomic.Bool
+
+// SetDebug enables or disables debug output.
+func SetDebug(enabled bool) {
+	debugEnabled.Store(enabled)
+}
+
+// DebugEnabled reports whether debug output is enabled, for callers that
+// want to skip building expensive messages.
+func DebugEnabled() bool {
+	return debugEnabled.Load()
+}
+
+// Debugf logs a message when debug output is enabled.
+func Debugf(format string, args ...any) {
+	if debugEnabled.Load() {
+		log.Printf("Debug: "+format, args...)
+	}
+}
+
+// LogPanic logs a panic of the calling goroutine with its stack and exits.
+// Deferred at the top of a goroutine, it sends the crash report through
+// the redacting log rather than raw to stderr, which desktop sessions
+// discard anyway.
+func LogPanic() {
+	if r := recover(); r != nil {
+		log.Printf("Panic: %v\n%s", r, debug.Stack())
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,197 @@
+// Package mqtt publishes usage to an MQTT broker, with optional Home
+// Assistant discovery.
+//
+// Only what publishing needs of MQTT 3.1.1 is implemented: each publish
+// opens a connection, sends CONNECT and QoS 0 PUBLISH packets and
+// disconnects. Usage changes every few minutes at most, so a persistent
+// connection is not worth its reconnect logic.
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+)
+
+// Packet types (upper nibble of the first byte).
+const (
+	packetConnect    = 0x10
+	packetConnack    = 0x20
+	packetPublish    = 0x30
+	packetDisconnect = 0xE0
+)
+
+// keepAlive is sent in CONNECT; connections are short-lived anyway.
+const keepAlive = 60
+
+// connackErrors describes CONNACK return codes.
+var connackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// Message is one message to publish.
+type Message struct {
+	Topic   string
+	Payload []byte
+	Retain  bool
+}
+
+// Broker is where messages are published.
+type Broker struct {
+	// URL is tcp://host:port or mqtt://host:port for plain connections,
+	// ssl://, tls:// or mqtts:// for TLS. The port defaults to 1883, or
+	// 8883 with TLS.
+	URL string
+
+	Username string
+	Password string
+	ClientID string
+}
+
+// Publish connects to the broker, publishes messages and disconnects.
+func (b Broker) Publish(ctx context.Context, messages []Message) error {
+	conn, err := b.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	w := bufio.NewWriter(conn)
+	w.Write(connectPacket(b.ClientID, b.Username, b.Password))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := readConnack(conn); err != nil {
+		return err
+	}
+
+	for _, m := range messages {
+		w.Write(publishPacket(m))
+	}
+	w.Write([]byte{packetDisconnect, 0})
+	return w.Flush()
+}
+
+// dial opens a connection to the broker.
+func (b Broker) dial(ctx context.Context) (net.Conn, error) {
+	u, err := url.Parse(b.URL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid broker URL %q", b.URL)
+	}
+
+	var useTLS bool
+	switch u.Scheme {
+	case "tcp", "mqtt":
+	case "ssl", "tls", "mqtts":
+		useTLS = true
+	default:
+		return nil, fmt.Errorf("unsupported broker scheme %q", u.Scheme)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		port := "1883"
+		if useTLS {
+			port = "8883"
+		}
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if useTLS {
+		d := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: u.Hostname()}}
+		return d.DialContext(ctx, "tcp", addr)
+	}
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+// readConnack reads the broker's CONNACK and checks its return code.
+func readConnack(r io.Reader) error {
+	var buf [4]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return fmt.Errorf("reading CONNACK: %w", err)
+	}
+	if buf[0] != packetConnack || buf[1] != 2 {
+		return errors.New("unexpected reply to CONNECT")
+	}
+	if code := buf[3]; code != 0 {
+		if msg, ok := connackErrors[code]; ok {
+			return fmt.Errorf("connection refused: %s", msg)
+		}
+		return fmt.Errorf("connection refused (code %d)", code)
+	}
+	return nil
+}
+
+// connectPacket encodes a CONNECT packet with a clean session.
+func connectPacket(clientID, username, password string) []byte {
+	flags := byte(0x02) // Clean session
+	body := appendString(nil, "MQTT")
+	body = append(body, 4) // Protocol level 3.1.1
+	flagsAt := len(body)
+	body = append(body, 0, keepAlive>>8, keepAlive&0xFF)
+
+	body = appendString(body, clientID)
+	if username != "" {
+		flags |= 0x80
+		body = appendString(body, username)
+		if password != "" {
+			flags |= 0x40
+			body = appendString(body, password)
+		}
+	}
+	body[flagsAt] = flags
+	return packet(packetConnect, body)
+}
+
+// publishPacket encodes a QoS 0 PUBLISH packet.
+func publishPacket(m Message) []byte {
+	header := byte(packetPublish)
+	if m.Retain {
+		header |= 0x01
+	}
+	body := appendString(nil, m.Topic)
+	body = append(body, m.Payload...)
+	return packet(header, body)
+}
+
+// packet prefixes body with the fixed header.
+func packet(header byte, body []byte) []byte {
+	out := []byte{header}
+	out = appendLength(out, len(body))
+	return append(out, body...)
+}
+
+// appendLength appends the variable-length "remaining length" encoding.
+func appendLength(b []byte, n int) []byte {
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			return b
+		}
+	}
+}
+
+// appendString appends a length-prefixed UTF-8 string.
+func appendString(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
@@ -0,0 +1,180 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"claude-usage/internal/stats"
+)
+
+// received is a packet read by the fake broker.
+type received struct {
+	header byte
+	body   []byte
+}
+
+// fakeBroker accepts one connection, answers CONNECT with returnCode and
+// sends every packet it reads on the returned channel.
+func fakeBroker(t *testing.T, returnCode byte) (string, <-chan received) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	packets := make(chan received, 32)
+	go func() {
+		defer close(packets)
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			p, err := readPacket(r)
+			if err != nil {
+				return
+			}
+			packets <- p
+			switch p.header & 0xF0 {
+			case packetConnect:
+				conn.Write([]byte{packetConnack, 2, 0, returnCode})
+			case packetDisconnect:
+				return
+			}
+		}
+	}()
+	return "tcp://" + ln.Addr().String(), packets
+}
+
+func readPacket(r *bufio.Reader) (received, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return received{}, err
+	}
+	length, multiplier := 0, 1
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return received{}, err
+		}
+		length += int(b&0x7F) * multiplier
+		multiplier *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return received{header, body}, err
+}
+
+// topicAndPayload splits a PUBLISH body.
+func topicAndPayload(body []byte) (string, string) {
+	n := int(body[0])<<8 | int(body[1])
+	return string(body[2 : 2+n]), string(body[2+n:])
+}
+
+func TestPublisher(t *testing.T) {
+	addr, packets := fakeBroker(t, 0)
+	p := NewPublisher(Broker{URL: addr, Username: "user", Password: "pass", ClientID: "test"}, "claude", true, "claude_usage")
+
+	ws := &stats.WeeklyStats{
+		HasAPIData:          true,
+		FiveHourUtilization: 0.42,
+		WeeklyUtilization:   0.9,
+		RateLimitStatus:     "throttled",
+		WeeklyReset:         time.Date(2026, 1, 16, 14, 0, 0, 0, time.UTC),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.Publish(ctx, ws); err != nil {
+		t.Fatal(err)
+	}
+
+	connect := <-packets
+	if connect.header != packetConnect {
+		t.Fatalf("first packet: got %#x, want CONNECT", connect.header)
+	}
+	// Flags: clean session, user name and password
+	if flags := connect.body[7]; flags != 0xC2 {
+		t.Errorf("CONNECT flags: got %#x, want 0xc2", flags)
+	}
+
+	state := make(map[string]string)
+	discovery := make(map[string]map[string]any)
+	for pkt := range packets {
+		if pkt.header&0xF0 != packetPublish {
+			continue
+		}
+		if pkt.header&0x01 == 0 {
+			t.Error("messages should be retained")
+		}
+		topic, payload := topicAndPayload(pkt.body)
+		if strings.HasPrefix(topic, "homeassistant/") {
+			var cfg map[string]any
+			if err := json.Unmarshal([]byte(payload), &cfg); err != nil {
+				t.Errorf("discovery payload for %s is not JSON: %s", topic, payload)
+			}
+			discovery[topic] = cfg
+			continue
+		}
+		state[topic] = payload
+	}
+
+	want := map[string]string{
+		"claude/five_hour":       "42",
+		"claude/weekly":          "90",
+		"claude/throttled":       "ON",
+		"claude/five_hour_reset": "None",
+		"claude/weekly_reset":    "2026-01-16T14:00:00Z",
+	}
+	for topic, payload := range want {
+		if state[topic] != payload {
+			t.Errorf("%s: got %q, want %q", topic, state[topic], payload)
+		}
+	}
+
+	cfg := discovery["homeassistant/binary_sensor/claude_usage/throttled/config"]
+	if cfg == nil || cfg["state_topic"] != "claude/throttled" || cfg["unique_id"] != "claude_usage_throttled" {
+		t.Errorf("throttled discovery config: %v", cfg)
+	}
+	if len(discovery) != 5 {
+		t.Errorf("got %d discovery messages, want 5", len(discovery))
+	}
+}
+
+func TestPublish_Refused(t *testing.T) {
+	addr, _ := fakeBroker(t, 5)
+	err := Broker{URL: addr, ClientID: "test"}.Publish(context.Background(), []Message{{Topic: "t", Payload: []byte("x")}})
+	if err == nil || err.Error() != "connection refused: not authorized" {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestAppendLength(t *testing.T) {
+	tests := []struct {
+		n    int
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{127, []byte{0x7F}},
+		{128, []byte{0x80, 0x01}},
+		{16383, []byte{0xFF, 0x7F}},
+		{16384, []byte{0x80, 0x80, 0x01}},
+	}
+	for _, tt := range tests {
+		got := appendLength(nil, tt.n)
+		if string(got) != string(tt.want) {
+			t.Errorf("appendLength(%d) = %x, want %x", tt.n, got, tt.want)
+		}
+	}
+}
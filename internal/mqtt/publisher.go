@@ -0,0 +1,162 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/stats"
+)
+
+// discoveryPrefix is Home Assistant's default MQTT discovery topic prefix.
+const discoveryPrefix = "homeassistant"
+
+// Publisher publishes usage state as retained messages under a base topic:
+//
+//	<topic>/five_hour        5-hour window usage in percent
+//	<topic>/weekly           weekly window usage in percent
+//	<topic>/throttled        ON or OFF
+//	<topic>/five_hour_reset  RFC 3339 reset time
+//	<topic>/weekly_reset     RFC 3339 reset time
+//
+// A nil Publisher does nothing. It is safe for concurrent use.
+type Publisher struct {
+	broker    Broker
+	topic     string
+	discovery bool
+
+	// nodeID identifies this instance in Home Assistant
+	nodeID string
+
+	mu        sync.Mutex
+	announced bool
+}
+
+// NewPublisher creates a publisher for topic. With discovery, Home
+// Assistant discovery messages are sent on the first successful publish.
+// nodeID must be unique per instance, e.g. per config profile.
+func NewPublisher(broker Broker, topic string, discovery bool, nodeID string) *Publisher {
+	return &Publisher{broker: broker, topic: topic, discovery: discovery, nodeID: nodeID}
+}
+
+// FromConfig creates a publisher from the MQTT settings in cfg, or returns
+// nil if no broker is configured.
+func FromConfig(cfg *config.Config) *Publisher {
+	if cfg.MQTTBroker == "" {
+		return nil
+	}
+	nodeID := "claude_usage"
+	if cfg.Profile != "" {
+		nodeID += "_" + sanitize(cfg.Profile)
+	}
+	broker := Broker{
+		URL:      cfg.MQTTBroker,
+		Username: cfg.MQTTUsername,
+		Password: cfg.MQTTPassword,
+		ClientID: fmt.Sprintf("%s_%d", nodeID, os.Getpid()),
+	}
+	return NewPublisher(broker, cfg.GetMQTTTopic(), cfg.MQTTHomeAssistant, nodeID)
+}
+
+// Publish sends the current state. Without API data there is nothing to
+// publish.
+func (p *Publisher) Publish(ctx context.Context, weeklyStats *stats.WeeklyStats) error {
+	if p == nil || weeklyStats == nil || !weeklyStats.HasAPIData {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var messages []Message
+	if p.discovery && !p.announced {
+		messages = append(messages, p.discoveryMessages()...)
+	}
+	messages = append(messages, p.stateMessages(weeklyStats)...)
+
+	if err := p.broker.Publish(ctx, messages); err != nil {
+		return err
+	}
+	p.announced = true
+	return nil
+}
+
+// stateMessages returns the retained state messages.
+func (p *Publisher) stateMessages(weeklyStats *stats.WeeklyStats) []Message {
+	throttled := "OFF"
+	if weeklyStats.IsThrottled() {
+		throttled = "ON"
+	}
+	state := []struct{ name, value string }{
+		{"five_hour", strconv.Itoa(int(weeklyStats.FiveHourUtilization * 100))},
+		{"weekly", strconv.Itoa(int(weeklyStats.WeeklyUtilization * 100))},
+		{"throttled", throttled},
+		{"five_hour_reset", formatTime(weeklyStats.FiveHourReset)},
+		{"weekly_reset", formatTime(weeklyStats.WeeklyReset)},
+	}
+
+	messages := make([]Message, 0, len(state))
+	for _, s := range state {
+		messages = append(messages, Message{Topic: p.topic + "/" + s.name, Payload: []byte(s.value), Retain: true})
+	}
+	return messages
+}
+
+// discoveryMessages returns Home Assistant discovery configs for each
+// state topic.
+func (p *Publisher) discoveryMessages() []Message {
+	device := map[string]any{
+		"identifiers":  []string{p.nodeID},
+		"name":         "Claude Usage",
+		"manufacturer": "claude-usage",
+	}
+	entities := []struct {
+		component, object string
+		config            map[string]any
+	}{
+		{"sensor", "five_hour", map[string]any{"name": "5-hour usage", "unit_of_measurement": "%", "state_class": "measurement", "icon": "mdi:gauge"}},
+		{"sensor", "weekly", map[string]any{"name": "Weekly usage", "unit_of_measurement": "%", "state_class": "measurement", "icon": "mdi:gauge"}},
+		{"binary_sensor", "throttled", map[string]any{"name": "Throttled", "device_class": "problem", "payload_on": "ON", "payload_off": "OFF"}},
+		{"sensor", "five_hour_reset", map[string]any{"name": "5-hour reset", "device_class": "timestamp"}},
+		{"sensor", "weekly_reset", map[string]any{"name": "Weekly reset", "device_class": "timestamp"}},
+	}
+
+	messages := make([]Message, 0, len(entities))
+	for _, e := range entities {
+		e.config["state_topic"] = p.topic + "/" + e.object
+		e.config["unique_id"] = p.nodeID + "_" + e.object
+		e.config["device"] = device
+		payload, _ := json.Marshal(e.config)
+		messages = append(messages, Message{
+			Topic:   fmt.Sprintf("%s/%s/%s/%s/config", discoveryPrefix, e.component, p.nodeID, e.object),
+			Payload: payload,
+			Retain:  true,
+		})
+	}
+	return messages
+}
+
+// formatTime formats a reset time for Home Assistant timestamp sensors;
+// unknown times are published as "None", which it treats as unknown.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "None"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// sanitize makes s safe for MQTT topics and Home Assistant IDs.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, s)
+}
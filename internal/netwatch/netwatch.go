@@ -0,0 +1,65 @@
+// Package netwatch reports when network connectivity returns, so a
+// refresh can follow right away instead of at the next tick:
+//   - Linux: NetworkManager's StateChanged signal on the system bus
+//   - macOS: SCNetworkReachability, polled through scutil
+//   - Windows: the Network List Manager, polled through COM
+package netwatch
+
+import (
+	"sync"
+	"time"
+)
+
+// pollInterval is how often connectivity is checked where it is polled.
+const pollInterval = 10 * time.Second
+
+// Watcher calls a function each time connectivity returns. A nil Watcher
+// does nothing.
+type Watcher struct {
+	stop chan struct{}
+	once sync.Once
+}
+
+func newWatcher() *Watcher {
+	return &Watcher{stop: make(chan struct{})}
+}
+
+// Close stops watching.
+func (w *Watcher) Close() {
+	if w == nil {
+		return
+	}
+	w.once.Do(func() { close(w.stop) })
+}
+
+// tracker follows connectivity to spot it returning.
+type tracker struct {
+	online, known bool
+}
+
+// update records whether the network is online and reports whether it
+// just came back. The first update only sets the starting point.
+func (t *tracker) update(online bool) bool {
+	back := t.known && online && !t.online
+	t.online, t.known = online, true
+	return back
+}
+
+// poll calls fn on its own goroutine each time check starts reporting
+// connectivity, checking every pollInterval until w is closed. Failed
+// checks are skipped.
+func (w *Watcher) poll(check func() (bool, error), fn func()) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	var t tracker
+	for {
+		if online, err := check(); err == nil && t.update(online) {
+			go fn()
+		}
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
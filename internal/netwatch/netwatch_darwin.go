@@ -0,0 +1,32 @@
+package netwatch
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// reachabilityHost is the host whose reachability stands for
+// connectivity.
+const reachabilityHost = "api.anthropic.com"
+
+// Watch calls fn, on its own goroutine, each time the API host becomes
+// reachable again.
+func Watch(fn func()) (*Watcher, error) {
+	if _, err := exec.LookPath("scutil"); err != nil {
+		return nil, fmt.Errorf("scutil not found: %w", err)
+	}
+	w := newWatcher()
+	go w.poll(reachable, fn)
+	return w, nil
+}
+
+// reachable reports whether SCNetworkReachability considers the API host
+// reachable.
+func reachable() (bool, error) {
+	out, err := exec.Command("scutil", "-r", reachabilityHost).Output()
+	if err != nil {
+		return false, fmt.Errorf("scutil failed: %w", err)
+	}
+	return strings.HasPrefix(strings.TrimSpace(string(out)), "Reachable"), nil
+}
@@ -0,0 +1,61 @@
+package netwatch
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// NetworkManager's D-Bus name and interface.
+const (
+	nmName  = "org.freedesktop.NetworkManager"
+	nmPath  = dbus.ObjectPath("/org/freedesktop/NetworkManager")
+	nmIface = "org.freedesktop.NetworkManager"
+
+	// nmConnectedGlobal is the NMState with full internet access
+	nmConnectedGlobal = 70
+)
+
+// Watch calls fn, on its own goroutine, each time NetworkManager reports
+// full connectivity again. It fails without NetworkManager.
+func Watch(fn func()) (*Watcher, error) {
+	// A private connection, so closing it leaves other connections alone
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to the D-Bus system bus: %w", err)
+	}
+	if err := conn.AddMatchSignal(dbus.WithMatchObjectPath(nmPath), dbus.WithMatchInterface(nmIface), dbus.WithMatchMember("StateChanged")); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("could not subscribe to NetworkManager: %w", err)
+	}
+	signals := make(chan *dbus.Signal, 8)
+	conn.Signal(signals)
+
+	v, err := conn.Object(nmName, nmPath).GetProperty(nmIface + ".State")
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("NetworkManager not available: %w", err)
+	}
+	var t tracker
+	state, _ := v.Value().(uint32)
+	t.update(state == nmConnectedGlobal)
+
+	w := newWatcher()
+	go func() {
+		<-w.stop
+		conn.Close()
+	}()
+	go func() {
+		// Closing the connection closes signals
+		for s := range signals {
+			if s.Name != nmIface+".StateChanged" || len(s.Body) != 1 {
+				continue
+			}
+			state, _ := s.Body[0].(uint32)
+			if t.update(state == nmConnectedGlobal) {
+				go fn()
+			}
+		}
+	}()
+	return w, nil
+}
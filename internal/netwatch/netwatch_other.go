@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !windows
+
+package netwatch
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Watch fails on platforms without connectivity notifications.
+func Watch(fn func()) (*Watcher, error) {
+	return nil, fmt.Errorf("network change detection is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,64 @@
+package netwatch
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	var tr tracker
+	steps := []struct {
+		online, back bool
+	}{
+		{true, false}, // starting point
+		{true, false},
+		{false, false},
+		{false, false},
+		{true, true},
+		{true, false},
+	}
+	for i, s := range steps {
+		if got := tr.update(s.online); got != s.back {
+			t.Errorf("step %d: update(%v) = %v, want %v", i, s.online, got, s.back)
+		}
+	}
+
+	// Starting offline, the first return counts
+	tr = tracker{}
+	tr.update(false)
+	if !tr.update(true) {
+		t.Error("return after starting offline not reported")
+	}
+}
+
+func TestPoll(t *testing.T) {
+	w := newWatcher()
+	defer w.Close()
+
+	var calls atomic.Int32
+	check := func() (bool, error) {
+		if calls.Add(1) == 1 {
+			return false, nil
+		}
+		return true, errors.New("stopped")
+	}
+	done := make(chan struct{})
+	go func() {
+		w.poll(check, func() { t.Error("fn called for a failed check") })
+		close(done)
+	}()
+
+	// The first check runs right away; then close
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	w.Close()
+	w.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("poll did not stop on Close")
+	}
+}
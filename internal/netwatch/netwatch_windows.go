@@ -0,0 +1,80 @@
+package netwatch
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var procCoCreateInstance = windows.NewLazySystemDLL("ole32.dll").NewProc("CoCreateInstance")
+
+var (
+	clsidNetworkListManager = windows.GUID{Data1: 0xDCB00C01, Data2: 0x570F, Data3: 0x4A9B, Data4: [8]byte{0x8D, 0x69, 0x19, 0x9F, 0xDB, 0xA5, 0x72, 0x3B}}
+	iidINetworkListManager  = windows.GUID{Data1: 0xDCB00000, Data2: 0x570F, Data3: 0x4A9B, Data4: [8]byte{0x8D, 0x69, 0x19, 0x9F, 0xDB, 0xA5, 0x72, 0x3B}}
+)
+
+// comObject is the memory layout of a COM interface pointer.
+type comObject struct {
+	vtbl *[16]uintptr
+}
+
+// INetworkListManager vtable slots.
+const (
+	vtblRelease                  = 2
+	vtblGetIsConnectedToInternet = 11
+
+	clsctxAll = 0x17
+)
+
+// Watch calls fn, on its own goroutine, each time the Network List
+// Manager reports internet connectivity again.
+func Watch(fn func()) (*Watcher, error) {
+	w := newWatcher()
+	errCh := make(chan error, 1)
+	go w.run(fn, errCh)
+	if err := <-errCh; err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// run creates the Network List Manager on a dedicated COM thread and polls
+// it until w is closed.
+func (w *Watcher) run(fn func(), errCh chan<- error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := windows.CoInitializeEx(0, windows.COINIT_MULTITHREADED); err != nil {
+		errCh <- fmt.Errorf("CoInitializeEx: %w", err)
+		return
+	}
+	defer windows.CoUninitialize()
+
+	var nlm *comObject
+	hr, _, _ := procCoCreateInstance.Call(
+		uintptr(unsafe.Pointer(&clsidNetworkListManager)), 0, clsctxAll,
+		uintptr(unsafe.Pointer(&iidINetworkListManager)), uintptr(unsafe.Pointer(&nlm)))
+	if int32(hr) < 0 {
+		errCh <- fmt.Errorf("INetworkListManager not available: HRESULT %#x", uint32(hr))
+		return
+	}
+	defer comCall(nlm, vtblRelease)
+	errCh <- nil
+
+	w.poll(func() (bool, error) {
+		var connected int16 // VARIANT_BOOL, -1 for true
+		if hr := comCall(nlm, vtblGetIsConnectedToInternet, uintptr(unsafe.Pointer(&connected))); int32(hr) < 0 {
+			return false, fmt.Errorf("IsConnectedToInternet: HRESULT %#x", uint32(hr))
+		}
+		return connected != 0, nil
+	}, fn)
+}
+
+// comCall calls the method in vtable slot of obj.
+func comCall(obj *comObject, slot int, args ...uintptr) uintptr {
+	r, _, _ := syscall.SyscallN(obj.vtbl[slot], append([]uintptr{uintptr(unsafe.Pointer(obj))}, args...)...)
+	return r
+}
@@ -0,0 +1,94 @@
+// Package notify shows desktop notifications.
+// Like package clipboard, it shells out to the platform's notification tool
+// instead of linking native APIs.
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// appName is the application name shown with notifications.
+const appName = "Claude Usage"
+
+// Show displays a desktop notification with a title and body.
+// - Linux: notify-send (libnotify)
+// - macOS: osascript "display notification"
+// - Windows: PowerShell toast via Windows.UI.Notifications
+func Show(title, body string) error {
+	cmd, err := notifyCommand(title, body)
+	if err != nil {
+		return err
+	}
+
+	hideWindow(cmd)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", cmd.Path, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// ShowWithAction displays a notification like Show with a button labeled
+// label, and reports whether it was clicked. It blocks until the
+// notification is dismissed, so call it from its own goroutine.
+//
+// Buttons need notify-send 0.7.9 or later on Linux. Elsewhere, and with
+// older versions, a plain notification is shown and false is returned.
+func ShowWithAction(title, body, label string) (bool, error) {
+	if runtime.GOOS != "linux" {
+		return false, Show(title, body)
+	}
+	path, err := exec.LookPath("notify-send")
+	if err != nil {
+		return false, fmt.Errorf("notify-send not found (install libnotify)")
+	}
+
+	// notify-send prints the name of the clicked action
+	cmd := exec.Command(path, "--app-name="+appName, "--wait", "--action=action="+label, title, body)
+	out, err := cmd.Output()
+	if err != nil {
+		// Older versions reject --action
+		return false, Show(title, body)
+	}
+	return strings.TrimSpace(string(out)) == "action", nil
+}
+
+// notifyCommand returns the command that displays the notification.
+func notifyCommand(title, body string) (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s",
+			appleScriptString(body), appleScriptString(appName+": "+title))
+		return exec.Command("osascript", "-e", script), nil
+
+	case "windows":
+		// Pass text via environment variables to avoid quoting issues
+		script := `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode($env:NOTIFY_TITLE)) > $null
+$text.Item(1).AppendChild($template.CreateTextNode($env:NOTIFY_BODY)) > $null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($env:NOTIFY_APP).Show($toast)`
+		cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+		cmd.Env = append(cmd.Environ(),
+			"NOTIFY_TITLE="+title,
+			"NOTIFY_BODY="+body,
+			"NOTIFY_APP="+appName)
+		return cmd, nil
+
+	default: // Linux and other Unix-like systems
+		path, err := exec.LookPath("notify-send")
+		if err != nil {
+			return nil, fmt.Errorf("notify-send not found (install libnotify)")
+		}
+		return exec.Command(path, "--app-name="+appName, title, body), nil
+	}
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
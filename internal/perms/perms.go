@@ -0,0 +1,79 @@
+// Package perms keeps the app's files private to the current user and
+// spots credentials files other users can read.
+//
+// Files are created with mode 0600, but files written by older versions,
+// restored from backups or copied between machines may be more open.
+// Windows files are protected by ACLs rather than modes and are left alone.
+package perms
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"runtime"
+
+	"claude-usage/internal/config"
+)
+
+// AppFiles returns the files the app writes for profile that may hold
+// tokens or usage data.
+func AppFiles(profile string) []string {
+	return []string{
+		config.GetConfigPath(),
+		config.GetLogPath(profile),
+		config.GetRateLimitCachePath(profile),
+		config.GetHistoryPath(profile),
+		config.GetSamplesPath(profile),
+		config.GetStatusPath(profile),
+		config.GetControlTokenPath(profile),
+		config.GetTokenStorePath(profile),
+		config.GetTokenKeyPath(),
+		config.GetTokenProtectedKeyPath(),
+		config.GetTokenSaltPath(),
+	}
+}
+
+// Exposed reports whether the file at path can be accessed by users other
+// than its owner, with its permission bits. Missing files are not exposed.
+func Exposed(path string) (fs.FileMode, bool) {
+	if runtime.GOOS == "windows" {
+		return 0, false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	perm := info.Mode().Perm()
+	return perm, perm&0077 != 0
+}
+
+// Tighten restricts those of paths other users can access to mode 0600,
+// logging each change, and returns the paths it changed.
+func Tighten(paths []string) []string {
+	var changed []string
+	for _, path := range paths {
+		perm, exposed := Exposed(path)
+		if !exposed {
+			continue
+		}
+		if err := os.Chmod(path, 0600); err != nil {
+			log.Printf("Warning: could not restrict %s to the current user: %v", path, err)
+			continue
+		}
+		log.Printf("Note: restricted %s to the current user (was mode %04o)", path, perm)
+		changed = append(changed, path)
+	}
+	return changed
+}
+
+// CheckCredentials returns a warning when other users can read the
+// credentials file at path, "" otherwise. The file belongs to Claude or
+// OpenCode, so it is reported rather than changed.
+func CheckCredentials(path string) string {
+	perm, exposed := Exposed(path)
+	if !exposed {
+		return ""
+	}
+	return fmt.Sprintf("%s is readable by other users (mode %04o)", path, perm)
+}
@@ -0,0 +1,49 @@
+package perms
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestTighten(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not used on Windows")
+	}
+	dir := t.TempDir()
+	open, private := filepath.Join(dir, "history.json"), filepath.Join(dir, "status.json")
+	if err := os.WriteFile(open, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(private, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := Tighten([]string{open, private, filepath.Join(dir, "missing.json")})
+	if len(changed) != 1 || changed[0] != open {
+		t.Errorf("changed %v, want only %s", changed, open)
+	}
+	if info, _ := os.Stat(open); info.Mode().Perm() != 0600 {
+		t.Errorf("mode %04o, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestCheckCredentials(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not used on Windows")
+	}
+	path := filepath.Join(t.TempDir(), ".credentials.json")
+	if err := os.WriteFile(path, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if w := CheckCredentials(path); w != "" {
+		t.Errorf("private file reported: %s", w)
+	}
+	if err := os.Chmod(path, 0640); err != nil {
+		t.Fatal(err)
+	}
+	if w := CheckCredentials(path); w == "" {
+		t.Error("group-readable file not reported")
+	}
+}
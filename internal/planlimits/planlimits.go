@@ -0,0 +1,140 @@
+// Package planlimits keeps the estimated weekly token limits per plan up
+// to date without a release.
+//
+// The limits only matter when the API's rate limit data is unavailable
+// and usage is estimated from token counts. A table published alongside
+// the source (config.GetPlanLimitsURL) is fetched once a day and cached;
+// until the first fetch, or when it fails, the table bundled with the
+// stats package is used. The user's plan_limits override both.
+package planlimits
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/httpclient"
+	"claude-usage/internal/stats"
+)
+
+// MaxAge is how long a fetched table is used before it is fetched again.
+const MaxAge = 24 * time.Hour
+
+// maxTableSize caps the size of a fetched table.
+const maxTableSize = 64 << 10
+
+// Table is a limits table as published and cached.
+type Table struct {
+	// Limits are weekly token limits keyed by rate limit tier (e.g.
+	// "default_claude_max_5x") or subscription type (e.g. "pro").
+	Limits map[string]int64 `json:"limits"`
+
+	// FetchedAt is when the table was fetched; only set in the cache.
+	FetchedAt time.Time `json:"fetched_at,omitzero"`
+}
+
+// Parse reads a published table, dropping limits that are not positive.
+func Parse(data []byte) (*Table, error) {
+	var t Table
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	for plan, limit := range t.Limits {
+		if limit <= 0 {
+			delete(t.Limits, plan)
+		}
+	}
+	if len(t.Limits) == 0 {
+		return nil, errors.New("no limits in table")
+	}
+	return &t, nil
+}
+
+// Fetch downloads the table at url.
+func Fetch(ctx context.Context, url string) (*Table, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpclient.New(30 * time.Second).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTableSize))
+	if err != nil {
+		return nil, err
+	}
+	t, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	t.FetchedAt = time.Now()
+	return t, nil
+}
+
+// Load reads the table cached by Save.
+func Load(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Save caches t at path.
+func Save(path string, t *Table) error {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Apply sets the limits used for estimates from the cached table and the
+// overrides in cfg.
+func Apply(cfg *config.Config) {
+	var fetched map[string]int64
+	if cfg.FetchPlanLimits {
+		if t, err := Load(config.GetPlanLimitsPath()); err == nil {
+			fetched = t.Limits
+		}
+	}
+	stats.SetPlanLimits(fetched, cfg.PlanLimits)
+}
+
+// Update fetches the table unless the cached one is younger than MaxAge,
+// caches it and applies it with the overrides in cfg. It returns when the
+// table should be fetched next.
+func Update(ctx context.Context, cfg *config.Config) (time.Duration, error) {
+	path := config.GetPlanLimitsPath()
+	if cached, err := Load(path); err == nil {
+		if age := time.Since(cached.FetchedAt); age < MaxAge {
+			return MaxAge - age, nil
+		}
+	}
+
+	t, err := Fetch(ctx, config.GetPlanLimitsURL())
+	if err != nil {
+		return time.Hour, err
+	}
+	stats.SetPlanLimits(t.Limits, cfg.PlanLimits)
+	return MaxAge, Save(path, t)
+}
@@ -0,0 +1,48 @@
+package planlimits
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	table, err := Parse([]byte(`{"limits": {"pro": 50000000, "free": 0, "max_5x": -1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Limits) != 1 || table.Limits["pro"] != 50_000_000 {
+		t.Errorf("unexpected limits: %v", table.Limits)
+	}
+
+	if _, err := Parse([]byte(`{"limits": {}}`)); err == nil {
+		t.Error("expected an error for an empty table")
+	}
+}
+
+// TestPublishedTable checks the table served from the repository.
+func TestPublishedTable(t *testing.T) {
+	data, err := os.ReadFile("../../plan-limits.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse(data); err != nil {
+		t.Errorf("plan-limits.json: %v", err)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan-limits.json")
+	fetched := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := Save(path, &Table{Limits: map[string]int64{"pro": 1}, FetchedAt: fetched}); err != nil {
+		t.Fatal(err)
+	}
+	table, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Limits["pro"] != 1 || !table.FetchedAt.Equal(fetched) {
+		t.Errorf("unexpected table: %+v", table)
+	}
+}
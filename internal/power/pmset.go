@@ -0,0 +1,9 @@
+package power
+
+import "strings"
+
+// parsePmset reports whether pmset -g batt output, which starts with
+// "Now drawing from 'Battery Power'" or "'AC Power'", shows battery power.
+func parsePmset(out string) bool {
+	return strings.Contains(out, "'Battery Power'")
+}
@@ -0,0 +1,14 @@
+package power
+
+import "testing"
+
+func TestParsePmset(t *testing.T) {
+	battery := "Now drawing from 'Battery Power'\n -InternalBattery-0 (id=4653155)\t81%; discharging; 5:12 remaining present: true\n"
+	if !parsePmset(battery) {
+		t.Error("battery power not detected")
+	}
+	ac := "Now drawing from 'AC Power'\n -InternalBattery-0 (id=4653155)\t100%; charged; 0:00 remaining present: true\n"
+	if parsePmset(ac) {
+		t.Error("AC power reported as battery")
+	}
+}
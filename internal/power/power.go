@@ -0,0 +1,12 @@
+// Package power tells whether the computer runs on battery:
+//   - Linux: the power supplies in /sys/class/power_supply
+//   - macOS: pmset -g batt
+//   - Windows: GetSystemPowerStatus
+package power
+
+// OnBattery reports whether the computer runs on battery power. Desktops
+// without a battery never do. It fails where the power source cannot be
+// read.
+func OnBattery() (bool, error) {
+	return onBattery()
+}
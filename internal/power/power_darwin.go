@@ -0,0 +1,14 @@
+package power
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+func onBattery() (bool, error) {
+	out, err := exec.Command("pmset", "-g", "batt").Output()
+	if err != nil {
+		return false, fmt.Errorf("pmset failed: %w", err)
+	}
+	return parsePmset(string(out)), nil
+}
@@ -0,0 +1,49 @@
+package power
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// powerSupplyDir lists the power supplies known to the kernel.
+const powerSupplyDir = "/sys/class/power_supply"
+
+func onBattery() (bool, error) {
+	return onBatteryIn(powerSupplyDir)
+}
+
+// onBatteryIn reads the power supplies in dir: the computer runs on
+// battery when it has a system battery and no adapter is online.
+// Batteries of devices such as mice (scope "Device") are ignored.
+func onBatteryIn(dir string) (bool, error) {
+	supplies, err := os.ReadDir(dir)
+	if err != nil {
+		return false, err
+	}
+	hasBattery := false
+	for _, supply := range supplies {
+		path := filepath.Join(dir, supply.Name())
+		switch readAttr(path, "type") {
+		case "Mains", "USB", "USB_C", "USB_PD":
+			if readAttr(path, "online") == "1" {
+				return false, nil
+			}
+		case "Battery":
+			if readAttr(path, "scope") != "Device" {
+				hasBattery = true
+			}
+		}
+	}
+	return hasBattery, nil
+}
+
+// readAttr returns the value of a power supply attribute, or "" if it
+// cannot be read.
+func readAttr(supply, name string) string {
+	data, err := os.ReadFile(filepath.Join(supply, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,63 @@
+package power
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSupply creates a power supply with the given attributes in dir.
+func writeSupply(t *testing.T, dir, name string, attrs map[string]string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for attr, value := range attrs {
+		if err := os.WriteFile(filepath.Join(path, attr), []byte(value+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestOnBatteryIn(t *testing.T) {
+	tests := []struct {
+		name     string
+		supplies map[string]map[string]string
+		want     bool
+	}{
+		{"desktop", map[string]map[string]string{}, false},
+		{"laptop on AC", map[string]map[string]string{
+			"AC":   {"type": "Mains", "online": "1"},
+			"BAT0": {"type": "Battery", "status": "Charging"},
+		}, false},
+		{"laptop on battery", map[string]map[string]string{
+			"AC":   {"type": "Mains", "online": "0"},
+			"BAT0": {"type": "Battery", "status": "Discharging"},
+		}, true},
+		{"USB-C charger", map[string]map[string]string{
+			"ucsi-source-psy-USBC000:001": {"type": "USB", "online": "1"},
+			"BAT0":                        {"type": "Battery"},
+		}, false},
+		{"desktop with a wireless mouse", map[string]map[string]string{
+			"hidpp_battery_0": {"type": "Battery", "scope": "Device"},
+		}, false},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		for name, attrs := range tt.supplies {
+			writeSupply(t, dir, name, attrs)
+		}
+		got, err := onBatteryIn(dir)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: onBatteryIn = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := onBatteryIn(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !windows
+
+package power
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func onBattery() (bool, error) {
+	return false, fmt.Errorf("power source detection is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,31 @@
+package presence
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hidIdleTimeRe matches ioreg's HIDIdleTime property, in nanoseconds.
+var hidIdleTimeRe = regexp.MustCompile(`"HIDIdleTime" = (\d+)`)
+
+// parseHIDIdleTime returns the idle time in ioreg's IOHIDSystem output.
+func parseHIDIdleTime(out string) (time.Duration, error) {
+	m := hidIdleTimeRe.FindStringSubmatch(out)
+	if m == nil {
+		return 0, errors.New("no HIDIdleTime in ioreg output")
+	}
+	ns, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ns), nil
+}
+
+// parseScreenLocked reports whether ioreg's Root output marks the
+// console session's screen as locked.
+func parseScreenLocked(out string) bool {
+	return strings.Contains(out, `"CGSSessionScreenIsLocked"=Yes`)
+}
@@ -0,0 +1,36 @@
+package presence
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParseHIDIdleTime(t *testing.T) {
+	out := `+-o IOHIDSystem  <class IOHIDSystem, id 0x100000466, registered, matched, active, busy 0 (0 ms), retain 25>
+    {
+      "HIDIdleTime" = 95123456789
+      "IOClass" = "IOHIDSystem"
+    }`
+	got, err := parseHIDIdleTime(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 95123456789 * time.Nanosecond; got != want {
+		t.Errorf("idle time = %v, want %v", got, want)
+	}
+
+	if _, err := parseHIDIdleTime(`"IOClass" = "IOHIDSystem"`); err == nil {
+		t.Error("expected an error without HIDIdleTime")
+	}
+}
+
+func TestParseScreenLocked(t *testing.T) {
+	users := `  "IOConsoleUsers" = ({"kCGSSessionOnConsoleKey"=Yes,"kSCSecuritySessionID"=100006,%s"kCGSSessionUserNameKey"="alice"})`
+	if !parseScreenLocked(fmt.Sprintf(users, `"CGSSessionScreenIsLocked"=Yes,`)) {
+		t.Error("locked screen not detected")
+	}
+	if parseScreenLocked(fmt.Sprintf(users, "")) {
+		t.Error("unlocked screen reported as locked")
+	}
+}
@@ -0,0 +1,55 @@
+// Package presence tells whether the user is at the computer, so polling
+// can stop while the screen is locked or nobody has touched the keyboard
+// or mouse for a while.
+//
+// The state is read on demand rather than through lock notifications:
+//   - Linux: the logind session's LockedHint and IdleHint
+//   - macOS: ioreg's CGSSessionScreenIsLocked and HIDIdleTime
+//   - Windows: whether the input desktop can be opened, and GetLastInputInfo
+package presence
+
+import "time"
+
+// State is whether the user is at the computer.
+type State int
+
+const (
+	// Present means the screen is unlocked and there was recent input
+	Present State = iota
+	// Idle means there was no input for the idle timeout
+	Idle
+	// Locked means the screen is locked
+	Locked
+)
+
+// String returns "present", "idle" or "locked".
+func (s State) String() string {
+	switch s {
+	case Idle:
+		return "idle"
+	case Locked:
+		return "locked"
+	}
+	return "present"
+}
+
+// Check returns the state of the user's session, idle meaning no input
+// for idleAfter. It fails where the state cannot be read, e.g. without
+// logind; callers should then assume the user is present.
+func Check(idleAfter time.Duration) (State, error) {
+	isLocked, err := locked()
+	if err != nil {
+		return Present, err
+	}
+	if isLocked {
+		return Locked, nil
+	}
+	idle, err := idleTime()
+	if err != nil {
+		return Present, err
+	}
+	if idle >= idleAfter {
+		return Idle, nil
+	}
+	return Present, nil
+}
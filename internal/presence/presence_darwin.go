@@ -0,0 +1,26 @@
+package presence
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// locked reports whether the screen is locked, as shown in the console
+// user's session dictionary.
+func locked() (bool, error) {
+	out, err := exec.Command("ioreg", "-n", "Root", "-d1").Output()
+	if err != nil {
+		return false, fmt.Errorf("ioreg failed: %w", err)
+	}
+	return parseScreenLocked(string(out)), nil
+}
+
+// idleTime returns the time since the last keyboard or mouse input.
+func idleTime() (time.Duration, error) {
+	out, err := exec.Command("ioreg", "-c", "IOHIDSystem", "-d", "4", "-r", "-k", "HIDIdleTime").Output()
+	if err != nil {
+		return 0, fmt.Errorf("ioreg failed: %w", err)
+	}
+	return parseHIDIdleTime(string(out))
+}
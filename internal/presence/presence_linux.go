@@ -0,0 +1,68 @@
+package presence
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// logind's D-Bus name and interfaces.
+const (
+	logindName    = "org.freedesktop.login1"
+	logindPath    = dbus.ObjectPath("/org/freedesktop/login1")
+	managerIface  = "org.freedesktop.login1.Manager"
+	sessionIface  = "org.freedesktop.login1.Session"
+	autoSessionID = "auto"
+)
+
+// session returns the logind session the app runs in, or the user's
+// graphical session when it runs as a user service.
+func session() (dbus.BusObject, error) {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to the D-Bus system bus: %w", err)
+	}
+	var path dbus.ObjectPath
+	if err := conn.Object(logindName, logindPath).Call(managerIface+".GetSession", 0, autoSessionID).Store(&path); err != nil {
+		return nil, fmt.Errorf("no logind session: %w", err)
+	}
+	return conn.Object(logindName, path), nil
+}
+
+// locked reports whether the session's screen is locked. The desktop's
+// screen locker sets LockedHint (systemd 230 and later).
+func locked() (bool, error) {
+	s, err := session()
+	if err != nil {
+		return false, err
+	}
+	v, err := s.GetProperty(sessionIface + ".LockedHint")
+	if err != nil {
+		return false, fmt.Errorf("could not read LockedHint: %w", err)
+	}
+	isLocked, _ := v.Value().(bool)
+	return isLocked, nil
+}
+
+// idleTime returns how long the session has been idle. The desktop sets
+// IdleHint after its own idle delay, so shorter idle times read as zero.
+func idleTime() (time.Duration, error) {
+	s, err := session()
+	if err != nil {
+		return 0, err
+	}
+	v, err := s.GetProperty(sessionIface + ".IdleHint")
+	if err != nil {
+		return 0, fmt.Errorf("could not read IdleHint: %w", err)
+	}
+	if idle, _ := v.Value().(bool); !idle {
+		return 0, nil
+	}
+	v, err = s.GetProperty(sessionIface + ".IdleSinceHint")
+	if err != nil {
+		return 0, fmt.Errorf("could not read IdleSinceHint: %w", err)
+	}
+	since, _ := v.Value().(uint64)
+	return time.Since(time.UnixMicro(int64(since))), nil
+}
@@ -0,0 +1,17 @@
+//go:build !linux && !darwin && !windows
+
+package presence
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+func locked() (bool, error) {
+	return false, fmt.Errorf("screen lock detection is not supported on %s", runtime.GOOS)
+}
+
+func idleTime() (time.Duration, error) {
+	return 0, fmt.Errorf("idle detection is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,49 @@
+package presence
+
+import (
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32   = windows.NewLazySystemDLL("user32.dll")
+	kernel32 = windows.NewLazySystemDLL("kernel32.dll")
+
+	procOpenInputDesktop = user32.NewProc("OpenInputDesktop")
+	procCloseDesktop     = user32.NewProc("CloseDesktop")
+	procGetLastInputInfo = user32.NewProc("GetLastInputInfo")
+	procGetTickCount     = kernel32.NewProc("GetTickCount")
+)
+
+// desktopSwitchDesktop is the access right OpenInputDesktop is asked for.
+const desktopSwitchDesktop = 0x0100
+
+type lastInputInfo struct {
+	size uint32
+	time uint32
+}
+
+// locked reports whether the workstation is locked: the input desktop is
+// then the secure Winlogon desktop, which the app cannot open. Session
+// notifications would need a window and message loop for the same answer.
+func locked() (bool, error) {
+	desktop, _, _ := procOpenInputDesktop.Call(0, 0, desktopSwitchDesktop)
+	if desktop == 0 {
+		return true, nil
+	}
+	procCloseDesktop.Call(desktop)
+	return false, nil
+}
+
+// idleTime returns the time since the last keyboard or mouse input.
+func idleTime() (time.Duration, error) {
+	info := lastInputInfo{size: uint32(unsafe.Sizeof(lastInputInfo{}))}
+	if r, _, err := procGetLastInputInfo.Call(uintptr(unsafe.Pointer(&info))); r == 0 {
+		return 0, err
+	}
+	now, _, _ := procGetTickCount.Call()
+	// Both wrap around after 49.7 days; the difference stays right
+	return time.Duration(uint32(now)-info.time) * time.Millisecond, nil
+}
@@ -0,0 +1,111 @@
+// Package redact keeps OAuth tokens out of everything the app writes for
+// people to read: logs, the token rotation notice and crash reports.
+//
+// Tokens are recognized by their sk-ant- prefix, as bearer credentials and
+// as the token fields of credential JSON. Tokens without a recognizable
+// form, such as OpenCode's, are registered with Secret when they are read.
+// The status file and control API carry no free text and need no
+// redaction.
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Placeholder replaces redacted tokens.
+const Placeholder = "[REDACTED]"
+
+// maxSecrets is how many registered secrets are kept. Tokens rotate, so
+// only the latest few can still be valid.
+const maxSecrets = 16
+
+// minSecretLength keeps short strings, which could match ordinary text,
+// from being registered.
+const minSecretLength = 16
+
+var (
+	// anthropicToken matches Anthropic OAuth tokens and API keys, e.g.
+	// "sk-ant-oat01-…" and "sk-ant-ort01-…"
+	anthropicToken = regexp.MustCompile(`sk-ant-[a-z]+\d*-[A-Za-z0-9_\-]+`)
+
+	// bearer matches credentials in Authorization headers
+	bearer = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/\-]+=*`)
+
+	// tokenField matches token fields of Claude's and OpenCode's
+	// credential files and OAuth responses
+	tokenField = regexp.MustCompile(`("(?:accessToken|refreshToken|access_token|refresh_token|access|refresh)"\s*:\s*")[^"]*(")`)
+)
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+// Secret registers s to be redacted wherever it appears. Strings shorter
+// than 16 characters are ignored.
+func Secret(s string) {
+	if len(s) < minSecretLength {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, known := range secrets {
+		if known == s {
+			return
+		}
+	}
+	secrets = append(secrets, s)
+	if len(secrets) > maxSecrets {
+		secrets = secrets[len(secrets)-maxSecrets:]
+	}
+}
+
+// String returns s with every token replaced by Placeholder.
+func String(s string) string {
+	s = anthropicToken.ReplaceAllString(s, Placeholder)
+	s = bearer.ReplaceAllString(s, "${1}"+Placeholder)
+	s = tokenField.ReplaceAllString(s, "${1}"+Placeholder+"${2}")
+
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Placeholder)
+	}
+	return s
+}
+
+// Fingerprint identifies token without revealing it, e.g.
+// "sha256:1f2e3d4c", so two tokens can be told apart in a report.
+func Fingerprint(token string) string {
+	if token == "" {
+		return "(none)"
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "sha256:" + hex.EncodeToString(sum[:4])
+}
+
+// writer redacts what is written through it.
+type writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a writer that redacts each write before passing it to
+// w. Tokens split across writes are not caught, so it suits writers that
+// receive whole messages, such as the standard logger's output.
+func NewWriter(w io.Writer) io.Writer {
+	return writer{w}
+}
+
+// Write implements io.Writer. It reports len(p) written on success, as
+// redaction changes the length.
+func (w writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
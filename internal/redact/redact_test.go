@@ -0,0 +1,52 @@
+package redact
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"access token", "token sk-ant-oat01-AbC_d-123 expired", "token [REDACTED] expired"},
+		{"refresh token", `refresh failed: {"refresh_token":"sk-ant-ort01-xyz"}`, `refresh failed: {"refresh_token":"[REDACTED]"}`},
+		{"bearer", "Authorization: Bearer abc.def-ghi", "Authorization: Bearer [REDACTED]"},
+		{"credentials json", `{"claudeAiOauth":{"accessToken":"opaque","refreshToken":"opaque2","expiresAt":1}}`,
+			`{"claudeAiOauth":{"accessToken":"[REDACTED]","refreshToken":"[REDACTED]","expiresAt":1}}`},
+		{"opencode json", `{"anthropic":{"access":"a1","refresh":"r1"}}`, `{"anthropic":{"access":"[REDACTED]","refresh":"[REDACTED]"}}`},
+		{"plain text", "API rate limits: 5h=12.0%, weekly=40.0%", "API rate limits: 5h=12.0%, weekly=40.0%"},
+	}
+	for _, tt := range tests {
+		if got := String(tt.in); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSecret(t *testing.T) {
+	Secret("short")
+	Secret("opencode-token-0123456789")
+	got := String("using short and opencode-token-0123456789")
+	if got != "using short and [REDACTED]" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New(NewWriter(&buf), "", 0)
+	logger.Printf("token refresh failed with status 400: %s", `{"access_token":"sk-ant-oat01-secret"}`)
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("token logged: %q", buf.String())
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a, b := Fingerprint("sk-ant-ort01-one"), Fingerprint("sk-ant-ort01-two")
+	if a == b || !strings.HasPrefix(a, "sha256:") || strings.Contains(a, "one") {
+		t.Errorf("fingerprints %q and %q", a, b)
+	}
+}
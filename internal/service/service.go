@@ -0,0 +1,66 @@
+// Package service installs claude-usage as a supervised per-user service that
+// starts at login and is restarted if it crashes.
+// - Linux: systemd user unit (~/.config/systemd/user/claude-usage.service)
+// - macOS: launchd LaunchAgent with KeepAlive
+// - Windows: Task Scheduler logon task
+//
+// A service replaces the plain login entry from package autostart, so the
+// app isn't launched twice at login.
+package service
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+
+	"claude-usage/internal/autostart"
+	"claude-usage/internal/config"
+)
+
+// Install writes and enables the service for profile.
+// Returns a short description of what was installed.
+func Install(profile string) (string, error) {
+	exe, err := config.GetExecutablePath()
+	if err != nil {
+		return "", fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	if autostart.IsEnabled(profile) {
+		if err := autostart.Disable(profile); err != nil {
+			log.Printf("Warning: could not remove login entry: %v", err)
+		}
+	}
+
+	return install(profile, exe, launchArgs(profile))
+}
+
+// Uninstall stops and removes the service for profile.
+func Uninstall(profile string) error {
+	return uninstall(profile)
+}
+
+// launchArgs returns the command-line arguments for the service.
+func launchArgs(profile string) []string {
+	if profile == "" {
+		return nil
+	}
+	return []string{"--profile=" + profile}
+}
+
+// serviceName returns the base name of the service, e.g. "claude-usage-work".
+func serviceName(profile string) string {
+	if profile == "" {
+		return "claude-usage"
+	}
+	return "claude-usage-" + profile
+}
+
+// run executes a service manager command, including its output in any error.
+func run(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
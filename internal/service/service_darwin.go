@@ -0,0 +1,93 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"claude-usage/internal/config"
+)
+
+// launchAgentTemplate keeps the app alive: launchd restarts it unless it
+// exits cleanly (e.g. via Quit). config.EnvSupervised tells the app so.
+const launchAgentTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+    <key>Label</key>
+    <string>%s</string>
+    <key>ProgramArguments</key>
+    <array>
+%s    </array>
+    <key>EnvironmentVariables</key>
+    <dict>
+        <key>` + config.EnvSupervised + `</key>
+        <string>1</string>
+    </dict>
+    <key>RunAtLoad</key>
+    <true/>
+    <key>KeepAlive</key>
+    <dict>
+        <key>SuccessfulExit</key>
+        <false/>
+    </dict>
+    <key>ThrottleInterval</key>
+    <integer>10</integer>
+    <key>ProcessType</key>
+    <string>Interactive</string>
+</dict>
+</plist>
+`
+
+// serviceLabel returns the launchd label for profile. It differs from the
+// autostart label so the two never overwrite each other.
+func serviceLabel(profile string) string {
+	return "com.github.utajum." + serviceName(profile) + ".service"
+}
+
+// plistPath returns the LaunchAgent plist path for profile.
+func plistPath(profile string) string {
+	return filepath.Join(config.GetHomeDir(), "Library", "LaunchAgents", serviceLabel(profile)+".plist")
+}
+
+func install(profile, exe string, args []string) (string, error) {
+	path := plistPath(profile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create LaunchAgents directory: %w", err)
+	}
+
+	var programArgs strings.Builder
+	for _, arg := range append([]string{exe}, args...) {
+		programArgs.WriteString("        <string>" + html.EscapeString(arg) + "</string>\n")
+	}
+
+	content := fmt.Sprintf(launchAgentTemplate, serviceLabel(profile), programArgs.String())
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write LaunchAgent: %w", err)
+	}
+
+	// Reload in case an older version of the agent is loaded
+	run("launchctl", "unload", path)
+	if err := run("launchctl", "load", "-w", path); err != nil {
+		return "", err
+	}
+
+	return "LaunchAgent " + path, nil
+}
+
+func uninstall(profile string) error {
+	path := plistPath(profile)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	if err := run("launchctl", "unload", "-w", path); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove LaunchAgent: %w", err)
+	}
+	return nil
+}
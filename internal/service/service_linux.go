@@ -0,0 +1,94 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"claude-usage/internal/config"
+)
+
+// unitTemplate ties the service to the graphical session, since the tray
+// icon needs a running desktop. Restart=on-failure also covers the exit
+// status asking for a restart after an update, which config.EnvSupervised
+// tells the app to use.
+const unitTemplate = `[Unit]
+Description=Claude Usage tray monitor
+PartOf=graphical-session.target
+After=graphical-session.target
+
+[Service]
+Type=simple
+ExecStart=%s
+Environment=` + config.EnvSupervised + `=1
+Restart=on-failure
+RestartSec=10
+
+[Install]
+WantedBy=graphical-session.target
+`
+
+// unitPath returns the systemd user unit path for profile.
+func unitPath(profile string) string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(config.GetHomeDir(), ".config")
+	}
+	return filepath.Join(configHome, "systemd", "user", serviceName(profile)+".service")
+}
+
+func install(profile, exe string, args []string) (string, error) {
+	path := unitPath(profile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create systemd user directory: %w", err)
+	}
+
+	execStart := quoteUnitArg(exe)
+	for _, arg := range args {
+		execStart += " " + quoteUnitArg(arg)
+	}
+
+	content := fmt.Sprintf(unitTemplate, execStart)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write unit file: %w", err)
+	}
+
+	unit := filepath.Base(path)
+	if err := run("systemctl", "--user", "daemon-reload"); err != nil {
+		return "", err
+	}
+	if err := run("systemctl", "--user", "enable", "--now", unit); err != nil {
+		return "", err
+	}
+
+	return "systemd user unit " + path, nil
+}
+
+func uninstall(profile string) error {
+	path := unitPath(profile)
+	unit := filepath.Base(path)
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	if err := run("systemctl", "--user", "disable", "--now", unit); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove unit file: %w", err)
+	}
+	return run("systemctl", "--user", "daemon-reload")
+}
+
+// quoteUnitArg quotes an ExecStart argument if needed and escapes '%',
+// which systemd treats as a specifier.
+func quoteUnitArg(arg string) string {
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	if !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + replacer.Replace(arg) + `"`
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin && !windows
+
+package service
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func install(profile, exe string, args []string) (string, error) {
+	return "", fmt.Errorf("service install is not supported on %s", runtime.GOOS)
+}
+
+func uninstall(profile string) error {
+	return fmt.Errorf("service uninstall is not supported on %s", runtime.GOOS)
+}
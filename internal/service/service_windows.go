@@ -0,0 +1,95 @@
+package service
+
+import (
+	"encoding/binary"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+// taskTemplate is a Task Scheduler definition that starts the app at logon
+// and asks Task Scheduler to retry if it fails.
+const taskTemplate = `<?xml version="1.0" encoding="UTF-16"?>
+<Task version="1.2" xmlns="http://schemas.microsoft.com/windows/2004/02/mit/task">
+  <RegistrationInfo>
+    <Description>Claude Usage tray monitor</Description>
+  </RegistrationInfo>
+  <Triggers>
+    <LogonTrigger>
+      <Enabled>true</Enabled>
+      <UserId>%s</UserId>
+    </LogonTrigger>
+  </Triggers>
+  <Principals>
+    <Principal id="Author">
+      <LogonType>InteractiveToken</LogonType>
+      <RunLevel>LeastPrivilege</RunLevel>
+    </Principal>
+  </Principals>
+  <Settings>
+    <MultipleInstancesPolicy>IgnoreNew</MultipleInstancesPolicy>
+    <DisallowStartIfOnBatteries>false</DisallowStartIfOnBatteries>
+    <StopIfGoingOnBatteries>false</StopIfGoingOnBatteries>
+    <ExecutionTimeLimit>PT0S</ExecutionTimeLimit>
+    <RestartOnFailure>
+      <Interval>PT1M</Interval>
+      <Count>10</Count>
+    </RestartOnFailure>
+  </Settings>
+  <Actions Context="Author">
+    <Exec>
+      <Command>%s</Command>
+      <Arguments>%s</Arguments>
+    </Exec>
+  </Actions>
+</Task>
+`
+
+// taskName returns the Task Scheduler task name for profile.
+func taskName(profile string) string {
+	if profile == "" {
+		return "Claude Usage"
+	}
+	return "Claude Usage (" + profile + ")"
+}
+
+func install(profile, exe string, args []string) (string, error) {
+	user := os.Getenv("USERDOMAIN") + `\` + os.Getenv("USERNAME")
+	content := fmt.Sprintf(taskTemplate,
+		html.EscapeString(user),
+		html.EscapeString(exe),
+		html.EscapeString(strings.Join(args, " ")))
+
+	// schtasks expects the XML file in UTF-16 with a byte order mark
+	tmpFile, err := os.CreateTemp("", "claude-usage-task-*.xml")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	encoded := utf16.Encode([]rune("\ufeff" + content))
+	err = binary.Write(tmpFile, binary.LittleEndian, encoded)
+	tmpFile.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to write task definition: %w", err)
+	}
+
+	name := taskName(profile)
+	if err := run("schtasks", "/Create", "/TN", name, "/XML", tmpFile.Name(), "/F"); err != nil {
+		return "", err
+	}
+
+	return "scheduled task \"" + name + "\"", nil
+}
+
+func uninstall(profile string) error {
+	name := taskName(profile)
+
+	// Query first so a missing task isn't treated as an error
+	if err := run("schtasks", "/Query", "/TN", name); err != nil {
+		return nil
+	}
+	return run("schtasks", "/Delete", "/TN", name, "/F")
+}
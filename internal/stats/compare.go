@@ -0,0 +1,70 @@
+package stats
+
+import "time"
+
+// WeekComparison compares this week's tokens so far with last week's tokens
+// up to the same point in the week.
+type WeekComparison struct {
+	ThisWeek int64
+	LastWeek int64
+}
+
+// Change returns the relative change from last week in percent, e.g. 14 for
+// "+14%". ok is false when last week has nothing to compare with.
+func (c WeekComparison) Change() (percent float64, ok bool) {
+	if c.LastWeek <= 0 {
+		return 0, false
+	}
+	return float64(c.ThisWeek-c.LastWeek) / float64(c.LastWeek) * 100, true
+}
+
+// CompareWeeks compares token usage of the calendar week containing now with
+// the previous week up to the same point. The stats cache only has daily
+// totals, so last week's matching day counts in proportion to how much of
+// today has passed. Days are calendar days in Location, so a DST change
+// between the two weeks does not shift the comparison. cache can be nil.
+func CompareWeeks(cache *StatsCache, now time.Time) WeekComparison {
+	var c WeekComparison
+	if cache == nil {
+		return c
+	}
+
+	weekStart, _ := WeekBoundsAt(now)
+	now = now.In(weekStart.Location())
+	today := startOfDay(now)
+	tomorrow := today.AddDate(0, 0, 1)
+	dayFraction := now.Sub(today).Seconds() / tomorrow.Sub(today).Seconds()
+
+	lastWeekStart := weekStart.AddDate(0, 0, -7)
+	lastWeekToday := today.AddDate(0, 0, -7)
+
+	for _, daily := range cache.DailyModelTokens {
+		date, err := ParseDate(daily.Date)
+		if err != nil {
+			continue
+		}
+		var tokens int64
+		for _, t := range daily.TokensByModel {
+			tokens += t
+		}
+
+		switch {
+		case !date.Before(weekStart) && !date.After(today):
+			c.ThisWeek += tokens
+		case !date.Before(lastWeekStart) && date.Before(lastWeekToday):
+			c.LastWeek += tokens
+		case date.Equal(lastWeekToday):
+			c.LastWeek += int64(float64(tokens) * dayFraction)
+		}
+	}
+	return c
+}
+
+// WeekOverWeek returns the change in percent from last week at the same
+// point in the week (see WeekComparison.Change).
+func (w *WeeklyStats) WeekOverWeek() (percent float64, ok bool) {
+	if w == nil {
+		return 0, false
+	}
+	return WeekComparison{ThisWeek: w.TotalTokens, LastWeek: w.LastWeekTokensSoFar}.Change()
+}
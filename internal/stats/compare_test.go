@@ -0,0 +1,88 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func cacheWithDays(days map[string]int64) *StatsCache {
+	cache := &StatsCache{}
+	for date, tokens := range days {
+		cache.DailyModelTokens = append(cache.DailyModelTokens, DailyModelTokens{
+			Date:          date,
+			TokensByModel: map[string]int64{"claude-sonnet-4-5": tokens},
+		})
+	}
+	return cache
+}
+
+func TestCompareWeeks(t *testing.T) {
+	// Wednesday 2026-01-14, noon UTC
+	now := time.Date(2026, 1, 14, 12, 0, 0, 0, time.UTC)
+	cache := cacheWithDays(map[string]int64{
+		"2026-01-04": 999, // Sunday before last week: ignored
+		"2026-01-05": 100, // last Monday
+		"2026-01-06": 100,
+		"2026-01-07": 200, // last Wednesday: half counts
+		"2026-01-08": 999, // last Thursday: not reached yet
+		"2026-01-12": 150, // this Monday
+		"2026-01-14": 130,
+	})
+
+	c := CompareWeeks(cache, now)
+	if c.ThisWeek != 280 || c.LastWeek != 300 {
+		t.Fatalf("got %+v, want this week 280, last week 300", c)
+	}
+	if pct, ok := c.Change(); !ok || math.Abs(pct-(-20.0/3)) > 1e-9 {
+		t.Errorf("Change: got %v, %v", pct, ok)
+	}
+}
+
+func TestCompareWeeks_Monday(t *testing.T) {
+	// Just after the week boundary only a sliver of last Monday counts
+	now := time.Date(2026, 1, 12, 6, 0, 0, 0, time.UTC)
+	cache := cacheWithDays(map[string]int64{
+		"2026-01-04": 1000, // Sunday: belongs to the week before last
+		"2026-01-05": 400,
+		"2026-01-11": 500, // yesterday, last week
+		"2026-01-12": 50,
+	})
+
+	c := CompareWeeks(cache, now)
+	if c.ThisWeek != 50 || c.LastWeek != 100 {
+		t.Fatalf("got %+v, want this week 50, last week 100", c)
+	}
+}
+
+func TestCompareWeeks_DST(t *testing.T) {
+	// Sunday 2026-03-29 is when Europe switches to summer time. 01:30
+	// local on Monday is still Sunday in UTC, so it belongs to the week
+	// that started on Monday 2026-03-23.
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+	now := time.Date(2026, 3, 30, 1, 30, 0, 0, berlin)
+	cache := cacheWithDays(map[string]int64{
+		"2026-03-16": 70,
+		"2026-03-23": 70,
+		"2026-03-29": 10,
+		"2026-03-30": 999,
+	})
+
+	c := CompareWeeks(cache, now)
+	if c.ThisWeek != 80 {
+		t.Errorf("this week: got %d, want 80", c.ThisWeek)
+	}
+	// 2026-03-22 (last Sunday) has no data, so last week is just Monday
+	if c.LastWeek != 70 {
+		t.Errorf("last week: got %d, want 70", c.LastWeek)
+	}
+}
+
+func TestChange_NoLastWeek(t *testing.T) {
+	if _, ok := (WeekComparison{ThisWeek: 10}).Change(); ok {
+		t.Error("Change without last week data should not be ok")
+	}
+}
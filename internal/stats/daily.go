@@ -0,0 +1,49 @@
+package stats
+
+import "time"
+
+// dateLayout is the date format used in the stats cache.
+const dateLayout = "2006-01-02"
+
+// DayTotal is the total token count for one day.
+type DayTotal struct {
+	Date   time.Time
+	Tokens int64
+}
+
+// DailyTotals returns the token total for every day from start to end
+// inclusive, oldest first. Days without data are included with zero tokens.
+// cache can be nil.
+func DailyTotals(cache *StatsCache, start, end time.Time) []DayTotal {
+	byDate := make(map[string]int64)
+	if cache != nil {
+		for _, daily := range cache.DailyModelTokens {
+			for _, tokens := range daily.TokensByModel {
+				byDate[daily.Date] += tokens
+			}
+		}
+	}
+
+	var totals []DayTotal
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	for !day.After(end) {
+		totals = append(totals, DayTotal{Date: day, Tokens: byDate[day.Format(dateLayout)]})
+		day = day.AddDate(0, 0, 1)
+	}
+	return totals
+}
+
+// TokensOn returns the tokens used on the given calendar day (in Location)
+// of the weekly window, or zero if the day is outside it.
+func (w *WeeklyStats) TokensOn(day time.Time) int64 {
+	if w == nil {
+		return 0
+	}
+	date := FormatDate(day)
+	for _, d := range w.DailyTokens {
+		if FormatDate(d.Date) == date {
+			return d.Tokens
+		}
+	}
+	return 0
+}
@@ -0,0 +1,41 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDailyTotals(t *testing.T) {
+	cache := &StatsCache{
+		DailyModelTokens: []DailyModelTokens{
+			{Date: "2026-01-05", TokensByModel: map[string]int64{"opus": 100, "sonnet": 50}},
+			{Date: "2026-01-07", TokensByModel: map[string]int64{"sonnet": 25}},
+			{Date: "2026-01-12", TokensByModel: map[string]int64{"sonnet": 999}},
+		},
+	}
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 1, 11, 23, 59, 59, 0, time.UTC)
+
+	totals := DailyTotals(cache, start, end)
+	if len(totals) != 7 {
+		t.Fatalf("got %d days, want 7", len(totals))
+	}
+	want := []int64{150, 0, 25, 0, 0, 0, 0}
+	for i, d := range totals {
+		if d.Tokens != want[i] {
+			t.Errorf("day %d (%s) = %d tokens, want %d", i, d.Date.Format("2006-01-02"), d.Tokens, want[i])
+		}
+	}
+
+	w := &WeeklyStats{DailyTokens: totals}
+	if got := w.TokensOn(time.Date(2026, 1, 5, 15, 0, 0, 0, time.UTC)); got != 150 {
+		t.Errorf("TokensOn(Jan 5) = %d, want 150", got)
+	}
+	if got := w.TokensOn(time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC)); got != 0 {
+		t.Errorf("TokensOn(Jan 12) = %d, want 0", got)
+	}
+
+	if got := DailyTotals(nil, start, end); len(got) != 7 || got[0].Tokens != 0 {
+		t.Errorf("DailyTotals(nil) = %v, want 7 empty days", got)
+	}
+}
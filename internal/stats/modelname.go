@@ -0,0 +1,135 @@
+package stats
+
+import (
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// modelNames are the configured display names; see SetModelNames.
+var modelNames = struct {
+	sync.RWMutex
+	names    map[string]string
+	patterns []string // keys of names with wildcards, longest first
+}{}
+
+// SetModelNames sets display names for model IDs, overriding the names
+// ModelDisplayName derives. Keys are model IDs or path.Match patterns such
+// as "claude-opus-5*"; exact IDs win over patterns and longer patterns
+// over shorter ones.
+func SetModelNames(names map[string]string) {
+	var patterns []string
+	for key := range names {
+		if strings.ContainsAny(key, "*?[") {
+			patterns = append(patterns, key)
+		}
+	}
+	slices.SortFunc(patterns, func(a, b string) int {
+		if len(a) != len(b) {
+			return len(b) - len(a)
+		}
+		return strings.Compare(a, b)
+	})
+
+	modelNames.Lock()
+	modelNames.names = names
+	modelNames.patterns = patterns
+	modelNames.Unlock()
+}
+
+// ModelDisplayName returns a human-friendly name for a model ID, such as
+// "Opus 4.5" for "claude-opus-4-5-20251101". IDs in the configured names
+// (see SetModelNames) use those; other Anthropic model IDs, including
+// Bedrock and Vertex AI ones, are parsed into family and version. Anything
+// else is shown as is, shortened to 20 characters.
+func ModelDisplayName(modelID string) string {
+	if name, ok := configuredModelName(modelID); ok {
+		return name
+	}
+	if name, ok := parseModelID(modelID); ok {
+		return name
+	}
+	if len(modelID) > 20 {
+		return modelID[:20] + "..."
+	}
+	return modelID
+}
+
+// configuredModelName looks modelID up in the configured names.
+func configuredModelName(modelID string) (string, bool) {
+	modelNames.RLock()
+	defer modelNames.RUnlock()
+	if name, ok := modelNames.names[modelID]; ok {
+		return name, true
+	}
+	for _, pattern := range modelNames.patterns {
+		if ok, _ := path.Match(pattern, modelID); ok {
+			return modelNames.names[pattern], true
+		}
+	}
+	return "", false
+}
+
+// parseModelID derives a display name from an Anthropic model ID. Both
+// orders are understood ("claude-3-5-sonnet-20241022", "claude-sonnet-4-5"),
+// as are provider prefixes and suffixes ("us.anthropic.claude-...-v1:0",
+// "claude-...@20250514") and context markers ("claude-...[1m]"). Dates,
+// "latest" and provider revisions are dropped.
+func parseModelID(modelID string) (string, bool) {
+	id, marker := modelID, ""
+	if i := strings.IndexByte(id, '['); i >= 0 && strings.HasSuffix(id, "]") {
+		id, marker = id[:i], strings.ToUpper(id[i+1:len(id)-1])
+	}
+	i := strings.Index(id, "claude-")
+	if i < 0 {
+		return "", false
+	}
+	id = id[i+len("claude-"):]
+	if i := strings.IndexAny(id, "@:"); i >= 0 {
+		id = id[:i]
+	}
+
+	var family, version []string
+	for _, part := range strings.Split(id, "-") {
+		switch {
+		case part == "" || part == "latest" || isRevision(part):
+		case len(part) == 8 && isVersion(part): // a date such as 20251101
+		case isVersion(part):
+			version = append(version, part)
+		default:
+			family = append(family, strings.ToUpper(part[:1])+part[1:])
+		}
+	}
+	if len(family) == 0 && len(version) == 0 {
+		return "", false
+	}
+	if len(family) == 0 {
+		family = []string{"Claude"}
+	}
+
+	name := strings.Join(family, " ")
+	if len(version) > 0 {
+		name += " " + strings.Join(version, ".")
+	}
+	if marker != "" {
+		name += " (" + marker + ")"
+	}
+	return name, true
+}
+
+// isVersion reports whether part is a version number such as "4" or "2.1".
+func isVersion(part string) bool {
+	for _, r := range part {
+		if !unicode.IsDigit(r) && r != '.' {
+			return false
+		}
+	}
+	return part[0] != '.'
+}
+
+// isRevision reports whether part is a provider revision such as "v2".
+func isRevision(part string) bool {
+	return len(part) > 1 && part[0] == 'v' && isVersion(part[1:])
+}
@@ -0,0 +1,46 @@
+package stats
+
+import "testing"
+
+func TestModelDisplayName(t *testing.T) {
+	tests := map[string]string{
+		"claude-opus-4-5-20251101":                   "Opus 4.5",
+		"claude-sonnet-4-20250514":                   "Sonnet 4",
+		"claude-haiku-4-5":                           "Haiku 4.5",
+		"claude-3-opus-20240229":                     "Opus 3",
+		"claude-3-5-sonnet-latest":                   "Sonnet 3.5",
+		"claude-3-7-sonnet-20250219":                 "Sonnet 3.7",
+		"claude-sonnet-4-5-20250929[1m]":             "Sonnet 4.5 (1M)",
+		"us.anthropic.claude-opus-4-1-20250805-v1:0": "Opus 4.1",
+		"claude-3-5-sonnet-v2@20241022":              "Sonnet 3.5",
+		"claude-2.1":                                 "Claude 2.1",
+		"<synthetic>":                                "<synthetic>",
+		"some-very-long-model-name-from-elsewhere":   "some-very-long-model...",
+	}
+	for id, want := range tests {
+		if got := ModelDisplayName(id); got != want {
+			t.Errorf("ModelDisplayName(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestModelDisplayName_Configured(t *testing.T) {
+	SetModelNames(map[string]string{
+		"claude-opus-4-5-20251101": "Opus (work)",
+		"claude-opus-*":            "Opus",
+		"claude-opus-5*":           "Opus Next",
+	})
+	defer SetModelNames(nil)
+
+	tests := map[string]string{
+		"claude-opus-4-5-20251101": "Opus (work)",
+		"claude-opus-5-20260101":   "Opus Next",
+		"claude-opus-4-1-20250805": "Opus",
+		"claude-sonnet-4-20250514": "Sonnet 4",
+	}
+	for id, want := range tests {
+		if got := ModelDisplayName(id); got != want {
+			t.Errorf("ModelDisplayName(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
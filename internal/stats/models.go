@@ -1,7 +1,10 @@
 // Package stats provides parsing and calculation of Claude usage statistics.
 package stats
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
 // StatsCache represents the structure of Claude's stats-cache.json file.
 type StatsCache struct {
@@ -54,6 +57,22 @@ type OAuthCredentials struct {
 	RateLimitTier    string   `json:"rateLimitTier"`
 }
 
+// MissingScopes returns those of required that the credentials lack.
+// Credentials files without a scopes list (older logins, OpenCode) are
+// assumed to have them all.
+func (o OAuthCredentials) MissingScopes(required ...string) []string {
+	if len(o.Scopes) == 0 {
+		return nil
+	}
+	var missing []string
+	for _, scope := range required {
+		if !slices.Contains(o.Scopes, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
 // OpenCodeCredentials represents OpenCode's auth.json structure.
 type OpenCodeCredentials struct {
 	Anthropic OpenCodeAnthropicAuth `json:"anthropic"`
@@ -69,10 +88,11 @@ type OpenCodeAnthropicAuth struct {
 
 // WeeklyStats represents calculated weekly usage statistics.
 type WeeklyStats struct {
-	// WeekStart is the start of the current week (Monday 00:00 UTC).
+	// WeekStart is the start of the weekly window: the API's rolling 7-day
+	// window when known, otherwise the calendar week (see GetWeekBounds).
 	WeekStart time.Time
 
-	// WeekEnd is the end of the current week (Sunday 23:59 UTC).
+	// WeekEnd is the end of the weekly window.
 	WeekEnd time.Time
 
 	// TokensByModel maps model names to their token counts for the week.
@@ -81,6 +101,13 @@ type WeeklyStats struct {
 	// TotalTokens is the sum of all model tokens.
 	TotalTokens int64
 
+	// DailyTokens holds the token total for each day of the window, oldest first.
+	DailyTokens []DayTotal
+
+	// LastWeekTokensSoFar is last week's token total up to the same point
+	// in the week as now (see CompareWeeks).
+	LastWeekTokensSoFar int64
+
 	// SubscriptionType is the user's subscription (free, pro, team, enterprise).
 	SubscriptionType string
 
@@ -113,28 +140,49 @@ type WeeklyStats struct {
 	OpusReset         time.Time
 	SonnetReset       time.Time
 
+	// Weekly utilization of the OAuth apps and Cowork buckets
+	OAuthAppsUtilization float64
+	CoworkUtilization    float64
+	OAuthAppsReset       time.Time
+	CoworkReset          time.Time
+
+	// Estimated 5-hour session window from transcript timestamps, for when
+	// API data is unavailable. SessionReset is zero when no window is open.
+	SessionStart  time.Time
+	SessionReset  time.Time
+	SessionTokens int64
+
+	// Extra usage (pay-as-you-go credits), in dollars.
+	// ExtraUsageLimit is zero when no monthly limit is set.
+	ExtraUsageEnabled bool
+	ExtraUsageUsed    float64
+	ExtraUsageLimit   float64
+
+	// Team-wide utilization (0.0-1.0) from the organization usage
+	// endpoint, set when HasTeamData
+	HasTeamData             bool
+	TeamFiveHourUtilization float64
+	TeamWeeklyUtilization   float64
+
+	// Utilization (0.0-1.0) in the rate limit headers of regular API
+	// responses, set when HeaderMismatch: they disagree with the usage
+	// endpoint
+	HeaderMismatch            bool
+	HeaderFiveHourUtilization float64
+	HeaderWeeklyUtilization   float64
+
+	// Estimated cost at API prices of this week's and this month's tokens,
+	// from the stats cache (see package cost)
+	WeekCostUSD  float64
+	MonthCostUSD float64
+
 	// HasAPIData indicates if we have real API rate limit data
 	HasAPIData bool
-}
-
-// ModelDisplayName returns a human-friendly name for a model ID.
-func ModelDisplayName(modelID string) string {
-	displayNames := map[string]string{
-		"claude-opus-4-5-20251101":   "Opus 4.5",
-		"claude-sonnet-4-5-20250929": "Sonnet 4.5",
-		"claude-haiku-3-5-20240307":  "Haiku 3.5",
-		"claude-3-opus-20240229":     "Opus 3",
-		"claude-3-sonnet-20240229":   "Sonnet 3",
-		"claude-3-haiku-20240307":    "Haiku 3",
-	}
 
-	if name, ok := displayNames[modelID]; ok {
-		return name
-	}
+	// APIDataStale is set when the API was unreachable and the rate limit
+	// fields hold the last known data
+	APIDataStale bool
 
-	// Fallback: extract model family from ID
-	if len(modelID) > 20 {
-		return modelID[:20] + "..."
-	}
-	return modelID
+	// APIFetchedAt is when the rate limit data was fetched
+	APIFetchedAt time.Time
 }
@@ -0,0 +1,38 @@
+package stats
+
+import "time"
+
+// WeekProgress returns a value from 0.0 to 1.0 representing progress
+// through the stats' weekly window, or through the calendar week (see
+// GetWeekProgress) when the window is unknown.
+func (w *WeeklyStats) WeekProgress() float64 {
+	if w == nil || w.WeekStart.IsZero() || w.WeekEnd.IsZero() {
+		return GetWeekProgress()
+	}
+	return progressAt(w.WeekStart, w.WeekEnd, time.Now())
+}
+
+// progressAt returns how far now is from start to end, from 0.0 to 1.0.
+func progressAt(start, end, now time.Time) float64 {
+	total := end.Sub(start).Seconds()
+	elapsed := now.Sub(start).Seconds()
+	switch {
+	case total <= 0 || elapsed < 0:
+		return 0.0
+	case elapsed > total:
+		return 1.0
+	}
+	return elapsed / total
+}
+
+// PaceDelta returns how many percentage points weekly usage is ahead of an
+// even pace through the week: 60% used with 40% of the week elapsed is
+// 20 ahead. Negative values are behind pace.
+func (w *WeeklyStats) PaceDelta() float64 {
+	return paceDelta(w.GetPercentageFloat(), w.WeekProgress())
+}
+
+// paceDelta returns percent used minus percent of the week elapsed.
+func paceDelta(percent, progress float64) float64 {
+	return percent - progress*100
+}
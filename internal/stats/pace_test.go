@@ -0,0 +1,51 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgressAt(t *testing.T) {
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+
+	for _, tc := range []struct {
+		name string
+		now  time.Time
+		want float64
+	}{
+		{"before", start.Add(-time.Hour), 0},
+		{"start", start, 0},
+		{"midweek", start.Add(84 * time.Hour), 0.5},
+		{"after", end.Add(time.Hour), 1},
+	} {
+		if got := progressAt(start, end, tc.now); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	if got := progressAt(end, start, start); got != 0 {
+		t.Errorf("empty window: got %v, want 0", got)
+	}
+}
+
+func TestPaceDelta(t *testing.T) {
+	if got := paceDelta(60, 0.4); got != 20 {
+		t.Errorf("ahead: got %v, want 20", got)
+	}
+	if got := paceDelta(10, 0.5); got != -40 {
+		t.Errorf("behind: got %v, want -40", got)
+	}
+
+	// A window from the API is used over the calendar week
+	now := time.Now()
+	w := &WeeklyStats{
+		HasAPIData:        true,
+		WeeklyUtilization: 0.6,
+		WeekStart:         now.Add(-42 * time.Hour),
+		WeekEnd:           now.Add(126 * time.Hour),
+	}
+	if got := w.PaceDelta(); got < 34.9 || got > 35.1 {
+		t.Errorf("PaceDelta: got %v, want 35", got)
+	}
+}
@@ -135,3 +135,15 @@ func TestUpdateRefreshToken_MissingClaudeAiOauth(t *testing.T) {
 		t.Error("Expected error for missing claudeAiOauth section, got nil")
 	}
 }
+
+func TestMissingScopes(t *testing.T) {
+	oauth := OAuthCredentials{Scopes: []string{"user:inference"}}
+	if got := oauth.MissingScopes("user:inference", "user:profile"); len(got) != 1 || got[0] != "user:profile" {
+		t.Errorf("MissingScopes = %v, want [user:profile]", got)
+	}
+
+	// Without a scopes list nothing is known to be missing
+	if got := (OAuthCredentials{}).MissingScopes("user:profile"); got != nil {
+		t.Errorf("MissingScopes without scopes = %v, want nil", got)
+	}
+}
@@ -0,0 +1,106 @@
+package stats
+
+import "time"
+
+// PeriodStats is local token usage from the stats cache over a date range.
+type PeriodStats struct {
+	Start time.Time
+	End   time.Time
+
+	// TokensByModel maps model names to their token counts for the period.
+	TokensByModel map[string]int64
+
+	// TotalTokens is the sum of all model tokens.
+	TotalTokens int64
+
+	// Activity counts for the period
+	Messages  int
+	Sessions  int
+	ToolCalls int
+}
+
+// LifetimeStats is all-time usage from the stats cache.
+type LifetimeStats struct {
+	PeriodStats
+
+	// FirstSessionDate is when Claude Code was first used (zero if unknown).
+	FirstSessionDate time.Time
+}
+
+// GetMonthBounds returns the start and end of the current calendar month in
+// Location.
+func GetMonthBounds() (start, end time.Time) {
+	now := time.Now().In(Location())
+	start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	end = start.AddDate(0, 1, 0).Add(-time.Nanosecond)
+	return start, end
+}
+
+// CalculatePeriodStats sums tokens and activity for days from start to end
+// inclusive. cache can be nil.
+func CalculatePeriodStats(cache *StatsCache, start, end time.Time) *PeriodStats {
+	p := &PeriodStats{
+		Start:         start,
+		End:           end,
+		TokensByModel: make(map[string]int64),
+	}
+	if cache == nil {
+		return p
+	}
+
+	inPeriod := func(date string) bool {
+		d, err := ParseDate(date)
+		return err == nil && !d.Before(start) && !d.After(end)
+	}
+
+	for _, daily := range cache.DailyModelTokens {
+		if !inPeriod(daily.Date) {
+			continue
+		}
+		for model, tokens := range daily.TokensByModel {
+			p.TokensByModel[model] += tokens
+			p.TotalTokens += tokens
+		}
+	}
+	for _, activity := range cache.DailyActivity {
+		if !inPeriod(activity.Date) {
+			continue
+		}
+		p.Messages += activity.MessageCount
+		p.Sessions += activity.SessionCount
+		p.ToolCalls += activity.ToolCallCount
+	}
+	return p
+}
+
+// CalculateMonthlyStats sums usage for the current calendar month.
+func CalculateMonthlyStats(cache *StatsCache) *PeriodStats {
+	start, end := GetMonthBounds()
+	return CalculatePeriodStats(cache, start, end)
+}
+
+// CalculateLifetimeStats sums all usage in the stats cache. Start is left
+// zero so it covers every recorded day. Session and
+// message totals come from the cache's own counters, which cover days that
+// have dropped out of the daily history.
+func CalculateLifetimeStats(cache *StatsCache) *LifetimeStats {
+	l := &LifetimeStats{
+		PeriodStats: *CalculatePeriodStats(cache, time.Time{}, time.Now()),
+	}
+	if cache == nil {
+		return l
+	}
+
+	if cache.TotalSessions > l.Sessions {
+		l.Sessions = cache.TotalSessions
+	}
+	if cache.TotalMessages > l.Messages {
+		l.Messages = cache.TotalMessages
+	}
+	if t, err := time.Parse(time.RFC3339, cache.FirstSessionDate); err == nil {
+		l.FirstSessionDate = t
+	} else if t, err := ParseDate(cache.FirstSessionDate); err == nil {
+		l.FirstSessionDate = t
+	}
+	return l
+}
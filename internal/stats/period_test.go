@@ -0,0 +1,55 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func testCache() *StatsCache {
+	return &StatsCache{
+		DailyModelTokens: []DailyModelTokens{
+			{Date: "2025-12-30", TokensByModel: map[string]int64{"opus": 1000}},
+			{Date: "2026-01-02", TokensByModel: map[string]int64{"opus": 100, "sonnet": 50}},
+			{Date: "2026-01-20", TokensByModel: map[string]int64{"sonnet": 25}},
+		},
+		DailyActivity: []DailyActivity{
+			{Date: "2025-12-30", MessageCount: 40, SessionCount: 4, ToolCallCount: 10},
+			{Date: "2026-01-02", MessageCount: 10, SessionCount: 2, ToolCallCount: 5},
+			{Date: "2026-01-20", MessageCount: 3, SessionCount: 1},
+		},
+		TotalSessions:    100,
+		TotalMessages:    20,
+		FirstSessionDate: "2025-03-01T10:00:00.000Z",
+	}
+}
+
+func TestCalculatePeriodStats(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 1, 31, 23, 59, 59, 0, time.UTC)
+
+	p := CalculatePeriodStats(testCache(), start, end)
+	if p.TotalTokens != 175 || p.TokensByModel["opus"] != 100 || p.TokensByModel["sonnet"] != 75 {
+		t.Errorf("tokens = %d %v, want 175 (opus 100, sonnet 75)", p.TotalTokens, p.TokensByModel)
+	}
+	if p.Messages != 13 || p.Sessions != 3 || p.ToolCalls != 5 {
+		t.Errorf("activity = %d messages, %d sessions, %d tool calls; want 13, 3, 5", p.Messages, p.Sessions, p.ToolCalls)
+	}
+
+	if empty := CalculatePeriodStats(nil, start, end); empty.TotalTokens != 0 {
+		t.Errorf("CalculatePeriodStats(nil) = %d tokens, want 0", empty.TotalTokens)
+	}
+}
+
+func TestCalculateLifetimeStats(t *testing.T) {
+	l := CalculateLifetimeStats(testCache())
+	if l.TotalTokens != 1175 {
+		t.Errorf("TotalTokens = %d, want 1175", l.TotalTokens)
+	}
+	// The cache's counter wins when it is higher; the daily sum otherwise
+	if l.Sessions != 100 || l.Messages != 53 {
+		t.Errorf("sessions = %d, messages = %d; want 100, 53", l.Sessions, l.Messages)
+	}
+	if want := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC); !l.FirstSessionDate.Equal(want) {
+		t.Errorf("FirstSessionDate = %v, want %v", l.FirstSessionDate, want)
+	}
+}
@@ -0,0 +1,62 @@
+package stats
+
+import (
+	"sync"
+	"time"
+)
+
+// weekSettings is where the calendar week used for estimates starts.
+// It only applies when the API's rolling window is unknown.
+var weekSettings = struct {
+	sync.RWMutex
+	day time.Weekday
+	loc *time.Location
+}{day: time.Monday, loc: time.UTC}
+
+// SetWeekStart sets the day and timezone calendar weeks start in. Dates in
+// the stats cache are read as calendar days in that timezone. The default
+// is Monday, UTC. A nil loc means UTC.
+func SetWeekStart(day time.Weekday, loc *time.Location) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	weekSettings.Lock()
+	weekSettings.day = day
+	weekSettings.loc = loc
+	weekSettings.Unlock()
+}
+
+// Location returns the timezone calendar days and weeks are counted in.
+func Location() *time.Location {
+	weekSettings.RLock()
+	defer weekSettings.RUnlock()
+	return weekSettings.loc
+}
+
+// ParseDate parses a stats-cache date ("2006-01-02") as midnight in
+// Location.
+func ParseDate(date string) (time.Time, error) {
+	return time.ParseInLocation(dateLayout, date, Location())
+}
+
+// FormatDate formats t as a stats-cache date in Location.
+func FormatDate(t time.Time) string {
+	return t.In(Location()).Format(dateLayout)
+}
+
+// startOfDay returns midnight of t's calendar day in t's location.
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// WindowFromReset returns the 7-day rolling window ending at the first
+// weekly reset after now, stepping forward from a known reset time (which
+// may be in the past, e.g. from cached API data).
+func WindowFromReset(reset, now time.Time) (start, end time.Time) {
+	const week = 7 * 24 * time.Hour
+	if !reset.After(now) {
+		weeks := now.Sub(reset)/week + 1
+		reset = reset.Add(weeks * week)
+	}
+	return reset.Add(-week), reset
+}
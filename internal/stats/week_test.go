@@ -0,0 +1,75 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWeekBounds_Configured(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+	SetWeekStart(time.Sunday, ny)
+	defer SetWeekStart(time.Monday, time.UTC)
+
+	// Sunday 2026-03-08 02:00 is when New York switches to daylight time;
+	// 03:30 UTC on the 8th is still Saturday evening there
+	start, end := WeekBoundsAt(time.Date(2026, 3, 8, 3, 30, 0, 0, time.UTC))
+	if want := time.Date(2026, 3, 1, 0, 0, 0, 0, ny); !start.Equal(want) {
+		t.Errorf("start: got %v, want %v", start, want)
+	}
+	if want := time.Date(2026, 3, 8, 0, 0, 0, 0, ny).Add(-time.Nanosecond); !end.Equal(want) {
+		t.Errorf("end: got %v, want %v", end, want)
+	}
+
+	// The next week starts at local midnight despite the DST change
+	start, _ = WeekBoundsAt(time.Date(2026, 3, 10, 12, 0, 0, 0, ny))
+	if start.Hour() != 0 || start.Day() != 8 {
+		t.Errorf("start after DST change: got %v", start)
+	}
+
+	if d, err := ParseDate("2026-03-08"); err != nil || !d.Equal(time.Date(2026, 3, 8, 0, 0, 0, 0, ny)) {
+		t.Errorf("ParseDate: got %v, %v", d, err)
+	}
+}
+
+func TestWindowFromReset(t *testing.T) {
+	reset := time.Date(2026, 1, 9, 14, 0, 0, 0, time.UTC)
+
+	start, end := WindowFromReset(reset, time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC))
+	if !end.Equal(reset) || !start.Equal(reset.AddDate(0, 0, -7)) {
+		t.Errorf("upcoming reset: got %v – %v", start, end)
+	}
+
+	// A cached reset that has passed steps forward a whole number of weeks
+	start, end = WindowFromReset(reset, time.Date(2026, 1, 20, 0, 0, 0, 0, time.UTC))
+	if want := reset.AddDate(0, 0, 14); !end.Equal(want) || !start.Equal(want.AddDate(0, 0, -7)) {
+		t.Errorf("past reset: got %v – %v", start, end)
+	}
+
+	// Exactly at the reset, the next window has begun
+	if _, end := WindowFromReset(reset, reset); !end.Equal(reset.AddDate(0, 0, 7)) {
+		t.Errorf("at reset: got end %v", end)
+	}
+}
+
+func TestCalculateWindowStats(t *testing.T) {
+	cache := cacheWithDays(map[string]int64{
+		"2026-01-01": 1000,
+		"2026-01-02": 10, // the window starts mid-day: counted in full
+		"2026-01-05": 20,
+		"2026-01-09": 30,
+		"2026-01-10": 1000,
+	})
+	start := time.Date(2026, 1, 2, 14, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+
+	s := CalculateWindowStats(cache, nil, start, end)
+	if s.TotalTokens != 60 {
+		t.Errorf("got %d tokens, want 60", s.TotalTokens)
+	}
+	if len(s.DailyTokens) != 8 || s.DailyTokens[0].Tokens != 10 {
+		t.Errorf("got %d days, first %+v", len(s.DailyTokens), s.DailyTokens)
+	}
+}
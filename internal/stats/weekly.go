@@ -1,12 +1,17 @@
 package stats
 
 import (
+	"math"
 	"math/rand"
+	"sync"
 	"time"
+
+	"claude-usage/pkg/format"
 )
 
 // Known weekly token limits by plan type (rough estimates)
-// These are approximations since Anthropic doesn't publish exact limits
+// These are approximations since Anthropic doesn't publish exact limits.
+// They are the bundled fallback for the fetched table; see SetPlanLimits.
 var planLimits = map[string]int64{
 	// Rate limit tiers (from .credentials.json rateLimitTier field)
 	"default_claude_pro":     45_000_000,  // ~45M tokens/week for Pro
@@ -22,59 +27,82 @@ var planLimits = map[string]int64{
 	"free":        8_000_000,   // Free tier
 }
 
+// planLimitTables are the limits that take precedence over planLimits:
+// the user's overrides, then the fetched table.
+var planLimitTables = struct {
+	sync.RWMutex
+	overrides, fetched map[string]int64
+}{}
+
+// SetPlanLimits sets the weekly token limits used instead of the bundled
+// ones: fetched is an updated table (see package planlimits), overrides
+// the user's own limits, which win over both. Keys are rate limit tiers or
+// subscription types; either map may be nil.
+func SetPlanLimits(fetched, overrides map[string]int64) {
+	planLimitTables.Lock()
+	planLimitTables.fetched = fetched
+	planLimitTables.overrides = overrides
+	planLimitTables.Unlock()
+}
+
 // GetWeeklyLimit returns the estimated weekly token limit based on plan type.
-// Returns 0 if plan is unknown.
+// Each table is searched by rate limit tier (more specific), then by
+// subscription type, before the next one. Unknown plans get the Pro limit.
 func GetWeeklyLimit(subscriptionType, rateLimitTier string) int64 {
-	// Check rate limit tier first (more specific)
-	if rateLimitTier != "" {
-		if limit, ok := planLimits[rateLimitTier]; ok {
+	planLimitTables.RLock()
+	tables := []map[string]int64{planLimitTables.overrides, planLimitTables.fetched, planLimits}
+	planLimitTables.RUnlock()
+
+	for _, table := range tables {
+		if limit, ok := table[rateLimitTier]; ok && rateLimitTier != "" {
 			return limit
 		}
-	}
-
-	// Fall back to subscription type
-	if subscriptionType != "" {
-		if limit, ok := planLimits[subscriptionType]; ok {
+		if limit, ok := table[subscriptionType]; ok && subscriptionType != "" {
 			return limit
 		}
 	}
 
 	// Default to Pro limits
+	for _, table := range tables {
+		if limit, ok := table["pro"]; ok {
+			return limit
+		}
+	}
 	return planLimits["pro"]
 }
 
-// GetWeekBounds returns the start and end of the current ISO week (Monday-Sunday).
-// Times are in UTC.
+// GetWeekBounds returns the start and end of the current calendar week,
+// Monday to Sunday in UTC unless changed with SetWeekStart.
 func GetWeekBounds() (start, end time.Time) {
-	now := time.Now().UTC()
-
-	// Find the Monday of the current week
-	weekday := now.Weekday()
-	if weekday == time.Sunday {
-		weekday = 7
-	}
-	daysFromMonday := int(weekday) - 1
+	return WeekBoundsAt(time.Now())
+}
 
-	// Start of week (Monday 00:00:00)
-	start = time.Date(
-		now.Year(), now.Month(), now.Day()-daysFromMonday,
-		0, 0, 0, 0, time.UTC,
-	)
+// WeekBoundsAt returns the bounds of the calendar week containing now, as
+// GetWeekBounds does for the current week.
+func WeekBoundsAt(now time.Time) (start, end time.Time) {
+	weekSettings.RLock()
+	day, loc := weekSettings.day, weekSettings.loc
+	weekSettings.RUnlock()
 
-	// End of week (Sunday 23:59:59)
-	end = start.AddDate(0, 0, 6)
-	end = time.Date(
-		end.Year(), end.Month(), end.Day(),
-		23, 59, 59, 999999999, time.UTC,
-	)
+	now = now.In(loc)
+	daysSinceStart := (int(now.Weekday()) - int(day) + 7) % 7
 
+	// Calendar arithmetic keeps midnight across DST changes
+	start = startOfDay(now).AddDate(0, 0, -daysSinceStart)
+	end = start.AddDate(0, 0, 7).Add(-time.Nanosecond)
 	return start, end
 }
 
-// CalculateWeeklyStats computes token usage for the current week.
+// CalculateWeeklyStats computes token usage for the current calendar week.
 func CalculateWeeklyStats(cache *StatsCache, creds *Credentials) *WeeklyStats {
 	weekStart, weekEnd := GetWeekBounds()
+	return CalculateWindowStats(cache, creds, weekStart, weekEnd)
+}
 
+// CalculateWindowStats computes token usage for a weekly window, such as the
+// API's rolling 7-day window. The stats cache only has daily totals, so a
+// window starting mid-day counts its first day in full.
+func CalculateWindowStats(cache *StatsCache, creds *Credentials, weekStart, weekEnd time.Time) *WeeklyStats {
 	stats := &WeeklyStats{
 		WeekStart:     weekStart,
 		WeekEnd:       weekEnd,
@@ -88,19 +116,23 @@ func CalculateWeeklyStats(cache *StatsCache, creds *Credentials) *WeeklyStats {
 		stats.RateLimitTier = creds.ClaudeAiOauth.RateLimitTier
 	}
 
+	firstDay := startOfDay(weekStart.In(Location()))
+	stats.DailyTokens = DailyTotals(cache, firstDay, weekEnd.In(Location()))
+	stats.LastWeekTokensSoFar = CompareWeeks(cache, time.Now()).LastWeek
+
 	if cache == nil {
 		return stats
 	}
 
-	// Parse and sum tokens for each day in the week
+	// Parse and sum tokens for each day in the window
 	for _, daily := range cache.DailyModelTokens {
-		date, err := time.Parse("2006-01-02", daily.Date)
+		date, err := ParseDate(daily.Date)
 		if err != nil {
 			continue
 		}
 
-		// Check if this date falls within the current week
-		if date.Before(weekStart) || date.After(weekEnd) {
+		// Check if this date falls within the window
+		if date.Before(firstDay) || date.After(weekEnd) {
 			continue
 		}
 
@@ -114,31 +146,35 @@ func CalculateWeeklyStats(cache *StatsCache, creds *Credentials) *WeeklyStats {
 	return stats
 }
 
-// GetDaysRemainingInWeek returns the number of days left in the current week.
+// GetDaysRemainingInWeek returns the number of full days left in the
+// current calendar week after today.
 func GetDaysRemainingInWeek() int {
-	now := time.Now().UTC()
-	weekday := now.Weekday()
-	if weekday == time.Sunday {
+	start, _ := GetWeekBounds()
+	today := startOfDay(time.Now().In(start.Location()))
+	days := 0
+	for d := today.AddDate(0, 0, 1); d.Before(start.AddDate(0, 0, 7)); d = d.AddDate(0, 0, 1) {
+		days++
+	}
+	return days
+}
+
+// DaysRemaining returns the number of full days left in the window, or in
+// the current calendar week if the window is unknown.
+func (w *WeeklyStats) DaysRemaining() int {
+	if w == nil || w.WeekEnd.IsZero() {
+		return GetDaysRemainingInWeek()
+	}
+	days := int(time.Until(w.WeekEnd).Hours() / 24)
+	if days < 0 {
 		return 0
 	}
-	return 7 - int(weekday)
+	return days
 }
 
 // GetWeekProgress returns a value from 0.0 to 1.0 representing progress through the week.
 func GetWeekProgress() float64 {
-	now := time.Now().UTC()
 	start, end := GetWeekBounds()
-
-	total := end.Sub(start).Seconds()
-	elapsed := now.Sub(start).Seconds()
-
-	if elapsed < 0 {
-		return 0.0
-	}
-	if elapsed > total {
-		return 1.0
-	}
-	return elapsed / total
+	return progressAt(start, end, time.Now())
 }
 
 // GetPercentage returns the usage percentage (0-99).
@@ -215,6 +251,21 @@ func (w *WeeklyStats) GetPercentageFloat() float64 {
 	return float64(w.TotalTokens) / float64(limit) * 100.0
 }
 
+// BudgetPercentage returns how much of a personal weekly budget the
+// window's usage takes, in percent and uncapped: of tokens, or of usd at
+// API prices when tokens is zero. It is 0 without a budget.
+func (w *WeeklyStats) BudgetPercentage(tokens int64, usd float64) float64 {
+	switch {
+	case w == nil:
+		return 0
+	case tokens > 0:
+		return float64(w.TotalTokens) / float64(tokens) * 100
+	case usd > 0:
+		return w.WeekCostUSD / usd * 100
+	}
+	return 0
+}
+
 // GetFiveHourPercentage returns the 5-hour window usage percentage (0-99).
 func (w *WeeklyStats) GetFiveHourPercentage() int {
 	if w == nil || !w.HasAPIData {
@@ -230,6 +281,31 @@ func (w *WeeklyStats) GetFiveHourPercentage() int {
 	return percentage
 }
 
+// ExtraUsageText returns extra credit spend as "$12.40 / $50 (25%)", or
+// "$12.40" without a monthly limit. Returns "" when extra usage is not
+// enabled.
+func (w *WeeklyStats) ExtraUsageText() string {
+	if w == nil || !w.HasAPIData || !w.ExtraUsageEnabled {
+		return ""
+	}
+	if w.ExtraUsageLimit > 0 {
+		return format.FormatDollars(w.ExtraUsageUsed) + " / " + format.FormatDollars(w.ExtraUsageLimit) +
+			" (" + format.FormatPercent(w.ExtraUsagePercent()) + ")"
+	}
+	return format.FormatDollars(w.ExtraUsageUsed)
+}
+
+// ExtraUsagePercent returns extra credit spend as a percentage of the
+// monthly limit, or 0 without extra usage or a limit.
+func (w *WeeklyStats) ExtraUsagePercent() int {
+	if w == nil || !w.ExtraUsageEnabled || w.ExtraUsageLimit <= 0 {
+		return 0
+	}
+	// In cents, so $57 of $100 is 57% rather than 56.99...%
+	used, limit := math.Round(w.ExtraUsageUsed*100), math.Round(w.ExtraUsageLimit*100)
+	return int(used * 100 / limit)
+}
+
 // IsThrottled returns true if currently rate limited.
 func (w *WeeklyStats) IsThrottled() bool {
 	if w == nil {
@@ -245,3 +321,13 @@ func (w *WeeklyStats) IsLimitedByFiveHour() bool {
 	}
 	return w.RepresentativeClaim == "five_hour"
 }
+
+// LimitingPercentage returns the usage percentage of the limiting window:
+// the 5-hour window when the API reports it as the limit, otherwise the
+// weekly window.
+func (w *WeeklyStats) LimitingPercentage() int {
+	if w.IsLimitedByFiveHour() && w.HasAPIData {
+		return w.GetFiveHourPercentage()
+	}
+	return w.GetPercentage()
+}
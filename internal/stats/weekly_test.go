@@ -0,0 +1,54 @@
+package stats
+
+import "testing"
+
+func TestGetWeeklyLimit(t *testing.T) {
+	SetPlanLimits(
+		map[string]int64{"default_claude_max_5x": 300_000_000, "pro": 50_000_000},
+		map[string]int64{"pro": 60_000_000},
+	)
+	defer SetPlanLimits(nil, nil)
+
+	tests := []struct {
+		subscription, tier string
+		want               int64
+	}{
+		{"max", "default_claude_max_5x", 300_000_000},  // fetched
+		{"pro", "default_claude_pro", 60_000_000},      // override by subscription wins over bundled tier
+		{"max", "default_claude_max_20x", 900_000_000}, // bundled
+		{"", "", 60_000_000},                           // unknown plans get the Pro limit
+	}
+	for _, tt := range tests {
+		if got := GetWeeklyLimit(tt.subscription, tt.tier); got != tt.want {
+			t.Errorf("GetWeeklyLimit(%q, %q) = %d, want %d", tt.subscription, tt.tier, got, tt.want)
+		}
+	}
+}
+
+func TestBudgetPercentage(t *testing.T) {
+	w := &WeeklyStats{TotalTokens: 6_000_000, WeekCostUSD: 12.5}
+	if got := w.BudgetPercentage(5_000_000, 0); got != 120 {
+		t.Errorf("token budget: got %v, want 120", got)
+	}
+	if got := w.BudgetPercentage(0, 50); got != 25 {
+		t.Errorf("cost budget: got %v, want 25", got)
+	}
+	if got := w.BudgetPercentage(0, 0); got != 0 {
+		t.Errorf("no budget: got %v, want 0", got)
+	}
+}
+
+func TestExtraUsagePercent(t *testing.T) {
+	w := &WeeklyStats{HasAPIData: true, ExtraUsageEnabled: true, ExtraUsageUsed: 57, ExtraUsageLimit: 100}
+	if got := w.ExtraUsagePercent(); got != 57 {
+		t.Errorf("got %d%%, want 57%%", got)
+	}
+	if got, want := w.ExtraUsageText(), "$57 / $100 (57%)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	w.ExtraUsageLimit = 0
+	if got := w.ExtraUsagePercent(); got != 0 {
+		t.Errorf("without a limit: got %d%%, want 0", got)
+	}
+}
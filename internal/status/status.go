@@ -0,0 +1,117 @@
+// Package status describes the app's current usage state in a compact,
+// stable JSON form for scripts and other local tools. The tray app keeps
+// the latest status in a file so readers never need to call the API.
+package status
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"claude-usage/internal/stats"
+)
+
+// Status is a snapshot of the usage shown in the tray.
+type Status struct {
+	// WeeklyPercent is the weekly window usage, from the API when
+	// available, otherwise estimated from local stats
+	WeeklyPercent int `json:"weekly_percent"`
+
+	// FiveHourPercent is the 5-hour window usage; zero without API data
+	FiveHourPercent int `json:"five_hour_percent"`
+
+	// Utilizations (0.0-1.0) as reported by the API; zero without API data
+	FiveHourUtilization float64 `json:"five_hour_utilization"`
+	WeeklyUtilization   float64 `json:"weekly_utilization"`
+
+	// Reset times in RFC 3339, empty when unknown
+	FiveHourReset string `json:"five_hour_reset,omitempty"`
+	WeeklyReset   string `json:"weekly_reset,omitempty"`
+
+	Throttled bool  `json:"throttled"`
+	Tokens    int64 `json:"tokens"`
+
+	// Estimated is set when there is no API data; Stale when the API data
+	// is the last known response
+	Estimated bool `json:"estimated"`
+	Stale     bool `json:"stale"`
+
+	// Paused is set while automatic refreshes are paused
+	Paused bool `json:"paused"`
+
+	// FetchedAt is when the API data was fetched in RFC 3339, empty
+	// without API data
+	FetchedAt string `json:"fetched_at,omitempty"`
+
+	// UpdatedAt is when the stats were last refreshed
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// New returns the status for weeklyStats, refreshed at updatedAt. Nil
+// stats (no refresh has completed yet) give an empty estimated status.
+func New(weeklyStats *stats.WeeklyStats, paused bool, updatedAt time.Time) Status {
+	s := Status{Estimated: true, Paused: paused, UpdatedAt: updatedAt}
+	if weeklyStats == nil {
+		return s
+	}
+	s.WeeklyPercent = weeklyStats.GetPercentage()
+	s.FiveHourPercent = weeklyStats.GetFiveHourPercentage()
+	s.FiveHourReset = formatTime(weeklyStats.FiveHourReset)
+	s.WeeklyReset = formatTime(weeklyStats.WeeklyReset)
+	s.Throttled = weeklyStats.IsThrottled()
+	s.Tokens = weeklyStats.TotalTokens
+	s.Estimated = !weeklyStats.HasAPIData
+	s.Stale = weeklyStats.APIDataStale
+	if weeklyStats.HasAPIData {
+		s.FiveHourUtilization = weeklyStats.FiveHourUtilization
+		s.WeeklyUtilization = weeklyStats.WeeklyUtilization
+		s.FetchedAt = formatTime(weeklyStats.APIFetchedAt)
+	}
+	return s
+}
+
+// Age returns how old the usage in s is at now: the time since the API data
+// was fetched, or since the last refresh for estimated usage.
+func (s Status) Age(now time.Time) time.Duration {
+	if fetched, err := time.Parse(time.RFC3339, s.FetchedAt); err == nil {
+		return now.Sub(fetched)
+	}
+	return now.Sub(s.UpdatedAt)
+}
+
+// formatTime formats t as RFC 3339, or returns "" for the zero time.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Save writes s to path for readers such as the prompt command or
+// external tools. The file is replaced atomically.
+func Save(path string, s Status) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Load reads a status previously written by Save.
+func Load(path string) (Status, error) {
+	var s Status
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(b, &s)
+	return s, err
+}
@@ -0,0 +1,65 @@
+package status
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"claude-usage/internal/stats"
+)
+
+func TestNew(t *testing.T) {
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	if s := New(nil, true, now); !s.Estimated || !s.Paused || s.WeeklyPercent != 0 {
+		t.Errorf("New(nil) = %+v, want an empty estimated status", s)
+	}
+
+	s := New(&stats.WeeklyStats{
+		HasAPIData:          true,
+		WeeklyUtilization:   0.42,
+		FiveHourUtilization: 0.17,
+		WeeklyReset:         now.Add(48 * time.Hour),
+		RateLimitStatus:     "throttled",
+		APIFetchedAt:        now,
+	}, false, now)
+	if s.WeeklyPercent != 42 || s.FiveHourPercent != 17 || !s.Throttled || s.Estimated {
+		t.Errorf("New = %+v", s)
+	}
+	if s.WeeklyUtilization != 0.42 || s.FiveHourUtilization != 0.17 || s.FetchedAt != "2026-03-04T10:00:00Z" {
+		t.Errorf("utilizations = %v, %v, fetched at %q", s.WeeklyUtilization, s.FiveHourUtilization, s.FetchedAt)
+	}
+	if s.WeeklyReset != "2026-03-06T10:00:00Z" || s.FiveHourReset != "" {
+		t.Errorf("resets = %q, %q", s.WeeklyReset, s.FiveHourReset)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.json")
+	want := Status{WeeklyPercent: 42, Paused: true, UpdatedAt: time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)}
+
+	if err := Save(path, want); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got != want {
+		t.Errorf("Load = %+v, want %+v", got, want)
+	}
+}
+
+func TestAge(t *testing.T) {
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	s := Status{FetchedAt: "2026-03-04T09:45:00Z", UpdatedAt: now.Add(-time.Minute)}
+	if got := s.Age(now); got != 15*time.Minute {
+		t.Errorf("Age with API data = %v, want 15m", got)
+	}
+
+	s = Status{Estimated: true, UpdatedAt: now.Add(-time.Minute)}
+	if got := s.Age(now); got != time.Minute {
+		t.Errorf("Age without API data = %v, want 1m", got)
+	}
+}
@@ -0,0 +1,4 @@
+// Package taskbar shows usage on a Windows taskbar button, for users who
+// hide the notification area: the button carries the tray icon as an
+// overlay badge and the percentage in its label.
+package taskbar
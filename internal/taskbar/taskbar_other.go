@@ -0,0 +1,19 @@
+//go:build !windows
+
+package taskbar
+
+import "errors"
+
+// Badge is only available on Windows. A nil Badge does nothing.
+type Badge struct{}
+
+// New fails outside Windows.
+func New(onClose func()) (*Badge, error) {
+	return nil, errors.New("the taskbar badge is only available on Windows")
+}
+
+// Set does nothing outside Windows.
+func (b *Badge) Set(icon []byte, label string) {}
+
+// Close does nothing outside Windows.
+func (b *Badge) Close() {}
@@ -0,0 +1,342 @@
+package taskbar
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32   = windows.NewLazySystemDLL("user32.dll")
+	ole32    = windows.NewLazySystemDLL("ole32.dll")
+	kernel32 = windows.NewLazySystemDLL("kernel32.dll")
+
+	procRegisterClassExW         = user32.NewProc("RegisterClassExW")
+	procCreateWindowExW          = user32.NewProc("CreateWindowExW")
+	procDefWindowProcW           = user32.NewProc("DefWindowProcW")
+	procDestroyWindow            = user32.NewProc("DestroyWindow")
+	procShowWindow               = user32.NewProc("ShowWindow")
+	procSetWindowTextW           = user32.NewProc("SetWindowTextW")
+	procGetMessageW              = user32.NewProc("GetMessageW")
+	procTranslateMessage         = user32.NewProc("TranslateMessage")
+	procDispatchMessageW         = user32.NewProc("DispatchMessageW")
+	procPostMessageW             = user32.NewProc("PostMessageW")
+	procPostQuitMessage          = user32.NewProc("PostQuitMessage")
+	procRegisterWindowMessageW   = user32.NewProc("RegisterWindowMessageW")
+	procCreateIconFromResourceEx = user32.NewProc("CreateIconFromResourceEx")
+	procDestroyIcon              = user32.NewProc("DestroyIcon")
+	procCoCreateInstance         = ole32.NewProc("CoCreateInstance")
+	procGetModuleHandleW         = kernel32.NewProc("GetModuleHandleW")
+)
+
+// Window messages and styles.
+const (
+	wmDestroy    = 0x0002
+	wmClose      = 0x0010
+	wmSysCommand = 0x0112
+	wmApp        = 0x8000
+
+	// wmUpdate applies the pending icon and label; wmQuit destroys the window
+	wmUpdate = wmApp + 1
+	wmQuit   = wmApp + 2
+
+	scMaximize = 0xF030
+	scRestore  = 0xF120
+
+	wsOverlappedWindow = 0x00CF0000
+	swShowMinNoActive  = 7
+	clsctxInprocServer = 0x1
+	lrDefaultColor     = 0
+)
+
+var (
+	clsidTaskbarList = windows.GUID{Data1: 0x56FDF344, Data2: 0xFD6D, Data3: 0x11D0, Data4: [8]byte{0x95, 0x8A, 0x00, 0x60, 0x97, 0xC9, 0xA0, 0x90}}
+	iidITaskbarList3 = windows.GUID{Data1: 0xEA1AFB91, Data2: 0x9E28, Data3: 0x4B86, Data4: [8]byte{0x90, 0xE9, 0x9E, 0x9F, 0x8A, 0x5E, 0xEE, 0x4B}}
+)
+
+// comObject is the memory layout of a COM interface pointer.
+type comObject struct {
+	vtbl *[32]uintptr
+}
+
+// ITaskbarList3 vtable slots.
+const (
+	vtblRelease        = 2
+	vtblHrInit         = 3
+	vtblSetOverlayIcon = 18
+)
+
+type wndClassEx struct {
+	size       uint32
+	style      uint32
+	wndProc    uintptr
+	clsExtra   int32
+	wndExtra   int32
+	instance   windows.Handle
+	icon       windows.Handle
+	cursor     windows.Handle
+	background windows.Handle
+	menuName   *uint16
+	className  *uint16
+	iconSm     windows.Handle
+}
+
+type msg struct {
+	hwnd    windows.HWND
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      struct{ x, y int32 }
+}
+
+// Badge is a minimized window whose taskbar button shows usage. A nil
+// Badge does nothing.
+type Badge struct {
+	hwnd    windows.HWND
+	onClose func()
+
+	// taskbar is the ITaskbarList3 interface; only used on the window's thread
+	taskbar *comObject
+
+	// buttonCreated is the message sent when the taskbar button exists;
+	// overlays set before then are lost
+	buttonCreated uint32
+	ready         bool
+	overlay       windows.Handle
+
+	mu      sync.Mutex
+	icon    []byte
+	label   string
+	pending bool
+}
+
+// current is the badge served by wndProc; there is at most one.
+var (
+	current     *Badge
+	registerCls sync.Once
+	className   = windows.StringToUTF16Ptr("ClaudeUsageTaskbarBadge")
+	wndProcPtr  = windows.NewCallback(wndProc)
+)
+
+// New creates the taskbar button. onClose is called when the user closes
+// it from the taskbar.
+func New(onClose func()) (*Badge, error) {
+	if current != nil {
+		return nil, errors.New("a taskbar badge already exists")
+	}
+	b := &Badge{onClose: onClose}
+	errCh := make(chan error, 1)
+	go b.run(errCh)
+	if err := <-errCh; err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// run creates the window and COM object on a dedicated thread and pumps
+// its messages until the window is destroyed.
+func (b *Badge) run(errCh chan<- error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := windows.CoInitializeEx(0, windows.COINIT_APARTMENTTHREADED); err != nil {
+		errCh <- fmt.Errorf("CoInitializeEx: %w", err)
+		return
+	}
+	defer windows.CoUninitialize()
+
+	hr, _, _ := procCoCreateInstance.Call(
+		uintptr(unsafe.Pointer(&clsidTaskbarList)), 0, clsctxInprocServer,
+		uintptr(unsafe.Pointer(&iidITaskbarList3)), uintptr(unsafe.Pointer(&b.taskbar)))
+	if int32(hr) < 0 {
+		errCh <- fmt.Errorf("ITaskbarList3 not available: HRESULT %#x", uint32(hr))
+		return
+	}
+	defer comCall(b.taskbar, vtblRelease)
+	if hr := comCall(b.taskbar, vtblHrInit); int32(hr) < 0 {
+		errCh <- fmt.Errorf("ITaskbarList3.HrInit: HRESULT %#x", uint32(hr))
+		return
+	}
+
+	b.buttonCreated = registerWindowMessage("TaskbarButtonCreated")
+	hwnd, err := createWindow()
+	if err != nil {
+		errCh <- err
+		return
+	}
+	b.hwnd = hwnd
+	current = b
+	procShowWindow.Call(uintptr(hwnd), swShowMinNoActive)
+	errCh <- nil
+
+	var m msg
+	for {
+		r, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+		if int32(r) <= 0 {
+			break
+		}
+		procTranslateMessage.Call(uintptr(unsafe.Pointer(&m)))
+		procDispatchMessageW.Call(uintptr(unsafe.Pointer(&m)))
+	}
+
+	if b.overlay != 0 {
+		procDestroyIcon.Call(uintptr(b.overlay))
+	}
+	current = nil
+}
+
+// createWindow registers the window class once and creates the window.
+func createWindow() (windows.HWND, error) {
+	instance, _, _ := procGetModuleHandleW.Call(0)
+	var regErr error
+	registerCls.Do(func() {
+		wc := wndClassEx{
+			wndProc:   wndProcPtr,
+			instance:  windows.Handle(instance),
+			className: className,
+		}
+		wc.size = uint32(unsafe.Sizeof(wc))
+		if r, _, err := procRegisterClassExW.Call(uintptr(unsafe.Pointer(&wc))); r == 0 {
+			regErr = fmt.Errorf("RegisterClassEx: %w", err)
+		}
+	})
+	if regErr != nil {
+		return 0, regErr
+	}
+
+	title := windows.StringToUTF16Ptr("Claude Usage")
+	hwnd, _, err := procCreateWindowExW.Call(0,
+		uintptr(unsafe.Pointer(className)), uintptr(unsafe.Pointer(title)),
+		wsOverlappedWindow, 0, 0, 0, 0, 0, 0, instance, 0)
+	if hwnd == 0 {
+		return 0, fmt.Errorf("CreateWindowEx: %w", err)
+	}
+	return windows.HWND(hwnd), nil
+}
+
+// wndProc keeps the window minimized, quits on close and applies updates
+// once the taskbar button exists.
+func wndProc(hwnd windows.HWND, message uint32, wParam, lParam uintptr) uintptr {
+	b := current
+	if b == nil || hwnd != b.hwnd {
+		r, _, _ := procDefWindowProcW.Call(uintptr(hwnd), uintptr(message), wParam, lParam)
+		return r
+	}
+
+	switch {
+	case message == b.buttonCreated:
+		b.ready = true
+		b.apply(true)
+		return 0
+	case message == wmUpdate:
+		b.apply(false)
+		return 0
+	case message == wmSysCommand && (wParam&0xFFF0 == scRestore || wParam&0xFFF0 == scMaximize):
+		// There is nothing to show; the button is the UI
+		return 0
+	case message == wmClose:
+		if b.onClose != nil {
+			go b.onClose()
+		}
+		return 0
+	case message == wmQuit:
+		procDestroyWindow.Call(uintptr(hwnd))
+		return 0
+	case message == wmDestroy:
+		procPostQuitMessage.Call(0)
+		return 0
+	}
+	r, _, _ := procDefWindowProcW.Call(uintptr(hwnd), uintptr(message), wParam, lParam)
+	return r
+}
+
+// Set shows icon (ICO data with one image) as the button's overlay and
+// label as its text. Safe to call from any goroutine.
+func (b *Badge) Set(icon []byte, label string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.icon, b.label, b.pending = icon, label, true
+	b.mu.Unlock()
+	procPostMessageW.Call(uintptr(b.hwnd), wmUpdate, 0, 0)
+}
+
+// apply sets the pending icon and label; with force, the last ones are
+// set again (the taskbar button was recreated, e.g. after Explorer restarted).
+func (b *Badge) apply(force bool) {
+	b.mu.Lock()
+	icon, label, pending := b.icon, b.label, b.pending
+	b.pending = false
+	b.mu.Unlock()
+	if !pending && !force {
+		return
+	}
+
+	text, err := windows.UTF16PtrFromString(label)
+	if err != nil {
+		return
+	}
+	procSetWindowTextW.Call(uintptr(b.hwnd), uintptr(unsafe.Pointer(text)))
+	if !b.ready || icon == nil {
+		return
+	}
+
+	overlay := iconFromICO(icon)
+	if overlay == 0 {
+		return
+	}
+	comCall(b.taskbar, vtblSetOverlayIcon, uintptr(b.hwnd), uintptr(overlay), uintptr(unsafe.Pointer(text)))
+
+	// The taskbar keeps its own copy
+	if b.overlay != 0 {
+		procDestroyIcon.Call(uintptr(b.overlay))
+	}
+	b.overlay = overlay
+}
+
+// Close removes the taskbar button.
+func (b *Badge) Close() {
+	if b == nil {
+		return
+	}
+	procPostMessageW.Call(uintptr(b.hwnd), wmQuit, 0, 0)
+}
+
+// iconFromICO creates an icon from the first image in ICO data, or
+// returns 0 if the data is invalid.
+func iconFromICO(ico []byte) windows.Handle {
+	// ICONDIR (6 bytes) followed by the first ICONDIRENTRY (16 bytes)
+	if len(ico) < 22 {
+		return 0
+	}
+	width, height := int(ico[6]), int(ico[7])
+	size := binary.LittleEndian.Uint32(ico[14:18])
+	offset := binary.LittleEndian.Uint32(ico[18:22])
+	if uint64(offset)+uint64(size) > uint64(len(ico)) || size == 0 {
+		return 0
+	}
+	h, _, _ := procCreateIconFromResourceEx.Call(
+		uintptr(unsafe.Pointer(&ico[offset])), uintptr(size), 1, 0x00030000,
+		uintptr(width), uintptr(height), lrDefaultColor)
+	return windows.Handle(h)
+}
+
+// registerWindowMessage returns the system-wide message ID for name.
+func registerWindowMessage(name string) uint32 {
+	r, _, _ := procRegisterWindowMessageW.Call(uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(name))))
+	return uint32(r)
+}
+
+// comCall calls method slot of the COM object obj.
+func comCall(obj *comObject, slot int, args ...uintptr) uintptr {
+	r, _, _ := syscall.SyscallN(obj.vtbl[slot], append([]uintptr{uintptr(unsafe.Pointer(obj))}, args...)...)
+	return r
+}
@@ -0,0 +1,140 @@
+// Package telemetry sends opt-in anonymous reports that help maintainers
+// prioritize platform bugs: once a day, how many API fetches succeeded and
+// failed, with the app version, OS and architecture. Reports carry no
+// usage figures, tokens, account details, paths or identifiers, and are
+// only sent after the user agreed (see config.Config.TelemetryEnabled).
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/history"
+	"claude-usage/internal/httpclient"
+)
+
+// Endpoint is where reports are sent. It is set at build time via
+// -ldflags; when empty and telemetry_url is not set, nothing is sent.
+var Endpoint = ""
+
+// Interval is how often a report is sent. It matches how long API fetches
+// are kept, so each report covers the fetches since the last one.
+const Interval = history.FetchRetention
+
+// Report is everything a report contains.
+type Report struct {
+	Version string `json:"version"`
+	OS      string `json:"os"`
+	Arch    string `json:"arch"`
+
+	// Fetches counts the API fetches of the last Interval; Failures those
+	// that failed, NetworkErrors those that never reached the API, and
+	// HTTPErrors the rest by status code
+	Fetches       int            `json:"fetches"`
+	Failures      int            `json:"failures"`
+	NetworkErrors int            `json:"network_errors"`
+	HTTPErrors    map[string]int `json:"http_errors,omitempty"`
+}
+
+// NewReport sums up fetches, oldest first, as of now.
+func NewReport(version string, fetches []history.Fetch, now time.Time) Report {
+	r := Report{Version: version, OS: runtime.GOOS, Arch: runtime.GOARCH}
+	cutoff := now.Add(-Interval)
+	for _, fe := range fetches {
+		if !fe.At.After(cutoff) {
+			continue
+		}
+		r.Fetches++
+		switch {
+		case fe.OK:
+		case fe.StatusCode == 0:
+			r.Failures++
+			r.NetworkErrors++
+		default:
+			r.Failures++
+			if r.HTTPErrors == nil {
+				r.HTTPErrors = make(map[string]int)
+			}
+			r.HTTPErrors[strconv.Itoa(fe.StatusCode)]++
+		}
+	}
+	return r
+}
+
+// Send posts r as JSON to url.
+func Send(ctx context.Context, url string, r Report) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpclient.New(30 * time.Second).Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// state is what is kept between reports.
+type state struct {
+	SentAt time.Time `json:"sent_at"`
+}
+
+// Update sends a report for cfg's profile unless one was sent less than
+// Interval ago or there is nowhere to send it. It returns when to try
+// next: after Interval, or an hour after a failure.
+func Update(ctx context.Context, cfg *config.Config, version string) (time.Duration, error) {
+	path := config.GetTelemetryPath(cfg.Profile)
+	var last state
+	if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &last) == nil {
+		if age := time.Since(last.SentAt); age >= 0 && age < Interval {
+			return Interval - age, nil
+		}
+	}
+
+	url := cfg.TelemetryURL
+	if url == "" {
+		url = Endpoint
+	}
+	if url == "" {
+		return Interval, nil
+	}
+
+	h, err := history.Load(config.GetHistoryPath(cfg.Profile))
+	if err != nil {
+		return time.Hour, err
+	}
+	if err := Send(ctx, url, NewReport(version, h.Fetches, time.Now())); err != nil {
+		return time.Hour, err
+	}
+	return Interval, save(path, state{SentAt: time.Now()})
+}
+
+// save writes s to path.
+func save(path string, s state) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0600)
+}
@@ -0,0 +1,84 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"claude-usage/internal/history"
+)
+
+func TestNewReport(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	fetches := []history.Fetch{
+		{At: now.Add(-Interval - time.Minute), OK: false, StatusCode: 500}, // too old
+		{At: now.Add(-3 * time.Hour), OK: true},
+		{At: now.Add(-2 * time.Hour), StatusCode: 429},
+		{At: now.Add(-time.Hour), StatusCode: 429},
+		{At: now.Add(-time.Minute)},
+		{At: now, OK: true},
+	}
+
+	r := NewReport("1.2.3", fetches, now)
+	if r.Version != "1.2.3" || r.OS != runtime.GOOS || r.Arch != runtime.GOARCH {
+		t.Errorf("unexpected version or platform: %+v", r)
+	}
+	if r.Fetches != 5 || r.Failures != 3 || r.NetworkErrors != 1 {
+		t.Errorf("got %d fetches, %d failures, %d network errors; expected 5, 3, 1", r.Fetches, r.Failures, r.NetworkErrors)
+	}
+	if len(r.HTTPErrors) != 1 || r.HTTPErrors["429"] != 2 {
+		t.Errorf("HTTPErrors: got %v, expected 429 twice", r.HTTPErrors)
+	}
+}
+
+func TestNewReport_NoFailures(t *testing.T) {
+	now := time.Now()
+	r := NewReport("dev", []history.Fetch{{At: now, OK: true}}, now)
+	body, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	json.Unmarshal(body, &fields)
+	if _, ok := fields["http_errors"]; ok {
+		t.Errorf("http_errors should be left out when empty: %s", body)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var got Report
+	var contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		contentType = req.Header.Get("Content-Type")
+		body, _ := io.ReadAll(req.Body)
+		json.Unmarshal(body, &got)
+	}))
+	defer srv.Close()
+
+	r := Report{Version: "1.2.3", OS: "linux", Arch: "amd64", Fetches: 10, Failures: 1, HTTPErrors: map[string]int{"503": 1}}
+	if err := Send(context.Background(), srv.URL, r); err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Content-Type: got %q", contentType)
+	}
+	if got.Version != r.Version || got.Fetches != 10 || got.HTTPErrors["503"] != 1 {
+		t.Errorf("server received %+v, expected %+v", got, r)
+	}
+}
+
+func TestSend_HTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	if err := Send(context.Background(), srv.URL, Report{}); err == nil {
+		t.Error("expected an error for HTTP 503")
+	}
+}
@@ -0,0 +1,266 @@
+// Package testserver is a fake Anthropic API for end-to-end tests. It
+// serves canned responses from the usage, token refresh and Messages API
+// endpoints, at the same paths as the real ones, and can expire the access
+// token or throttle requests to exercise token rotation and rate limiting.
+//
+// The app can be pointed at it, or at any other mock, with --api-base.
+package testserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The endpoints the server answers, as in the production API.
+const (
+	UsagePath    = "/api/oauth/usage"
+	TokenPath    = "/v1/oauth/token"
+	MessagesPath = "/v1/messages"
+)
+
+// The tokens the server accepts until it rotates them.
+const (
+	AccessToken  = "test-access-token"
+	RefreshToken = "test-refresh-token"
+)
+
+// Usage is the usage the server reports, in percent.
+type Usage struct {
+	FiveHour      float64
+	FiveHourReset time.Time
+	Weekly        float64
+	WeeklyReset   time.Time
+}
+
+// Server is a fake API server. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// mu guards the fields below
+	mu           sync.Mutex
+	usage        Usage
+	headerUsage  *Usage
+	accessToken  string
+	refreshToken string
+	rotations    int
+	throttled    int
+	retryAfter   time.Duration
+
+	usageRequests    int
+	tokenRequests    int
+	messagesRequests int
+}
+
+// New starts a server reporting 42% of the 5-hour window and 10% of the
+// week used. Close it when done.
+func New() *Server {
+	now := time.Now().UTC().Truncate(time.Second)
+	s := &Server{
+		usage: Usage{
+			FiveHour:      42,
+			FiveHourReset: now.Add(3 * time.Hour),
+			Weekly:        10,
+			WeeklyReset:   now.Add(4 * 24 * time.Hour),
+		},
+		accessToken:  AccessToken,
+		refreshToken: RefreshToken,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+UsagePath, s.handleUsage)
+	mux.HandleFunc("POST "+TokenPath, s.handleToken)
+	mux.HandleFunc("POST "+MessagesPath, s.handleMessages)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// SetUsage changes the usage reported from now on.
+func (s *Server) SetUsage(u Usage) {
+	s.mu.Lock()
+	s.usage = u
+	s.mu.Unlock()
+}
+
+// SetHeaderUsage makes the rate limit headers of Messages API responses
+// report u instead of the usage endpoint's usage, so they disagree.
+func (s *Server) SetHeaderUsage(u Usage) {
+	s.mu.Lock()
+	s.headerUsage = &u
+	s.mu.Unlock()
+}
+
+// ExpireToken rejects the current access token with 401 until a client
+// exchanges the refresh token for a new one. That exchange rotates the
+// refresh token too, as the real server may.
+func (s *Server) ExpireToken() {
+	s.mu.Lock()
+	s.accessToken = ""
+	s.mu.Unlock()
+}
+
+// Throttle answers the next n usage requests with 429 and a Retry-After
+// header of retryAfter, rounded up to whole seconds.
+func (s *Server) Throttle(n int, retryAfter time.Duration) {
+	s.mu.Lock()
+	s.throttled = n
+	s.retryAfter = retryAfter
+	s.mu.Unlock()
+}
+
+// Tokens returns the access and refresh tokens the server accepts.
+func (s *Server) Tokens() (accessToken, refreshToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.accessToken, s.refreshToken
+}
+
+// UsageRequests returns how many usage requests were received.
+func (s *Server) UsageRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usageRequests
+}
+
+// TokenRequests returns how many token refresh requests were received.
+func (s *Server) TokenRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokenRequests
+}
+
+// MessagesRequests returns how many Messages API requests were received.
+func (s *Server) MessagesRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.messagesRequests
+}
+
+// handleUsage serves the usage endpoint.
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usageRequests++
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" || token != s.accessToken {
+		writeError(w, http.StatusUnauthorized, "authentication_error", "OAuth token has expired")
+		return
+	}
+	if s.throttled > 0 {
+		s.throttled--
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(s.retryAfter.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, "rate_limit_error", "Rate limited")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"five_hour": bucket(s.usage.FiveHour, s.usage.FiveHourReset),
+		"seven_day": bucket(s.usage.Weekly, s.usage.WeeklyReset),
+	})
+}
+
+// handleToken serves the token refresh endpoint.
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		GrantType    string `json:"grant_type"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "malformed body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenRequests++
+
+	if req.GrantType != "refresh_token" || req.RefreshToken != s.refreshToken {
+		writeError(w, http.StatusBadRequest, "invalid_grant", "Invalid refresh token")
+		return
+	}
+	s.rotations++
+	s.accessToken = fmt.Sprintf("test-access-token-%d", s.rotations)
+	s.refreshToken = fmt.Sprintf("test-refresh-token-%d", s.rotations)
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token":  s.accessToken,
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+		"refresh_token": s.refreshToken,
+	})
+}
+
+// handleMessages serves the Messages API with a one-token reply and the
+// rate limit headers of regular API responses.
+func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messagesRequests++
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" || token != s.accessToken {
+		writeError(w, http.StatusUnauthorized, "authentication_error", "OAuth token has expired")
+		return
+	}
+
+	u := s.usage
+	if s.headerUsage != nil {
+		u = *s.headerUsage
+	}
+	status := "allowed"
+	if u.FiveHour >= 100 || u.Weekly >= 100 {
+		status = "rejected"
+	}
+	h := w.Header()
+	h.Set("Anthropic-Ratelimit-Unified-Status", status)
+	h.Set("Anthropic-Ratelimit-Unified-5h-Utilization", strconv.FormatFloat(u.FiveHour/100, 'f', 2, 64))
+	h.Set("Anthropic-Ratelimit-Unified-7d-Utilization", strconv.FormatFloat(u.Weekly/100, 'f', 2, 64))
+	if !u.FiveHourReset.IsZero() {
+		h.Set("Anthropic-Ratelimit-Unified-5h-Reset", strconv.FormatInt(u.FiveHourReset.Unix(), 10))
+	}
+	if !u.WeeklyReset.IsZero() {
+		h.Set("Anthropic-Ratelimit-Unified-7d-Reset", strconv.FormatInt(u.WeeklyReset.Unix(), 10))
+	}
+	if status == "rejected" {
+		writeError(w, http.StatusTooManyRequests, "rate_limit_error", "Rate limited")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"type":        "message",
+		"role":        "assistant",
+		"content":     []map[string]string{{"type": "text", "text": "OK"}},
+		"stop_reason": "max_tokens",
+		"usage":       map[string]int{"input_tokens": 8, "output_tokens": 1},
+	})
+}
+
+// bucket returns a usage window as the usage endpoint reports it.
+func bucket(percent float64, reset time.Time) map[string]any {
+	b := map[string]any{"utilization": percent}
+	if !reset.IsZero() {
+		b["resets_at"] = reset.Format(time.RFC3339)
+	}
+	return b
+}
+
+// writeError writes an error in the API's format.
+func writeError(w http.ResponseWriter, status int, kind, message string) {
+	writeJSON(w, status, map[string]any{
+		"type":  "error",
+		"error": map[string]string{"type": kind, "message": message},
+	})
+}
+
+// writeJSON writes v as the response body.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
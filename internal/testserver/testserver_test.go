@@ -0,0 +1,65 @@
+package testserver
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func get(t *testing.T, srv *Server, token string) *http.Response {
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+UsagePath, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestThrottle(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	srv.Throttle(1, 1500*time.Millisecond)
+	resp := get(t, srv, AccessToken)
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "2" {
+		t.Errorf("got %d, Retry-After %q; want 429, 2", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+	if resp := get(t, srv, AccessToken); resp.StatusCode != http.StatusOK {
+		t.Errorf("got %d after throttling, want 200", resp.StatusCode)
+	}
+}
+
+func TestTokenRotation(t *testing.T) {
+	srv := New()
+	defer srv.Close()
+
+	srv.ExpireToken()
+	if resp := get(t, srv, AccessToken); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expired token: got %d, want 401", resp.StatusCode)
+	}
+
+	refresh := func(token string) int {
+		body := `{"grant_type":"refresh_token","refresh_token":"` + token + `"}`
+		resp, err := http.Post(srv.URL+TokenPath, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := refresh(RefreshToken); code != http.StatusOK {
+		t.Fatalf("refresh: got %d, want 200", code)
+	}
+	access, _ := srv.Tokens()
+	if resp := get(t, srv, access); resp.StatusCode != http.StatusOK {
+		t.Errorf("new token: got %d, want 200", resp.StatusCode)
+	}
+
+	// The old refresh token was rotated away
+	if code := refresh(RefreshToken); code != http.StatusBadRequest {
+		t.Errorf("old refresh token: got %d, want 400", code)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package tokenstore
+
+import "errors"
+
+// errNoDPAPI is returned outside Windows, where the keyring holds the key.
+var errNoDPAPI = errors.New("DPAPI is only available on Windows")
+
+func protect([]byte) ([]byte, error) { return nil, errNoDPAPI }
+
+func unprotect([]byte) ([]byte, error) { return nil, errNoDPAPI }
@@ -0,0 +1,150 @@
+package tokenstore
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// The key's entry in the OS keyring.
+const (
+	keyringService = "claude-usage"
+	keyringAccount = "token-store-key"
+)
+
+// keyringKey is a random key kept in the OS keyring: the Secret Service on
+// Linux (through secret-tool), the login keychain on macOS (through
+// security) and, on Windows, a file protected with DPAPI for the current
+// user. It shells out to the platform's tools instead of linking native
+// APIs.
+type keyringKey struct {
+	// dpapiPath is the protected key file used on Windows
+	dpapiPath string
+}
+
+// KeyringKey returns a key kept in the OS keyring. On Windows it is kept
+// DPAPI-protected in the file at dpapiPath.
+func KeyringKey(dpapiPath string) KeySource {
+	return keyringKey{dpapiPath}
+}
+
+// Key implements KeySource.
+func (k keyringKey) Key(create bool) ([]byte, error) {
+	if runtime.GOOS == "windows" {
+		return k.protectedFileKey(create)
+	}
+
+	secret, found, err := keyringLookup()
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		key, err := hex.DecodeString(secret)
+		if err != nil || len(key) != keySize {
+			return nil, errors.New("token store key in the keyring is invalid")
+		}
+		return key, nil
+	}
+	if !create {
+		return nil, errors.New("token store key not found in the keyring")
+	}
+
+	key, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+	return key, keyringStore(hex.EncodeToString(key))
+}
+
+// protectedFileKey reads the DPAPI-protected key file, creating it when
+// create is set.
+func (k keyringKey) protectedFileKey(create bool) ([]byte, error) {
+	blob, err := os.ReadFile(k.dpapiPath)
+	if errors.Is(err, os.ErrNotExist) && create {
+		key, err := newKey()
+		if err != nil {
+			return nil, err
+		}
+		blob, err := protect(key)
+		if err != nil {
+			return nil, err
+		}
+		return key, writeSecret(k.dpapiPath, blob)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read token store key: %w", err)
+	}
+	key, err := unprotect(blob)
+	if err != nil || len(key) != keySize {
+		return nil, errors.New("token store key in " + k.dpapiPath + " cannot be unprotected by this user")
+	}
+	return key, nil
+}
+
+// String implements KeySource.
+func (k keyringKey) String() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "DPAPI-protected key file " + k.dpapiPath
+	case "darwin":
+		return "login keychain"
+	default:
+		return "Secret Service keyring"
+	}
+}
+
+// keyringLookup reads the key's entry from the keyring. A missing entry is
+// not an error.
+func keyringLookup() (secret string, found bool, err error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", keyringAccount, "-w")
+	default: // Linux and other Unix-like systems
+		path, err := exec.LookPath("secret-tool")
+		if err != nil {
+			return "", false, errors.New("secret-tool not found (install libsecret-tools) for token_store_key \"keyring\"")
+		}
+		cmd = exec.Command(path, "lookup", "service", keyringService, "account", keyringAccount)
+	}
+
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// Both tools fail with a non-zero status when there is no entry
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("%s failed: %w", cmd.Path, err)
+	}
+	secret = strings.TrimSpace(string(out))
+	return secret, secret != "", nil
+}
+
+// keyringStore saves secret as the key's entry in the keyring.
+func keyringStore(secret string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// security only takes the password as an argument; it is visible
+		// to the user's own processes for as long as the command runs
+		cmd = exec.Command("security", "add-generic-password", "-U", "-s", keyringService, "-a", keyringAccount,
+			"-l", "Claude Usage token store key", "-w", secret)
+	default:
+		cmd = exec.Command("secret-tool", "store", "--label=Claude Usage token store key",
+			"service", keyringService, "account", keyringAccount)
+		cmd.Stdin = strings.NewReader(secret)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", cmd.Path, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package tokenstore
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"claude-usage/internal/config"
+)
+
+// keySize is the AES-256 key length in bytes.
+const keySize = 32
+
+// pbkdf2Iterations is the PBKDF2-SHA256 work factor for passphrase keys,
+// as recommended by OWASP.
+const pbkdf2Iterations = 600_000
+
+// KeySource provides the key sealing a token store.
+type KeySource interface {
+	// Key returns the key. When create is set, a missing key is created.
+	Key(create bool) ([]byte, error)
+
+	// String describes where the key is kept, for diagnostics.
+	String() string
+}
+
+// KeyFor returns the key source selected by cfg's token_store_key.
+func KeyFor(cfg *config.Config) KeySource {
+	switch cfg.TokenStoreKey {
+	case config.TokenKeyKeyring:
+		return KeyringKey(config.GetTokenProtectedKeyPath())
+	case config.TokenKeyPassphrase:
+		passphrase, _ := os.LookupEnv(config.EnvTokenPassphrase)
+		return PassphraseKey(passphrase, config.GetTokenSaltPath())
+	default:
+		return FileKey(config.GetTokenKeyPath())
+	}
+}
+
+// fileKey is a random key kept hex-encoded in a file only the current user
+// can read.
+type fileKey struct {
+	path string
+}
+
+// FileKey returns a key kept in the file at path.
+func FileKey(path string) KeySource {
+	return fileKey{path}
+}
+
+// Key implements KeySource.
+func (k fileKey) Key(create bool) ([]byte, error) {
+	b, err := os.ReadFile(k.path)
+	if errors.Is(err, os.ErrNotExist) && create {
+		key, err := newKey()
+		if err != nil {
+			return nil, err
+		}
+		return key, writeSecret(k.path, []byte(hex.EncodeToString(key)+"\n"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read token store key: %w", err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil || len(key) != keySize {
+		return nil, errors.New("token store key in " + k.path + " is invalid")
+	}
+	return key, nil
+}
+
+// String implements KeySource.
+func (k fileKey) String() string {
+	return "key file " + k.path
+}
+
+// passphraseKey derives the key from a passphrase and a random salt kept
+// in a file, so nothing on disk unlocks the store without the passphrase.
+type passphraseKey struct {
+	passphrase string
+	saltPath   string
+}
+
+// PassphraseKey returns a key derived from passphrase with the salt in the
+// file at saltPath.
+func PassphraseKey(passphrase, saltPath string) KeySource {
+	return passphraseKey{passphrase, saltPath}
+}
+
+// Key implements KeySource.
+func (k passphraseKey) Key(create bool) ([]byte, error) {
+	if k.passphrase == "" {
+		return nil, errors.New(config.EnvTokenPassphrase + " is not set")
+	}
+	salt, err := os.ReadFile(k.saltPath)
+	if errors.Is(err, os.ErrNotExist) && create {
+		salt = make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		err = writeSecret(k.saltPath, salt)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read token store salt: %w", err)
+	}
+	return pbkdf2.Key(sha256.New, k.passphrase, salt, pbkdf2Iterations, keySize)
+}
+
+// String implements KeySource.
+func (k passphraseKey) String() string {
+	return "passphrase from " + config.EnvTokenPassphrase
+}
+
+// newKey returns a random key.
+func newKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate token store key: %w", err)
+	}
+	return key, nil
+}
+
+// writeSecret writes b to path, readable only by the current user.
+func writeSecret(path string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0600)
+}
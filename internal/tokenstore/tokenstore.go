@@ -0,0 +1,205 @@
+// Package tokenstore keeps OAuth tokens rotated by the server for
+// read_only_credentials, so the app never writes to Claude's or OpenCode's
+// credentials file, and for tokens given in the environment, which have no
+// file to go back to. Tokens obtained by the app are never written in
+// plaintext.
+//
+// Tokens are sealed with AES-256-GCM. The key is a random one kept in a
+// separate file or in the OS keyring, or is derived from a passphrase (see
+// KeySource). Files are only readable by the current user; the encryption
+// keeps the tokens out of backups, sync folders and bug reports that pick
+// up the store alone.
+package tokenstore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/stats"
+)
+
+// Tokens are the tokens that replace those of the credentials file.
+type Tokens struct {
+	// Source is the credentials file's refresh token these replace. Once
+	// the file holds another one, as after a new login, they are stale.
+	Source string `json:"source"`
+
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	SavedAt      time.Time `json:"saved_at"`
+}
+
+// Apply replaces the tokens of creds, read from the credentials file, and
+// reports whether it did. Stale tokens are not applied.
+func (t *Tokens) Apply(creds *stats.Credentials) bool {
+	if t == nil || creds == nil || t.Source != creds.ClaudeAiOauth.RefreshToken {
+		return false
+	}
+	creds.ClaudeAiOauth.AccessToken = t.AccessToken
+	creds.ClaudeAiOauth.RefreshToken = t.RefreshToken
+
+	// The stored token's expiry is unknown; a 401 refreshes it
+	creds.ClaudeAiOauth.ExpiresAt = 0
+	return true
+}
+
+// Load reads the tokens stored at path with key. A missing store yields nil
+// tokens.
+func Load(path string, key KeySource) (*Tokens, error) {
+	sealed, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	k, err := key.Key(false)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(k)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("token store is truncated")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("token store cannot be decrypted with the " + key.String())
+	}
+	var t Tokens
+	if err := json.Unmarshal(plain, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Save encrypts t with key and writes it to path, creating the key on first
+// use. The store is replaced atomically.
+func Save(path string, key KeySource, t *Tokens) error {
+	k, err := key.Key(true)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(k)
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, gcm.Seal(nonce, nonce, plain, nil), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// newGCM returns an AES-GCM cipher for key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Location describes where tokens rotated by the server are kept for cfg,
+// for logs and diagnostics.
+func Location(cfg *config.Config) string {
+	if cfg.UsesTokenStore() {
+		return "the encrypted token store " + config.GetTokenStorePath(cfg.Profile) + " (" + KeyFor(cfg).String() + ")"
+	}
+	return "the credentials file " + cfg.GetCredentialsPath()
+}
+
+// ReadCredentials returns the OAuth credentials of cfg's source: the
+// credentials file, or the environment when CLAUDE_USAGE_ACCESS_TOKEN is
+// set. With the token store in use, tokens rotated since are taken from it.
+func ReadCredentials(cfg *config.Config) (*stats.Credentials, error) {
+	creds, err := parseSource(cfg)
+	if err != nil || !cfg.UsesTokenStore() {
+		return creds, err
+	}
+
+	stored, err := Load(config.GetTokenStorePath(cfg.Profile), KeyFor(cfg))
+	if err != nil {
+		log.Printf("Warning: could not read the token store, using the %s tokens: %v", sourceName(), err)
+		return creds, nil
+	}
+	stored.Apply(creds)
+	return creds, nil
+}
+
+// SaveRotated keeps tokens rotated by the server: in the token store with
+// read_only_credentials or environment credentials, otherwise in the
+// credentials file, where only the refresh token is updated.
+func SaveRotated(cfg *config.Config, accessToken, refreshToken string) error {
+	path := cfg.GetCredentialsPath()
+	if !cfg.UsesTokenStore() {
+		if cfg.IsOpenCode() {
+			return stats.UpdateOpenCodeRefreshToken(path, refreshToken)
+		}
+		return stats.UpdateRefreshToken(path, refreshToken)
+	}
+
+	// Record which of the source's tokens these replace, so a new login or
+	// new environment tokens are noticed
+	creds, err := parseSource(cfg)
+	if err != nil {
+		return err
+	}
+	return Save(config.GetTokenStorePath(cfg.Profile), KeyFor(cfg), &Tokens{
+		Source:       creds.ClaudeAiOauth.RefreshToken,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		SavedAt:      time.Now(),
+	})
+}
+
+// parseSource reads the credentials from the environment or from the
+// credentials file of cfg's source.
+func parseSource(cfg *config.Config) (*stats.Credentials, error) {
+	if config.UsesEnvCredentials() {
+		return EnvCredentials(), nil
+	}
+	if cfg.IsOpenCode() {
+		return stats.ParseOpenCodeCredentials(cfg.GetCredentialsPath())
+	}
+	return stats.ParseCredentials(cfg.GetCredentialsPath())
+}
+
+// EnvCredentials returns the tokens set in the environment. Without a
+// refresh token, the access token is used until it expires.
+func EnvCredentials() *stats.Credentials {
+	creds := &stats.Credentials{}
+	creds.ClaudeAiOauth.AccessToken = os.Getenv(config.EnvAccessToken)
+	creds.ClaudeAiOauth.RefreshToken = os.Getenv(config.EnvRefreshToken)
+	return creds
+}
+
+// sourceName names where ReadCredentials gets its tokens, for messages.
+func sourceName() string {
+	if config.UsesEnvCredentials() {
+		return "environment's"
+	}
+	return "credentials file's"
+}
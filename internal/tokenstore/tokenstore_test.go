@@ -0,0 +1,153 @@
+package tokenstore
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/stats"
+)
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	path, keyPath := filepath.Join(dir, "tokens.enc"), filepath.Join(dir, "token-key")
+	key := FileKey(keyPath)
+
+	if got, err := Load(path, key); got != nil || err != nil {
+		t.Fatalf("missing store: got %v, %v", got, err)
+	}
+
+	want := &Tokens{Source: "file-refresh", AccessToken: "access-2", RefreshToken: "refresh-2"}
+	if err := Save(path, key, want); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, []byte("refresh-2")) {
+		t.Error("store holds the refresh token in plain text")
+	}
+
+	got, err := Load(path, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// A store sealed with another key must not load
+	os.Remove(keyPath)
+	if err := Save(filepath.Join(dir, "other.enc"), key, want); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path, key); err == nil {
+		t.Error("loaded a store with the wrong key")
+	}
+}
+
+func TestPassphraseKey(t *testing.T) {
+	dir := t.TempDir()
+	path, saltPath := filepath.Join(dir, "tokens.enc"), filepath.Join(dir, "token-salt")
+
+	if _, err := PassphraseKey("", saltPath).Key(true); err == nil {
+		t.Error("derived a key without a passphrase")
+	}
+
+	want := &Tokens{Source: "env-refresh", AccessToken: "access-2", RefreshToken: "refresh-2"}
+	if err := Save(path, PassphraseKey("correct horse", saltPath), want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path, PassphraseKey("correct horse", saltPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if _, err := Load(path, PassphraseKey("wrong horse", saltPath)); err == nil {
+		t.Error("loaded a store with the wrong passphrase")
+	}
+}
+
+func TestApply(t *testing.T) {
+	stored := &Tokens{Source: "file-refresh", AccessToken: "access-2", RefreshToken: "refresh-2"}
+
+	creds := &stats.Credentials{ClaudeAiOauth: stats.OAuthCredentials{AccessToken: "access-1", RefreshToken: "file-refresh", ExpiresAt: 1}}
+	if !stored.Apply(creds) || creds.ClaudeAiOauth.AccessToken != "access-2" || creds.ClaudeAiOauth.RefreshToken != "refresh-2" {
+		t.Errorf("stored tokens not applied: %+v", creds.ClaudeAiOauth)
+	}
+
+	// After a new login the file's tokens win
+	creds = &stats.Credentials{ClaudeAiOauth: stats.OAuthCredentials{AccessToken: "access-3", RefreshToken: "login-refresh"}}
+	if stored.Apply(creds) || creds.ClaudeAiOauth.AccessToken != "access-3" {
+		t.Errorf("stale tokens applied: %+v", creds.ClaudeAiOauth)
+	}
+}
+
+func TestSaveRotated_ReadOnly(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("relies on XDG_CONFIG_HOME")
+	}
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	credsPath := filepath.Join(dir, ".credentials.json")
+	body := []byte(`{"claudeAiOauth":{"accessToken":"access-1","refreshToken":"refresh-1"}}`)
+	if err := os.WriteFile(credsPath, body, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default()
+	cfg.Source = config.SourceClaude
+	cfg.ClaudeCredentialsPath = credsPath
+	cfg.ReadOnlyCredentials = true
+
+	if err := SaveRotated(cfg, "access-2", "refresh-2"); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(credsPath); !bytes.Equal(b, body) {
+		t.Errorf("credentials file changed: %s", b)
+	}
+
+	creds, err := ReadCredentials(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.ClaudeAiOauth.AccessToken != "access-2" || creds.ClaudeAiOauth.RefreshToken != "refresh-2" {
+		t.Errorf("got %+v, want the rotated tokens", creds.ClaudeAiOauth)
+	}
+}
+
+func TestSaveRotated_Env(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("relies on XDG_CONFIG_HOME")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(config.EnvAccessToken, "access-1")
+	t.Setenv(config.EnvRefreshToken, "refresh-1")
+
+	cfg := config.Default()
+	if !cfg.UsesTokenStore() {
+		t.Fatal("environment credentials do not use the token store")
+	}
+	if err := SaveRotated(cfg, "access-2", "refresh-2"); err != nil {
+		t.Fatal(err)
+	}
+	creds, err := ReadCredentials(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.ClaudeAiOauth.AccessToken != "access-2" || creds.ClaudeAiOauth.RefreshToken != "refresh-2" {
+		t.Errorf("got %+v, want the rotated tokens", creds.ClaudeAiOauth)
+	}
+
+	// New tokens in the environment replace the rotated ones
+	t.Setenv(config.EnvRefreshToken, "refresh-3")
+	if creds, _ := ReadCredentials(cfg); creds.ClaudeAiOauth.RefreshToken != "refresh-3" {
+		t.Errorf("got %+v, want the environment's tokens", creds.ClaudeAiOauth)
+	}
+}
@@ -0,0 +1,38 @@
+// Package tooltiptmpl parses custom tooltip templates. The tray renders
+// them and config validation checks them with the same Parse, so a
+// template that passes validation also renders.
+package tooltiptmpl
+
+import (
+	"sync/atomic"
+	"text/template"
+
+	"claude-usage/pkg/format"
+)
+
+// asciiBars draws progress bars with ASCII characters instead of Unicode
+// blocks.
+var asciiBars atomic.Bool
+
+// SetASCIIBars chooses ASCII progress bars ("[###---]", true) or Unicode
+// block bars ("▕███░░░▏", false, the default).
+func SetASCIIBars(ascii bool) {
+	asciiBars.Store(ascii)
+}
+
+// Bar draws a progress bar width characters wide in the chosen style.
+func Bar(percentage, width int) string {
+	return format.FormatProgressBar(percentage, width, asciiBars.Load())
+}
+
+// funcs are the functions available to tooltip templates.
+var funcs = template.FuncMap{
+	"bar":     Bar,
+	"percent": format.FormatPercent,
+	"tokens":  format.FormatTokens,
+}
+
+// Parse parses a tooltip template.
+func Parse(text string) (*template.Template, error) {
+	return template.New("tooltip").Funcs(funcs).Option("missingkey=error").Parse(text)
+}
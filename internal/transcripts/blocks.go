@@ -0,0 +1,70 @@
+package transcripts
+
+import (
+	"sort"
+	"time"
+
+	"claude-usage/internal/stats"
+)
+
+// BlockDuration is the length of Claude's rolling session window.
+const BlockDuration = 5 * time.Hour
+
+// Block is one 5-hour session window. A window opens with the first message
+// after the previous one closed, rounded down to the hour, and lasts
+// BlockDuration.
+type Block struct {
+	Start        time.Time
+	End          time.Time
+	LastActivity time.Time
+
+	// Tokens counts input plus output tokens
+	Tokens   int64
+	Messages int
+}
+
+// Blocks groups entries into session windows, oldest first.
+func Blocks(entries []Entry) []Block {
+	sorted := make([]Entry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	var blocks []Block
+	for _, e := range sorted {
+		if len(blocks) == 0 || !e.Time.Before(blocks[len(blocks)-1].End) {
+			start := e.Time.UTC().Truncate(time.Hour)
+			blocks = append(blocks, Block{Start: start, End: start.Add(BlockDuration)})
+		}
+		b := &blocks[len(blocks)-1]
+		b.LastActivity = e.Time
+		b.Tokens += e.Usage.Tokens()
+		b.Messages++
+	}
+	return blocks
+}
+
+// ActiveBlock returns the session window open at now, if any.
+func ActiveBlock(entries []Entry, now time.Time) (Block, bool) {
+	blocks := Blocks(entries)
+	if len(blocks) == 0 {
+		return Block{}, false
+	}
+	last := blocks[len(blocks)-1]
+	if now.Before(last.Start) || !now.Before(last.End) {
+		return Block{}, false
+	}
+	return last, true
+}
+
+// ApplyActiveBlock sets the session window estimate on weeklyStats from
+// the window open at now, leaving it unset when there is none.
+func ApplyActiveBlock(weeklyStats *stats.WeeklyStats, entries []Entry, now time.Time) {
+	if weeklyStats == nil {
+		return
+	}
+	if b, ok := ActiveBlock(entries, now); ok {
+		weeklyStats.SessionStart = b.Start
+		weeklyStats.SessionReset = b.End
+		weeklyStats.SessionTokens = b.Tokens
+	}
+}
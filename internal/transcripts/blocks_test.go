@@ -0,0 +1,38 @@
+package transcripts
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBlocks(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 1, 5, h, m, 0, 0, time.UTC) }
+	entries := []Entry{
+		{Time: at(14, 0), Usage: Usage{InputTokens: 5}},
+		{Time: at(9, 20), Usage: Usage{InputTokens: 10}},
+		{Time: at(13, 59), Usage: Usage{OutputTokens: 20}},
+		// 14:00 is past the 09:00 window, so it opens a new one
+		{Time: at(18, 30), Usage: Usage{OutputTokens: 1}},
+	}
+
+	blocks := Blocks(entries)
+	if len(blocks) != 2 {
+		t.Fatalf("got %d blocks, want 2", len(blocks))
+	}
+	if !blocks[0].Start.Equal(at(9, 0)) || !blocks[0].End.Equal(at(14, 0)) || blocks[0].Tokens != 30 || blocks[0].Messages != 2 {
+		t.Errorf("first block: %+v", blocks[0])
+	}
+	if !blocks[1].Start.Equal(at(14, 0)) || blocks[1].Tokens != 6 || !blocks[1].LastActivity.Equal(at(18, 30)) {
+		t.Errorf("second block: %+v", blocks[1])
+	}
+
+	if b, ok := ActiveBlock(entries, at(18, 45)); !ok || !b.Start.Equal(at(14, 0)) {
+		t.Errorf("ActiveBlock at 18:45: got %+v, %v", b, ok)
+	}
+	if _, ok := ActiveBlock(entries, at(19, 0)); ok {
+		t.Error("ActiveBlock at 19:00: window should have closed")
+	}
+	if _, ok := ActiveBlock(nil, at(12, 0)); ok {
+		t.Error("ActiveBlock without entries: got a block")
+	}
+}
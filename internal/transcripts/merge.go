@@ -0,0 +1,65 @@
+package transcripts
+
+import (
+	"sort"
+
+	"claude-usage/internal/stats"
+)
+
+// Merge returns a copy of cache with each day's per-model tokens taken from
+// the transcripts wherever they record more than the cache. The cache lags
+// behind recent activity, while old transcripts are eventually deleted by
+// Claude Code, so neither source is complete on its own.
+//
+// It also returns the entries of the days taken from the transcripts.
+// cache can be nil.
+func Merge(cache *stats.StatsCache, entries []Entry) (*stats.StatsCache, []Entry) {
+	merged := &stats.StatsCache{}
+	if cache != nil {
+		*merged = *cache
+	}
+
+	byDate := make(map[string]map[string]int64)
+	for _, e := range entries {
+		date := stats.FormatDate(e.Time)
+		if byDate[date] == nil {
+			byDate[date] = make(map[string]int64)
+		}
+		byDate[date][e.Model] += e.Usage.Tokens()
+	}
+
+	taken := make(map[string]bool)
+	var daily []stats.DailyModelTokens
+	for _, d := range merged.DailyModelTokens {
+		if t, ok := byDate[d.Date]; ok && sum(t) >= sum(d.TokensByModel) {
+			daily = append(daily, stats.DailyModelTokens{Date: d.Date, TokensByModel: t})
+			taken[d.Date] = true
+		} else {
+			daily = append(daily, d)
+		}
+		delete(byDate, d.Date)
+	}
+	for date, t := range byDate {
+		daily = append(daily, stats.DailyModelTokens{Date: date, TokensByModel: t})
+		taken[date] = true
+	}
+	sort.Slice(daily, func(i, j int) bool { return daily[i].Date < daily[j].Date })
+	merged.DailyModelTokens = daily
+
+	var used []Entry
+	for _, e := range entries {
+		if taken[stats.FormatDate(e.Time)] {
+			used = append(used, e)
+		}
+	}
+	return merged, used
+}
+
+// sum returns the total of a per-model token map.
+func sum(tokensByModel map[string]int64) int64 {
+	var total int64
+	for _, tokens := range tokensByModel {
+		total += tokens
+	}
+	return total
+}
@@ -0,0 +1,45 @@
+package transcripts
+
+import (
+	"sort"
+	"time"
+)
+
+// ProjectTotal is the token usage of one project over a period.
+type ProjectTotal struct {
+	// Project is the working directory Claude Code ran in
+	Project string
+
+	// Tokens counts input plus output tokens, like stats-cache.json
+	Tokens int64
+
+	// Messages is the number of assistant responses
+	Messages int
+}
+
+// ByProject totals entries from start to end inclusive per project, the
+// heaviest first. Ties are ordered by project name.
+func ByProject(entries []Entry, start, end time.Time) []ProjectTotal {
+	byProject := make(map[string]*ProjectTotal)
+	for _, e := range Between(entries, start, end) {
+		p := byProject[e.Project]
+		if p == nil {
+			p = &ProjectTotal{Project: e.Project}
+			byProject[e.Project] = p
+		}
+		p.Tokens += e.Usage.Tokens()
+		p.Messages++
+	}
+
+	totals := make([]ProjectTotal, 0, len(byProject))
+	for _, p := range byProject {
+		totals = append(totals, *p)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Tokens != totals[j].Tokens {
+			return totals[i].Tokens > totals[j].Tokens
+		}
+		return totals[i].Project < totals[j].Project
+	})
+	return totals
+}
@@ -0,0 +1,33 @@
+package transcripts
+
+import (
+	"testing"
+	"time"
+)
+
+func TestByProject(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 12, 0, 0, 0, time.UTC) }
+	entries := []Entry{
+		{Time: day(5), Project: "/src/a", Usage: Usage{InputTokens: 10, OutputTokens: 5}},
+		{Time: day(5), Project: "/src/b", Usage: Usage{InputTokens: 100}},
+		{Time: day(6), Project: "/src/a", Usage: Usage{OutputTokens: 20}},
+		{Time: day(6), Project: "/src/c", Usage: Usage{OutputTokens: 35}},
+		// Outside the period
+		{Time: day(1), Project: "/src/a", Usage: Usage{InputTokens: 1000}},
+	}
+
+	got := ByProject(entries, day(5).Add(-12*time.Hour), day(7))
+	want := []ProjectTotal{
+		{Project: "/src/b", Tokens: 100, Messages: 1},
+		{Project: "/src/a", Tokens: 35, Messages: 2},
+		{Project: "/src/c", Tokens: 35, Messages: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("[%d] got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Package transcripts reads token usage from Claude Code's session
+// transcripts (~/.claude/projects/<project>/<session>.jsonl).
+//
+// Transcripts are written as messages arrive, so they are much fresher than
+// stats-cache.json, which Claude Code only recomputes now and then. They
+// also record the working directory, which allows per-project breakdowns.
+package transcripts
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Usage is the token usage of one API response.
+type Usage struct {
+	InputTokens         int64 `json:"input_tokens"`
+	OutputTokens        int64 `json:"output_tokens"`
+	CacheCreationTokens int64 `json:"cache_creation_input_tokens"`
+	CacheReadTokens     int64 `json:"cache_read_input_tokens"`
+}
+
+// Tokens returns input plus output tokens, matching how stats-cache.json
+// counts tokens.
+func (u Usage) Tokens() int64 {
+	return u.InputTokens + u.OutputTokens
+}
+
+// Entry is one assistant message with token usage.
+type Entry struct {
+	Time    time.Time
+	Model   string
+	Project string // Working directory, or the transcript's folder name
+	Usage   Usage
+
+	// CostUSD is the cost Claude Code recorded, if any (zero for
+	// subscription plans)
+	CostUSD float64
+
+	// id identifies the API response; streamed responses are logged
+	// several times with the same id
+	id string
+}
+
+// line is the subset of a transcript line that carries usage.
+type line struct {
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Cwd       string    `json:"cwd"`
+	RequestID string    `json:"requestId"`
+	CostUSD   float64   `json:"costUSD"`
+	Message   struct {
+		ID    string `json:"id"`
+		Model string `json:"model"`
+		Usage *Usage `json:"usage"`
+	} `json:"message"`
+}
+
+// fileState is what is known about one transcript file.
+type fileState struct {
+	modTime time.Time
+	offset  int64 // bytes parsed so far, always at a line boundary
+	entries []Entry
+}
+
+// Scanner reads transcripts under a projects directory. Files are only
+// re-read when their modification time changes, and files that grew are
+// read from where the last scan stopped. A Scanner is safe for concurrent use.
+type Scanner struct {
+	root string
+
+	mu    sync.Mutex
+	files map[string]*fileState
+}
+
+// NewScanner creates a scanner for the given projects directory.
+func NewScanner(root string) *Scanner {
+	return &Scanner{root: root, files: make(map[string]*fileState)}
+}
+
+// Scan returns the usage entries of every transcript, deduplicated and in
+// no particular order. A missing projects directory yields no entries.
+func (s *Scanner) Scan() ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]bool)
+	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == s.root && os.IsNotExist(err) {
+				return fs.SkipAll
+			}
+			// Skip unreadable folders rather than failing the scan
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".jsonl") {
+			return nil
+		}
+		seen[path] = true
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		state := s.files[path]
+		if state != nil && info.ModTime().Equal(state.modTime) && info.Size() >= state.offset {
+			return nil
+		}
+		if state == nil || info.Size() < state.offset {
+			// New or truncated: read from the start
+			state = &fileState{}
+			s.files[path] = state
+		}
+		s.readFile(path, state, filepath.Base(filepath.Dir(path)))
+		state.modTime = info.ModTime()
+		return nil
+	})
+
+	// Forget deleted files
+	for path := range s.files {
+		if !seen[path] {
+			delete(s.files, path)
+		}
+	}
+
+	dedup := make(map[string]bool)
+	var entries []Entry
+	for _, state := range s.files {
+		for _, e := range state.entries {
+			if e.id != "" {
+				if dedup[e.id] {
+					continue
+				}
+				dedup[e.id] = true
+			}
+			entries = append(entries, e)
+		}
+	}
+	return entries, err
+}
+
+// readFile parses complete lines from state.offset onwards. A partly
+// written last line is left for the next scan.
+func (s *Scanner) readFile(path string, state *fileState, folder string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(state.offset, io.SeekStart); err != nil {
+		return
+	}
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	for {
+		raw, err := r.ReadBytes('\n')
+		if err != nil {
+			// EOF or a read error: an incomplete line is retried next time
+			return
+		}
+		state.offset += int64(len(raw))
+		if e, ok := parseLine(raw, folder); ok {
+			state.entries = append(state.entries, e)
+		}
+	}
+}
+
+// parseLine extracts usage from one transcript line.
+func parseLine(raw []byte, folder string) (Entry, bool) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || !bytes.Contains(raw, []byte(`"usage"`)) {
+		return Entry{}, false
+	}
+
+	var l line
+	if err := json.Unmarshal(raw, &l); err != nil {
+		return Entry{}, false
+	}
+	if l.Type != "assistant" || l.Message.Usage == nil || l.Message.Model == "" || l.Message.Model == "<synthetic>" {
+		return Entry{}, false
+	}
+
+	e := Entry{
+		Time:    l.Timestamp,
+		Model:   l.Message.Model,
+		Project: l.Cwd,
+		Usage:   *l.Message.Usage,
+		CostUSD: l.CostUSD,
+	}
+	if e.Project == "" {
+		e.Project = folder
+	}
+	if l.Message.ID != "" {
+		e.id = l.Message.ID + ":" + l.RequestID
+	}
+	return e, true
+}
+
+// Between returns the entries from start to end inclusive.
+func Between(entries []Entry, start, end time.Time) []Entry {
+	var out []Entry
+	for _, e := range entries {
+		if !e.Time.Before(start) && !e.Time.After(end) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,165 @@
+package transcripts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"claude-usage/internal/stats"
+)
+
+const (
+	assistantLine = `{"type":"assistant","timestamp":"2026-01-05T10:00:00Z","cwd":"/home/me/app","requestId":"req_1","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":100,"output_tokens":50,"cache_creation_input_tokens":10,"cache_read_input_tokens":1000}}}` + "\n"
+	userLine      = `{"type":"user","timestamp":"2026-01-05T09:59:00Z","message":{"role":"user","content":"hi"}}` + "\n"
+	secondLine    = `{"type":"assistant","timestamp":"2026-01-06T10:00:00Z","requestId":"req_2","message":{"id":"msg_2","model":"claude-opus-4-5","usage":{"input_tokens":7,"output_tokens":3}}}` + "\n"
+)
+
+func writeTranscript(t *testing.T, root, project, content string) string {
+	t.Helper()
+	dir := filepath.Join(root, project)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "session.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func appendTo(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	// Make sure the modification time changes on coarse filesystems
+	later := time.Now().Add(time.Second)
+	os.Chtimes(path, later, later)
+}
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	writeTranscript(t, root, "-home-me-app", userLine+assistantLine+"not json\n")
+
+	entries, err := NewScanner(root).Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Model != "claude-sonnet-4-5" || e.Project != "/home/me/app" {
+		t.Errorf("got model %q, project %q", e.Model, e.Project)
+	}
+	if e.Usage.Tokens() != 150 || e.Usage.CacheReadTokens != 1000 || e.Usage.CacheCreationTokens != 10 {
+		t.Errorf("unexpected usage: %+v", e.Usage)
+	}
+	if !e.Time.Equal(time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("got time %v", e.Time)
+	}
+}
+
+func TestScan_ProjectFallsBackToFolder(t *testing.T) {
+	root := t.TempDir()
+	writeTranscript(t, root, "-home-me-other", secondLine)
+
+	entries, _ := NewScanner(root).Scan()
+	if len(entries) != 1 || entries[0].Project != "-home-me-other" {
+		t.Fatalf("got %+v", entries)
+	}
+}
+
+func TestScan_Incremental(t *testing.T) {
+	root := t.TempDir()
+	// The second line is still being written
+	path := writeTranscript(t, root, "p", assistantLine+secondLine[:20])
+	s := NewScanner(root)
+
+	entries, _ := s.Scan()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+
+	appendTo(t, path, secondLine[20:])
+	entries, _ = s.Scan()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries after append, want 2", len(entries))
+	}
+
+	// Truncated files are re-read from the start
+	if err := os.WriteFile(path, []byte(secondLine), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ = s.Scan()
+	if len(entries) != 1 || entries[0].Model != "claude-opus-4-5" {
+		t.Fatalf("got %+v after truncation", entries)
+	}
+
+	// Deleted files are forgotten
+	os.Remove(path)
+	entries, _ = s.Scan()
+	if len(entries) != 0 {
+		t.Fatalf("got %d entries after deletion, want 0", len(entries))
+	}
+}
+
+func TestScan_Deduplicates(t *testing.T) {
+	root := t.TempDir()
+	// Streamed responses and resumed sessions repeat the same message
+	writeTranscript(t, root, "a", assistantLine+assistantLine)
+	writeTranscript(t, root, "b", assistantLine)
+
+	entries, _ := NewScanner(root).Scan()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+}
+
+func TestScan_MissingRoot(t *testing.T) {
+	entries, err := NewScanner(filepath.Join(t.TempDir(), "missing")).Scan()
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("got %v, %v", entries, err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	cache := &stats.StatsCache{
+		DailyModelTokens: []stats.DailyModelTokens{
+			{Date: "2026-01-04", TokensByModel: map[string]int64{"claude-sonnet-4-5": 500}},
+			// Lagging: the cache has less than the transcripts
+			{Date: "2026-01-05", TokensByModel: map[string]int64{"claude-sonnet-4-5": 20}},
+		},
+	}
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 12, 0, 0, 0, time.UTC) }
+	entries := []Entry{
+		// Transcripts for old days may be partly cleaned up
+		{Time: day(4), Model: "claude-sonnet-4-5", Usage: Usage{InputTokens: 10}},
+		{Time: day(5), Model: "claude-sonnet-4-5", Usage: Usage{InputTokens: 100, OutputTokens: 50}},
+		{Time: day(6), Model: "claude-opus-4-5", Usage: Usage{OutputTokens: 7}},
+	}
+
+	merged, used := Merge(cache, entries)
+
+	want := map[string]int64{"2026-01-04": 500, "2026-01-05": 150, "2026-01-06": 7}
+	if len(merged.DailyModelTokens) != len(want) {
+		t.Fatalf("got %d days, want %d", len(merged.DailyModelTokens), len(want))
+	}
+	for _, d := range merged.DailyModelTokens {
+		if got := sum(d.TokensByModel); got != want[d.Date] {
+			t.Errorf("%s: got %d tokens, want %d", d.Date, got, want[d.Date])
+		}
+	}
+	if len(used) != 2 {
+		t.Errorf("got %d used entries, want 2", len(used))
+	}
+	if sum(cache.DailyModelTokens[1].TokensByModel) != 20 {
+		t.Error("Merge modified the original cache")
+	}
+}
@@ -0,0 +1,188 @@
+package tray
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"claude-usage/internal/stats"
+	"claude-usage/pkg/format"
+)
+
+// accessible writes text for screen readers; see SetAccessible.
+var accessible atomic.Bool
+
+// SetAccessible chooses plain sentences for the tooltip, menu labels and
+// notifications (true): words instead of bar glyphs, symbols and unit
+// abbreviations, as in "Weekly usage 42 percent, resets in 3 days".
+func SetAccessible(on bool) {
+	accessible.Store(on)
+}
+
+// Accessible reports whether SetAccessible turned accessible text on.
+func Accessible() bool {
+	return accessible.Load()
+}
+
+// Percent formats a percentage for display: "42%" in the locale's style,
+// or "42 percent" with accessible text.
+func Percent(percent int) string {
+	if accessible.Load() {
+		return fmt.Sprintf("%d percent", percent)
+	}
+	return format.FormatPercent(percent)
+}
+
+// Tokens formats a token count for display: "2.1M", or "2.1 million" with
+// accessible text.
+func Tokens(n int64) string {
+	s := format.FormatTokens(n)
+	if !accessible.Load() {
+		return s
+	}
+	for _, unit := range []struct{ suffix, word string }{{"K", "thousand"}, {"M", "million"}, {"B", "billion"}} {
+		if number, ok := strings.CutSuffix(s, unit.suffix); ok {
+			return strings.TrimSuffix(number, "\u00a0") + " " + unit.word
+		}
+	}
+	return s
+}
+
+// approxDollars formats an estimated cost, e.g. "≈$23.10", or
+// "about $23.10" with accessible text.
+func approxDollars(usd float64) string {
+	if accessible.Load() {
+		return fmt.Sprintf("about $%.2f", usd)
+	}
+	return fmt.Sprintf("≈$%.2f", usd)
+}
+
+// spokenDuration formats a duration in words, e.g. "3 days 4 hours",
+// "2 hours 10 minutes" or "less than a minute".
+func spokenDuration(d time.Duration) string {
+	if d < time.Minute {
+		return "less than a minute"
+	}
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+
+	var parts []string
+	switch {
+	case days > 0:
+		parts = append(parts, plural(days, "day"))
+		if hours > 0 {
+			parts = append(parts, plural(hours, "hour"))
+		}
+	case hours > 0:
+		parts = append(parts, plural(hours, "hour"))
+		if minutes > 0 {
+			parts = append(parts, plural(minutes, "minute"))
+		}
+	default:
+		parts = append(parts, plural(minutes, "minute"))
+	}
+	return strings.Join(parts, " ")
+}
+
+// plural formats a count of unit, e.g. "1 day" or "3 days".
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// spokenReset describes a reset in words, e.g. "resets in 3 days" or
+// "resets Friday at 14:00".
+func spokenReset(reset time.Time) string {
+	if absoluteResets.Load() {
+		t, now := reset.Local(), time.Now()
+		switch {
+		case t.YearDay() == now.YearDay() && t.Year() == now.Year():
+			return "resets today at " + t.Format("15:04")
+		case t.Sub(now) < 6*24*time.Hour:
+			return "resets " + t.Format("Monday") + " at " + t.Format("15:04")
+		default:
+			return "resets " + t.Format("January 2") + " at " + t.Format("15:04")
+		}
+	}
+	return "resets in " + spokenDuration(time.Until(reset))
+}
+
+// FormatTooltipAccessible describes weeklyStats in sentences, one per
+// line, for screen readers. Compact keeps to the 5-hour and weekly
+// windows, for hosts that cut tooltips short.
+func FormatTooltipAccessible(weeklyStats *stats.WeeklyStats, compact bool) string {
+	if weeklyStats == nil {
+		return "Claude usage: no data available"
+	}
+
+	var lines []string
+	header := "Claude usage"
+	if weeklyStats.SubscriptionType != "" && !compact {
+		header += ", " + format.FormatPlanName(weeklyStats.SubscriptionType, weeklyStats.RateLimitTier) + " plan"
+	}
+	lines = append(lines, header)
+	if weeklyStats.IsThrottled() {
+		lines = append(lines, "Throttled")
+	}
+	if weeklyStats.APIDataStale {
+		lines = append(lines, "Last updated "+spokenDuration(time.Since(weeklyStats.APIFetchedAt))+" ago")
+	}
+
+	window := func(name string, utilization float64, reset time.Time) string {
+		return fmt.Sprintf("%s usage %s, %s", name, Percent(int(utilization*100)), spokenReset(reset))
+	}
+	if weeklyStats.HasAPIData {
+		fiveHour := window("5-hour", weeklyStats.FiveHourUtilization, weeklyStats.FiveHourReset)
+		weekly := window("Weekly", weeklyStats.WeeklyUtilization, weeklyStats.WeeklyReset)
+		if !compact {
+			if weeklyStats.IsLimitedByFiveHour() {
+				fiveHour += ", limiting"
+			} else {
+				weekly += ", limiting"
+			}
+		}
+		lines = append(lines, fiveHour, weekly)
+		if !compact {
+			for _, w := range []struct {
+				name        string
+				utilization float64
+				reset       time.Time
+			}{
+				{"Opus weekly", weeklyStats.OpusUtilization, weeklyStats.OpusReset},
+				{"Sonnet weekly", weeklyStats.SonnetUtilization, weeklyStats.SonnetReset},
+				{"OAuth apps weekly", weeklyStats.OAuthAppsUtilization, weeklyStats.OAuthAppsReset},
+				{"Cowork weekly", weeklyStats.CoworkUtilization, weeklyStats.CoworkReset},
+			} {
+				if w.utilization > 0 {
+					lines = append(lines, window(w.name, w.utilization, w.reset))
+				}
+			}
+			if weeklyStats.ExtraUsageEnabled && weeklyStats.ExtraUsageUsed > 0 {
+				extra := "Extra usage " + format.FormatDollars(weeklyStats.ExtraUsageUsed)
+				if weeklyStats.ExtraUsageLimit > 0 {
+					extra += " of " + format.FormatDollars(weeklyStats.ExtraUsageLimit)
+				}
+				lines = append(lines, extra)
+			}
+		}
+	} else {
+		lines = append(lines, fmt.Sprintf("Weekly usage about %s, estimated, %s left",
+			Percent(weeklyStats.GetPercentage()), plural(weeklyStats.DaysRemaining(), "day")))
+		if !weeklyStats.SessionReset.IsZero() && !compact {
+			lines = append(lines, fmt.Sprintf("5-hour window %s tokens, %s",
+				Tokens(weeklyStats.SessionTokens), spokenReset(weeklyStats.SessionReset)))
+		}
+	}
+
+	if !compact {
+		lines = append(lines, "Pace "+formatPace(weeklyStats.PaceDelta()))
+		if weeklyStats.WeekCostUSD > 0 {
+			lines = append(lines, "Cost "+approxDollars(weeklyStats.WeekCostUSD)+" this week")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
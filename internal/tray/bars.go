@@ -0,0 +1,9 @@
+package tray
+
+import "claude-usage/internal/tooltiptmpl"
+
+// SetASCIIBars chooses ASCII progress bars ("[###---]", true) or Unicode
+// block bars ("▕███░░░▏", false, the default).
+func SetASCIIBars(ascii bool) {
+	tooltiptmpl.SetASCIIBars(ascii)
+}
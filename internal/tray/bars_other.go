@@ -0,0 +1,9 @@
+//go:build !windows
+
+package tray
+
+// UnicodeBarsSupported reports whether tooltips can show the Unicode block
+// characters, which they always can outside Windows.
+func UnicodeBarsSupported() bool {
+	return true
+}
@@ -0,0 +1,9 @@
+package tray
+
+import "golang.org/x/sys/windows"
+
+// UnicodeBarsSupported reports whether tooltips can show the Unicode block
+// characters. Tooltip fonts before Windows 10 lack some of them.
+func UnicodeBarsSupported() bool {
+	return windows.RtlGetVersion().MajorVersion >= 10
+}
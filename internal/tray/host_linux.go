@@ -0,0 +1,26 @@
+package tray
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// statusNotifierWatcher is the D-Bus name owned by hosts that can show
+// StatusNotifierItem tray icons.
+const statusNotifierWatcher = "org.kde.StatusNotifierWatcher"
+
+// HasStatusNotifierHost reports whether the desktop can show the tray
+// icon. Plain GNOME needs the AppIndicator extension for that.
+func HasStatusNotifierHost() (bool, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return false, fmt.Errorf("cannot connect to the D-Bus session bus: %w", err)
+	}
+
+	var hasOwner bool
+	if err := conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, statusNotifierWatcher).Store(&hasOwner); err != nil {
+		return false, fmt.Errorf("could not query the session bus: %w", err)
+	}
+	return hasOwner, nil
+}
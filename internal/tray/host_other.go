@@ -0,0 +1,9 @@
+//go:build !linux
+
+package tray
+
+// HasStatusNotifierHost reports whether the desktop can show the tray
+// icon. Windows and macOS always can.
+func HasStatusNotifierHost() (bool, error) {
+	return true, nil
+}
@@ -0,0 +1,61 @@
+package tray
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// TooltipLayout is how much the built-in tooltip shows.
+type TooltipLayout int32
+
+const (
+	// LayoutFull shows every window, pace, cost and the week-over-week
+	// change (DefaultTooltipTemplate)
+	LayoutFull TooltipLayout = iota
+	// LayoutMedium shows the windows with shorter bars and pace and cost
+	// on one line (MediumTooltipTemplate)
+	LayoutMedium
+	// LayoutCompact shows only the 5-hour and weekly windows, for hosts
+	// that cut tooltips short (FormatTooltipCompact)
+	LayoutCompact
+)
+
+// tooltipLayout is the built-in layout in use; see SetTooltipLayout.
+var tooltipLayout atomic.Int32
+
+// SetTooltipLayout chooses the built-in tooltip layout (LayoutFull by
+// default). A custom layout set with SetTooltipTemplate overrides it.
+func SetTooltipLayout(layout TooltipLayout) {
+	tooltipLayout.Store(int32(layout))
+}
+
+// DetectTooltipLayout returns the layout the tray host shows without
+// truncating: compact on Windows, whose tooltips are limited to 127
+// characters, and on Linux by desktop environment (see DesktopLayout).
+func DetectTooltipLayout() TooltipLayout {
+	switch runtime.GOOS {
+	case "windows":
+		return LayoutCompact
+	case "linux":
+		return DesktopLayout(os.Getenv("XDG_CURRENT_DESKTOP"))
+	}
+	return LayoutFull
+}
+
+// DesktopLayout returns the layout for an XDG_CURRENT_DESKTOP value such as
+// "ubuntu:GNOME". GNOME's AppIndicator extension and its derivatives show
+// only the first few lines, and Plasma's status notifier host cuts off
+// long tooltips; other hosts show them in full.
+func DesktopLayout(desktops string) TooltipLayout {
+	for _, desktop := range strings.Split(desktops, ":") {
+		switch strings.ToLower(desktop) {
+		case "gnome", "unity", "pantheon", "budgie":
+			return LayoutCompact
+		case "kde", "lxqt":
+			return LayoutMedium
+		}
+	}
+	return LayoutFull
+}
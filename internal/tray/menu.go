@@ -1,43 +1,223 @@
 package tray
 
 import (
+	"fmt"
 	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"fyne.io/systray"
+
+	"claude-usage/internal/config"
 )
 
+// RefreshIntervalChoices are the refresh intervals offered in the Settings submenu.
+var RefreshIntervalChoices = []time.Duration{
+	1 * time.Minute,
+	2 * time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+}
+
+// IconMetricChoices are the icon metrics offered in the Settings submenu,
+// with their labels.
+var IconMetricChoices = []struct{ Metric, Label string }{
+	{config.IconWeekly, "Weekly"},
+	{config.IconFiveHour, "5-hour"},
+	{config.IconOpus, "Opus"},
+	{config.IconSonnet, "Sonnet"},
+	{config.IconCost, "Cost"},
+}
+
+// AlertThresholdChoices are the alert thresholds offered in the Settings
+// submenu; the first is config.DefaultAlertThresholds.
+var AlertThresholdChoices = [][]int{
+	{50, 80, 90},
+	{75, 90},
+	{80, 95},
+	{90},
+}
+
 // MenuItems holds references to menu items for updating.
 type MenuItems struct {
 	Version      *systray.MenuItem
+	Profile      *systray.MenuItem // Only populated when a profile is active
+	FiveHourIn   *systray.MenuItem // Reset countdown, hidden until API data arrives
+	WeeklyIn     *systray.MenuItem // Reset countdown, hidden until API data arrives
+	Retry        *systray.MenuItem // Hidden unless the last refresh failed
 	Refresh      *systray.MenuItem
+	CopyUsage    *systray.MenuItem
+	ExportData   *systray.MenuItem
+	ExportChart  *systray.MenuItem
+	OpenUsage    *systray.MenuItem
+	OpenConfig   *systray.MenuItem
+	OpenLog      *systray.MenuItem
 	Update       *systray.MenuItem
+	Rollback     *systray.MenuItem // Hidden unless a previous version is kept
+	Statistics   *systray.MenuItem // Submenu, hidden until stats-cache data arrives
+	Diagnostics  *systray.MenuItem // Submenu, hidden until the first API fetch
+	Settings     *systray.MenuItem
+	EditConfig   *systray.MenuItem
+	Autostart    *systray.MenuItem
+	TeamUsage    *systray.MenuItem // Hidden unless team_usage_url is set
+	Telemetry    *systray.MenuItem
+	NotifyErrors *systray.MenuItem
+	NotifyWeek   *systray.MenuItem
 	SourceToggle *systray.MenuItem // Only populated on Linux
 	Quit         *systray.MenuItem
+
+	// StatisticsLines are informational items under Statistics
+	StatisticsLines []*systray.MenuItem
+
+	// DiagnosticsLines are informational items under Diagnostics
+	DiagnosticsLines []*systray.MenuItem
+
+	// RefreshIntervals are radio-style items under Settings, parallel to RefreshIntervalChoices
+	RefreshIntervals []*systray.MenuItem
+
+	// IconMetrics are radio-style items under Settings, parallel to IconMetricChoices
+	IconMetrics []*systray.MenuItem
+
+	// AlertThresholds are radio-style items under Settings, parallel to AlertThresholdChoices
+	AlertThresholds []*systray.MenuItem
+}
+
+// MenuHandlers holds the callbacks invoked when menu items are clicked.
+// Nil handlers are ignored.
+type MenuHandlers struct {
+	OnRetry        func()
+	OnRefresh      func()
+	OnCopyUsage    func()
+	OnExportData   func()
+	OnExportChart  func()
+	OnOpenUsage    func()
+	OnOpenConfig   func()
+	OnOpenLog      func()
+	OnUpdate       func()
+	OnRollback     func()
+	OnEditConfig   func()
+	OnAutostart    func()
+	OnTeamUsage    func()
+	OnTelemetry    func()
+	OnNotifyErrors func()
+	OnNotifyWeek   func()
+	OnSourceToggle func() // Linux only
+
+	// OnRefreshInterval is called with the chosen interval from the Settings submenu
+	OnRefreshInterval func(time.Duration)
+
+	// OnIconMetric is called with the chosen metric from the Settings submenu
+	OnIconMetric func(string)
+
+	// OnAlertThresholds is called with the chosen thresholds from the Settings submenu
+	OnAlertThresholds func([]int)
+
+	OnQuit func()
 }
 
 // SetupMenu creates the tray menu with Version display, Refresh, Update, and Quit options.
 // The version parameter is displayed as a non-clickable menu item.
+// The profile is the active config profile name, shown when non-empty.
 // The sourceDisplayName is the current source ("Claude Code" or "OpenCode").
 // Returns the menu items for event handling.
-func SetupMenu(version string, sourceDisplayName string) *MenuItems {
+func SetupMenu(version string, profile string, sourceDisplayName string) *MenuItems {
 	items := &MenuItems{}
 
 	// Version display (disabled/grayed out - not clickable)
 	items.Version = systray.AddMenuItem("Version: "+version, "Current application version")
 	items.Version.Disable()
 
+	// Profile display, so side-by-side instances can be told apart
+	if profile != "" {
+		items.Profile = systray.AddMenuItem("Profile: "+profile, "Active config profile")
+		items.Profile.Disable()
+	}
+
+	// Reset countdowns (disabled - informational only).
+	// Tooltips are unreliable on some Linux status-notifier hosts, so the
+	// countdowns are also shown directly in the menu.
+	items.FiveHourIn = systray.AddMenuItem("5h resets in --", "Time until the 5-hour window resets")
+	items.FiveHourIn.Disable()
+	items.FiveHourIn.Hide()
+	items.WeeklyIn = systray.AddMenuItem("Week resets in --", "Time until the weekly window resets")
+	items.WeeklyIn.Disable()
+	items.WeeklyIn.Hide()
+
 	// Separator
 	systray.AddSeparator()
 
+	// Retry option, shown while refreshes fail
+	items.Retry = systray.AddMenuItem("Retry Now", "The last refresh failed; try again right away")
+	items.Retry.Hide()
+
 	// Refresh option
 	items.Refresh = systray.AddMenuItem("Refresh", "Refresh usage statistics")
 
+	// Copy usage summary to clipboard
+	items.CopyUsage = systray.AddMenuItem("Copy Usage", "Copy a usage summary to the clipboard")
+	items.ExportData = systray.AddMenuItem("Export Data…", "Save the last 30 days of usage as a CSV file")
+	items.ExportChart = systray.AddMenuItem("Export Chart…", "Save a chart of the last 30 days of usage as a PNG image")
+
+	// Open the claude.ai usage page, the app's config folder and its log
+	items.OpenUsage = systray.AddMenuItem("Open Usage Page", "Open the Claude usage page in your browser")
+	items.OpenConfig = systray.AddMenuItem("Open Config Folder", "Open the claude-usage config folder")
+	items.OpenLog = systray.AddMenuItem("Open Log File", "Open the claude-usage log file")
+
 	// Update option
-	items.Update = systray.AddMenuItem("Update", "Download and install the latest version")
+	items.Update = systray.AddMenuItem("Check for Updates", "Check for a newer version")
+	items.Rollback = systray.AddMenuItem("Rollback Last Update", "Restore the version replaced by the last update")
+	items.Rollback.Hide()
+
+	// Statistics submenu (informational, filled in by SetStatistics)
+	systray.AddSeparator()
+	items.Statistics = systray.AddMenuItem("Statistics", "Usage beyond the current week")
+	for i := 0; i < statisticsLines; i++ {
+		line := items.Statistics.AddSubMenuItem("", "")
+		line.Disable()
+		items.StatisticsLines = append(items.StatisticsLines, line)
+	}
+	items.Statistics.Hide()
+
+	// Diagnostics submenu (informational, filled in by SetDiagnostics)
+	items.Diagnostics = systray.AddMenuItem("Diagnostics", "Recent API fetch latency and errors")
+	for i := 0; i < diagnosticsLines; i++ {
+		line := items.Diagnostics.AddSubMenuItem("", "")
+		line.Disable()
+		items.DiagnosticsLines = append(items.DiagnosticsLines, line)
+	}
+	items.Diagnostics.Hide()
+
+	// Settings submenu
+	items.Settings = systray.AddMenuItem("Settings", "Change preferences")
+	refreshMenu := items.Settings.AddSubMenuItem("Refresh Interval", "How often usage is refreshed")
+	for _, d := range RefreshIntervalChoices {
+		label := intervalLabel(d)
+		items.RefreshIntervals = append(items.RefreshIntervals, refreshMenu.AddSubMenuItemCheckbox(label, "Refresh every "+label, false))
+	}
+	metricMenu := items.Settings.AddSubMenuItem("Icon Metric", "What the number on the icon shows")
+	for _, choice := range IconMetricChoices {
+		items.IconMetrics = append(items.IconMetrics, metricMenu.AddSubMenuItemCheckbox(choice.Label, "Show "+choice.Label+" usage on the icon", false))
+	}
+	thresholdMenu := items.Settings.AddSubMenuItem("Alert Thresholds", "Usage percentages that send Slack, Discord and webhook alerts")
+	for _, thresholds := range AlertThresholdChoices {
+		label := thresholdsLabel(thresholds)
+		items.AlertThresholds = append(items.AlertThresholds, thresholdMenu.AddSubMenuItemCheckbox(label, "Alert at "+label+" usage", false))
+	}
+	notifyMenu := items.Settings.AddSubMenuItem("Notifications", "Which desktop notifications are shown")
+	items.NotifyErrors = notifyMenu.AddSubMenuItemCheckbox("Refresh Errors", "Notify when refreshes start failing", false)
+	items.NotifyWeek = notifyMenu.AddSubMenuItemCheckbox("New Usage Week", "Notify with last week's usage when a new week starts", false)
+	items.Autostart = items.Settings.AddSubMenuItemCheckbox("Start at Login", "Launch Claude Usage when you log in", false)
+	items.TeamUsage = items.Settings.AddSubMenuItemCheckbox("Show Team Usage", "Show your organization's usage next to yours", false)
+	items.TeamUsage.Hide()
+	items.Telemetry = items.Settings.AddSubMenuItemCheckbox("Share Anonymous Statistics", "Send daily counts of successful and failed refreshes to help fix bugs", false)
+	items.EditConfig = items.Settings.AddSubMenuItem("Edit Config File…", "Open config.json in your default editor")
 
 	// Source toggle - Linux only
 	if runtime.GOOS == "linux" {
-		systray.AddSeparator()
 		items.SourceToggle = systray.AddMenuItem("Source: "+sourceDisplayName, "Toggle between Claude Code and OpenCode")
 	}
 
@@ -57,52 +237,270 @@ func (m *MenuItems) UpdateSourceToggle(sourceDisplayName string) {
 	}
 }
 
+// intervalLabel labels a refresh interval item, e.g. "5 min", or
+// "5 minutes" with accessible text.
+func intervalLabel(d time.Duration) string {
+	if accessible.Load() {
+		return plural(int(d.Minutes()), "minute")
+	}
+	return fmt.Sprintf("%d min", int(d.Minutes()))
+}
+
+// SetRefreshInterval checks the Settings item matching d and unchecks the rest.
+// If d is not one of RefreshIntervalChoices, no item is checked. The items
+// are relabelled, picking up a change of SetAccessible.
+func (m *MenuItems) SetRefreshInterval(d time.Duration) {
+	for i, item := range m.RefreshIntervals {
+		item.SetTitle(intervalLabel(RefreshIntervalChoices[i]))
+		if RefreshIntervalChoices[i] == d {
+			item.Check()
+		} else {
+			item.Uncheck()
+		}
+	}
+}
+
+// SetIconMetric checks the Settings item matching metric, where empty
+// means config.IconWeekly, and unchecks the rest.
+func (m *MenuItems) SetIconMetric(metric string) {
+	if metric == "" {
+		metric = config.IconWeekly
+	}
+	for i, item := range m.IconMetrics {
+		if IconMetricChoices[i].Metric == metric {
+			item.Check()
+		} else {
+			item.Uncheck()
+		}
+	}
+}
+
+// thresholdsLabel labels an alert thresholds item, e.g. "50, 80, 90%".
+func thresholdsLabel(thresholds []int) string {
+	parts := make([]string, len(thresholds))
+	for i, t := range thresholds {
+		parts[i] = strconv.Itoa(t)
+	}
+	return strings.Join(parts, ", ") + "%"
+}
+
+// SetAlertThresholds checks the Settings item matching thresholds and
+// unchecks the rest. Thresholds not among AlertThresholdChoices check no item.
+func (m *MenuItems) SetAlertThresholds(thresholds []int) {
+	for i, item := range m.AlertThresholds {
+		if slices.Equal(AlertThresholdChoices[i], thresholds) {
+			item.Check()
+		} else {
+			item.Uncheck()
+		}
+	}
+}
+
+// SetNotifications sets the checked state of the Notifications items.
+func (m *MenuItems) SetNotifications(refreshErrors, weekStart bool) {
+	setChecked(m.NotifyErrors, refreshErrors)
+	setChecked(m.NotifyWeek, weekStart)
+}
+
+// setChecked checks or unchecks item. Nil items are ignored.
+func setChecked(item *systray.MenuItem, checked bool) {
+	if item == nil {
+		return
+	}
+	if checked {
+		item.Check()
+	} else {
+		item.Uncheck()
+	}
+}
+
+// SetAutostart sets the checked state of the Start at Login item.
+func (m *MenuItems) SetAutostart(enabled bool) {
+	if m.Autostart == nil {
+		return
+	}
+	if enabled {
+		m.Autostart.Check()
+	} else {
+		m.Autostart.Uncheck()
+	}
+}
+
+// SetTeamUsage shows the Show Team Usage item when a team usage endpoint
+// is configured, checked when team usage is shown.
+func (m *MenuItems) SetTeamUsage(available, enabled bool) {
+	if m.TeamUsage == nil {
+		return
+	}
+	if !available {
+		m.TeamUsage.Hide()
+		return
+	}
+	if enabled {
+		m.TeamUsage.Check()
+	} else {
+		m.TeamUsage.Uncheck()
+	}
+	m.TeamUsage.Show()
+}
+
+// SetTelemetry sets the checked state of the Share Anonymous Statistics item.
+func (m *MenuItems) SetTelemetry(enabled bool) {
+	if m.Telemetry == nil {
+		return
+	}
+	if enabled {
+		m.Telemetry.Check()
+	} else {
+		m.Telemetry.Uncheck()
+	}
+}
+
+// statisticsLines and diagnosticsLines are the number of items in the
+// Statistics and Diagnostics submenus.
+const (
+	statisticsLines  = 4
+	diagnosticsLines = 2
+)
+
+// SetStatistics fills the Statistics submenu with up to statisticsLines
+// lines; unused items are hidden. No lines hides the submenu.
+func (m *MenuItems) SetStatistics(lines []string) {
+	setSubmenuLines(m.Statistics, m.StatisticsLines, lines)
+}
+
+// SetDiagnostics fills the Diagnostics submenu like SetStatistics.
+func (m *MenuItems) SetDiagnostics(lines []string) {
+	setSubmenuLines(m.Diagnostics, m.DiagnosticsLines, lines)
+}
+
+// setSubmenuLines sets the titles of a submenu's informational items,
+// hiding unused items, or the whole submenu when there are no lines.
+func setSubmenuLines(menu *systray.MenuItem, items []*systray.MenuItem, lines []string) {
+	if menu == nil {
+		return
+	}
+	if len(lines) == 0 {
+		menu.Hide()
+		return
+	}
+	for i, item := range items {
+		if i < len(lines) {
+			item.SetTitle(lines[i])
+			item.Show()
+		} else {
+			item.Hide()
+		}
+	}
+	menu.Show()
+}
+
+// SetRetry shows or hides the Retry Now item.
+func (m *MenuItems) SetRetry(visible bool) {
+	if m.Retry == nil {
+		return
+	}
+	if visible {
+		m.Retry.Show()
+	} else {
+		m.Retry.Hide()
+	}
+}
+
+// SetRollbackAvailable shows or hides the Rollback Last Update item.
+func (m *MenuItems) SetRollbackAvailable(available bool) {
+	if m.Rollback == nil {
+		return
+	}
+	if available {
+		m.Rollback.Show()
+	} else {
+		m.Rollback.Hide()
+	}
+}
+
+// UpdateCountdowns updates the reset countdown menu items.
+// A zero reset time hides the corresponding item.
+func (m *MenuItems) UpdateCountdowns(fiveHourReset, weeklyReset time.Time) {
+	if accessible.Load() {
+		updateCountdownItem(m.FiveHourIn, "5-hour window ", fiveHourReset)
+		updateCountdownItem(m.WeeklyIn, "Weekly window ", weeklyReset)
+		return
+	}
+	updateCountdownItem(m.FiveHourIn, "5h ", fiveHourReset)
+	updateCountdownItem(m.WeeklyIn, "Week ", weeklyReset)
+}
+
+// updateCountdownItem sets a countdown item's label, or hides it if reset is zero.
+func updateCountdownItem(item *systray.MenuItem, prefix string, reset time.Time) {
+	if item == nil {
+		return
+	}
+	if reset.IsZero() {
+		item.Hide()
+		return
+	}
+	item.SetTitle(prefix + resetPhrase(reset))
+	item.Show()
+}
+
 // HandleMenuEvents starts goroutines to handle menu item clicks.
-// onRefresh is called when Refresh is clicked.
-// onUpdate is called when Update is clicked.
-// onSourceToggle is called when Source toggle is clicked (Linux only).
-// onQuit is called when Quit is clicked.
-func HandleMenuEvents(items *MenuItems, onRefresh, onUpdate, onSourceToggle, onQuit func()) {
+// Each clickable item gets its own goroutine so optional items (such as the
+// Linux-only source toggle) don't complicate a shared select statement.
+func HandleMenuEvents(items *MenuItems, handlers MenuHandlers) {
+	handleClicks(items.Retry, handlers.OnRetry)
+	handleClicks(items.Refresh, handlers.OnRefresh)
+	handleClicks(items.CopyUsage, handlers.OnCopyUsage)
+	handleClicks(items.ExportData, handlers.OnExportData)
+	handleClicks(items.ExportChart, handlers.OnExportChart)
+	handleClicks(items.OpenUsage, handlers.OnOpenUsage)
+	handleClicks(items.OpenConfig, handlers.OnOpenConfig)
+	handleClicks(items.OpenLog, handlers.OnOpenLog)
+	handleClicks(items.Update, handlers.OnUpdate)
+	handleClicks(items.Rollback, handlers.OnRollback)
+	handleClicks(items.EditConfig, handlers.OnEditConfig)
+	handleClicks(items.Autostart, handlers.OnAutostart)
+	handleClicks(items.TeamUsage, handlers.OnTeamUsage)
+	handleClicks(items.Telemetry, handlers.OnTelemetry)
+	handleClicks(items.NotifyErrors, handlers.OnNotifyErrors)
+	handleClicks(items.NotifyWeek, handlers.OnNotifyWeek)
+	handleClicks(items.SourceToggle, handlers.OnSourceToggle)
+
+	if handlers.OnRefreshInterval != nil {
+		for i, item := range items.RefreshIntervals {
+			d := RefreshIntervalChoices[i]
+			handleClicks(item, func() { handlers.OnRefreshInterval(d) })
+		}
+	}
+	if handlers.OnIconMetric != nil {
+		for i, item := range items.IconMetrics {
+			metric := IconMetricChoices[i].Metric
+			handleClicks(item, func() { handlers.OnIconMetric(metric) })
+		}
+	}
+	if handlers.OnAlertThresholds != nil {
+		for i, item := range items.AlertThresholds {
+			thresholds := AlertThresholdChoices[i]
+			handleClicks(item, func() { handlers.OnAlertThresholds(slices.Clone(thresholds)) })
+		}
+	}
+
+	go func() {
+		<-items.Quit.ClickedCh
+		if handlers.OnQuit != nil {
+			handlers.OnQuit()
+		}
+	}()
+}
+
+// handleClicks calls fn for every click on item. Nil items or handlers are ignored.
+func handleClicks(item *systray.MenuItem, fn func()) {
+	if item == nil || fn == nil {
+		return
+	}
 	go func() {
-		for {
-			// Build select cases dynamically based on available menu items
-			if items.SourceToggle != nil {
-				select {
-				case <-items.Refresh.ClickedCh:
-					if onRefresh != nil {
-						onRefresh()
-					}
-				case <-items.Update.ClickedCh:
-					if onUpdate != nil {
-						onUpdate()
-					}
-				case <-items.SourceToggle.ClickedCh:
-					if onSourceToggle != nil {
-						onSourceToggle()
-					}
-				case <-items.Quit.ClickedCh:
-					if onQuit != nil {
-						onQuit()
-					}
-					return
-				}
-			} else {
-				select {
-				case <-items.Refresh.ClickedCh:
-					if onRefresh != nil {
-						onRefresh()
-					}
-				case <-items.Update.ClickedCh:
-					if onUpdate != nil {
-						onUpdate()
-					}
-				case <-items.Quit.ClickedCh:
-					if onQuit != nil {
-						onQuit()
-					}
-					return
-				}
-			}
+		for range item.ClickedCh {
+			fn()
 		}
 	}()
 }
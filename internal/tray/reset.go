@@ -0,0 +1,60 @@
+package tray
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// absoluteResets selects clock times ("Fri 14:00") over countdowns
+// ("2h 10m") for reset times.
+var absoluteResets atomic.Bool
+
+// SetAbsoluteResetTimes chooses between showing reset times as local clock
+// times (true) or as countdowns (false, the default).
+func SetAbsoluteResetTimes(absolute bool) {
+	absoluteResets.Store(absolute)
+}
+
+// formatReset formats a reset time for the tooltip: a countdown such as
+// "2h 10m", or a local clock time such as "Fri 14:00".
+func formatReset(reset time.Time) string {
+	if absoluteResets.Load() {
+		return formatClock(reset, time.Now())
+	}
+	return formatShortDuration(time.Until(reset))
+}
+
+// formatResetCompact is formatReset for the Windows tooltip.
+func formatResetCompact(reset time.Time) string {
+	if absoluteResets.Load() {
+		return formatClock(reset, time.Now())
+	}
+	return formatVeryShortDuration(time.Until(reset))
+}
+
+// resetPhrase describes a reset, e.g. "resets in 2h 10m" or "resets Fri 14:00",
+// in words with accessible text (see spokenReset).
+func resetPhrase(reset time.Time) string {
+	if accessible.Load() {
+		return spokenReset(reset)
+	}
+	if absoluteResets.Load() {
+		return "resets " + formatClock(reset, time.Now())
+	}
+	return "resets in " + formatShortDuration(time.Until(reset))
+}
+
+// formatClock formats t in the local timezone: "14:00" later today,
+// "Fri 14:00" within the next six days and "Jan 2 14:00" beyond that.
+func formatClock(t, now time.Time) string {
+	t = t.Local()
+	now = now.Local()
+	switch {
+	case t.Year() == now.Year() && t.YearDay() == now.YearDay():
+		return t.Format("15:04")
+	case t.Sub(now) < 6*24*time.Hour:
+		return t.Format("Mon 15:04")
+	default:
+		return t.Format("Jan 2 15:04")
+	}
+}
@@ -0,0 +1,146 @@
+package tray
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"claude-usage/internal/history"
+	"claude-usage/internal/stats"
+	"claude-usage/pkg/format"
+)
+
+// FormatSummary creates a plain-text usage summary suitable for pasting into
+// chat or issue trackers. Unlike the tooltip it uses no bar glyphs.
+func FormatSummary(weeklyStats *stats.WeeklyStats) string {
+	if weeklyStats == nil {
+		return "Claude usage: no data available"
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("Claude usage")
+	if weeklyStats.SubscriptionType != "" {
+		sb.WriteString(" (" + format.FormatPlanName(weeklyStats.SubscriptionType, weeklyStats.RateLimitTier) + ")")
+	}
+	if weeklyStats.IsThrottled() {
+		sb.WriteString(" - THROTTLED")
+	}
+	if weeklyStats.APIDataStale {
+		sb.WriteString(" - stale, last updated " + formatAge(time.Since(weeklyStats.APIFetchedAt)) + " ago")
+	}
+	sb.WriteString("\n")
+
+	if weeklyStats.HasAPIData {
+		sb.WriteString(fmt.Sprintf("5-hour: %s (%s)\n",
+			format.FormatPercent(weeklyStats.GetFiveHourPercentage()), resetPhrase(weeklyStats.FiveHourReset)))
+		sb.WriteString(fmt.Sprintf("Weekly: %s (%s)\n",
+			format.FormatPercent(weeklyStats.GetPercentage()), resetPhrase(weeklyStats.WeeklyReset)))
+		if weeklyStats.OpusUtilization > 0 {
+			sb.WriteString(fmt.Sprintf("Opus: %s (%s)\n",
+				format.FormatPercent(int(weeklyStats.OpusUtilization*100)), resetPhrase(weeklyStats.OpusReset)))
+		}
+		if weeklyStats.SonnetUtilization > 0 {
+			sb.WriteString(fmt.Sprintf("Sonnet: %s (%s)\n",
+				format.FormatPercent(int(weeklyStats.SonnetUtilization*100)), resetPhrase(weeklyStats.SonnetReset)))
+		}
+		if weeklyStats.OAuthAppsUtilization > 0 {
+			sb.WriteString(fmt.Sprintf("OAuth apps: %s (%s)\n",
+				format.FormatPercent(int(weeklyStats.OAuthAppsUtilization*100)), resetPhrase(weeklyStats.OAuthAppsReset)))
+		}
+		if weeklyStats.CoworkUtilization > 0 {
+			sb.WriteString(fmt.Sprintf("Cowork: %s (%s)\n",
+				format.FormatPercent(int(weeklyStats.CoworkUtilization*100)), resetPhrase(weeklyStats.CoworkReset)))
+		}
+		if extra := weeklyStats.ExtraUsageText(); extra != "" {
+			sb.WriteString("Extra credits: " + extra + "\n")
+		}
+	} else {
+		sb.WriteString(fmt.Sprintf("Weekly: ~%s estimated (%s tokens, %dd left)\n",
+			format.FormatPercent(weeklyStats.GetPercentage()), format.FormatTokens(weeklyStats.TotalTokens), weeklyStats.DaysRemaining()))
+		if !weeklyStats.SessionReset.IsZero() {
+			sb.WriteString(fmt.Sprintf("5-hour: %s tokens estimated (%s)\n",
+				format.FormatTokens(weeklyStats.SessionTokens), resetPhrase(weeklyStats.SessionReset)))
+		}
+	}
+
+	if weeklyStats.WeekCostUSD > 0 {
+		sb.WriteString(fmt.Sprintf("Estimated cost: ≈$%.2f this week, ≈$%.2f this month\n",
+			weeklyStats.WeekCostUSD, weeklyStats.MonthCostUSD))
+	}
+	if change, ok := weeklyStats.WeekOverWeek(); ok {
+		sb.WriteString("Tokens vs last week at this time: " + formatChange(change) + "\n")
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// FormatStatisticsLines returns the lines for the Statistics submenu, or nil
+// without stats-cache data.
+func FormatStatisticsLines(month *stats.PeriodStats, lifetime *stats.LifetimeStats, monthCostUSD float64) []string {
+	if lifetime == nil || lifetime.TotalTokens == 0 {
+		return nil
+	}
+
+	monthLine := "This month: " + Tokens(month.TotalTokens) + " tokens"
+	if monthCostUSD > 0 {
+		monthLine += " (" + approxDollars(monthCostUSD) + ")"
+	}
+	lines := []string{
+		monthLine,
+		fmt.Sprintf("This month: %d messages, %d sessions", month.Messages, month.Sessions),
+		"All time: " + Tokens(lifetime.TotalTokens) + " tokens",
+		fmt.Sprintf("All time: %d messages, %d sessions", lifetime.Messages, lifetime.Sessions),
+	}
+	if !lifetime.FirstSessionDate.IsZero() {
+		lines[2] += " since " + lifetime.FirstSessionDate.Format("Jan 2006")
+	}
+	return lines
+}
+
+// FormatDiagnosticsLines returns the lines for the Diagnostics submenu, such
+// as "Last fetch: 320ms" and "2 errors in last hour", or nil before the
+// first API fetch.
+func FormatDiagnosticsLines(s history.FetchSummary) []string {
+	if s.Last == nil {
+		return nil
+	}
+
+	last := "Last fetch: " + formatLatency(s.Last.Latency())
+	if !s.Last.OK {
+		if s.Last.StatusCode != 0 {
+			last = fmt.Sprintf("Last fetch: failed after %s (HTTP %d)", formatLatency(s.Last.Latency()), s.Last.StatusCode)
+		} else {
+			last = fmt.Sprintf("Last fetch: failed after %s (network)", formatLatency(s.Last.Latency()))
+		}
+	}
+
+	var errs string
+	switch {
+	case s.Errors == 0:
+		errs = "No errors in last hour"
+	case s.Errors == 1:
+		errs = "1 error in last hour"
+	default:
+		errs = fmt.Sprintf("%d errors in last hour", s.Errors)
+	}
+	if s.NetworkErrors > 0 {
+		errs += fmt.Sprintf(" (%d network)", s.NetworkErrors)
+	}
+	return []string{last, errs}
+}
+
+// formatLatency formats d as "320ms" below a second, otherwise as "2.5s",
+// with the units in words with accessible text.
+func formatLatency(d time.Duration) string {
+	if accessible.Load() {
+		if d < time.Second {
+			return fmt.Sprintf("%d milliseconds", d.Milliseconds())
+		}
+		return fmt.Sprintf("%.1f seconds", d.Seconds())
+	}
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
@@ -0,0 +1,289 @@
+package tray
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"claude-usage/internal/stats"
+	"claude-usage/internal/tooltiptmpl"
+	"claude-usage/pkg/format"
+)
+
+// DefaultTooltipTemplate is the full tooltip layout. Custom layouts set
+// with SetTooltipTemplate get the same TooltipData and functions.
+const DefaultTooltipTemplate = `CLAUDE USAGE
+{{- if .Plan}}
+Plan: {{.Plan}}
+{{- end}}
+{{- if .Throttled}}
+STATUS: THROTTLED
+{{- end}}
+{{- if .Stale}}
+Stale (last updated {{.StaleFor}} ago)
+{{- end}}
+{{- if .HasAPIData}}
+{{bar .FiveHour.Percent 10}} {{percent .FiveHour.Percent | printf "%4s"}} {{.FiveHour.Reset}}{{if .FiveHour.Limiting}} ◀{{end}}
+{{bar .Weekly.Percent 10}} {{percent .Weekly.Percent | printf "%4s"}} {{.Weekly.Reset}}{{if .Weekly.Limiting}} ◀{{end}}
+{{- with .Opus}}
+{{bar .Percent 10}} {{percent .Percent | printf "%4s"}} {{.Reset}}
+{{- end}}
+{{- with .Sonnet}}
+{{bar .Percent 10}} {{percent .Percent | printf "%4s"}} {{.Reset}}
+{{- end}}
+{{- with .OAuthApps}}
+{{bar .Percent 10}} {{percent .Percent | printf "%4s"}} {{.Reset}} OAuth apps
+{{- end}}
+{{- with .Cowork}}
+{{bar .Percent 10}} {{percent .Percent | printf "%4s"}} {{.Reset}} Cowork
+{{- end}}
+{{- if .ExtraCredits}}
+Extra credits: {{.ExtraCredits}}
+{{- end}}
+{{- with .Team}}
+Team: {{percent .FiveHour}} 5h · {{percent .Weekly}} week
+{{- end}}
+{{- with .Headers}}
+API headers: {{percent .FiveHour}} 5h · {{percent .Weekly}} week
+{{- end}}
+{{- else}}
+{{bar .Weekly.Percent 10}} ~{{percent .Weekly.Percent | printf "%4s"}} {{.DaysRemaining}}d
+{{- with .Session}}
+5h window: {{tokens .Tokens}} tokens, {{.Reset}}
+{{- end}}
+{{- end}}
+Pace: {{.Pace}}
+{{- if .CostUSD}}
+≈${{printf "%.2f" .CostUSD}} this week
+{{- end}}
+{{- if .WeekOverWeek}}
+vs last week at this time: {{.WeekOverWeek}}
+{{- end}}`
+
+// MediumTooltipTemplate is the medium tooltip layout (see LayoutMedium):
+// the plan on the first line, 8-character bars, the Opus and Sonnet windows
+// labelled and pace and cost on one line.
+const MediumTooltipTemplate = `CLAUDE USAGE{{with .Plan}} {{.}}{{end}}
+{{- if .Throttled}}
+THROTTLED
+{{- end}}
+{{- if .Stale}}
+Stale {{.StaleFor}} ago
+{{- end}}
+{{- if .HasAPIData}}
+{{bar .FiveHour.Percent 8}} {{percent .FiveHour.Percent | printf "%4s"}} {{.FiveHour.Reset}}{{if .FiveHour.Limiting}} ◀{{end}}
+{{bar .Weekly.Percent 8}} {{percent .Weekly.Percent | printf "%4s"}} {{.Weekly.Reset}}{{if .Weekly.Limiting}} ◀{{end}}
+{{- if or .Opus .Sonnet}}
+{{with .Opus}}Opus {{percent .Percent}}{{end}}{{if and .Opus .Sonnet}} · {{end}}{{with .Sonnet}}Sonnet {{percent .Percent}}{{end}}
+{{- end}}
+{{- if .ExtraCredits}}
+Extra: {{.ExtraCredits}}
+{{- end}}
+{{- with .Headers}}
+Headers: {{percent .FiveHour}} 5h · {{percent .Weekly}} wk
+{{- end}}
+{{- else}}
+{{bar .Weekly.Percent 8}} ~{{percent .Weekly.Percent | printf "%4s"}} {{.DaysRemaining}}d
+{{- with .Session}}
+5h: {{tokens .Tokens}}, {{.Reset}}
+{{- end}}
+{{- end}}
+Pace: {{.Pace}}{{if .CostUSD}} · ≈${{printf "%.2f" .CostUSD}}{{end}}`
+
+// TooltipData is what tooltip templates are executed with.
+type TooltipData struct {
+	Plan      string // e.g. "Max 5x", empty when unknown
+	Throttled bool
+
+	// Stale is set while the API is unreachable and cached data is shown,
+	// last updated StaleFor ago (e.g. "23m")
+	Stale    bool
+	StaleFor string
+
+	// HasAPIData is set when the windows below come from the API;
+	// otherwise only Weekly.Percent (estimated) is filled in
+	HasAPIData bool
+	FiveHour   Window
+	Weekly     Window
+
+	// Per-model and per-product weekly windows; nil when unused
+	Opus      *Window
+	Sonnet    *Window
+	OAuthApps *Window
+	Cowork    *Window
+
+	// ExtraCredits is pay-as-you-go spend, e.g. "$12.40 / $50"
+	ExtraCredits string
+
+	// Team is organization-wide usage; nil unless shown
+	Team *TeamUsage
+
+	// Headers is usage from the rate limit headers of regular API
+	// responses; nil unless it disagrees with the windows above
+	Headers *HeaderUsage
+
+	// DaysRemaining in the weekly window
+	DaysRemaining int
+
+	// Pace compares weekly usage with the share of the week elapsed,
+	// e.g. "20% ahead", "5% behind" or "on track"
+	Pace string
+
+	// Session is the 5-hour window estimated from transcripts when there
+	// is no API data; nil when none is open
+	Session *SessionWindow
+
+	// Tokens is this week's local token total, by model in Models
+	Tokens int64
+	Models []ModelUsage
+
+	// CostUSD is this week's estimated cost at API prices
+	CostUSD float64
+
+	// WeekOverWeek is the change from last week at this time, e.g. "+14%"
+	WeekOverWeek string
+}
+
+// Window is a usage window in TooltipData.
+type Window struct {
+	Percent  int
+	Reset    string // e.g. "2h 10m" or "Fri 14:00"
+	Limiting bool   // whether this window is the one limiting usage
+}
+
+// TeamUsage is organization-wide usage in TooltipData, in percent.
+type TeamUsage struct {
+	FiveHour int
+	Weekly   int
+}
+
+// HeaderUsage is usage from rate limit headers in TooltipData, in percent.
+type HeaderUsage struct {
+	FiveHour int
+	Weekly   int
+}
+
+// SessionWindow is the estimated 5-hour window in TooltipData.
+type SessionWindow struct {
+	Tokens int64
+	Reset  string // e.g. "resets in 2h 10m"
+}
+
+// ModelUsage is a model's share of this week's tokens.
+type ModelUsage struct {
+	Name   string
+	Tokens int64
+}
+
+var (
+	defaultTooltip = template.Must(tooltiptmpl.Parse(DefaultTooltipTemplate))
+	mediumTooltip  = template.Must(tooltiptmpl.Parse(MediumTooltipTemplate))
+
+	// customTooltip replaces the tooltip on every platform; nil uses the
+	// built-in layouts
+	customTooltip   *template.Template
+	customTooltipMu sync.RWMutex
+)
+
+// SetTooltipTemplate sets a custom tooltip layout; empty text restores the
+// built-in ones. On error the current layout is kept.
+func SetTooltipTemplate(text string) error {
+	var t *template.Template
+	if text != "" {
+		var err error
+		if t, err = tooltiptmpl.Parse(text); err != nil {
+			return err
+		}
+	}
+	customTooltipMu.Lock()
+	customTooltip = t
+	customTooltipMu.Unlock()
+	return nil
+}
+
+// NewTooltipData collects the tooltip fields from weeklyStats.
+func NewTooltipData(weeklyStats *stats.WeeklyStats) TooltipData {
+	d := TooltipData{
+		Throttled:     weeklyStats.IsThrottled(),
+		Stale:         weeklyStats.APIDataStale,
+		HasAPIData:    weeklyStats.HasAPIData,
+		ExtraCredits:  weeklyStats.ExtraUsageText(),
+		DaysRemaining: weeklyStats.DaysRemaining(),
+		Pace:          formatPace(weeklyStats.PaceDelta()),
+		Tokens:        weeklyStats.TotalTokens,
+		CostUSD:       weeklyStats.WeekCostUSD,
+	}
+	if weeklyStats.SubscriptionType != "" {
+		d.Plan = format.FormatPlanName(weeklyStats.SubscriptionType, weeklyStats.RateLimitTier)
+	}
+	if d.Stale {
+		d.StaleFor = formatAge(time.Since(weeklyStats.APIFetchedAt))
+	}
+
+	d.Weekly.Percent = weeklyStats.GetPercentage()
+	if weeklyStats.HasAPIData {
+		d.FiveHour = Window{
+			Percent:  weeklyStats.GetFiveHourPercentage(),
+			Reset:    formatReset(weeklyStats.FiveHourReset),
+			Limiting: weeklyStats.IsLimitedByFiveHour(),
+		}
+		d.Weekly.Reset = formatReset(weeklyStats.WeeklyReset)
+		d.Weekly.Limiting = !weeklyStats.IsLimitedByFiveHour()
+		d.Opus = optionalWindow(weeklyStats.OpusUtilization, weeklyStats.OpusReset)
+		d.Sonnet = optionalWindow(weeklyStats.SonnetUtilization, weeklyStats.SonnetReset)
+		d.OAuthApps = optionalWindow(weeklyStats.OAuthAppsUtilization, weeklyStats.OAuthAppsReset)
+		d.Cowork = optionalWindow(weeklyStats.CoworkUtilization, weeklyStats.CoworkReset)
+		if weeklyStats.HasTeamData {
+			d.Team = &TeamUsage{
+				FiveHour: int(weeklyStats.TeamFiveHourUtilization * 100),
+				Weekly:   int(weeklyStats.TeamWeeklyUtilization * 100),
+			}
+		}
+		if weeklyStats.HeaderMismatch {
+			d.Headers = &HeaderUsage{
+				FiveHour: int(weeklyStats.HeaderFiveHourUtilization * 100),
+				Weekly:   int(weeklyStats.HeaderWeeklyUtilization * 100),
+			}
+		}
+	} else if !weeklyStats.SessionReset.IsZero() {
+		d.Session = &SessionWindow{Tokens: weeklyStats.SessionTokens, Reset: resetPhrase(weeklyStats.SessionReset)}
+	}
+
+	for model, tokens := range weeklyStats.TokensByModel {
+		d.Models = append(d.Models, ModelUsage{stats.ModelDisplayName(model), tokens})
+	}
+	sort.Slice(d.Models, func(i, j int) bool {
+		if d.Models[i].Tokens != d.Models[j].Tokens {
+			return d.Models[i].Tokens > d.Models[j].Tokens
+		}
+		return d.Models[i].Name < d.Models[j].Name
+	})
+
+	if change, ok := weeklyStats.WeekOverWeek(); ok {
+		d.WeekOverWeek = formatChange(change)
+	}
+	return d
+}
+
+// optionalWindow returns a window for a utilization, or nil if it is unused.
+func optionalWindow(utilization float64, reset time.Time) *Window {
+	if utilization <= 0 {
+		return nil
+	}
+	return &Window{Percent: int(utilization * 100), Reset: formatReset(reset)}
+}
+
+// executeTooltip renders t for weeklyStats.
+func executeTooltip(t *template.Template, weeklyStats *stats.WeeklyStats) string {
+	if weeklyStats == nil {
+		return "Claude Usage\nNo data available"
+	}
+	var sb strings.Builder
+	if err := t.Execute(&sb, NewTooltipData(weeklyStats)); err != nil {
+		return fmt.Sprintf("Claude Usage\nTooltip template error: %v", err)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -3,81 +3,59 @@ package tray
 
 import (
 	"fmt"
-	"runtime"
+	"math"
 	"strings"
 	"time"
 
 	"claude-usage/internal/stats"
+	"claude-usage/internal/tooltiptmpl"
 	"claude-usage/pkg/format"
 )
 
-// FormatTooltip creates a formatted tooltip string from weekly statistics.
+// FormatTooltip creates a formatted tooltip string from weekly statistics,
+// using DefaultTooltipTemplate.
 func FormatTooltip(weeklyStats *stats.WeeklyStats) string {
-	if weeklyStats == nil {
-		return "Claude Usage\nNo data available"
-	}
-
-	var sb strings.Builder
-
-	// Header
-	sb.WriteString("CLAUDE USAGE\n")
+	return executeTooltip(defaultTooltip, weeklyStats)
+}
 
-	// Plan info
-	if weeklyStats.SubscriptionType != "" {
-		planName := format.FormatPlanName(weeklyStats.SubscriptionType, weeklyStats.RateLimitTier)
-		sb.WriteString(fmt.Sprintf("Plan: %s\n", planName))
+// FormatDailyLine returns today's and yesterday's token totals, e.g.
+// "Today: 2.1M, Yesterday: 5.4M". Yesterday is omitted on the first day of
+// the weekly window, since it belongs to the previous one.
+func FormatDailyLine(weeklyStats *stats.WeeklyStats) string {
+	now := time.Now()
+	line := "Today: " + Tokens(weeklyStats.TokensOn(now))
+	if days := weeklyStats.DailyTokens; len(days) > 0 && stats.FormatDate(days[0].Date) != stats.FormatDate(now) {
+		line += ", Yesterday: " + Tokens(weeklyStats.TokensOn(now.AddDate(0, 0, -1)))
 	}
-
-	// Status (throttled warning)
-	if weeklyStats.IsThrottled() {
-		sb.WriteString("STATUS: THROTTLED\n")
+	if accessible.Load() {
+		line += " tokens"
 	}
+	return line
+}
 
-	// Rate Limit Section
-	if weeklyStats.HasAPIData {
-		// 5-hour window
-		fiveHourPct := weeklyStats.GetFiveHourPercentage()
-		fiveHourBar := makeProgressBar(fiveHourPct, 10)
-		fiveHourReset := formatShortDuration(time.Until(weeklyStats.FiveHourReset))
-		marker := ""
-		if weeklyStats.IsLimitedByFiveHour() {
-			marker = " ◀"
-		}
-		sb.WriteString(fmt.Sprintf("%s %3d%% %s%s\n", fiveHourBar, fiveHourPct, fiveHourReset, marker))
-
-		// Weekly window
-		weeklyPct := weeklyStats.GetPercentage()
-		weeklyBar := makeProgressBar(weeklyPct, 10)
-		weeklyReset := formatShortDuration(time.Until(weeklyStats.WeeklyReset))
-		marker = ""
-		if !weeklyStats.IsLimitedByFiveHour() {
-			marker = " ◀"
-		}
-		sb.WriteString(fmt.Sprintf("%s %3d%% %s%s\n", weeklyBar, weeklyPct, weeklyReset, marker))
-
-		// Show model-specific limits if available
-		if weeklyStats.OpusUtilization > 0 {
-			opusPct := int(weeklyStats.OpusUtilization * 100)
-			opusBar := makeProgressBar(opusPct, 10)
-			opusReset := formatShortDuration(time.Until(weeklyStats.OpusReset))
-			sb.WriteString(fmt.Sprintf("%s %3d%% %s\n", opusBar, opusPct, opusReset))
-		}
-		if weeklyStats.SonnetUtilization > 0 {
-			sonnetPct := int(weeklyStats.SonnetUtilization * 100)
-			sonnetBar := makeProgressBar(sonnetPct, 10)
-			sonnetReset := formatShortDuration(time.Until(weeklyStats.SonnetReset))
-			sb.WriteString(fmt.Sprintf("%s %3d%% %s\n", sonnetBar, sonnetPct, sonnetReset))
+// formatChange formats a relative change in percent with its sign, e.g.
+// "+14%", or "up 14 percent" with accessible text.
+func formatChange(percent float64) string {
+	points := int(math.Round(percent))
+	if accessible.Load() {
+		if points < 0 {
+			return "down " + Percent(-points)
 		}
-	} else {
-		// Show estimated usage based on token counts
-		weeklyPct := weeklyStats.GetPercentage()
-		weeklyBar := makeProgressBar(weeklyPct, 10)
-		daysRemaining := stats.GetDaysRemainingInWeek()
-		resetStr := fmt.Sprintf("%dd", daysRemaining)
-		sb.WriteString(fmt.Sprintf("%s ~%3d%% %s\n", weeklyBar, weeklyPct, resetStr))
+		return "up " + Percent(points)
 	}
+	return format.FormatSignedPercent(points)
+}
 
-	return strings.TrimRight(sb.String(), "\n")
+// formatPace formats a PaceDelta as "20% ahead", "5% behind" or "on track".
+func formatPace(delta float64) string {
+	points := int(math.Round(delta))
+	switch {
+	case points > 0:
+		return Percent(points) + " ahead"
+	case points < 0:
+		return Percent(-points) + " behind"
+	}
+	return "on track"
 }
 
 // formatShortDuration formats a duration as compact "Xh Ym" or "Xd Yh" format.
@@ -96,23 +74,47 @@ func formatShortDuration(d time.Duration) string {
 	return fmt.Sprintf("%dh %dm", hours, minutes)
 }
 
-// makeProgressBar creates a text-based progress bar using Unicode block characters.
-func makeProgressBar(percentage int, width int) string {
-	if percentage < 0 {
-		percentage = 0
+// formatAge formats how long ago something happened, e.g. "23m", "2h 5m" or "1d 3h".
+func formatAge(d time.Duration) string {
+	if d < time.Minute {
+		return "<1m"
+	}
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
 	}
-	if percentage > 100 {
-		percentage = 100
+	return formatShortDuration(d)
+}
+
+// FormatStaleLine returns the tooltip's first line for data of the given
+// age, e.g. "⚠ data 47m old", or "Warning: data 47 minutes old" with
+// accessible text.
+func FormatStaleLine(age time.Duration) string {
+	if accessible.Load() {
+		return "Warning: data " + spokenDuration(age) + " old"
 	}
+	return "⚠ data " + formatAge(age) + " old"
+}
 
-	filled := (percentage * width) / 100
-	empty := width - filled
+// FormatBudgetLine returns the tooltip line for a personal weekly budget
+// of tokens or usd (see config.Budget), e.g. "Budget: 62% of 5.0M tokens"
+// or "Budget: 62% of $50 (≈$31.00)".
+func FormatBudgetLine(weeklyStats *stats.WeeklyStats, tokens int64, usd float64) string {
+	line := "Budget: " + Percent(int(weeklyStats.BudgetPercentage(tokens, usd))) + " of "
+	if tokens > 0 {
+		return line + Tokens(tokens) + " tokens"
+	}
+	return line + format.FormatDollars(usd) + " (" + approxDollars(weeklyStats.WeekCostUSD) + ")"
+}
 
-	return "▕" + strings.Repeat("█", filled) + strings.Repeat("░", empty) + "▏"
+// makeProgressBar creates a text-based progress bar in the configured
+// style (see SetASCIIBars).
+func makeProgressBar(percentage int, width int) string {
+	return tooltiptmpl.Bar(percentage, width)
 }
 
-// FormatTooltipCompact creates a condensed tooltip for Windows (127 char limit).
-// Shows only 5-hour and weekly bars, skips Opus/Sonnet to fit within Windows tooltip limit.
+// FormatTooltipCompact creates a condensed tooltip for Windows (127 char
+// limit) and other hosts that cut tooltips short (see LayoutCompact).
+// Shows only 5-hour and weekly bars, skipping Opus/Sonnet to fit.
 func FormatTooltipCompact(weeklyStats *stats.WeeklyStats) string {
 	if weeklyStats == nil {
 		return "Claude Usage\nNo data"
@@ -132,35 +134,42 @@ func FormatTooltipCompact(weeklyStats *stats.WeeklyStats) string {
 	if weeklyStats.IsThrottled() {
 		sb.WriteString("THROTTLED\n")
 	}
+	if weeklyStats.APIDataStale {
+		sb.WriteString(fmt.Sprintf("Stale %s ago\n", formatAge(time.Since(weeklyStats.APIFetchedAt))))
+	}
 
 	// Rate Limit Section - only 5-hour and weekly (skip Opus/Sonnet)
 	if weeklyStats.HasAPIData {
 		// 5-hour window - shorter bar (6 chars) and shorter time format
 		fiveHourPct := weeklyStats.GetFiveHourPercentage()
 		fiveHourBar := makeProgressBar(fiveHourPct, 6)
-		fiveHourReset := formatVeryShortDuration(time.Until(weeklyStats.FiveHourReset))
+		fiveHourReset := formatResetCompact(weeklyStats.FiveHourReset)
 		marker := ""
 		if weeklyStats.IsLimitedByFiveHour() {
 			marker = " ◀"
 		}
-		sb.WriteString(fmt.Sprintf("%s %3d%% %s%s\n", fiveHourBar, fiveHourPct, fiveHourReset, marker))
+		sb.WriteString(fmt.Sprintf("%s %4s %s%s\n", fiveHourBar, format.FormatPercent(fiveHourPct), fiveHourReset, marker))
 
 		// Weekly window - shorter bar (6 chars) and shorter time format
 		weeklyPct := weeklyStats.GetPercentage()
 		weeklyBar := makeProgressBar(weeklyPct, 6)
-		weeklyReset := formatVeryShortDuration(time.Until(weeklyStats.WeeklyReset))
+		weeklyReset := formatResetCompact(weeklyStats.WeeklyReset)
 		marker = ""
 		if !weeklyStats.IsLimitedByFiveHour() {
 			marker = " ◀"
 		}
-		sb.WriteString(fmt.Sprintf("%s %3d%% %s%s", weeklyBar, weeklyPct, weeklyReset, marker))
+		sb.WriteString(fmt.Sprintf("%s %4s %s%s", weeklyBar, format.FormatPercent(weeklyPct), weeklyReset, marker))
 	} else {
 		// Show estimated usage based on token counts
 		weeklyPct := weeklyStats.GetPercentage()
 		weeklyBar := makeProgressBar(weeklyPct, 6)
-		daysRemaining := stats.GetDaysRemainingInWeek()
+		daysRemaining := weeklyStats.DaysRemaining()
 		resetStr := fmt.Sprintf("%dd", daysRemaining)
-		sb.WriteString(fmt.Sprintf("%s ~%3d%% %s", weeklyBar, weeklyPct, resetStr))
+		sb.WriteString(fmt.Sprintf("%s ~%4s %s", weeklyBar, format.FormatPercent(weeklyPct), resetStr))
+		if !weeklyStats.SessionReset.IsZero() {
+			sb.WriteString(fmt.Sprintf("\n5h %s %s", format.FormatTokens(weeklyStats.SessionTokens),
+				formatResetCompact(weeklyStats.SessionReset)))
+		}
 	}
 
 	return sb.String()
@@ -189,11 +198,27 @@ func formatVeryShortDuration(d time.Duration) string {
 	return fmt.Sprintf("%dm", minutes)
 }
 
-// FormatTooltipForPlatform returns the appropriate tooltip format based on OS.
-// Windows gets a compact version (127 char limit), other platforms get full version.
+// FormatTooltipForPlatform returns the tooltip in the layout chosen with
+// SetTooltipLayout, usually DetectTooltipLayout's for the platform and
+// desktop, written as sentences with accessible text (see SetAccessible).
+// A custom layout set with SetTooltipTemplate is used on every platform.
 func FormatTooltipForPlatform(weeklyStats *stats.WeeklyStats) string {
-	if runtime.GOOS == "windows" {
+	customTooltipMu.RLock()
+	custom := customTooltip
+	customTooltipMu.RUnlock()
+	if custom != nil {
+		return executeTooltip(custom, weeklyStats)
+	}
+
+	layout := TooltipLayout(tooltipLayout.Load())
+	if accessible.Load() {
+		return FormatTooltipAccessible(weeklyStats, layout == LayoutCompact)
+	}
+	switch layout {
+	case LayoutCompact:
 		return FormatTooltipCompact(weeklyStats)
+	case LayoutMedium:
+		return executeTooltip(mediumTooltip, weeklyStats)
 	}
 	return FormatTooltip(weeklyStats)
 }
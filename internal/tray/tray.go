@@ -1,18 +1,61 @@
 package tray
 
 import (
+	"sync"
+	"time"
+
 	"fyne.io/systray"
+
+	"claude-usage/pkg/format"
 )
 
+// countdownInterval is how often the reset countdown menu items are refreshed.
+const countdownInterval = time.Minute
+
 // Tray manages the system tray icon and interactions.
 type Tray struct {
 	menuItems         *MenuItems
 	version           string
+	profile           string
 	sourceDisplayName string
+	onRetry           func()
+	retry             bool
 	onRefresh         func()
+	onCopyUsage       func()
+	onExportData      func()
+	onExportChart     func()
+	onOpenUsage       func()
+	onOpenConfig      func()
+	onOpenLog         func()
 	onUpdate          func()
+	onRollback        func()
+	rollbackAvailable bool
+	onEditConfig      func()
+	onAutostart       func()
+	autostart         bool
+	onTeamUsage       func()
+	teamAvailable     bool
+	teamUsage         bool
+	onTelemetry       func()
+	telemetry         bool
+	onNotifyErrors    func()
+	onNotifyWeek      func()
+	notifyErrors      bool
+	notifyWeek        bool
 	onSourceToggle    func()
 	onQuit            func()
+	onExit            func()
+	onRefreshInterval func(time.Duration)
+	refreshInterval   time.Duration
+	onIconMetric      func(string)
+	iconMetric        string
+	onAlertThresholds func([]int)
+	alertThresholds   []int
+
+	// Reset times shown in the countdown menu items
+	resetMu       sync.Mutex
+	fiveHourReset time.Time
+	weeklyReset   time.Time
 }
 
 // New creates a new Tray manager with the given version string and source display name.
@@ -23,16 +66,109 @@ func New(version string, sourceDisplayName string) *Tray {
 	}
 }
 
+// SetProfile sets the active profile name shown in the menu. Must be called before Run.
+func (t *Tray) SetProfile(profile string) {
+	t.profile = profile
+}
+
+// SetOnRetry sets the callback for the Retry Now menu item.
+func (t *Tray) SetOnRetry(fn func()) {
+	t.onRetry = fn
+}
+
 // SetOnRefresh sets the callback for the Refresh menu item.
 func (t *Tray) SetOnRefresh(fn func()) {
 	t.onRefresh = fn
 }
 
+// SetOnCopyUsage sets the callback for the Copy Usage menu item.
+func (t *Tray) SetOnCopyUsage(fn func()) {
+	t.onCopyUsage = fn
+}
+
+// SetOnExportData sets the callback for the Export Data menu item.
+func (t *Tray) SetOnExportData(fn func()) {
+	t.onExportData = fn
+}
+
+// SetOnExportChart sets the callback for the Export Chart menu item.
+func (t *Tray) SetOnExportChart(fn func()) {
+	t.onExportChart = fn
+}
+
+// SetOnOpenUsage sets the callback for the Open Usage Page menu item.
+func (t *Tray) SetOnOpenUsage(fn func()) {
+	t.onOpenUsage = fn
+}
+
+// SetOnOpenConfig sets the callback for the Open Config Folder menu item.
+func (t *Tray) SetOnOpenConfig(fn func()) {
+	t.onOpenConfig = fn
+}
+
+// SetOnOpenLog sets the callback for the Open Log File menu item.
+func (t *Tray) SetOnOpenLog(fn func()) {
+	t.onOpenLog = fn
+}
+
 // SetOnUpdate sets the callback for the Update menu item.
 func (t *Tray) SetOnUpdate(fn func()) {
 	t.onUpdate = fn
 }
 
+// SetOnRollback sets the callback for the Rollback Last Update menu item.
+func (t *Tray) SetOnRollback(fn func()) {
+	t.onRollback = fn
+}
+
+// SetOnEditConfig sets the callback for the Settings > Edit Config File menu item.
+func (t *Tray) SetOnEditConfig(fn func()) {
+	t.onEditConfig = fn
+}
+
+// SetOnAutostart sets the callback for the Settings > Start at Login menu item.
+func (t *Tray) SetOnAutostart(fn func()) {
+	t.onAutostart = fn
+}
+
+// SetOnTeamUsage sets the callback for the Settings > Show Team Usage menu item.
+func (t *Tray) SetOnTeamUsage(fn func()) {
+	t.onTeamUsage = fn
+}
+
+// SetOnTelemetry sets the callback for the Settings > Share Anonymous
+// Statistics menu item.
+func (t *Tray) SetOnTelemetry(fn func()) {
+	t.onTelemetry = fn
+}
+
+// SetOnNotifyErrors sets the callback for the Settings > Notifications >
+// Refresh Errors menu item.
+func (t *Tray) SetOnNotifyErrors(fn func()) {
+	t.onNotifyErrors = fn
+}
+
+// SetOnNotifyWeek sets the callback for the Settings > Notifications >
+// New Usage Week menu item.
+func (t *Tray) SetOnNotifyWeek(fn func()) {
+	t.onNotifyWeek = fn
+}
+
+// SetOnRefreshInterval sets the callback for the Settings > Refresh Interval menu items.
+func (t *Tray) SetOnRefreshInterval(fn func(time.Duration)) {
+	t.onRefreshInterval = fn
+}
+
+// SetOnIconMetric sets the callback for the Settings > Icon Metric menu items.
+func (t *Tray) SetOnIconMetric(fn func(string)) {
+	t.onIconMetric = fn
+}
+
+// SetOnAlertThresholds sets the callback for the Settings > Alert Thresholds menu items.
+func (t *Tray) SetOnAlertThresholds(fn func([]int)) {
+	t.onAlertThresholds = fn
+}
+
 // SetOnSourceToggle sets the callback for the Source toggle menu item (Linux only).
 func (t *Tray) SetOnSourceToggle(fn func()) {
 	t.onSourceToggle = fn
@@ -43,6 +179,12 @@ func (t *Tray) SetOnQuit(fn func()) {
 	t.onQuit = fn
 }
 
+// SetOnExit sets the callback run when the tray shuts down, including when
+// the session ends on Windows.
+func (t *Tray) SetOnExit(fn func()) {
+	t.onExit = fn
+}
+
 // Run starts the system tray. This blocks until Quit is called.
 // onReady is called when the tray is initialized and ready.
 func (t *Tray) Run(onReady func()) {
@@ -51,23 +193,59 @@ func (t *Tray) Run(onReady func()) {
 		systray.SetTitle("")
 		systray.SetTooltip("Claude Usage - Loading...")
 
-		// Setup menu with version and source
-		t.menuItems = SetupMenu(t.version, t.sourceDisplayName)
+		// Setup menu with version, profile and source
+		t.menuItems = SetupMenu(t.version, t.profile, t.sourceDisplayName)
+		t.menuItems.SetRefreshInterval(t.refreshInterval)
+		t.menuItems.SetIconMetric(t.iconMetric)
+		t.menuItems.SetAlertThresholds(t.alertThresholds)
+		t.menuItems.SetNotifications(t.notifyErrors, t.notifyWeek)
+		t.menuItems.SetAutostart(t.autostart)
+		t.menuItems.SetTeamUsage(t.teamAvailable, t.teamUsage)
+		t.menuItems.SetTelemetry(t.telemetry)
+		t.menuItems.SetRollbackAvailable(t.rollbackAvailable)
+		t.menuItems.SetRetry(t.retry)
 
 		// Handle menu events
-		HandleMenuEvents(t.menuItems, t.onRefresh, t.onUpdate, t.onSourceToggle, func() {
-			if t.onQuit != nil {
-				t.onQuit()
-			}
-			systray.Quit()
+		HandleMenuEvents(t.menuItems, MenuHandlers{
+			OnRetry:           t.onRetry,
+			OnRefresh:         t.onRefresh,
+			OnCopyUsage:       t.onCopyUsage,
+			OnExportData:      t.onExportData,
+			OnExportChart:     t.onExportChart,
+			OnOpenUsage:       t.onOpenUsage,
+			OnOpenConfig:      t.onOpenConfig,
+			OnOpenLog:         t.onOpenLog,
+			OnUpdate:          t.onUpdate,
+			OnRollback:        t.onRollback,
+			OnEditConfig:      t.onEditConfig,
+			OnAutostart:       t.onAutostart,
+			OnTeamUsage:       t.onTeamUsage,
+			OnTelemetry:       t.onTelemetry,
+			OnNotifyErrors:    t.onNotifyErrors,
+			OnNotifyWeek:      t.onNotifyWeek,
+			OnSourceToggle:    t.onSourceToggle,
+			OnRefreshInterval: t.onRefreshInterval,
+			OnIconMetric:      t.onIconMetric,
+			OnAlertThresholds: t.onAlertThresholds,
+			OnQuit: func() {
+				if t.onQuit != nil {
+					t.onQuit()
+				}
+				systray.Quit()
+			},
 		})
 
+		// Keep the reset countdowns ticking between refreshes
+		go t.countdownLoop()
+
 		// Call ready callback
 		if onReady != nil {
 			onReady()
 		}
 	}, func() {
-		// onExit callback - cleanup if needed
+		if t.onExit != nil {
+			t.onExit()
+		}
 	})
 }
 
@@ -79,6 +257,130 @@ func (t *Tray) UpdateSourceToggle(sourceDisplayName string) {
 	}
 }
 
+// SetRefreshInterval marks d as the current refresh interval in the Settings submenu.
+func (t *Tray) SetRefreshInterval(d time.Duration) {
+	t.refreshInterval = d
+	if t.menuItems != nil {
+		t.menuItems.SetRefreshInterval(d)
+	}
+}
+
+// SetIconMetric marks metric as the current icon metric in the Settings submenu.
+func (t *Tray) SetIconMetric(metric string) {
+	t.iconMetric = metric
+	if t.menuItems != nil {
+		t.menuItems.SetIconMetric(metric)
+	}
+}
+
+// SetAlertThresholds marks thresholds as the current alert thresholds in the
+// Settings submenu.
+func (t *Tray) SetAlertThresholds(thresholds []int) {
+	t.alertThresholds = thresholds
+	if t.menuItems != nil {
+		t.menuItems.SetAlertThresholds(thresholds)
+	}
+}
+
+// SetNotifications sets the checked state of the Settings > Notifications
+// menu items.
+func (t *Tray) SetNotifications(refreshErrors, weekStart bool) {
+	t.notifyErrors, t.notifyWeek = refreshErrors, weekStart
+	if t.menuItems != nil {
+		t.menuItems.SetNotifications(refreshErrors, weekStart)
+	}
+}
+
+// SetAutostart sets the checked state of the Start at Login menu item.
+func (t *Tray) SetAutostart(enabled bool) {
+	t.autostart = enabled
+	if t.menuItems != nil {
+		t.menuItems.SetAutostart(enabled)
+	}
+}
+
+// SetTeamUsage shows the Show Team Usage menu item when available, checked
+// when enabled.
+func (t *Tray) SetTeamUsage(available, enabled bool) {
+	t.teamAvailable, t.teamUsage = available, enabled
+	if t.menuItems != nil {
+		t.menuItems.SetTeamUsage(available, enabled)
+	}
+}
+
+// SetTelemetry sets the checked state of the Share Anonymous Statistics
+// menu item.
+func (t *Tray) SetTelemetry(enabled bool) {
+	t.telemetry = enabled
+	if t.menuItems != nil {
+		t.menuItems.SetTelemetry(enabled)
+	}
+}
+
+// SetRetry shows the Retry Now menu item while refreshes fail.
+func (t *Tray) SetRetry(visible bool) {
+	t.retry = visible
+	if t.menuItems != nil {
+		t.menuItems.SetRetry(visible)
+	}
+}
+
+// SetRollbackAvailable shows or hides the Rollback Last Update menu item.
+func (t *Tray) SetRollbackAvailable(available bool) {
+	t.rollbackAvailable = available
+	if t.menuItems != nil {
+		t.menuItems.SetRollbackAvailable(available)
+	}
+}
+
+// SetResetTimes sets the reset times shown in the countdown menu items.
+// Pass zero times to hide the countdowns (e.g. when no API data is available).
+func (t *Tray) SetResetTimes(fiveHourReset, weeklyReset time.Time) {
+	t.resetMu.Lock()
+	t.fiveHourReset = fiveHourReset
+	t.weeklyReset = weeklyReset
+	t.resetMu.Unlock()
+
+	t.updateCountdowns()
+}
+
+// countdownLoop refreshes the countdown menu items every minute.
+func (t *Tray) countdownLoop() {
+	ticker := time.NewTicker(countdownInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		t.updateCountdowns()
+	}
+}
+
+// updateCountdowns re-renders the countdown menu items from the stored reset times.
+func (t *Tray) updateCountdowns() {
+	if t.menuItems == nil {
+		return
+	}
+
+	t.resetMu.Lock()
+	fiveHourReset, weeklyReset := t.fiveHourReset, t.weeklyReset
+	t.resetMu.Unlock()
+
+	t.menuItems.UpdateCountdowns(fiveHourReset, weeklyReset)
+}
+
+// SetStatistics sets the lines shown in the Statistics submenu.
+func (t *Tray) SetStatistics(lines []string) {
+	if t.menuItems != nil {
+		t.menuItems.SetStatistics(lines)
+	}
+}
+
+// SetDiagnostics sets the lines shown in the Diagnostics submenu.
+func (t *Tray) SetDiagnostics(lines []string) {
+	if t.menuItems != nil {
+		t.menuItems.SetDiagnostics(lines)
+	}
+}
+
 // SetIcon sets the tray icon from PNG bytes.
 func (t *Tray) SetIcon(iconBytes []byte) {
 	systray.SetIcon(iconBytes)
@@ -94,12 +396,45 @@ func (t *Tray) Quit() {
 	systray.Quit()
 }
 
-// SetUpdateComplete marks the update as complete and changes the menu item text.
-// The menu item is disabled since the user needs to restart.
+// SetUpdateAvailable labels the Update menu item with the newer version;
+// its tooltip also carries a summary of the release notes.
+func (t *Tray) SetUpdateAvailable(version, summary string) {
+	if t.menuItems != nil && t.menuItems.Update != nil {
+		t.menuItems.Update.SetTitle("Update available: " + version)
+		tooltip := "Download and install " + version
+		if summary != "" {
+			tooltip += "\n" + summary
+		}
+		t.menuItems.Update.SetTooltip(tooltip)
+	}
+}
+
+// SetUpdateProgress shows download progress on the Update menu item.
+// A negative percent means the size is unknown.
+func (t *Tray) SetUpdateProgress(percent int) {
+	if t.menuItems != nil && t.menuItems.Update != nil {
+		if percent < 0 {
+			t.menuItems.Update.SetTitle("Downloading update…")
+		} else {
+			t.menuItems.Update.SetTitle("Downloading update… " + format.FormatPercent(percent))
+		}
+	}
+}
+
+// SetUpToDate labels the Update menu item as up to date.
+// Clicking it checks again.
+func (t *Tray) SetUpToDate() {
+	if t.menuItems != nil && t.menuItems.Update != nil {
+		t.menuItems.Update.SetTitle("Up to date")
+		t.menuItems.Update.SetTooltip("Check for a newer version")
+	}
+}
+
+// SetUpdateComplete marks the update as complete. The menu item becomes
+// "Restart Now", which restarts into the new version when clicked.
 func (t *Tray) SetUpdateComplete() {
 	if t.menuItems != nil && t.menuItems.Update != nil {
-		t.menuItems.Update.SetTitle("Restart Required")
-		t.menuItems.Update.SetTooltip("Update downloaded. Please restart the application.")
-		t.menuItems.Update.Disable()
+		t.menuItems.Update.SetTitle("Restart Now")
+		t.menuItems.Update.SetTooltip("Update downloaded. Restart to use the new version.")
 	}
 }
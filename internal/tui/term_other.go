@@ -0,0 +1,19 @@
+//go:build !linux && !darwin && !windows
+
+package tui
+
+import "errors"
+
+// makeRaw fails: raw terminal mode is only implemented for Linux, macOS
+// and Windows.
+func makeRaw(uintptr) (func(), error) {
+	return nil, errors.New("the live view is not supported on this platform")
+}
+
+// enableANSI does nothing.
+func enableANSI(uintptr) {}
+
+// termSize returns the default 80x24.
+func termSize(uintptr) (width, height int) {
+	return 80, 24
+}
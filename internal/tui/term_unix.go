@@ -0,0 +1,40 @@
+//go:build linux || darwin
+
+package tui
+
+import "golang.org/x/sys/unix"
+
+// makeRaw puts the terminal on fd into raw mode, so keys arrive unbuffered
+// and unechoed, and returns a function restoring the previous mode. It
+// fails when fd is not a terminal.
+func makeRaw(fd uintptr) (restore func(), err error) {
+	old, err := unix.IoctlGetTermios(int(fd), ioctlGetTermios)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := *old
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Oflag &^= unix.OPOST
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(int(fd), ioctlSetTermios, &raw); err != nil {
+		return nil, err
+	}
+	return func() { unix.IoctlSetTermios(int(fd), ioctlSetTermios, old) }, nil
+}
+
+// enableANSI is a no-op: Unix terminals understand escape sequences.
+func enableANSI(uintptr) {}
+
+// termSize returns the size of the terminal on fd, or 80x24 when unknown.
+func termSize(fd uintptr) (width, height int) {
+	ws, err := unix.IoctlGetWinsize(int(fd), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row == 0 {
+		return 80, 24
+	}
+	return int(ws.Col), int(ws.Row)
+}
@@ -0,0 +1,36 @@
+package tui
+
+import "golang.org/x/sys/windows"
+
+// makeRaw switches the console input on fd to unbuffered, unechoed virtual
+// terminal input and returns a function restoring the previous mode. It
+// fails when fd is not a console.
+func makeRaw(fd uintptr) (restore func(), err error) {
+	var old uint32
+	if err := windows.GetConsoleMode(windows.Handle(fd), &old); err != nil {
+		return nil, err
+	}
+	raw := old&^(windows.ENABLE_LINE_INPUT|windows.ENABLE_ECHO_INPUT|windows.ENABLE_PROCESSED_INPUT) | windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+	if err := windows.SetConsoleMode(windows.Handle(fd), raw); err != nil {
+		return nil, err
+	}
+	return func() { windows.SetConsoleMode(windows.Handle(fd), old) }, nil
+}
+
+// enableANSI makes the console output on fd interpret escape sequences.
+func enableANSI(fd uintptr) {
+	var mode uint32
+	if windows.GetConsoleMode(windows.Handle(fd), &mode) == nil {
+		windows.SetConsoleMode(windows.Handle(fd), mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+	}
+}
+
+// termSize returns the size of the console window on fd, or 80x24 when
+// unknown.
+func termSize(fd uintptr) (width, height int) {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(fd), &info); err != nil {
+		return 80, 24
+	}
+	return int(info.Window.Right-info.Window.Left) + 1, int(info.Window.Bottom-info.Window.Top) + 1
+}
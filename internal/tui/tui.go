@@ -0,0 +1,137 @@
+// Package tui is a full-screen live view of usage for terminals, such as
+// SSH sessions, where no system tray exists. It loads usage itself the way
+// the tray app does and redraws every second so reset countdowns tick.
+//
+// The view is drawn with plain ANSI escape sequences; keys are read with
+// the terminal in raw mode.
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/planlimits"
+	"claude-usage/internal/stats"
+	"claude-usage/internal/usage"
+	"claude-usage/pkg/format"
+)
+
+// Escape sequences for the alternate screen, the cursor and line wrapping.
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l\x1b[?7l"
+	leaveScreen = "\x1b[?7h\x1b[?25h\x1b[?1049l"
+	home        = "\x1b[H"
+	clearLine   = "\x1b[K"
+	clearBelow  = "\x1b[J"
+)
+
+// redrawInterval is how often the screen is redrawn between loads.
+const redrawInterval = time.Second
+
+// ErrNotTerminal is returned by Run when stdin or stdout is not a terminal.
+var ErrNotTerminal = errors.New("the live view needs an interactive terminal")
+
+// Run shows the live view on the terminal until q or Ctrl+C is pressed or
+// ctx is done. Usage is reloaded every refresh interval and on r.
+func Run(ctx context.Context, cfg *config.Config) error {
+	in, out := os.Stdin, os.Stdout
+	restore, err := makeRaw(in.Fd())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotTerminal, err)
+	}
+	defer restore()
+	enableANSI(out.Fd())
+
+	// Log lines from the API client would scribble over the view
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	fmt.Fprint(out, enterScreen)
+	defer fmt.Fprint(out, leaveScreen)
+
+	stats.SetWeekStart(cfg.WeekStart())
+	planlimits.Apply(cfg)
+	format.SetLocale(cfg.Locale)
+	stats.SetModelNames(cfg.ModelNames)
+	l := usage.NewLoader(cfg)
+	v := view{profile: cfg.Profile, ascii: cfg.BarStyle == config.BarASCII}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	keys := readKeys(in)
+	loaded := make(chan usage.Usage, 1)
+	reload := func() {
+		if v.loading {
+			return
+		}
+		v.loading = true
+		go func() { loaded <- l.Load(ctx) }()
+	}
+
+	draw := func() {
+		width, height := termSize(out.Fd())
+		lines := v.render(width, height, time.Now())
+		fmt.Fprint(out, home+strings.Join(lines, clearLine+"\r\n")+clearLine+clearBelow)
+	}
+
+	reload()
+	draw()
+	redraw := time.NewTicker(redrawInterval)
+	defer redraw.Stop()
+	refresh := time.NewTicker(cfg.RefreshInterval)
+	defer refresh.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case key, ok := <-keys:
+			switch {
+			case !ok || key == 'q' || key == 'Q' || key == ctrlC || key == ctrlD:
+				return nil
+			case key == 'r' || key == 'R':
+				reload()
+				refresh.Reset(cfg.RefreshInterval)
+			}
+		case u := <-loaded:
+			v.Usage = u
+			v.loading = false
+		case <-refresh.C:
+			reload()
+		case <-redraw.C:
+		}
+		draw()
+	}
+}
+
+// Control keys that quit the view; raw mode delivers them as bytes.
+const (
+	ctrlC = 3
+	ctrlD = 4
+)
+
+// readKeys delivers the bytes read from r until it fails.
+func readKeys(r io.Reader) <-chan byte {
+	keys := make(chan byte)
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 16)
+		for {
+			n, err := r.Read(buf)
+			for _, b := range buf[:n] {
+				keys <- b
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return keys
+}
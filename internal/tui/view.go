@@ -0,0 +1,286 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"claude-usage/internal/history"
+	"claude-usage/internal/icon"
+	"claude-usage/internal/stats"
+	"claude-usage/internal/usage"
+	"claude-usage/pkg/format"
+)
+
+// ANSI escape sequences used by the view.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiOrange = "\x1b[38;5;208m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// sparkBlocks are the sparkline glyphs, lowest first.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// labelWidth is the width of the label column.
+const labelWidth = 11
+
+// view is what the screen shows.
+type view struct {
+	usage.Usage
+
+	// profile is the active config profile, empty for the default
+	profile string
+
+	// loading is set while a load is in flight
+	loading bool
+
+	// ascii draws bars with ASCII characters
+	ascii bool
+}
+
+// render returns the screen's lines for a terminal width columns wide,
+// at most height lines. Lines may contain color escapes.
+func (v view) render(width, height int, now time.Time) []string {
+	var lines []string
+	add := func(format string, args ...any) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	lines = append(lines, v.header(width, now), "")
+
+	w := v.Stats
+	if w == nil {
+		switch {
+		case v.Err != nil:
+			add(ansiRed+"%s"+ansiReset, v.Err)
+		default:
+			add("Loading usage…")
+		}
+		return v.finish(lines, width, height)
+	}
+	if v.Err != nil {
+		add(ansiYellow+"! %s"+ansiReset, v.Err)
+	}
+	if v.Warning != "" {
+		add(ansiYellow+"! %s"+ansiReset, v.Warning)
+	}
+	if v.Err != nil || v.Warning != "" {
+		lines = append(lines, "")
+	}
+
+	barWidth := min(max(width-labelWidth-30, 10), 40)
+	if w.HasAPIData {
+		lines = append(lines, v.window("5-hour", w.FiveHourUtilization, w.FiveHourReset, barWidth, now))
+		lines = append(lines, v.window("Weekly", w.WeeklyUtilization, w.WeeklyReset, barWidth, now))
+		for _, model := range []struct {
+			name        string
+			utilization float64
+			reset       time.Time
+		}{
+			{"Opus", w.OpusUtilization, w.OpusReset},
+			{"Sonnet", w.SonnetUtilization, w.SonnetReset},
+			{"OAuth apps", w.OAuthAppsUtilization, w.OAuthAppsReset},
+			{"Cowork", w.CoworkUtilization, w.CoworkReset},
+		} {
+			if model.utilization > 0 {
+				lines = append(lines, v.window(model.name, model.utilization, model.reset, barWidth, now))
+			}
+		}
+		if extra := w.ExtraUsageText(); extra != "" {
+			add("%-*s %s", labelWidth, "Extra", extra)
+		}
+		if w.IsThrottled() {
+			add(ansiRed + ansiBold + "THROTTLED" + ansiReset)
+		}
+	} else {
+		pct := w.GetPercentage()
+		add("%-*s %s %4s estimated, %dd left", labelWidth, "Weekly",
+			colorize(format.FormatProgressBar(pct, barWidth, v.ascii), pct), format.FormatPercent(pct), w.DaysRemaining())
+		if !w.SessionReset.IsZero() {
+			add("%-*s %s tokens, resets in %s", labelWidth, "5-hour",
+				format.FormatTokens(w.SessionTokens), countdown(w.SessionReset.Sub(now)))
+		}
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, v.models(barWidth)...)
+
+	if trend := v.trend(width - labelWidth - 1); trend != "" {
+		lines = append(lines, "", fmt.Sprintf("%-*s %s", labelWidth, "Last 24h", trend))
+	}
+	if weeks := v.recentWeeks(barWidth); len(weeks) > 0 {
+		lines = append(lines, "", ansiBold+"Recent weeks"+ansiReset)
+		lines = append(lines, weeks...)
+	}
+	return v.finish(lines, width, height)
+}
+
+// header returns the title line, with the data's age on the right.
+func (v view) header(width int, now time.Time) string {
+	title := "Claude Usage"
+	if w := v.Stats; w != nil && w.SubscriptionType != "" {
+		title += " · " + format.FormatPlanName(w.SubscriptionType, w.RateLimitTier)
+	}
+	if v.profile != "" {
+		title += " · " + v.profile
+	}
+
+	var age string
+	switch {
+	case v.loading:
+		age = "refreshing…"
+	case v.Stats != nil && v.Stats.APIDataStale:
+		age = "data from " + format.FormatDuration(int64(now.Sub(v.Stats.APIFetchedAt).Seconds())) + " ago"
+	case !v.At.IsZero():
+		age = "updated " + format.FormatDuration(int64(now.Sub(v.At).Seconds())) + " ago"
+	}
+	pad := max(1, width-utf8.RuneCountInString(title)-utf8.RuneCountInString(age))
+	return ansiBold + ansiCyan + title + ansiReset + strings.Repeat(" ", pad) + ansiDim + age + ansiReset
+}
+
+// window returns the line for a usage window: its bar, percentage and
+// reset countdown.
+func (v view) window(label string, utilization float64, reset time.Time, barWidth int, now time.Time) string {
+	pct := int(utilization * 100)
+	line := fmt.Sprintf("%-*s %s %4s", labelWidth, label, colorize(format.FormatProgressBar(pct, barWidth, v.ascii), pct), format.FormatPercent(pct))
+	if !reset.IsZero() {
+		line += "   resets in " + countdown(reset.Sub(now))
+	}
+	return line
+}
+
+// models returns the per-model token table, largest first.
+func (v view) models(barWidth int) []string {
+	w := v.Stats
+	if len(w.TokensByModel) == 0 {
+		return []string{fmt.Sprintf("%-*s %s", labelWidth, "Tokens", format.FormatTokens(w.TotalTokens))}
+	}
+
+	models := make([]string, 0, len(w.TokensByModel))
+	for model := range w.TokensByModel {
+		models = append(models, model)
+	}
+	sort.Slice(models, func(i, j int) bool { return w.TokensByModel[models[i]] > w.TokensByModel[models[j]] })
+
+	lines := []string{fmt.Sprintf(ansiBold+"%-*s %*s  %s"+ansiReset, labelWidth+10, "Model", 8, "Tokens", "Share")}
+	for _, model := range models {
+		tokens := w.TokensByModel[model]
+		share := 0
+		if w.TotalTokens > 0 {
+			share = int(tokens * 100 / w.TotalTokens)
+		}
+		lines = append(lines, fmt.Sprintf("%-*s %*s  %s %4s", labelWidth+10, truncate(stats.ModelDisplayName(model), labelWidth+10),
+			8, format.FormatTokens(tokens), format.FormatProgressBar(share, barWidth/2, v.ascii), format.FormatPercent(share)))
+	}
+	total := fmt.Sprintf("%-*s %*s", labelWidth+10, "Total", 8, format.FormatTokens(w.TotalTokens))
+	if w.WeekCostUSD > 0 {
+		total += fmt.Sprintf("  ≈$%.2f at API prices", w.WeekCostUSD)
+	}
+	return append(lines, total)
+}
+
+// trend returns a sparkline of the fuller window over the last 24 hours,
+// at most columns wide, or "" without samples.
+func (v view) trend(columns int) string {
+	if len(v.Samples) == 0 || columns < 8 {
+		return ""
+	}
+	columns = min(columns, 48)
+	values := history.Sparkline(v.Samples, v.At, usage.SampleSpan, columns)
+
+	var sb strings.Builder
+	peak := 0.0
+	for _, value := range values {
+		if value < 0 {
+			sb.WriteString(ansiDim + "·" + ansiReset)
+			continue
+		}
+		peak = max(peak, value)
+		i := min(int(value*float64(len(sparkBlocks))), len(sparkBlocks)-1)
+		sb.WriteString(colorize(string(sparkBlocks[i]), int(value*100)))
+	}
+	return sb.String() + "  peak " + format.FormatPercent(int(peak*100))
+}
+
+// recentWeeks returns a bar per finished week, newest first, scaled to the
+// busiest week's tokens.
+func (v view) recentWeeks(barWidth int) []string {
+	var most int64
+	for _, w := range v.Weeks {
+		most = max(most, w.Tokens)
+	}
+	if most == 0 {
+		return nil
+	}
+
+	var lines []string
+	for i := len(v.Weeks) - 1; i >= 0; i-- {
+		w := v.Weeks[i]
+		line := fmt.Sprintf("%-*s %s %8s", labelWidth, "ended "+w.End.Format("Jan 2"),
+			format.FormatProgressBar(int(w.Tokens*100/most), barWidth, v.ascii), format.FormatTokens(w.Tokens))
+		if !w.Imported {
+			line += fmt.Sprintf("  %s", colorize(fmt.Sprintf("%4s", format.FormatPercent(w.Percentage())), w.Percentage()))
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// finish adds the key help and fits lines into height.
+func (v view) finish(lines []string, width, height int) []string {
+	help := ansiDim + "r refresh · q quit" + ansiReset
+	if len(lines)+2 > height {
+		lines = lines[:max(0, height-2)]
+	}
+	return append(lines, "", help)
+}
+
+// colorize colors s by the usage level of percentage, like the icon pins.
+func colorize(s string, percentage int) string {
+	c := ansiGreen
+	switch {
+	case percentage >= icon.UsageCritical:
+		c = ansiRed
+	case percentage >= icon.UsageHigh:
+		c = ansiOrange
+	case percentage >= icon.UsageMedium:
+		c = ansiYellow
+	}
+	return c + s + ansiReset
+}
+
+// countdown formats d to the second, e.g. "2d 04h 10m" or "1h 05m 09s".
+func countdown(d time.Duration) string {
+	if d <= 0 {
+		return "now"
+	}
+	d = d.Round(time.Second)
+	days := int(d / (24 * time.Hour))
+	h := int(d/time.Hour) % 24
+	m := int(d/time.Minute) % 60
+	s := int(d/time.Second) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %02dh %02dm", days, h, m)
+	case h > 0:
+		return fmt.Sprintf("%dh %02dm %02ds", h, m, s)
+	default:
+		return fmt.Sprintf("%dm %02ds", m, s)
+	}
+}
+
+// truncate shortens s to at most n runes.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"claude-usage/internal/history"
+	"claude-usage/internal/stats"
+	"claude-usage/internal/usage"
+)
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[a-zA-Z]`)
+
+// plain renders v and strips the color escapes.
+func plain(v view, width, height int, now time.Time) string {
+	return ansiEscape.ReplaceAllString(strings.Join(v.render(width, height, now), "\n"), "")
+}
+
+func TestRender(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	v := view{Usage: usage.Usage{
+		At: now.Add(-30 * time.Second),
+		Stats: &stats.WeeklyStats{
+			HasAPIData:          true,
+			FiveHourUtilization: 0.42,
+			WeeklyUtilization:   0.91,
+			FiveHourReset:       now.Add(2*time.Hour + 5*time.Minute + 9*time.Second),
+			WeeklyReset:         now.Add(50 * time.Hour),
+			TokensByModel:       map[string]int64{"claude-opus-4-5-20251101": 750, "claude-sonnet-4-5-20250929": 250},
+			TotalTokens:         1000,
+		},
+		Samples: []history.Sample{{At: now.Add(-time.Hour), FiveHourUtilization: 0.42}},
+		Weeks:   []history.Week{{End: now.AddDate(0, 0, -5), Tokens: 2000, Utilization: 0.8}},
+	}}
+
+	out := plain(v, 100, 40, now)
+	for _, want := range []string{
+		"5-hour", " 42%   resets in 2h 05m 09s",
+		"Weekly", " 91%   resets in 2d 02h 00m",
+		"Opus 4.5", " 75%",
+		"Last 24h", "peak 42%",
+		"ended Mar 5", "80%",
+		"q quit",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("view lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRender_FitsHeight(t *testing.T) {
+	v := view{Usage: usage.Usage{Stats: &stats.WeeklyStats{HasAPIData: true, TotalTokens: 5}}}
+	if lines := v.render(80, 4, time.Now()); len(lines) != 4 {
+		t.Errorf("got %d lines for a 4-line terminal", len(lines))
+	}
+}
+
+func TestCountdown(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		-time.Second:                            "now",
+		59 * time.Second:                        "0m 59s",
+		time.Hour + 2*time.Minute + time.Second: "1h 02m 01s",
+		49*time.Hour + 30*time.Minute:           "2d 01h 30m",
+	} {
+		if got := countdown(d); got != want {
+			t.Errorf("countdown(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
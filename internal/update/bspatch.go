@@ -0,0 +1,99 @@
+package update
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// bsdiffMagic starts every patch produced by bsdiff 4.x.
+const bsdiffMagic = "BSDIFF40"
+
+// maxPatchedSize caps the new file size a patch header may claim, so a
+// corrupt header can't make bspatch allocate more than any release binary
+// needs.
+const maxPatchedSize = 256 << 20
+
+// errCorruptPatch means a patch is malformed or doesn't fit the old file.
+var errCorruptPatch = errors.New("corrupt patch")
+
+// bspatch applies a bsdiff 4.x patch to old and returns the new file.
+//
+// The patch is a 32-byte header (magic, control block length, diff block
+// length, new file size) followed by three bzip2 streams: control tuples,
+// diff bytes added to old, and extra bytes copied verbatim.
+func bspatch(old, patch []byte) ([]byte, error) {
+	if len(patch) < 32 || string(patch[:8]) != bsdiffMagic {
+		return nil, fmt.Errorf("%w: bad header", errCorruptPatch)
+	}
+
+	// Checked one at a time against what is left, as sums could overflow
+	body := patch[32:]
+	ctrlLen := offtin(patch[8:16])
+	if ctrlLen < 0 || ctrlLen > int64(len(body)) {
+		return nil, fmt.Errorf("%w: control block length out of range", errCorruptPatch)
+	}
+	diffLen := offtin(patch[16:24])
+	if diffLen < 0 || diffLen > int64(len(body))-ctrlLen {
+		return nil, fmt.Errorf("%w: diff block length out of range", errCorruptPatch)
+	}
+	newSize := offtin(patch[24:32])
+	if newSize < 0 || newSize > maxPatchedSize {
+		return nil, fmt.Errorf("%w: new file size out of range", errCorruptPatch)
+	}
+
+	ctrl := bzip2.NewReader(bytes.NewReader(body[:ctrlLen]))
+	diff := bzip2.NewReader(bytes.NewReader(body[ctrlLen : ctrlLen+diffLen]))
+	extra := bzip2.NewReader(bytes.NewReader(body[ctrlLen+diffLen:]))
+
+	newFile := make([]byte, newSize)
+	var oldPos, newPos int64
+	var tuple [24]byte
+	for newPos < newSize {
+		if _, err := io.ReadFull(ctrl, tuple[:]); err != nil {
+			return nil, fmt.Errorf("%w: control block: %v", errCorruptPatch, err)
+		}
+		addLen := offtin(tuple[0:8])
+		copyLen := offtin(tuple[8:16])
+		seek := offtin(tuple[16:24])
+
+		// Add diff bytes to old bytes
+		if addLen < 0 || addLen > newSize-newPos {
+			return nil, fmt.Errorf("%w: diff length out of range", errCorruptPatch)
+		}
+		if _, err := io.ReadFull(diff, newFile[newPos:newPos+addLen]); err != nil {
+			return nil, fmt.Errorf("%w: diff block: %v", errCorruptPatch, err)
+		}
+		for i := int64(0); i < addLen; i++ {
+			if oldPos+i >= 0 && oldPos+i < int64(len(old)) {
+				newFile[newPos+i] += old[oldPos+i]
+			}
+		}
+		newPos += addLen
+		oldPos += addLen
+
+		// Copy extra bytes
+		if copyLen < 0 || copyLen > newSize-newPos {
+			return nil, fmt.Errorf("%w: extra length out of range", errCorruptPatch)
+		}
+		if _, err := io.ReadFull(extra, newFile[newPos:newPos+copyLen]); err != nil {
+			return nil, fmt.Errorf("%w: extra block: %v", errCorruptPatch, err)
+		}
+		newPos += copyLen
+		oldPos += seek
+	}
+
+	return newFile, nil
+}
+
+// offtin decodes bsdiff's 8-byte sign-magnitude little-endian integer.
+func offtin(b []byte) int64 {
+	v := int64(binary.LittleEndian.Uint64(b) &^ (1 << 63))
+	if b[7]&0x80 != 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,82 @@
+package update
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// testPatch turns testOld into testNew. It was produced with Python's bz2
+// module following the bsdiff 4.x layout: one control tuple adding a diff
+// over all of testOld, then two extra bytes.
+const testPatch = "42534449464634302b0000000000000032000000000000002600000000000000" +
+	"425a6839314159265359ff3af658000005d00058080400200030cd00901a41566e2ee48a70a121fe75ecb0" +
+	"425a6839314159265359c4031f880000006000d2300c000020a00031064c411a189a158034f8c9e2ee48a70a12188063f100" +
+	"425a68393141592653599110c72f000000900020002000211846c2ee48a70a12122218e5e0"
+
+const (
+	testOld = "hello old world, this is version one"
+	testNew = "hello new world, this is version two!!"
+)
+
+func TestBspatch(t *testing.T) {
+	patch, err := hex.DecodeString(testPatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := bspatch([]byte(testOld), patch)
+	if err != nil {
+		t.Fatalf("bspatch: %v", err)
+	}
+	if string(got) != testNew {
+		t.Errorf("bspatch = %q, want %q", got, testNew)
+	}
+}
+
+func TestBspatchCorrupt(t *testing.T) {
+	patch, err := hex.DecodeString(testPatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := bspatch([]byte(testOld), patch[:40]); !errors.Is(err, errCorruptPatch) {
+		t.Errorf("truncated patch: got %v, want errCorruptPatch", err)
+	}
+	if _, err := bspatch([]byte(testOld), []byte("not a patch at all, definitely not")); !errors.Is(err, errCorruptPatch) {
+		t.Errorf("bad magic: got %v, want errCorruptPatch", err)
+	}
+}
+
+// patchHeader returns a patch header with the given lengths, followed by
+// bodyLen zero bytes.
+func patchHeader(ctrlLen, diffLen, newSize uint64, bodyLen int) []byte {
+	patch := make([]byte, 32+bodyLen)
+	copy(patch, bsdiffMagic)
+	binary.LittleEndian.PutUint64(patch[8:], ctrlLen)
+	binary.LittleEndian.PutUint64(patch[16:], diffLen)
+	binary.LittleEndian.PutUint64(patch[24:], newSize)
+	return patch
+}
+
+func TestBspatchBadHeader(t *testing.T) {
+	const negative = 1<<63 | 1
+	tests := []struct {
+		name  string
+		patch []byte
+	}{
+		{"negative control length", patchHeader(negative, 0, 10, 16)},
+		{"control past the end", patchHeader(17, 0, 10, 16)},
+		{"negative diff length", patchHeader(8, negative, 10, 16)},
+		{"diff past the end", patchHeader(8, 9, 10, 16)},
+		{"lengths overflowing", patchHeader(8, 1<<63-1, 10, 16)},
+		{"negative new size", patchHeader(8, 8, negative, 16)},
+		{"new size too large", patchHeader(8, 8, 1<<62, 16)},
+	}
+	for _, tt := range tests {
+		if _, err := bspatch([]byte(testOld), tt.patch); !errors.Is(err, errCorruptPatch) {
+			t.Errorf("%s: got %v, want errCorruptPatch", tt.name, err)
+		}
+	}
+}
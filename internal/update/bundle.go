@@ -0,0 +1,161 @@
+package update
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// appBundleAssetName is the release asset containing the zipped macOS .app bundle.
+const appBundleAssetName = "claude-usage-darwin-app.zip"
+
+// appBundleDir returns the .app bundle directory containing exePath, if the
+// app runs from a macOS bundle (".../Claude Usage.app/Contents/MacOS/claude-usage").
+func appBundleDir(exePath string) (string, bool) {
+	if runtime.GOOS != "darwin" {
+		return "", false
+	}
+	idx := strings.Index(exePath, ".app/Contents/MacOS/")
+	if idx < 0 {
+		return "", false
+	}
+	return exePath[:idx+len(".app")], true
+}
+
+// runningInAppBundle reports whether the current executable is inside a
+// macOS .app bundle.
+func runningInAppBundle() bool {
+	exePath, err := os.Executable()
+	if err != nil {
+		return false
+	}
+	if resolved, err := filepath.EvalSymlinks(exePath); err == nil {
+		exePath = resolved
+	}
+	_, ok := appBundleDir(exePath)
+	return ok
+}
+
+// updateAppBundle installs the bundle from a zipped .app into bundleDir.
+// Contents/MacOS is swapped with a single rename so the bundle never holds a
+// half-written binary, and the old executable is kept as
+// claude-usage.previous for Rollback.
+func updateAppBundle(bundleDir, exePath, zipPath string) (*Result, error) {
+	contentsDir := filepath.Join(bundleDir, "Contents")
+
+	// Stage inside the bundle so the final renames stay on one filesystem
+	stageDir, err := os.MkdirTemp(contentsDir, ".update-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stageDir)
+
+	if err := extractBundleContents(zipPath, stageDir); err != nil {
+		return nil, fmt.Errorf("failed to extract update: %w", err)
+	}
+
+	newMacOS := filepath.Join(stageDir, "MacOS")
+	if _, err := os.Stat(filepath.Join(newMacOS, filepath.Base(exePath))); err != nil {
+		return nil, fmt.Errorf("update bundle has no %s executable", filepath.Base(exePath))
+	}
+
+	// Keep the running binary for rollback inside the new MacOS directory
+	if err := copyFile(exePath, previousPath(filepath.Join(newMacOS, filepath.Base(exePath)))); err != nil {
+		return nil, fmt.Errorf("failed to keep current binary: %w", err)
+	}
+	os.Chmod(previousPath(filepath.Join(newMacOS, filepath.Base(exePath))), 0755)
+
+	// Swap Contents/MacOS
+	macOSDir := filepath.Join(contentsDir, "MacOS")
+	oldMacOS := filepath.Join(stageDir, "MacOS.old")
+	if err := os.Rename(macOSDir, oldMacOS); err != nil {
+		return nil, fmt.Errorf("failed to move current binary aside: %w", err)
+	}
+	if err := os.Rename(newMacOS, macOSDir); err != nil {
+		// Try to restore on failure
+		os.Rename(oldMacOS, macOSDir)
+		return nil, fmt.Errorf("failed to install update: %w", err)
+	}
+
+	// Info.plist carries the version Finder shows; not fatal if it fails
+	if _, err := os.Stat(filepath.Join(stageDir, "Info.plist")); err == nil {
+		if err := os.Rename(filepath.Join(stageDir, "Info.plist"), filepath.Join(contentsDir, "Info.plist")); err != nil {
+			fmt.Printf("Warning: could not update Info.plist: %v\n", err)
+		}
+	}
+
+	return &Result{
+		Success:      true,
+		Message:      "Update installed successfully. Restart to use the new version.",
+		NeedsRestart: true,
+	}, nil
+}
+
+// extractBundleContents extracts the Contents/MacOS files and Info.plist of
+// the .app bundle in a zip into destDir (as destDir/MacOS/... and
+// destDir/Info.plist).
+func extractBundleContents(zipPath, destDir string) error {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to open zip: %w", err)
+	}
+	defer reader.Close()
+
+	found := false
+	for _, f := range reader.File {
+		// Entries look like "Claude Usage.app/Contents/MacOS/claude-usage"
+		_, rel, ok := strings.Cut(f.Name, ".app/Contents/")
+		if !ok || f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		if rel != "Info.plist" && !strings.HasPrefix(rel, "MacOS/") {
+			continue
+		}
+
+		target := filepath.Join(destDir, filepath.FromSlash(rel))
+		if !strings.HasPrefix(target, filepath.Clean(destDir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in zip: %s", f.Name)
+		}
+		if err := extractZipFile(f, target); err != nil {
+			return err
+		}
+		found = true
+	}
+
+	if !found {
+		return fmt.Errorf("no .app bundle found in zip")
+	}
+	return nil
+}
+
+// extractZipFile writes a single zip entry to target, keeping its mode.
+func extractZipFile(f *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	mode := f.Mode().Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, rc)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
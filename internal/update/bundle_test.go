@@ -0,0 +1,50 @@
+package update
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractBundleContents(t *testing.T) {
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "app.zip")
+
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	for name, content := range map[string]string{
+		"Claude Usage.app/Contents/MacOS/claude-usage":        "binary",
+		"Claude Usage.app/Contents/Info.plist":                "plist",
+		"Claude Usage.app/Contents/Resources/AppIcon.icns":    "icon",
+		"__MACOSX/Claude Usage.app/Contents/MacOS/._whatever": "junk",
+	} {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(content))
+	}
+	w.Close()
+	f.Close()
+
+	dest := filepath.Join(dir, "stage")
+	if err := extractBundleContents(zipPath, dest); err != nil {
+		t.Fatalf("extractBundleContents: %v", err)
+	}
+
+	for rel, want := range map[string]string{"MacOS/claude-usage": "binary", "Info.plist": "plist"} {
+		got, err := os.ReadFile(filepath.Join(dest, rel))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", rel, got, err, want)
+		}
+	}
+	for _, rel := range []string{"Resources", "MacOS/._whatever"} {
+		if _, err := os.Stat(filepath.Join(dest, rel)); !os.IsNotExist(err) {
+			t.Errorf("%s should not be extracted", rel)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package update
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// maxPatchSize caps delta patch downloads; anything larger isn't worth it.
+const maxPatchSize = 16 << 20
+
+// getPatchName returns the name of the delta patch that turns the binary
+// with SHA-256 digest oldSum into the release asset named asset, e.g.
+// "claude-usage-linux-amd64.1a2b3c4d5e6f7a8b.bsdiff". Naming patches after
+// the old binary's digest means a patch is only ever applied to the exact
+// bytes it was made from.
+func getPatchName(asset, oldSum string) string {
+	return fmt.Sprintf("%s.%s.bsdiff", asset, oldSum[:16])
+}
+
+// downloadDelta downloads the bsdiff patch from the running binary to asset
+// in the release tagged tag, applies it, and returns the path of the patched binary.
+// The result is checked against expectedSum. Any error means the caller
+// should fall back to a full download.
+func downloadDelta(tag, asset, exePath, expectedSum string) (string, error) {
+	// Windows releases and macOS bundles are zipped, so there is no raw
+	// binary to patch
+	if runtime.GOOS == "windows" {
+		return "", fmt.Errorf("not supported on windows")
+	}
+	if _, ok := appBundleDir(exePath); ok {
+		return "", fmt.Errorf("not supported for .app bundles")
+	}
+
+	old, err := os.ReadFile(exePath)
+	if err != nil {
+		return "", err
+	}
+	oldSum := sha256.Sum256(old)
+
+	patch, err := downloadLimited(getReleaseFileURL(tag, getPatchName(asset, hex.EncodeToString(oldSum[:]))), maxPatchSize)
+	if err != nil {
+		return "", err
+	}
+
+	newData, err := bspatch(old, patch)
+	if err != nil {
+		return "", err
+	}
+
+	tmpFile, err := createUpdateTemp()
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmpFile.Name()
+
+	_, err = tmpFile.Write(newData)
+	tmpFile.Close()
+	if err == nil {
+		err = verifyChecksum(tmpPath, expectedSum)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	return tmpPath, nil
+}
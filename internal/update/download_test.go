@@ -0,0 +1,54 @@
+package update
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadAttemptResumes(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 1000))
+	var gotRange string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRange = r.Header.Get("Range")
+		http.ServeContent(w, r, "bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	// Simulate an earlier attempt that stopped halfway
+	f, err := os.Create(filepath.Join(t.TempDir(), "partial"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Write(content[:4000]); err != nil {
+		t.Fatal(err)
+	}
+
+	var lastDownloaded, lastTotal int64
+	err = downloadAttempt(srv.URL, f, func(downloaded, total int64) {
+		lastDownloaded, lastTotal = downloaded, total
+	})
+	if err != nil {
+		t.Fatalf("downloadAttempt: %v", err)
+	}
+
+	if gotRange != "bytes=4000-" {
+		t.Errorf("Range header = %q, want bytes=4000-", gotRange)
+	}
+	got, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("resumed file has %d bytes, want %d matching bytes", len(got), len(content))
+	}
+	if lastDownloaded != int64(len(content)) || lastTotal != int64(len(content)) {
+		t.Errorf("final progress = %d/%d, want %d/%d", lastDownloaded, lastTotal, len(content), len(content))
+	}
+}
@@ -0,0 +1,40 @@
+package update
+
+import (
+	"regexp"
+	"strings"
+)
+
+// markdownLink matches "[text](url)" so only the text is kept.
+var markdownLink = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+
+// Summary returns the first lines of release notes as plain text, at most
+// maxLen characters, for a notification or menu tooltip. Headings, blank
+// lines and Markdown markup are dropped; list items are joined with "; ".
+func Summary(notes string, maxLen int) string {
+	var items []string
+	length := 0
+	for _, line := range strings.Split(notes, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "---") {
+			continue
+		}
+		line = strings.TrimLeft(line, "-*+ ")
+		line = markdownLink.ReplaceAllString(line, "$1")
+		line = strings.NewReplacer("**", "", "__", "", "`", "").Replace(line)
+		if line == "" {
+			continue
+		}
+		items = append(items, line)
+		length += len(line) + 2
+		if length >= maxLen {
+			break
+		}
+	}
+
+	summary := strings.Join(items, "; ")
+	if r := []rune(summary); len(r) > maxLen {
+		summary = strings.TrimSpace(string(r[:maxLen-1])) + "…"
+	}
+	return summary
+}
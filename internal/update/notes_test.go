@@ -0,0 +1,17 @@
+package update
+
+import "testing"
+
+func TestSummary(t *testing.T) {
+	notes := "## What's new\n\n- **Pace** alerts in the tooltip\r\n- Email [notifications](https://example.com/docs)\n\n## Fixes\n* `tmux` colors\n"
+
+	if got, want := Summary(notes, 200), "Pace alerts in the tooltip; Email notifications; tmux colors"; got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+	if got, want := Summary(notes, 20), "Pace alerts in the…"; got != want {
+		t.Errorf("truncated Summary = %q, want %q", got, want)
+	}
+	if got := Summary("", 100); got != "" {
+		t.Errorf("empty notes: got %q", got)
+	}
+}
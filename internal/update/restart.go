@@ -0,0 +1,43 @@
+package update
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"claude-usage/internal/config"
+)
+
+// Restart starts the (freshly updated) executable with the same arguments as
+// the current process. The caller should exit right after, having released
+// anything the new process needs, such as the single-instance lock.
+func Restart() error {
+	exePath, err := config.GetExecutablePath()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	cmd := exec.Command(exePath, os.Args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start new version: %w", err)
+	}
+
+	// Don't wait: the new process outlives this one
+	return cmd.Process.Release()
+}
+
+// RestartExitCode is the exit status used to ask a service manager to
+// restart the app (EX_TEMPFAIL).
+const RestartExitCode = 75
+
+// Supervised reports whether the app runs under a service manager that
+// restarts it on a non-zero exit (see package service). A detached child
+// would be killed along with the service, so such runs should exit with
+// RestartExitCode instead of calling Restart.
+func Supervised() bool {
+	// Not INVOCATION_ID or XPC_SERVICE_NAME: systemd and launchd set those
+	// for autostart entries too, which nothing restarts
+	return os.Getenv(config.EnvSupervised) == "1"
+}
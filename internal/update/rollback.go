@@ -0,0 +1,69 @@
+package update
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"claude-usage/internal/config"
+)
+
+// previousPath returns where the binary replaced by the last update is kept.
+func previousPath(exePath string) string {
+	return exePath + ".previous"
+}
+
+// HasPrevious reports whether a previous version is available for Rollback.
+func HasPrevious() bool {
+	exePath, err := config.GetExecutablePath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(previousPath(exePath))
+	return err == nil
+}
+
+// Rollback swaps the current binary with the one replaced by the last
+// update. Rolling back twice returns to the newer version.
+// Returns a Result indicating success and that a restart is needed.
+func Rollback() (*Result, error) {
+	exePath, err := config.GetExecutablePath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	prevPath := previousPath(exePath)
+	if _, err := os.Stat(prevPath); err != nil {
+		return nil, fmt.Errorf("no previous version to roll back to")
+	}
+
+	// Move the current binary aside. On Windows the running exe can be
+	// renamed but not replaced, so it becomes .old and CleanupOldBinary
+	// turns it into .previous on next start.
+	asidePath := exePath + ".rollback"
+	if runtime.GOOS == "windows" {
+		asidePath = exePath + ".old"
+		os.Remove(asidePath)
+	}
+	if err := os.Rename(exePath, asidePath); err != nil {
+		return nil, fmt.Errorf("failed to move current binary aside: %w", err)
+	}
+
+	if err := os.Rename(prevPath, exePath); err != nil {
+		// Try to restore on failure
+		os.Rename(asidePath, exePath)
+		return nil, fmt.Errorf("failed to restore previous version: %w", err)
+	}
+
+	if runtime.GOOS != "windows" {
+		if err := os.Rename(asidePath, prevPath); err != nil {
+			fmt.Printf("Warning: could not keep replaced binary: %v\n", err)
+		}
+	}
+
+	return &Result{
+		Success:      true,
+		Message:      "Rolled back to the previous version. Restart to use it.",
+		NeedsRestart: true,
+	}, nil
+}
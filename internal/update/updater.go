@@ -5,6 +5,7 @@ import (
 	"archive/zip"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -13,8 +14,13 @@ import (
 	"time"
 
 	"claude-usage/internal/config"
+	"claude-usage/internal/httpclient"
 )
 
+// PublicKey is the minisign public key releases are signed with. It is set
+// at build time via -ldflags; when empty, only checksums are verified.
+var PublicKey = ""
+
 // Result represents the outcome of an update operation.
 type Result struct {
 	Success      bool
@@ -22,8 +28,11 @@ type Result struct {
 	NeedsRestart bool
 }
 
-// CleanupOldBinary removes any leftover .old file from a previous Windows update.
-// This should be called on application startup.
+// CleanupOldBinary turns the .old file left by a Windows update or rollback
+// into the .previous binary used by Rollback.
+// This should be called on application startup. After an automatic restart
+// the previous process may still be exiting and holding the file, so the
+// rename is retried for a few seconds.
 func CleanupOldBinary() {
 	if runtime.GOOS != "windows" {
 		return
@@ -35,42 +44,112 @@ func CleanupOldBinary() {
 	}
 
 	oldPath := exePath + ".old"
-	if _, err := os.Stat(oldPath); err == nil {
-		// .old file exists, try to remove it
-		if err := os.Remove(oldPath); err == nil {
-			fmt.Printf("Cleaned up old binary: %s\n", oldPath)
+	for attempt := 0; attempt < 10; attempt++ {
+		if _, err := os.Stat(oldPath); err != nil {
+			return
+		}
+		// .old file exists, keep it for rollback
+		if err := os.Rename(oldPath, previousPath(exePath)); err == nil {
+			fmt.Printf("Kept old binary for rollback: %s\n", previousPath(exePath))
+			return
 		}
+		time.Sleep(500 * time.Millisecond)
 	}
 }
 
 // GetPlatformBinaryName returns the appropriate binary name for the current platform.
 func GetPlatformBinaryName() string {
-	goos := runtime.GOOS
-	goarch := runtime.GOARCH
+	return getBinaryName(runtime.GOOS, runtime.GOARCH)
+}
 
+// getBinaryName returns the release asset name for goos/goarch.
+func getBinaryName(goos, goarch string) string {
 	switch goos {
 	case "windows":
-		return "claude-usage-windows-amd64.zip"
+		return fmt.Sprintf("claude-usage-windows-%s.zip", goarch)
 	case "darwin":
+		// Apps installed from the DMG update the whole .app bundle
+		if runningInAppBundle() {
+			return appBundleAssetName
+		}
 		// macOS uses universal binary names
 		return fmt.Sprintf("claude-usage-darwin-%s", goarch)
-	case "linux":
-		return fmt.Sprintf("claude-usage-linux-%s", goarch)
 	default:
 		return fmt.Sprintf("claude-usage-%s-%s", goos, goarch)
 	}
 }
 
-// GetDownloadURL returns the full URL for downloading the latest binary.
-func GetDownloadURL() string {
-	baseURL := config.GetUpdateURL()
-	binaryName := GetPlatformBinaryName()
-	return fmt.Sprintf("%s/%s", baseURL, binaryName)
+// emulatedArchs lists architectures whose binaries can run on another
+// through OS-provided emulation: x64 on Windows on ARM and on Apple Silicon
+// via Rosetta 2.
+var emulatedArchs = map[string][]string{
+	"windows/arm64": {"amd64"},
+	"darwin/arm64":  {"amd64"},
+}
+
+// GetPlatformBinaryCandidates returns the release asset names that can run
+// on this machine, best first: the native build, then builds that run under
+// emulation. The first one listed in the release's SHA256SUMS is used.
+func GetPlatformBinaryCandidates() []string {
+	names := []string{GetPlatformBinaryName()}
+	for _, arch := range emulatedArchs[runtime.GOOS+"/"+runtime.GOARCH] {
+		if name := getBinaryName(runtime.GOOS, arch); name != names[0] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// GetDownloadURL returns the full URL for downloading the binary of the
+// release tagged tag. An empty tag means the latest stable release.
+func GetDownloadURL(tag string) string {
+	return getReleaseFileURL(tag, GetPlatformBinaryName())
+}
+
+// getReleaseFileURL returns the URL of a file published with the release
+// tagged tag, or with the latest stable release if tag is empty.
+func getReleaseFileURL(tag, name string) string {
+	if tag == "" {
+		return fmt.Sprintf("%s/%s", config.GetUpdateURL(), name)
+	}
+	return fmt.Sprintf("%s/%s/%s", config.GetReleaseDownloadURL(), tag, name)
 }
 
-// Update downloads the latest version and replaces the current binary.
+// fetchExpectedChecksum downloads SHA256SUMS, verifies its signature when a
+// public key is configured, and returns the best release asset for this
+// platform along with its digest.
+func fetchExpectedChecksum(tag string) (asset, sum string, err error) {
+	sums, err := downloadSmall(getReleaseFileURL(tag, ChecksumsFileName))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to download %s: %w", ChecksumsFileName, err)
+	}
+
+	if PublicKey != "" {
+		sig, err := downloadSmall(getReleaseFileURL(tag, SignatureFileName))
+		if err != nil {
+			return "", "", fmt.Errorf("failed to download %s: %w", SignatureFileName, err)
+		}
+		if err := verifySignature(sums, sig, PublicKey); err != nil {
+			return "", "", err
+		}
+	}
+
+	candidates := GetPlatformBinaryCandidates()
+	parsed := parseChecksums(sums)
+	for _, name := range candidates {
+		if sum, ok := parsed[name]; ok {
+			return name, sum, nil
+		}
+	}
+	return "", "", fmt.Errorf("no release build for %s/%s (looked for %s)",
+		runtime.GOOS, runtime.GOARCH, strings.Join(candidates, ", "))
+}
+
+// Update downloads the release tagged tag (the latest stable release if tag
+// is empty) and replaces the current binary. progress, if non-nil, receives
+// download progress.
 // Returns a Result indicating success/failure and whether restart is needed.
-func Update() (*Result, error) {
+func Update(tag string, progress ProgressFunc) (*Result, error) {
 	// Get current executable path
 	exePath, err := os.Executable()
 	if err != nil {
@@ -83,14 +162,39 @@ func Update() (*Result, error) {
 		return nil, fmt.Errorf("failed to resolve executable path: %w", err)
 	}
 
-	// Download the update (ZIP for Windows, binary for others)
-	downloadURL := GetDownloadURL()
-	tmpFile, err := downloadBinary(downloadURL)
+	// Pick the release asset and fetch its checksum before downloading
+	// anything large
+	asset, expectedSum, err := fetchExpectedChecksum(tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify update: %w", err)
+	}
+	if asset != GetPlatformBinaryName() {
+		log.Printf("No native build in this release, using %s", asset)
+	}
+
+	// Try a small delta patch first, falling back to the full download
+	tmpFile, err := downloadDelta(tag, asset, exePath, expectedSum)
 	if err != nil {
-		return nil, fmt.Errorf("failed to download update: %w", err)
+		log.Printf("Delta update unavailable, downloading full binary: %v", err)
+
+		// Download the update (ZIP for Windows, binary for others)
+		tmpFile, err = downloadBinary(getReleaseFileURL(tag, asset), progress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download update: %w", err)
+		}
 	}
 	defer os.Remove(tmpFile) // Clean up temp file on error
 
+	// Never install bytes that don't match the published checksum
+	if err := verifyChecksum(tmpFile, expectedSum); err != nil {
+		return nil, fmt.Errorf("failed to verify update: %w", err)
+	}
+
+	// macOS .app bundles are replaced from the zipped bundle
+	if bundleDir, ok := appBundleDir(exePath); ok {
+		return updateAppBundle(bundleDir, exePath, tmpFile)
+	}
+
 	// For Windows, extract the exe from the ZIP
 	var newBinaryPath string
 	if runtime.GOOS == "windows" {
@@ -116,8 +220,8 @@ func Update() (*Result, error) {
 		return updateWindows(exePath, newBinaryPath)
 	}
 
-	// Linux/macOS: Backup and replace
-	backupPath := exePath + ".backup"
+	// Linux/macOS: Backup and replace. The backup is kept for Rollback.
+	backupPath := previousPath(exePath)
 	if err := os.Rename(exePath, backupPath); err != nil {
 		return nil, fmt.Errorf("failed to backup current binary: %w", err)
 	}
@@ -135,12 +239,9 @@ func Update() (*Result, error) {
 		fmt.Printf("Warning: could not set executable permission: %v\n", err)
 	}
 
-	// Remove backup (optional, ignore errors)
-	os.Remove(backupPath)
-
 	return &Result{
 		Success:      true,
-		Message:      "Update installed successfully. Please restart the application.",
+		Message:      "Update installed successfully. Restart to use the new version.",
 		NeedsRestart: true,
 	}, nil
 }
@@ -203,7 +304,7 @@ func extractWindowsZip(zipPath string) (string, error) {
 // Windows can't replace a running executable, so we:
 // 1. Rename current exe to .old
 // 2. Copy new exe to original location
-// 3. The .old file will be cleaned up on next restart
+// 3. The .old file becomes the .previous binary on next restart
 func updateWindows(exePath, newBinaryPath string) (*Result, error) {
 	oldPath := exePath + ".old"
 
@@ -226,7 +327,7 @@ func updateWindows(exePath, newBinaryPath string) (*Result, error) {
 
 	return &Result{
 		Success:      true,
-		Message:      "Update installed successfully. Please restart the application.",
+		Message:      "Update installed successfully. Restart to use the new version.",
 		NeedsRestart: true,
 	}, nil
 }
@@ -249,57 +350,173 @@ func copyFile(src, dst string) error {
 	return err
 }
 
-// downloadBinary downloads the binary from the given URL to a temporary file.
-// Returns the path to the temporary file.
-func downloadBinary(url string) (string, error) {
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 5 * time.Minute,
-		// Follow redirects (GitHub releases use redirects)
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= 10 {
-				return fmt.Errorf("too many redirects")
-			}
-			return nil
-		},
+// maxSmallDownload caps the size of checksum and signature downloads.
+const maxSmallDownload = 1 << 20
+
+// newHTTPClient returns the HTTP client used for update downloads.
+func newHTTPClient() *http.Client {
+	client := httpclient.New(5 * time.Minute)
+	// Follow redirects (GitHub releases use redirects)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("too many redirects")
+		}
+		return nil
 	}
+	return client
+}
 
-	// Make request
-	resp, err := client.Get(url)
+// downloadSmall downloads a small file (e.g. SHA256SUMS) into memory.
+func downloadSmall(url string) ([]byte, error) {
+	return downloadLimited(url, maxSmallDownload)
+}
+
+// downloadLimited downloads a file into memory, failing if it is larger
+// than limit bytes.
+func downloadLimited(url string, limit int64) ([]byte, error) {
+	resp, err := newHTTPClient().Get(url)
 	if err != nil {
-		return "", fmt.Errorf("HTTP request failed: %w", err)
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
 	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("file exceeds %d bytes", limit)
+	}
+	return data, nil
+}
 
-	// Create temporary file in the same directory as the executable
-	// This ensures we can rename it later (same filesystem)
+// createUpdateTemp creates a temporary file for a new binary in the same
+// directory as the executable. This ensures we can rename it later (same
+// filesystem).
+func createUpdateTemp() (*os.File, error) {
 	exePath, err := os.Executable()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	exeDir := filepath.Dir(exePath)
 
-	tmpFile, err := os.CreateTemp(exeDir, "claude-usage-update-*")
+	tmpFile, err := os.CreateTemp(filepath.Dir(exePath), "claude-usage-update-*")
 	if err != nil {
 		// Fallback to system temp dir
 		tmpFile, err = os.CreateTemp("", "claude-usage-update-*")
 		if err != nil {
-			return "", fmt.Errorf("failed to create temp file: %w", err)
+			return nil, fmt.Errorf("failed to create temp file: %w", err)
 		}
 	}
+	return tmpFile, nil
+}
+
+// ProgressFunc receives download progress. total is -1 if the server
+// didn't report a size.
+type ProgressFunc func(downloaded, total int64)
+
+// downloadAttempts is how many times a download is tried. Retries resume
+// from where the previous attempt stopped when the server supports it.
+const downloadAttempts = 3
+
+// downloadBinary downloads the binary from the given URL to a temporary file,
+// reporting progress if progress is non-nil.
+// Returns the path to the temporary file.
+func downloadBinary(url string, progress ProgressFunc) (string, error) {
+	tmpFile, err := createUpdateTemp()
+	if err != nil {
+		return "", err
+	}
 	tmpPath := tmpFile.Name()
+	defer tmpFile.Close()
+
+	for attempt := 1; ; attempt++ {
+		err = downloadAttempt(url, tmpFile, progress)
+		if err == nil {
+			return tmpPath, nil
+		}
+		if attempt == downloadAttempts {
+			break
+		}
+		log.Printf("Update download interrupted (attempt %d/%d): %v", attempt, downloadAttempts, err)
+		time.Sleep(time.Duration(attempt) * 2 * time.Second)
+	}
 
-	// Download to temp file
-	_, err = io.Copy(tmpFile, resp.Body)
 	tmpFile.Close()
+	os.Remove(tmpPath)
+	return "", fmt.Errorf("failed to download file: %w", err)
+}
+
+// downloadAttempt downloads url into f, resuming after any bytes f already
+// holds if the server honours the Range request.
+func downloadAttempt(url string, f *os.File, progress ProgressFunc) error {
+	offset, err := f.Seek(0, io.SeekEnd)
 	if err != nil {
-		os.Remove(tmpPath)
-		return "", fmt.Errorf("failed to download file: %w", err)
+		return err
 	}
 
-	return tmpPath, nil
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := newHTTPClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		// Resuming
+	case resp.StatusCode == http.StatusOK:
+		// Server ignored the Range header (or this is the first attempt)
+		if offset > 0 {
+			if err := f.Truncate(0); err != nil {
+				return err
+			}
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			offset = 0
+		}
+	default:
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+
+	var dst io.Writer = f
+	if progress != nil {
+		progress(offset, total)
+		dst = &progressWriter{w: f, downloaded: offset, total: total, progress: progress}
+	}
+
+	_, err = io.Copy(dst, resp.Body)
+	return err
+}
+
+// progressWriter counts bytes written and reports them to a ProgressFunc.
+type progressWriter struct {
+	w          io.Writer
+	downloaded int64
+	total      int64
+	progress   ProgressFunc
+}
+
+// Write implements io.Writer.
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.downloaded += int64(n)
+	p.progress(p.downloaded, p.total)
+	return n, err
 }
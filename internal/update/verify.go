@@ -0,0 +1,100 @@
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ChecksumsFileName is the checksum manifest published with each release.
+// SignatureFileName is its optional minisign signature.
+const (
+	ChecksumsFileName = "SHA256SUMS"
+	SignatureFileName = "SHA256SUMS.minisig"
+)
+
+var (
+	// ErrChecksumMismatch means the downloaded file doesn't match SHA256SUMS.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
+	// ErrSignatureInvalid means SHA256SUMS isn't signed by the release key.
+	ErrSignatureInvalid = errors.New("invalid signature")
+)
+
+// parseChecksums parses sha256sum output ("<hex>  <name>" per line) into a
+// map from file name to lowercase hex digest.
+func parseChecksums(data []byte) map[string]string {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		// Binary mode entries are prefixed with '*'
+		name := strings.TrimPrefix(fields[1], "*")
+		sums[name] = strings.ToLower(fields[0])
+	}
+	return sums
+}
+
+// verifyChecksum checks that the SHA-256 digest of the file at path matches
+// the expected hex digest.
+func verifyChecksum(path, expected string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("failed to hash download: %w", err)
+	}
+
+	actual := hex.EncodeToString(h.Sum(nil))
+	if actual != expected {
+		return fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, expected, actual)
+	}
+	return nil
+}
+
+// verifySignature checks a minisign signature over data against a minisign
+// public key (the base64 line of a minisign .pub file). Only legacy
+// signatures ("minisign -S -l"), which sign the data directly with Ed25519,
+// are supported.
+func verifySignature(data, sigFile []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != 2+8+ed25519.PublicKeySize || string(key[:2]) != "Ed" {
+		return fmt.Errorf("malformed update public key")
+	}
+
+	// Line 1 is an untrusted comment, line 2 the base64 signature
+	lines := strings.Split(strings.ReplaceAll(string(sigFile), "\r\n", "\n"), "\n")
+	if len(lines) < 2 {
+		return fmt.Errorf("%w: malformed signature file", ErrSignatureInvalid)
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("%w: malformed signature file", ErrSignatureInvalid)
+	}
+	if string(sig[:2]) != "Ed" {
+		return fmt.Errorf("%w: unsupported signature algorithm %q", ErrSignatureInvalid, sig[:2])
+	}
+	if !bytes.Equal(sig[2:10], key[2:10]) {
+		return fmt.Errorf("%w: signed with a different key", ErrSignatureInvalid)
+	}
+
+	if !ed25519.Verify(ed25519.PublicKey(key[10:]), data, sig[10:]) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseChecksums(t *testing.T) {
+	data := []byte("ABCDEF  claude-usage-linux-amd64\n" +
+		"012345 *claude-usage-windows-amd64.zip\n" +
+		"garbage line with too many fields\n")
+
+	sums := parseChecksums(data)
+	if sums["claude-usage-linux-amd64"] != "abcdef" {
+		t.Errorf("linux sum = %q, want abcdef", sums["claude-usage-linux-amd64"])
+	}
+	if sums["claude-usage-windows-amd64.zip"] != "012345" {
+		t.Errorf("windows sum = %q, want 012345", sums["claude-usage-windows-amd64.zip"])
+	}
+	if len(sums) != 2 {
+		t.Errorf("got %d entries, want 2", len(sums))
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bin")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	const helloSum = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if err := verifyChecksum(path, helloSum); err != nil {
+		t.Errorf("valid checksum: %v", err)
+	}
+	if err := verifyChecksum(path, "00"+helloSum[2:]); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("tampered checksum: got %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	publicKey := base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...))
+
+	data := []byte("abcdef  claude-usage-linux-amd64\n")
+	sig := append(append([]byte("Ed"), keyID...), ed25519.Sign(priv, data)...)
+	sigFile := []byte("untrusted comment: test\n" + base64.StdEncoding.EncodeToString(sig) + "\n")
+
+	if err := verifySignature(data, sigFile, publicKey); err != nil {
+		t.Errorf("valid signature: %v", err)
+	}
+	if err := verifySignature([]byte("tampered"), sigFile, publicKey); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("tampered data: got %v, want ErrSignatureInvalid", err)
+	}
+}
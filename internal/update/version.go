@@ -0,0 +1,180 @@
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"claude-usage/internal/config"
+	"claude-usage/internal/httpclient"
+)
+
+// releaseInfo is the subset of the GitHub releases API response we use.
+type releaseInfo struct {
+	TagName    string `json:"tag_name"`
+	Body       string `json:"body"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+// Release is a published release.
+type Release struct {
+	Tag string
+
+	// Notes is the release description, in Markdown
+	Notes string
+}
+
+// CheckLatestVersion queries the GitHub releases API for the latest release
+// on channel and reports whether it is newer than currentVersion. The beta
+// channel also considers pre-releases. Builds without a semver version
+// (e.g. "dev") are always considered out of date.
+func CheckLatestVersion(currentVersion, channel string) (latest string, newer bool, err error) {
+	release, newer, err := CheckLatestRelease(currentVersion, channel)
+	return release.Tag, newer, err
+}
+
+// CheckLatestRelease is CheckLatestVersion returning the release notes too.
+func CheckLatestRelease(currentVersion, channel string) (latest Release, newer bool, err error) {
+	var release releaseInfo
+	if channel == config.ChannelBeta {
+		release, err = fetchNewestRelease()
+	} else {
+		release, err = fetchLatestStableRelease()
+	}
+	if err != nil {
+		return Release{}, false, err
+	}
+	latest = Release{Tag: release.TagName, Notes: release.Body}
+
+	latestVer, ok := parseVersion(latest.Tag)
+	if !ok {
+		return Release{}, false, fmt.Errorf("latest release has invalid version %q", latest.Tag)
+	}
+
+	currentVer, ok := parseVersion(currentVersion)
+	if !ok {
+		return latest, true, nil
+	}
+
+	return latest, compareVersions(latestVer, currentVer) > 0, nil
+}
+
+// fetchLatestStableRelease returns the latest non-prerelease.
+func fetchLatestStableRelease() (releaseInfo, error) {
+	var release releaseInfo
+	err := getReleasesAPI(config.GetReleasesAPIURL()+"/latest", &release)
+	return release, err
+}
+
+// fetchNewestRelease returns the highest-versioned release among recent
+// releases, including pre-releases.
+func fetchNewestRelease() (releaseInfo, error) {
+	var releases []releaseInfo
+	if err := getReleasesAPI(config.GetReleasesAPIURL()+"?per_page=30", &releases); err != nil {
+		return releaseInfo{}, err
+	}
+
+	var newestRelease releaseInfo
+	var newest semver
+	for _, r := range releases {
+		v, ok := parseVersion(r.TagName)
+		if r.Draft || !ok {
+			continue
+		}
+		if newestRelease.TagName == "" || compareVersions(v, newest) > 0 {
+			newestRelease, newest = r, v
+		}
+	}
+
+	if newestRelease.TagName == "" {
+		return releaseInfo{}, fmt.Errorf("no releases found")
+	}
+	return newestRelease, nil
+}
+
+// getReleasesAPI fetches url from the GitHub API and decodes the JSON response.
+func getReleasesAPI(url string, v any) error {
+	client := httpclient.New(30 * time.Second)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse release info: %w", err)
+	}
+	return nil
+}
+
+// semver is a parsed major.minor.patch version with optional pre-release.
+type semver struct {
+	major, minor, patch int
+	pre                 string
+}
+
+// parseVersion parses "v1.2.3", "1.2.3" or "v1.2.3-rc.1". Build metadata
+// after '+' is ignored.
+func parseVersion(s string) (semver, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, pre, _ := strings.Cut(s, "-")
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return semver{}, false
+	}
+
+	var nums [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return semver{}, false
+		}
+		nums[i] = n
+	}
+
+	return semver{major: nums[0], minor: nums[1], patch: nums[2], pre: pre}, true
+}
+
+// compareVersions returns -1, 0 or 1 as a is older than, equal to, or newer
+// than b. A pre-release sorts before its release; pre-release identifiers
+// are compared as plain strings.
+func compareVersions(a, b semver) int {
+	for _, d := range []int{a.major - b.major, a.minor - b.minor, a.patch - b.patch} {
+		if d != 0 {
+			if d < 0 {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case a.pre == b.pre:
+		return 0
+	case a.pre == "":
+		return 1
+	case b.pre == "":
+		return -1
+	case a.pre < b.pre:
+		return -1
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,37 @@
+package update
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "v1.2.3", 0},
+		{"v1.2.4", "v1.2.3", 1},
+		{"v1.10.0", "v1.9.9", 1},
+		{"1.0.0", "v2.0.0", -1},
+		{"v1.0.0", "v1.0.0-rc.1", 1},
+		{"v1.0.0-rc.1", "v1.0.0-rc.2", -1},
+		{"v1.0.0+build.5", "v1.0.0", 0},
+	}
+
+	for _, tt := range tests {
+		a, okA := parseVersion(tt.a)
+		b, okB := parseVersion(tt.b)
+		if !okA || !okB {
+			t.Fatalf("parseVersion(%q, %q) failed", tt.a, tt.b)
+		}
+		if got := compareVersions(a, b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseVersionInvalid(t *testing.T) {
+	for _, s := range []string{"dev", "", "v1.2", "v1.x.3"} {
+		if _, ok := parseVersion(s); ok {
+			t.Errorf("parseVersion(%q) succeeded, want failure", s)
+		}
+	}
+}
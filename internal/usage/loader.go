@@ -0,0 +1,174 @@
+// Package usage loads usage the way the tray app's refresh does, for the
+// terminal view and the headless agent, which run without the tray app.
+package usage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"claude-usage/internal/api"
+	"claude-usage/internal/config"
+	"claude-usage/internal/history"
+	"claude-usage/internal/stats"
+	"claude-usage/internal/tokenstore"
+	"claude-usage/internal/transcripts"
+)
+
+// SampleSpan is how far back Usage.Samples reach.
+const SampleSpan = 24 * time.Hour
+
+// RecentWeeks is how many finished weeks Usage.Weeks holds at most.
+const RecentWeeks = 6
+
+// Usage is the result of one load.
+type Usage struct {
+	Stats *stats.WeeklyStats
+	At    time.Time
+
+	// RateLimits is the last successful API response, nil before the
+	// first; Stats holds it marked stale when the fetch failed
+	RateLimits *api.RateLimitData
+
+	// Fetch records the API fetch; nil when the credentials could not be
+	// read or the provider is not the real API
+	Fetch *history.Fetch
+
+	// Weeks are the last finished weeks of the history and Samples the
+	// samples of the last SampleSpan, oldest first
+	Weeks   []history.Week
+	Samples []history.Sample
+
+	// Err is why the API could not be read; Stats then hold the last
+	// known rate limits, if any, or local estimates
+	Err error
+
+	// Warning is set when other users can read the credentials file
+	Warning string
+}
+
+// Loader gathers usage the way the tray app's refresh does. It is not
+// safe for concurrent use.
+type Loader struct {
+	cfg      *config.Config
+	provider api.UsageProvider
+	scanner  *transcripts.Scanner
+
+	// last is the last successful API response, shown as stale data
+	// while the API is unreachable
+	last *api.RateLimitData
+
+	// samples are the samples of the last SampleSpan, from the tray
+	// app's sample log and this loader's own fetches
+	samples []history.Sample
+
+	// warning is the last credentials warning, logged when it changes
+	warning string
+}
+
+// NewLoader creates a loader for cfg, seeded with the tray app's cached
+// rate limits and sample log.
+func NewLoader(cfg *config.Config) *Loader {
+	l := &Loader{cfg: cfg, scanner: transcripts.NewScanner(cfg.GetProjectsPath())}
+
+	switch cfg.GetUsageProvider() {
+	case config.ProviderFile:
+		l.provider = api.NewFileProvider(cfg.UsageFilePath)
+	case config.ProviderRemote:
+		l.provider = api.NewRemoteProvider(cfg.RemoteURL, cfg.RemoteToken)
+	case config.ProviderDemo:
+		l.provider = api.NewDemoProvider()
+	default:
+		client := api.NewClient("")
+		client.SetRefreshTokenCallback(l.saveRefreshToken)
+		l.provider = client
+		if cached, err := api.LoadCache(config.GetRateLimitCachePath(cfg.Profile)); err == nil {
+			l.last = cached
+		}
+	}
+
+	samples, err := history.LoadSamples(config.GetSamplesPath(cfg.Profile), time.Now().Add(-SampleSpan))
+	if err != nil {
+		log.Printf("Warning: could not read usage samples: %v", err)
+	}
+	l.samples = samples
+	return l
+}
+
+// saveRefreshToken persists tokens rotated by the server, as the tray app
+// does, so the credentials stay usable.
+func (l *Loader) saveRefreshToken(accessToken, refreshToken string) {
+	if err := tokenstore.SaveRotated(l.cfg, accessToken, refreshToken); err != nil {
+		log.Printf("ERROR: Failed to save the new refresh token: %v", err)
+	}
+}
+
+// Load reads the credentials, the stats cache and session transcripts and
+// fetches the rate limits. Only a failure to read the credentials an OAuth
+// provider needs leaves Usage.Stats nil.
+func (l *Loader) Load(ctx context.Context) Usage {
+	now := time.Now()
+	u := Usage{At: now}
+
+	u.Warning = CredentialsWarning(l.cfg)
+	if u.Warning != l.warning && u.Warning != "" {
+		log.Printf("Warning: %s; run 'chmod 600 %s'", u.Warning, l.cfg.GetCredentialsPath())
+	}
+	l.warning = u.Warning
+
+	creds, err := ReadCredentials(l.cfg)
+	if err != nil {
+		u.Err = err
+		return u
+	}
+
+	var entries []transcripts.Entry
+	if !l.cfg.IsOpenCode() {
+		entries, _ = l.scanner.Scan()
+	}
+	weeklyStats, _ := WeekStats(l.cfg, creds, entries, l.last, now)
+	u.Stats = weeklyStats
+
+	if auth, ok := l.provider.(api.TokenAuthenticator); ok && creds != nil {
+		auth.SetToken(creds.ClaudeAiOauth.AccessToken)
+		auth.SetRefreshToken(creds.ClaudeAiOauth.RefreshToken)
+	}
+	start := time.Now()
+	rateLimits, err := l.provider.FetchRateLimits(ctx)
+	if l.cfg.UsesOAuth() {
+		u.Fetch = &history.Fetch{At: start, LatencyMS: time.Since(start).Milliseconds(), OK: err == nil}
+		var statusErr *api.StatusError
+		if errors.As(err, &statusErr) {
+			u.Fetch.StatusCode = statusErr.StatusCode
+		}
+	}
+	switch {
+	case err != nil:
+		u.Err = err
+		if l.last != nil {
+			l.last.Apply(weeklyStats)
+			weeklyStats.APIDataStale = true
+			weeklyStats.APIFetchedAt = l.last.FetchedAt
+		}
+	default:
+		rateLimits.Apply(weeklyStats)
+		weeklyStats.APIFetchedAt = rateLimits.FetchedAt
+		l.last = rateLimits
+		l.samples = append(l.samples, history.SampleFromStats(weeklyStats, now))
+	}
+
+	u.RateLimits = l.last
+
+	cutoff := now.Add(-SampleSpan)
+	for len(l.samples) > 0 && l.samples[0].At.Before(cutoff) {
+		l.samples = l.samples[1:]
+	}
+	u.Samples = slices.Clone(l.samples)
+
+	if h, err := history.Load(config.GetHistoryPath(l.cfg.Profile)); err == nil {
+		u.Weeks = h.Weeks[max(0, len(h.Weeks)-RecentWeeks):]
+	}
+	return u
+}
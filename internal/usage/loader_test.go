@@ -0,0 +1,129 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"claude-usage/internal/api"
+	"claude-usage/internal/config"
+	"claude-usage/internal/stats"
+	"claude-usage/internal/testserver"
+)
+
+// newTestLoader returns a loader fetching from a fake API server, with
+// the server's tokens in a credentials file and no local usage data.
+func newTestLoader(t *testing.T) (*Loader, *testserver.Server, *config.Config) {
+	if runtime.GOOS != "linux" {
+		t.Skip("relies on XDG_CONFIG_HOME")
+	}
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	srv := testserver.New()
+	t.Cleanup(srv.Close)
+	if err := api.SetBaseURL(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	credsPath := filepath.Join(dir, ".credentials.json")
+	body := `{"claudeAiOauth":{"accessToken":"` + testserver.AccessToken + `","refreshToken":"` + testserver.RefreshToken + `"}}`
+	if err := os.WriteFile(credsPath, []byte(body), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default()
+	cfg.Source = config.SourceClaude
+	cfg.ClaudeCredentialsPath = credsPath
+	cfg.ClaudeStatsPath = filepath.Join(dir, "claude", "stats-cache.json")
+	return NewLoader(cfg), srv, cfg
+}
+
+func TestLoad_TestServer(t *testing.T) {
+	l, srv, _ := newTestLoader(t)
+	srv.SetUsage(testserver.Usage{FiveHour: 42, Weekly: 81})
+
+	u := l.Load(context.Background())
+	if u.Err != nil {
+		t.Fatal(u.Err)
+	}
+	if !u.Stats.HasAPIData || u.Stats.FiveHourUtilization != 0.42 || u.Stats.WeeklyUtilization != 0.81 {
+		t.Errorf("got 5h %v, weekly %v, want 0.42, 0.81", u.Stats.FiveHourUtilization, u.Stats.WeeklyUtilization)
+	}
+	if u.Fetch == nil || !u.Fetch.OK {
+		t.Errorf("fetch not recorded as successful: %+v", u.Fetch)
+	}
+	if len(u.Samples) != 1 {
+		t.Errorf("got %d samples, want 1", len(u.Samples))
+	}
+}
+
+func TestLoad_TokenRotation(t *testing.T) {
+	l, srv, cfg := newTestLoader(t)
+	srv.ExpireToken()
+
+	if u := l.Load(context.Background()); u.Err != nil {
+		t.Fatal(u.Err)
+	}
+
+	// The rotated refresh token is saved, so the credentials stay usable
+	_, refresh := srv.Tokens()
+	creds, err := stats.ParseCredentials(cfg.GetCredentialsPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.ClaudeAiOauth.RefreshToken != refresh {
+		t.Errorf("credentials hold refresh token %q, want %q", creds.ClaudeAiOauth.RefreshToken, refresh)
+	}
+
+	// The next load refreshes the access token with it
+	srv.ExpireToken()
+	if u := l.Load(context.Background()); u.Err != nil {
+		t.Fatal(u.Err)
+	}
+	if srv.TokenRequests() != 2 {
+		t.Errorf("got %d token requests, want 2", srv.TokenRequests())
+	}
+}
+
+func TestLoad_Throttled(t *testing.T) {
+	l, srv, _ := newTestLoader(t)
+	srv.SetUsage(testserver.Usage{FiveHour: 30, Weekly: 60})
+	if u := l.Load(context.Background()); u.Err != nil {
+		t.Fatal(u.Err)
+	}
+
+	// Throttled for longer than the retries wait: the last rate limits
+	// are shown, marked stale
+	srv.Throttle(1, time.Hour)
+	u := l.Load(context.Background())
+	var statusErr *api.StatusError
+	if !errors.As(u.Err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("got %v, want a 429 StatusError", u.Err)
+	}
+	if u.Fetch == nil || u.Fetch.OK || u.Fetch.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("fetch not recorded as a 429: %+v", u.Fetch)
+	}
+	if !u.Stats.APIDataStale || u.Stats.WeeklyUtilization != 0.60 {
+		t.Errorf("got stale %v, weekly %v, want the last rate limits marked stale", u.Stats.APIDataStale, u.Stats.WeeklyUtilization)
+	}
+}
+
+func TestLoad_CredentialsWarning(t *testing.T) {
+	l, _, cfg := newTestLoader(t)
+	if u := l.Load(context.Background()); u.Warning != "" {
+		t.Errorf("got warning %q for private credentials", u.Warning)
+	}
+
+	if err := os.Chmod(cfg.GetCredentialsPath(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if u := l.Load(context.Background()); u.Warning == "" {
+		t.Error("got no warning for world-readable credentials")
+	}
+}
@@ -0,0 +1,73 @@
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"claude-usage/internal/api"
+	"claude-usage/internal/config"
+	"claude-usage/internal/cost"
+	"claude-usage/internal/perms"
+	"claude-usage/internal/stats"
+	"claude-usage/internal/tokenstore"
+	"claude-usage/internal/transcripts"
+)
+
+// ReadCredentials reads the credentials for cfg's usage provider. Only the
+// OAuth provider needs them, so only for it are unreadable credentials or
+// a missing access token an error; other providers get nil credentials
+// and use them for plan info when available.
+func ReadCredentials(cfg *config.Config) (*stats.Credentials, error) {
+	creds, err := tokenstore.ReadCredentials(cfg)
+	if err != nil {
+		if cfg.UsesOAuth() {
+			return nil, fmt.Errorf("could not read credentials: %w", err)
+		}
+		log.Printf("Note: credentials not available: %v", err)
+		return nil, nil
+	}
+	if cfg.UsesOAuth() && creds.ClaudeAiOauth.AccessToken == "" {
+		return nil, errors.New("no access token in credentials file")
+	}
+	return creds, nil
+}
+
+// CredentialsWarning returns a warning when other users can read cfg's
+// credentials file, "" otherwise.
+func CredentialsWarning(cfg *config.Config) string {
+	if config.UsesEnvCredentials() {
+		return ""
+	}
+	return perms.CheckCredentials(cfg.GetCredentialsPath())
+}
+
+// WeekStats calculates this week's usage from the stats cache and the
+// transcript entries, over the API's rolling window when last holds its
+// reset time, otherwise the calendar week. creds and last may be nil. The
+// stats cache with the entries merged in is returned too, nil when there
+// is neither.
+func WeekStats(cfg *config.Config, creds *stats.Credentials, entries []transcripts.Entry, last *api.RateLimitData, now time.Time) (*stats.WeeklyStats, *stats.StatsCache) {
+	// The stats cache is optional; the API data is preferred
+	cache, err := stats.ParseStatsCache(cfg.GetStatsPath())
+	if err != nil {
+		log.Printf("Note: stats cache not available: %v", err)
+		cache = nil
+	}
+
+	// Transcripts are fresher than the stats cache
+	var used []transcripts.Entry
+	if len(entries) > 0 {
+		cache, used = transcripts.Merge(cache, entries)
+	}
+
+	weekStart, weekEnd := stats.GetWeekBounds()
+	if last != nil && !last.WeeklyReset.IsZero() {
+		weekStart, weekEnd = stats.WindowFromReset(last.WeeklyReset, now)
+	}
+	weeklyStats := stats.CalculateWindowStats(cache, creds, weekStart, weekEnd)
+	cost.ApplyEntries(weeklyStats, cache, used, cfg.Pricing)
+	transcripts.ApplyActiveBlock(weeklyStats, entries, now)
+	return weeklyStats, cache
+}
@@ -0,0 +1,24 @@
+package format
+
+import "strings"
+
+// FormatProgressBar draws a bar width characters wide, filled to
+// percentage (clamped to 0-100). Unicode bars look like "▕███░░░░░░░▏";
+// ascii bars like "[###-------]" for fonts and consoles lacking the block
+// characters.
+func FormatProgressBar(percentage, width int, ascii bool) string {
+	if percentage < 0 {
+		percentage = 0
+	}
+	if percentage > 100 {
+		percentage = 100
+	}
+
+	filled := (percentage * width) / 100
+	empty := width - filled
+
+	if ascii {
+		return "[" + strings.Repeat("#", filled) + strings.Repeat("-", empty) + "]"
+	}
+	return "▕" + strings.Repeat("█", filled) + strings.Repeat("░", empty) + "▏"
+}
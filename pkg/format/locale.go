@@ -0,0 +1,174 @@
+package format
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// nbsp keeps a number and its unit or percent sign on one line.
+const nbsp = "\u00a0"
+
+// numberStyle is how a locale writes decimals and percentages.
+type numberStyle struct {
+	decimal      string // decimal separator
+	spaced       bool   // a space before unit suffixes and the percent sign
+	percentFirst bool   // the percent sign before the number, as in "%42"
+}
+
+var (
+	dotStyle          = numberStyle{decimal: "."}
+	commaStyle        = numberStyle{decimal: ","}
+	spacedCommaStyle  = numberStyle{decimal: ",", spaced: true}
+	percentFirstStyle = numberStyle{decimal: ",", percentFirst: true}
+)
+
+// languageStyles are the number styles of languages that do not write
+// numbers like English, after CLDR. Languages missing here use dotStyle.
+var languageStyles = map[string]numberStyle{
+	"bg": spacedCommaStyle, "ca": commaStyle, "cs": spacedCommaStyle,
+	"da": spacedCommaStyle, "de": spacedCommaStyle, "el": commaStyle,
+	"es": spacedCommaStyle, "et": spacedCommaStyle, "fi": spacedCommaStyle,
+	"fr": spacedCommaStyle, "hr": commaStyle, "hu": commaStyle,
+	"id": commaStyle, "it": commaStyle, "lt": spacedCommaStyle,
+	"lv": commaStyle, "nb": spacedCommaStyle, "nl": commaStyle,
+	"nn": spacedCommaStyle, "no": spacedCommaStyle, "pl": commaStyle,
+	"pt": commaStyle, "ro": spacedCommaStyle, "ru": spacedCommaStyle,
+	"sk": spacedCommaStyle, "sl": commaStyle, "sr": commaStyle,
+	"sv": spacedCommaStyle, "tr": percentFirstStyle, "uk": spacedCommaStyle,
+	"vi": commaStyle,
+}
+
+// regionStyles override languageStyles for regions that write decimals
+// differently from the rest of the language.
+var regionStyles = map[string]numberStyle{
+	"de-CH": {decimal: ".", spaced: true},
+	"de-LI": {decimal: ".", spaced: true},
+	"es-MX": dotStyle,
+	"es-US": dotStyle,
+	"it-CH": dotStyle,
+}
+
+// style is the number style in use; see SetLocale.
+var style atomic.Pointer[numberStyle]
+
+func init() {
+	style.Store(&dotStyle)
+}
+
+// SetLocale chooses how numbers and percentages are written from a locale
+// tag such as "de-DE" or "fr_FR.UTF-8". An empty tag uses the system's
+// locale. Unknown languages, "C" and "POSIX" write numbers like English,
+// which is also the default before SetLocale is called.
+func SetLocale(tag string) {
+	if tag == "" {
+		tag = SystemLocale()
+	}
+	lang, region := parseLocale(tag)
+	s, ok := regionStyles[lang+"-"+region]
+	if !ok {
+		s, ok = languageStyles[lang]
+	}
+	if !ok {
+		s = dotStyle
+	}
+	style.Store(&s)
+}
+
+// ValidLocale reports whether tag looks like a locale tag SetLocale
+// understands: a language code, optionally followed by a region and
+// encoding, or "C" or "POSIX".
+func ValidLocale(tag string) bool {
+	lang, region := parseLocale(tag)
+	if lang == "c" || lang == "posix" {
+		return region == ""
+	}
+	return isLetters(lang, 2, 3) && (region == "" || isLetters(region, 2, 2) || isDigits(region, 3))
+}
+
+// SystemLocale returns the user's locale from LC_ALL, LC_NUMERIC or LANG,
+// or from the system settings when none is set, or "" when unknown.
+func SystemLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return platformLocale()
+}
+
+// parseLocale splits a tag like "de-DE", "pt_BR.UTF-8" or "sr_RS@latin"
+// into its lowercase language and uppercase region.
+func parseLocale(tag string) (lang, region string) {
+	if i := strings.IndexAny(tag, ".@"); i >= 0 {
+		tag = tag[:i]
+	}
+	parts := strings.Split(strings.ReplaceAll(tag, "_", "-"), "-")
+	// skip scripts, as in "sr-Latn-RS"; variants after the region are dropped
+	if len(parts) > 2 && len(parts[1]) == 4 {
+		parts = append(parts[:1], parts[2:]...)
+	}
+	if len(parts) > 1 {
+		region = parts[1]
+	}
+	return strings.ToLower(parts[0]), strings.ToUpper(region)
+}
+
+func isLetters(s string, minLen, maxLen int) bool {
+	if len(s) < minLen || len(s) > maxLen {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatPercent formats a percentage for the locale, e.g. "42%", "42 %"
+// or "%42".
+func FormatPercent(percent int) string {
+	s := style.Load()
+	n := strconv.Itoa(percent)
+	switch {
+	case s.percentFirst:
+		return "%" + n
+	case s.spaced:
+		return n + nbsp + "%"
+	}
+	return n + "%"
+}
+
+// FormatSignedPercent formats a change in percent with its sign, e.g.
+// "+14%" or "-3 %".
+func FormatSignedPercent(percent int) string {
+	if percent > 0 {
+		return "+" + FormatPercent(percent)
+	}
+	return FormatPercent(percent)
+}
+
+// withUnit joins a decimal number formatted with "." and a unit suffix in
+// the locale's style, e.g. "2.1M" or "2,1 M".
+func withUnit(number, unit string) string {
+	s := style.Load()
+	number = strings.Replace(number, ".", s.decimal, 1)
+	if s.spaced {
+		return number + nbsp + unit
+	}
+	return number + unit
+}
@@ -0,0 +1,16 @@
+package format
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// platformLocale returns the region format chosen in System Settings,
+// e.g. "de_DE". Apps started from Finder or at login get no LANG.
+func platformLocale() string {
+	out, err := exec.Command("defaults", "read", "-g", "AppleLocale").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,7 @@
+//go:build !windows && !darwin
+
+package format
+
+// platformLocale returns "": outside Windows and macOS the locale comes
+// from the environment only.
+func platformLocale() string { return "" }
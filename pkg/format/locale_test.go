@@ -0,0 +1,50 @@
+package format
+
+import "testing"
+
+func TestSetLocale(t *testing.T) {
+	defer SetLocale("C")
+	tests := []struct {
+		locale, tokens, percent string
+	}{
+		{"en_US.UTF-8", "2.1M", "42%"},
+		{"de-DE", "2,1\u00a0M", "42\u00a0%"},
+		{"de_CH", "2.1\u00a0M", "42\u00a0%"},
+		{"it_IT.UTF-8", "2,1M", "42%"},
+		{"tr-TR", "2,1M", "%42"},
+		{"sr-Latn-RS", "2,1M", "42%"},
+		{"xx", "2.1M", "42%"},
+		{"C", "2.1M", "42%"},
+	}
+	for _, tt := range tests {
+		SetLocale(tt.locale)
+		if got := FormatTokens(2_100_000); got != tt.tokens {
+			t.Errorf("%s: FormatTokens = %q, want %q", tt.locale, got, tt.tokens)
+		}
+		if got := FormatPercent(42); got != tt.percent {
+			t.Errorf("%s: FormatPercent = %q, want %q", tt.locale, got, tt.percent)
+		}
+	}
+}
+
+func TestSetLocaleFromEnvironment(t *testing.T) {
+	defer SetLocale("C")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_NUMERIC", "fr_FR.UTF-8")
+	t.Setenv("LANG", "en_US.UTF-8")
+	SetLocale("")
+	if got := FormatTokens(1_500); got != "1,5\u00a0K" {
+		t.Errorf("FormatTokens = %q, want LC_NUMERIC's style", got)
+	}
+}
+
+func TestValidLocale(t *testing.T) {
+	for tag, want := range map[string]bool{
+		"de-DE": true, "pt_BR.UTF-8": true, "es-419": true, "POSIX": true, "C.UTF-8": true,
+		"": false, "German": false, "de-Germany": false, "C-DE": false,
+	} {
+		if got := ValidLocale(tag); got != want {
+			t.Errorf("ValidLocale(%q) = %v, want %v", tag, got, want)
+		}
+	}
+}
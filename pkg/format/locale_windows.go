@@ -0,0 +1,13 @@
+package format
+
+import "golang.org/x/sys/windows"
+
+// platformLocale returns the user's preferred display language, e.g.
+// "de-DE".
+func platformLocale() string {
+	langs, err := windows.GetUserPreferredUILanguages(windows.MUI_LANGUAGE_NAME)
+	if err != nil || len(langs) == 0 {
+		return ""
+	}
+	return langs[0]
+}
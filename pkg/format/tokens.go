@@ -3,20 +3,30 @@ package format
 
 import "fmt"
 
-// FormatTokens formats a token count in compact notation (K, M, B).
+// FormatTokens formats a token count in compact notation (K, M, B) in the
+// locale's style, e.g. "2.1M" or "2,1 M" (see SetLocale).
 func FormatTokens(n int64) string {
 	switch {
 	case n >= 1_000_000_000:
-		return fmt.Sprintf("%.1fB", float64(n)/1_000_000_000)
+		return withUnit(fmt.Sprintf("%.1f", float64(n)/1_000_000_000), "B")
 	case n >= 1_000_000:
-		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+		return withUnit(fmt.Sprintf("%.1f", float64(n)/1_000_000), "M")
 	case n >= 1_000:
-		return fmt.Sprintf("%.1fK", float64(n)/1_000)
+		return withUnit(fmt.Sprintf("%.1f", float64(n)/1_000), "K")
 	default:
 		return fmt.Sprintf("%d", n)
 	}
 }
 
+// FormatDollars formats a dollar amount, dropping the cents when they are zero.
+// Examples: "$50", "$12.40"
+func FormatDollars(amount float64) string {
+	if amount == float64(int64(amount)) {
+		return fmt.Sprintf("$%d", int64(amount))
+	}
+	return fmt.Sprintf("$%.2f", amount)
+}
+
 // FormatPlanName formats the subscription type and rate limit tier.
 func FormatPlanName(subscriptionType, rateLimitTier string) string {
 	if subscriptionType == "" {